@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -18,7 +19,7 @@ func AICredentialTable(items []AICredentialView) templ.Component {
 			return err
 		}
 
-		if _, err := io.WriteString(w, `<table class="ai-settings__table"><thead><tr><th>Provider</th><th>Scope</th><th>Label</th><th>Fingerprint</th><th>Metadata</th><th>Updated</th><th>Last used</th><th>Actions</th></tr></thead><tbody>`); err != nil {
+		if _, err := io.WriteString(w, `<table class="ai-settings__table"><thead><tr><th>Provider</th><th>Scope</th><th>Label</th><th>Fingerprint</th><th>Metadata</th><th>Updated</th><th>Last used</th><th>Health</th><th>Actions</th></tr></thead><tbody>`); err != nil {
 			return err
 		}
 
@@ -41,7 +42,7 @@ func AICredentialTable(items []AICredentialView) templ.Component {
 			}
 
 			if _, err := fmt.Fprintf(w,
-				`<tr><td>%s</td><td>%s</td><td>%s</td><td><code%s>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>`+
+				`<tr><td>%s</td><td>%s</td><td>%s</td><td><code%s>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>`+
 					`<div class="ai-settings__row-actions">`+
 					`<button class="ai-settings__link" hx-post="/api/ai/providers/%s/credential/test" hx-vals="{&quot;credentialId&quot;:&quot;%s&quot;}" hx-target="#ai-settings-notice" hx-swap="innerHTML">Test</button>`+
 					`<button class="ai-settings__link ai-settings__link--danger" hx-delete="/api/ai/credentials/%s" hx-target="#ai-settings-notice" hx-swap="innerHTML" hx-confirm="Delete this credential?">Delete</button>`+
@@ -54,6 +55,7 @@ func AICredentialTable(items []AICredentialView) templ.Component {
 				renderMetadataHTML(item.Metadata),
 				templ.EscapeString(item.UpdatedAt.Format(time.RFC822)),
 				templ.EscapeString(renderMaybeTimeString(item.LastUsedAt)),
+				renderCredentialHealthHTML(item, time.Now()),
 				templ.EscapeString(item.Provider),
 				templ.EscapeString(item.ID),
 				templ.EscapeString(item.ID),
@@ -128,6 +130,49 @@ func renderMetadataHTML(meta map[string]any) string {
 	return builder.String()
 }
 
+// renderCredentialHealthHTML shows the most urgent of expiry, rotation and the last
+// background check as a status badge.
+func renderCredentialHealthHTML(item AICredentialView, now time.Time) string {
+	status, message, title := "ok", "OK", ""
+	daysUntil := func(t *time.Time) int {
+		if t == nil {
+			return 0
+		}
+		return int(math.Ceil(t.Sub(now).Hours() / 24))
+	}
+	switch {
+	case item.Lifecycle == "expired":
+		status, message = "error", "Expired"
+	case item.HealthStatus == "failure":
+		status, message, title = "error", "Check failing", item.HealthError
+	case item.Lifecycle == "expiring":
+		status, message = "warning", fmt.Sprintf("Expires in %s", pluralDays(daysUntil(item.LifecycleDueAt)))
+	case item.Lifecycle == "stale":
+		status, message = "warning", "Rotation overdue"
+	case item.Lifecycle == "rotation_due":
+		status, message = "warning", fmt.Sprintf("Rotate in %s", pluralDays(daysUntil(item.LifecycleDueAt)))
+	case item.LastTestedAt != nil:
+		message = "Checked " + item.LastTestedAt.Format("02 Jan 06")
+	}
+	if title == "" && item.LifecycleDueAt != nil {
+		title = "Due " + item.LifecycleDueAt.Format(time.RFC822)
+	}
+
+	titleAttr := ""
+	if title != "" {
+		titleAttr = fmt.Sprintf(" title=\"%s\"", templ.EscapeString(title))
+	}
+	return fmt.Sprintf(`<span class="%s"%s><span class="status-badge__dot"></span>%s</span>`,
+		StatusBadgeClasses(status), titleAttr, templ.EscapeString(message))
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
 func renderMaybeTimeString(t *time.Time) string {
 	if t == nil {
 		return "—"
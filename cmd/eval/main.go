@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/eval"
+	openaiProvider "github.com/JonMunkholm/RevProject1/internal/ai/provider/openai"
+	"github.com/joho/godotenv"
+)
+
+type options struct {
+	CasesPath        string
+	Provider         string
+	Model            string
+	ReplayPath       string
+	RecordPath       string
+	JudgeProvider    string
+	JudgeModel       string
+	ChatTemplate     string
+	DocumentTemplate string
+	OutPath          string
+	MarkdownPath     string
+	BaselinePath     string
+	Timeout          time.Duration
+	MinPassRate      float64
+}
+
+// providerEnv lists the environment variables each provider is configured from.
+var providerEnv = map[string]struct{ Key, Base, Model string }{
+	"openai":           {"OPENAI_API_KEY", "OPENAI_API_BASE", "OPENAI_MODEL"},
+	"gemini":           {"GEMINI_API_KEY", "GEMINI_API_BASE", "GEMINI_MODEL"},
+	"anthropic":        {"ANTHROPIC_API_KEY", "ANTHROPIC_API_BASE", "ANTHROPIC_MODEL"},
+	ai.LocalProviderID: {"LOCAL_AI_API_KEY", "LOCAL_AI_BASE_URL", "LOCAL_AI_MODEL"},
+}
+
+func main() {
+	log.SetFlags(0)
+	if err := run(context.Background()); err != nil {
+		log.Fatalf("eval: %v", err)
+	}
+}
+
+func run(ctx context.Context) error {
+	_ = godotenv.Load()
+
+	opts, err := parseOptions()
+	if err != nil {
+		return err
+	}
+
+	cases, err := eval.LoadCases(opts.CasesPath)
+	if err != nil {
+		return err
+	}
+	chatTemplate, err := readOptional(opts.ChatTemplate)
+	if err != nil {
+		return fmt.Errorf("read chat template: %w", err)
+	}
+	documentTemplate, err := readOptional(opts.DocumentTemplate)
+	if err != nil {
+		return fmt.Errorf("read document template: %w", err)
+	}
+
+	var recording *eval.Recording
+	switch {
+	case opts.ReplayPath != "":
+		if recording, err = eval.LoadRecording(opts.ReplayPath); err != nil {
+			return err
+		}
+	case opts.RecordPath != "":
+		recording = eval.NewRecording()
+	}
+
+	models := map[string]string{opts.Provider: opts.Model}
+	if opts.JudgeProvider != "" && opts.JudgeProvider != opts.Provider {
+		models[opts.JudgeProvider] = opts.JudgeModel
+	}
+	factories := make(map[string]ai.ProviderFactory, len(models))
+	for name, model := range models {
+		factory, err := providerFactory(name, model)
+		if err != nil {
+			return err
+		}
+		switch {
+		case opts.ReplayPath != "":
+			factory = eval.ReplayFactory(name, recording)
+		case opts.RecordPath != "":
+			factory = eval.RecordFactory(factory, recording)
+		}
+		factories[name] = factory
+	}
+
+	client, err := ai.NewClient(ai.Config{
+		Providers:       factories,
+		DefaultProvider: opts.Provider,
+	})
+	if err != nil {
+		return fmt.Errorf("create ai client: %w", err)
+	}
+
+	runner := &eval.Runner{
+		Client:           client,
+		Options:          userOptions(opts.Provider, opts.Model),
+		Model:            opts.Model,
+		ChatTemplate:     chatTemplate,
+		DocumentTemplate: documentTemplate,
+		Timeout:          opts.Timeout,
+	}
+	if opts.JudgeProvider != "" {
+		judgeModel := opts.JudgeModel
+		if opts.JudgeProvider == opts.Provider && judgeModel == "" {
+			judgeModel = opts.Model
+		}
+		judgeOptions := userOptions(opts.JudgeProvider, judgeModel)
+		runner.Judge = &eval.Judge{Client: client, Options: judgeOptions, Model: judgeModel}
+	}
+
+	log.Printf("running %d cases against %s", len(cases), opts.Provider)
+	report := runner.Run(ctx, cases)
+
+	if opts.RecordPath != "" {
+		if err := recording.Save(opts.RecordPath); err != nil {
+			return fmt.Errorf("save recording: %w", err)
+		}
+	}
+	if err := writeReports(opts, report); err != nil {
+		return err
+	}
+
+	s := report.Summary
+	fmt.Printf("%d/%d passed (%d failed, %d errors), fact recall %.0f%%, %d forbidden claims",
+		s.Passed, s.Cases, s.Failed, s.Errors, s.FactRecall*100, s.ForbiddenHits)
+	if s.JudgeMean > 0 {
+		fmt.Printf(", judge mean %.2f", s.JudgeMean)
+	}
+	fmt.Println()
+
+	if s.PassRate() < opts.MinPassRate {
+		return fmt.Errorf("pass rate %.0f%% below minimum %.0f%%", s.PassRate()*100, opts.MinPassRate*100)
+	}
+	return nil
+}
+
+func parseOptions() (options, error) {
+	opts := options{
+		Provider: "openai",
+		Timeout:  2 * time.Minute,
+	}
+
+	flag.StringVar(&opts.CasesPath, "cases", "", "Case file or directory of *.json case files")
+	flag.StringVar(&opts.Provider, "provider", opts.Provider, "Provider under test (openai|gemini|anthropic|local)")
+	flag.StringVar(&opts.Model, "model", "", "Model under test (defaults to the provider's configured model)")
+	flag.StringVar(&opts.ReplayPath, "replay", "", "Replay responses from this recording instead of calling providers")
+	flag.StringVar(&opts.RecordPath, "record", "", "Record live responses to this file for later replay")
+	flag.StringVar(&opts.JudgeProvider, "judge-provider", "", "Provider used to grade answers (disabled when empty)")
+	flag.StringVar(&opts.JudgeModel, "judge-model", "", "Model used to grade answers")
+	flag.StringVar(&opts.ChatTemplate, "chat-template", "", "Chat prompt template file applied to cases without their own")
+	flag.StringVar(&opts.DocumentTemplate, "document-template", "", "Document summary template file applied to cases without their own")
+	flag.StringVar(&opts.OutPath, "out", "", "Write the JSON report to this file")
+	flag.StringVar(&opts.MarkdownPath, "markdown", "", "Write the Markdown report to this file (- for stdout)")
+	flag.StringVar(&opts.BaselinePath, "baseline", "", "JSON report from a previous run to compare against")
+	flag.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "Time limit per case, including judging")
+	flag.Float64Var(&opts.MinPassRate, "min-pass", 0, "Exit non-zero when the pass rate is below this fraction")
+	flag.Parse()
+
+	if opts.CasesPath == "" {
+		return options{}, errors.New("cases path is required (use -cases)")
+	}
+	if opts.ReplayPath != "" && opts.RecordPath != "" {
+		return options{}, errors.New("-replay and -record cannot be combined")
+	}
+	opts.Provider = strings.ToLower(strings.TrimSpace(opts.Provider))
+	opts.JudgeProvider = strings.ToLower(strings.TrimSpace(opts.JudgeProvider))
+	for _, name := range []string{opts.Provider, opts.JudgeProvider} {
+		if _, ok := providerEnv[name]; name != "" && !ok {
+			return options{}, fmt.Errorf("unknown provider %q", name)
+		}
+	}
+	if opts.MinPassRate < 0 || opts.MinPassRate > 1 {
+		return options{}, errors.New("-min-pass must be between 0 and 1")
+	}
+	return opts, nil
+}
+
+// providerFactory builds the live factory for name from the same environment variables the
+// application uses, with model overriding the configured default.
+func providerFactory(name, model string) (ai.ProviderFactory, error) {
+	env := providerEnv[name]
+	if model == "" {
+		model = os.Getenv(env.Model)
+	}
+	baseURL := os.Getenv(env.Base)
+	systemPrompt := os.Getenv("AI_SYSTEM_PROMPT")
+
+	switch name {
+	case "openai":
+		return ai.NewOpenAIProviderFactory(openaiProvider.Config{BaseURL: baseURL, Model: model, SystemPrompt: systemPrompt}), nil
+	case "gemini":
+		return ai.NewGeminiProviderFactory(ai.GeminiConfig{BaseURL: baseURL, Model: model}), nil
+	case "anthropic":
+		return ai.NewAnthropicProviderFactory(ai.AnthropicConfig{BaseURL: baseURL, Model: model, SystemPrompt: systemPrompt}), nil
+	case ai.LocalProviderID:
+		return ai.NewLocalProviderFactory(ai.LocalConfig{BaseURL: baseURL, Model: model, SystemPrompt: systemPrompt}), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+}
+
+func userOptions(provider, model string) ai.UserOptions {
+	opts := ai.UserOptions{
+		Provider: provider,
+		APIKey:   os.Getenv(providerEnv[provider].Key),
+	}
+	if model != "" {
+		opts.Metadata = map[string]any{"model": model}
+	}
+	return opts
+}
+
+func writeReports(opts options, report eval.Report) error {
+	var baseline *eval.Report
+	if opts.BaselinePath != "" {
+		loaded, err := eval.LoadReport(opts.BaselinePath)
+		if err != nil {
+			return err
+		}
+		baseline = &loaded
+	}
+
+	if opts.OutPath != "" {
+		file, err := os.Create(opts.OutPath)
+		if err != nil {
+			return fmt.Errorf("create report: %w", err)
+		}
+		defer file.Close()
+		if err := eval.WriteJSON(file, report); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	}
+
+	switch opts.MarkdownPath {
+	case "":
+	case "-":
+		return eval.WriteMarkdown(os.Stdout, report, baseline)
+	default:
+		file, err := os.Create(opts.MarkdownPath)
+		if err != nil {
+			return fmt.Errorf("create markdown report: %w", err)
+		}
+		defer file.Close()
+		if err := eval.WriteMarkdown(file, report, baseline); err != nil {
+			return fmt.Errorf("write markdown report: %w", err)
+		}
+	}
+	return nil
+}
+
+func readOptional(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/rekey"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/sqlstore"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+type options struct {
+	DBURL     string
+	BatchSize int
+	DryRun    bool
+	Verbose   bool
+}
+
+// stdLogger prints credential log lines; Info lines only with -v.
+type stdLogger struct {
+	verbose bool
+}
+
+func (l stdLogger) Info(_ context.Context, msg string, attrs map[string]any) {
+	if l.verbose {
+		log.Printf("%s %s", msg, formatAttrs(attrs))
+	}
+}
+
+func (l stdLogger) Warn(_ context.Context, msg string, err error, attrs map[string]any) {
+	log.Printf("%s %s: %v", msg, formatAttrs(attrs), err)
+}
+
+func main() {
+	log.SetFlags(0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx); err != nil {
+		log.Fatalf("rekey: %v", err)
+	}
+}
+
+func run(ctx context.Context) error {
+	_ = godotenv.Load()
+
+	opts, err := parseOptions()
+	if err != nil {
+		return err
+	}
+
+	cipher, err := ai.NewCredentialCipherFromEnv(os.Getenv)
+	if err != nil {
+		return fmt.Errorf("load credential cipher: %w", err)
+	}
+
+	db, err := sql.Open("postgres", opts.DBURL)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(2)
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping db: %w", err)
+	}
+
+	queries := database.New(db)
+	mode := "re-encrypting"
+	if opts.DryRun {
+		mode = "dry run: checking"
+	}
+	log.Printf("%s credentials with active key %q", mode, cipher.ActiveKeyID())
+
+	started := time.Now()
+	stats, err := rekey.Run(ctx, sqlstore.New(queries), cipher, sqlstore.NewEventStore(queries), rekey.Options{
+		BatchSize: opts.BatchSize,
+		DryRun:    opts.DryRun,
+		Logger:    stdLogger{verbose: opts.Verbose},
+	})
+	verb := "migrated"
+	if opts.DryRun {
+		verb = "to migrate"
+	}
+	fmt.Printf("scanned %d credentials in %s: %d %s, %d already current, %d changed concurrently, %d failed\n",
+		stats.Scanned, time.Since(started).Round(time.Millisecond), stats.Migrated, verb, stats.Current, stats.Conflicts, stats.Failed)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case stats.Failed > 0:
+		return fmt.Errorf("%d credentials could not be re-encrypted; keep the retired keys configured", stats.Failed)
+	case stats.Conflicts > 0:
+		return errors.New("some credentials changed during the run; run again before removing retired keys")
+	case !opts.DryRun:
+		log.Printf("all credentials use key %q; retired keys are no longer needed", cipher.ActiveKeyID())
+	}
+	return nil
+}
+
+func parseOptions() (options, error) {
+	opts := options{BatchSize: 100}
+
+	flag.StringVar(&opts.DBURL, "db", "", "Postgres connection string (defaults to DB_URL env)")
+	flag.IntVar(&opts.BatchSize, "batch", opts.BatchSize, "Credentials read per batch")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "Report credentials that need re-encryption without writing")
+	flag.BoolVar(&opts.Verbose, "v", false, "Log every re-encrypted credential")
+	flag.Parse()
+
+	if opts.DBURL == "" {
+		opts.DBURL = os.Getenv("DB_URL")
+	}
+	if opts.DBURL == "" {
+		return options{}, errors.New("db connection string not provided (set DB_URL or use -db)")
+	}
+	if opts.BatchSize <= 0 {
+		return options{}, errors.New("-batch must be positive")
+	}
+	return opts, nil
+}
+
+func formatAttrs(attrs map[string]any) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, attrs[k]))
+	}
+	return strings.Join(parts, " ")
+}
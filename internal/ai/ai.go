@@ -1,21 +1,36 @@
 package ai
 
 import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+
 	c "github.com/JonMunkholm/RevProject1/internal/ai/client"
 	"github.com/JonMunkholm/RevProject1/internal/ai/conversation"
 	conversationsqlstore "github.com/JonMunkholm/RevProject1/internal/ai/conversation/sqlstore"
 	cred "github.com/JonMunkholm/RevProject1/internal/ai/credentials"
 	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/aescipher"
 	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/envelope"
 	credentialsqlstore "github.com/JonMunkholm/RevProject1/internal/ai/credentials/sqlstore"
 	doc "github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents/blob"
 	documentsqlstore "github.com/JonMunkholm/RevProject1/internal/ai/documents/sqlstore"
+	"github.com/JonMunkholm/RevProject1/internal/ai/grounding"
+	"github.com/JonMunkholm/RevProject1/internal/ai/logging"
+	"github.com/JonMunkholm/RevProject1/internal/ai/preferences"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
+	"github.com/JonMunkholm/RevProject1/internal/ai/provider/anthropic"
 	"github.com/JonMunkholm/RevProject1/internal/ai/provider/catalog"
 	"github.com/JonMunkholm/RevProject1/internal/ai/provider/gemini"
+	"github.com/JonMunkholm/RevProject1/internal/ai/provider/local"
 	"github.com/JonMunkholm/RevProject1/internal/ai/provider/openai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
 	t "github.com/JonMunkholm/RevProject1/internal/ai/tool"
 	"github.com/JonMunkholm/RevProject1/internal/ai/tool/audit"
 	toolsqlstore "github.com/JonMunkholm/RevProject1/internal/ai/tool/sqlstore"
+	"github.com/JonMunkholm/RevProject1/internal/ai/usage"
 	"github.com/JonMunkholm/RevProject1/internal/database"
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -30,6 +45,8 @@ type (
 	CompletionResponse  = c.CompletionResponse
 	ConversationMessage = c.ConversationMessage
 	ConversationReply   = c.ConversationReply
+	ChatRequest         = c.ChatRequest
+	ToolCall            = c.ToolCall
 	DocumentRequest     = c.DocumentRequest
 	DocumentResponse    = c.DocumentResponse
 	Provider            = c.Provider
@@ -51,24 +68,71 @@ type (
 	ProviderCatalogEntry = catalog.Entry
 	ProviderField        = catalog.Field
 	GeminiConfig         = gemini.Config
+	AnthropicConfig      = anthropic.Config
+	LocalConfig          = local.Config
 
 	ConversationService       = conversation.Service
 	ConversationSession       = conversation.Session
 	ConversationMessageRecord = conversation.Message
 	DocumentService           = doc.Service
 	DocumentJob               = doc.Job
+	DocumentFile              = doc.File
 	ToolAuditor               = audit.AuditingExecutor
 	CredentialRecord          = dbresolver.Record
 	CredentialCipher          = dbresolver.Cipher
+	CredentialBinding         = cred.Binding
+	CredentialKeyManager      = envelope.KeyManager
+	VaultTransitConfig        = envelope.VaultConfig
 	CredentialStore           = dbresolver.CredentialStore
 	CredentialReference       = dbresolver.Reference
 	CredentialEventStore      = credentialsqlstore.EventStore
 	CredentialMetrics         = metrics.CredentialMetrics
+	CredentialMetricsOption   = metrics.CredentialOption
+	ProviderMetrics           = metrics.ProviderMetrics
+	DocumentJobMetrics        = metrics.DocumentJobMetrics
+	CompanySettingsService    = settings.Service
+	RetryPolicy               = c.RetryPolicy
+	BreakerConfig             = c.BreakerConfig
+	ProviderCacheConfig       = c.CacheConfig
+	LogConfig                 = logging.Config
+	Usage                     = c.Usage
+	Accountant                = c.Accountant
+	CompanyBudget             = settings.Budget
+	ModelAllowlist            = settings.Allowlist
+	ModelPolicy               = c.ModelPolicy
+	UsageService              = usage.Service
+	UsageSummary              = usage.Summary
+	GroundingService          = grounding.Service
+	GuidanceSearcher          = grounding.Searcher
+	Citation                  = grounding.Citation
+	PromptTemplateService     = prompts.Service
+	PromptTemplate            = prompts.Template
+	PromptTemplateVersion     = prompts.Version
+	UserPreferenceService     = preferences.Service
+	UserPreference            = preferences.Preference
+)
+
+// LocalProviderID identifies the self-hosted OpenAI-compatible provider.
+const LocalProviderID = local.ProviderID
+
+// Conversation roles shared by every provider.
+const (
+	RoleSystem    = c.RoleSystem
+	RoleUser      = c.RoleUser
+	RoleAssistant = c.RoleAssistant
+	RoleTool      = c.RoleTool
 )
 
 var (
 	ErrProviderNotConfigured    = c.ErrProviderNotConfigured
 	ErrCapabilityNotImplemented = c.ErrCapabilityNotImplemented
+	ErrCircuitOpen              = c.ErrCircuitOpen
+	ErrBudgetExceeded           = c.ErrBudgetExceeded
+	ErrInvalidBudget            = settings.ErrInvalidBudget
+	ErrProviderNotAllowed       = c.ErrProviderNotAllowed
+	ErrModelNotAllowed          = c.ErrModelNotAllowed
+	ErrInvalidModelAllowlist    = settings.ErrInvalidAllowlist
+	ErrInvalidUserPreference    = preferences.ErrInvalidPreference
 )
 
 func NewClient(cfg Config) (*Client, error) { return c.NewClient(cfg) }
@@ -99,12 +163,33 @@ func NewDBCredentialResolver(store CredentialStore, cipher CredentialCipher, log
 
 func NewNoopLogger() Logger { return c.NewNoopLogger() }
 
+// NewStructuredLogger builds the slog logger used across the AI stack, with request and
+// tenant attribution and secret redaction.
+func NewStructuredLogger(cfg LogConfig) *slog.Logger { return logging.New(cfg) }
+
+// NewSlogLogger adapts a slog logger for the client, tools and AI services.
+func NewSlogLogger(logger *slog.Logger) Logger { return logging.NewLogger(logger) }
+
+// NewSlogCredentialLogger adapts a slog logger for the credential resolver.
+func NewSlogCredentialLogger(logger *slog.Logger) CredentialLogger {
+	return logging.NewCredentialLogger(logger)
+}
+
 func ParseCredentialReference(ref string) (CredentialReference, error) {
 	return dbresolver.ParseReference(ref)
 }
 
 func NewOpenAIProviderFactory(cfg openai.Config) ProviderFactory { return openai.Factory(cfg) }
 func NewGeminiProviderFactory(cfg GeminiConfig) ProviderFactory  { return gemini.Factory(cfg) }
+func NewAnthropicProviderFactory(cfg AnthropicConfig) ProviderFactory {
+	return anthropic.Factory(cfg)
+}
+func NewLocalProviderFactory(cfg LocalConfig) ProviderFactory { return local.Factory(cfg) }
+
+// ListLocalModels queries a self-hosted server's /v1/models endpoint.
+func ListLocalModels(ctx context.Context, httpClient *http.Client, baseURL, apiKey string) ([]string, error) {
+	return local.Models(ctx, httpClient, baseURL, apiKey)
+}
 
 func NewAESCipher(key []byte) (CredentialCipher, error) { return aescipher.New(key) }
 
@@ -112,6 +197,32 @@ func NewAESCipherFromBase64(encoded string) (CredentialCipher, error) {
 	return aescipher.NewFromBase64(encoded)
 }
 
+// NewAESKeyRing builds the credential cipher from the base64 active key, its ID, and
+// retired keys as comma-separated "id:base64" pairs.
+func NewAESKeyRing(activeKey, activeID, retired string) (*aescipher.Cipher, error) {
+	return aescipher.Load(activeKey, activeID, retired)
+}
+
+// NewEnvelopeCipher encrypts each credential under its own data key wrapped by kms. legacy,
+// when non-nil, decrypts credentials written before envelope encryption was enabled.
+func NewEnvelopeCipher(kms CredentialKeyManager, legacy CredentialCipher) (*envelope.Cipher, error) {
+	return envelope.New(kms, legacy)
+}
+
+// NewFileKeyManager loads (or creates) a local key-encryption key file for development.
+func NewFileKeyManager(path string) (CredentialKeyManager, error) {
+	return envelope.NewFileKeyManager(path)
+}
+
+func NewVaultTransitKeyManager(cfg VaultTransitConfig) (CredentialKeyManager, error) {
+	return envelope.NewVaultTransit(cfg)
+}
+
+// WithCredentialBinding attaches the credential row to ctx for binding-aware ciphers.
+func WithCredentialBinding(ctx context.Context, b CredentialBinding) context.Context {
+	return cred.WithBinding(ctx, b)
+}
+
 func NewCredentialSQLStore(q *database.Queries) CredentialStore {
 	return credentialsqlstore.New(q)
 }
@@ -120,8 +231,46 @@ func NewCredentialEventSQLStore(q *database.Queries) *CredentialEventStore {
 	return credentialsqlstore.NewEventStore(q)
 }
 
-func NewCredentialMetrics(reg prometheus.Registerer) CredentialMetrics {
-	return metrics.NewCredentialMetrics(reg)
+func NewCredentialMetrics(reg prometheus.Registerer, opts ...CredentialMetricsOption) CredentialMetrics {
+	return metrics.NewCredentialMetrics(reg, opts...)
+}
+
+// CredentialMetricsWithCompanyLabels labels credential counters by company ID.
+func CredentialMetricsWithCompanyLabels() CredentialMetricsOption {
+	return metrics.WithCompanyLabels()
+}
+
+func NewProviderMetrics(reg prometheus.Registerer) ProviderMetrics {
+	return metrics.NewProviderMetrics(reg)
+}
+
+func NewDocumentJobMetrics(reg prometheus.Registerer) DocumentJobMetrics {
+	return metrics.NewDocumentJobMetrics(reg)
+}
+
+func NewCompanySettingsService(q *database.Queries) *CompanySettingsService {
+	return settings.New(q)
+}
+
+func NewUsageService(q *database.Queries, budgets *CompanySettingsService) *UsageService {
+	return usage.New(q, budgets)
+}
+
+func NewPromptTemplateService(q *database.Queries, conn *sql.DB) *PromptTemplateService {
+	return prompts.New(q, conn)
+}
+
+func NewUserPreferenceService(q *database.Queries) *UserPreferenceService {
+	return preferences.New(q)
+}
+
+func NewGroundingService(searcher GuidanceSearcher) *GroundingService {
+	return grounding.New(searcher)
+}
+
+// NewGuidanceSearchTool exposes ASC guidance search to models as search_asc_guidance.
+func NewGuidanceSearchTool(searcher GuidanceSearcher) Tool {
+	return grounding.SearchTool{Searcher: searcher}
 }
 
 func NewConversationSQLStore(q *database.Queries) conversation.Store {
@@ -132,12 +281,24 @@ func NewDocumentSQLStore(q *database.Queries) doc.Store {
 	return documentsqlstore.New(q)
 }
 
+func NewDocumentFileSQLStore(q *database.Queries) doc.FileStore {
+	return documentsqlstore.New(q)
+}
+
+// NewLocalBlobStore keeps uploaded document bytes under dir.
+func NewLocalBlobStore(dir string) (blob.Store, error) {
+	return blob.NewLocal(dir)
+}
+
 func NewToolAuditSQLStore(q *database.Queries) audit.InvocationStore {
 	return toolsqlstore.New(q)
 }
 
 func ProviderCatalog() []ProviderCatalogEntry { return catalog.Catalog() }
 
+// ParseModelAllowlist reads the "provider: model, model" lines used by the settings form.
+func ParseModelAllowlist(text string) (ModelAllowlist, error) { return settings.ParseAllowlist(text) }
+
 func ProviderCatalogEntryByID(id string) (ProviderCatalogEntry, bool) {
 	return catalog.Lookup(id)
 }
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/envelope"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/rekey"
+)
+
+// RotatingCredentialCipher is a credential cipher that cmd/rekey can migrate rows onto.
+type RotatingCredentialCipher = rekey.Cipher
+
+const defaultKMSKeyFile = "data/credential-kek"
+
+// NewCredentialCipherFromEnv builds the credential cipher selected by AI_CREDENTIAL_KMS:
+//
+//   - "" or "aes": the static AES key ring (AI_CREDENTIAL_KEY, AI_CREDENTIAL_KEY_ID,
+//     AI_CREDENTIAL_RETIRED_KEYS).
+//   - "file": envelope encryption with a local key file (AI_CREDENTIAL_KMS_FILE).
+//   - "vault": envelope encryption with Vault transit (VAULT_ADDR, VAULT_TOKEN,
+//     VAULT_NAMESPACE, AI_CREDENTIAL_VAULT_MOUNT, AI_CREDENTIAL_VAULT_KEY).
+//
+// With a KMS configured, AI_CREDENTIAL_KEY is optional and only decrypts credentials
+// written before the switch.
+func NewCredentialCipherFromEnv(getenv func(string) string) (RotatingCredentialCipher, error) {
+	var legacy RotatingCredentialCipher
+	if key := getenv("AI_CREDENTIAL_KEY"); key != "" {
+		ring, err := NewAESKeyRing(key, getenv("AI_CREDENTIAL_KEY_ID"), getenv("AI_CREDENTIAL_RETIRED_KEYS"))
+		if err != nil {
+			return nil, err
+		}
+		legacy = ring
+	}
+
+	var kms CredentialKeyManager
+	var err error
+	switch mode := strings.ToLower(strings.TrimSpace(getenv("AI_CREDENTIAL_KMS"))); mode {
+	case "", "aes":
+		if legacy == nil {
+			return nil, errors.New("AI_CREDENTIAL_KEY must be set")
+		}
+		return legacy, nil
+	case "file":
+		path := getenv("AI_CREDENTIAL_KMS_FILE")
+		if path == "" {
+			path = defaultKMSKeyFile
+		}
+		kms, err = NewFileKeyManager(path)
+	case "vault":
+		kms, err = NewVaultTransitKeyManager(VaultTransitConfig{
+			Address:   getenv("VAULT_ADDR"),
+			Token:     getenv("VAULT_TOKEN"),
+			Namespace: getenv("VAULT_NAMESPACE"),
+			Mount:     getenv("AI_CREDENTIAL_VAULT_MOUNT"),
+			KeyName:   getenv("AI_CREDENTIAL_VAULT_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown AI_CREDENTIAL_KMS %q (want aes, file or vault)", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return envelope.New(kms, legacy)
+}
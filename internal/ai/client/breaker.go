@@ -0,0 +1,121 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when every candidate provider is short-circuited.
+var ErrCircuitOpen = errors.New("ai: provider circuit open")
+
+// BreakerConfig tunes the per-provider circuit breakers.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive provider failures that opens the circuit.
+	FailureThreshold int
+	// Cooldown is how long an open circuit rejects calls before allowing a single probe.
+	Cooldown time.Duration
+}
+
+func (c BreakerConfig) normalized() BreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	return c
+}
+
+// Breaker states, as reported to Metrics.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+type breaker struct {
+	provider string
+	cfg      BreakerConfig
+	metrics  Metrics
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(provider string, cfg BreakerConfig, metrics Metrics) *breaker {
+	return &breaker{provider: provider, cfg: cfg.normalized(), metrics: metrics, now: time.Now, state: BreakerClosed}
+}
+
+// allow reports whether a call may proceed. Once the cooldown elapses an open breaker
+// lets exactly one probe through; its outcome decides whether the circuit closes again.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.transition(BreakerClosed)
+	}
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = b.now()
+		if b.state != BreakerOpen {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// release frees a half-open probe slot without judging provider health, e.g. when the
+// provider could not even be constructed.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) currentState() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *breaker) transition(state string) {
+	b.state = state
+	b.metrics.BreakerState(b.provider, state)
+}
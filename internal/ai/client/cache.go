@@ -0,0 +1,134 @@
+package client
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CacheConfig bounds the provider instance cache.
+type CacheConfig struct {
+	// MaxEntries caps the cached provider instances; the least recently used goes first.
+	MaxEntries int
+	// TTL is how long an instance is reused after it was built, so keys changed outside
+	// this process are picked up eventually.
+	TTL time.Duration
+}
+
+func (c CacheConfig) normalized() CacheConfig {
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = 256
+	}
+	if c.TTL <= 0 {
+		c.TTL = 30 * time.Minute
+	}
+	return c
+}
+
+// Cache eviction reasons, as reported to Metrics.
+const (
+	EvictCapacity    = "capacity"
+	EvictExpired     = "expired"
+	EvictInvalidated = "invalidated"
+)
+
+type cacheEntry struct {
+	key          string
+	providerID   string
+	credentialID uuid.UUID
+	provider     Provider
+	expires      time.Time
+}
+
+// providerCache is an LRU of provider instances whose entries also expire after a TTL.
+type providerCache struct {
+	cfg     CacheConfig
+	metrics Metrics
+	now     func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newProviderCache(cfg CacheConfig, metrics Metrics) *providerCache {
+	return &providerCache{
+		cfg:     cfg.normalized(),
+		metrics: metrics,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *providerCache) get(key, providerID string) (Provider, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && c.now().After(elem.Value.(*cacheEntry).expires) {
+		c.remove(elem, EvictExpired)
+		ok = false
+	}
+	if !ok {
+		c.metrics.CacheMiss(providerID)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	c.metrics.CacheHit(providerID)
+	return elem.Value.(*cacheEntry).provider, true
+}
+
+func (c *providerCache) put(key, providerID string, credentialID uuid.UUID, provider Provider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{
+		key:          key,
+		providerID:   providerID,
+		credentialID: credentialID,
+		provider:     provider,
+		expires:      c.now().Add(c.cfg.TTL),
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.cfg.MaxEntries {
+		c.remove(c.order.Back(), EvictCapacity)
+	}
+}
+
+// evict removes every entry matching match and returns how many were dropped.
+func (c *providerCache) evict(match func(*cacheEntry) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if match(elem.Value.(*cacheEntry)) {
+			c.remove(elem, EvictInvalidated)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+func (c *providerCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *providerCache) remove(elem *list.Element, reason string) {
+	entry := elem.Value.(*cacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	c.metrics.CacheEviction(entry.providerID, reason)
+}
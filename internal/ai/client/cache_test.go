@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestProviderCacheEvictsLeastRecentlyUsedAndExpired(t *testing.T) {
+	metrics := &recordingMetrics{}
+	cache := newProviderCache(CacheConfig{MaxEntries: 2, TTL: time.Minute}, metrics)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", "openai", uuid.Nil, &stubProvider{name: "a"})
+	cache.put("b", "openai", uuid.Nil, &stubProvider{name: "b"})
+	if _, ok := cache.get("a", "openai"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.put("c", "gemini", uuid.Nil, &stubProvider{name: "c"})
+	if _, ok := cache.get("b", "openai"); ok {
+		t.Fatal("b should have been evicted as least recently used")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.get("c", "gemini"); ok {
+		t.Fatal("c should have expired")
+	}
+
+	want := []string{
+		"openai:hit",
+		"openai:evict:capacity",
+		"openai:miss",
+		"gemini:evict:expired",
+		"gemini:miss",
+	}
+	if !reflect.DeepEqual(metrics.cache, want) {
+		t.Fatalf("cache events = %v, want %v", metrics.cache, want)
+	}
+	if cache.len() != 1 {
+		t.Fatalf("len = %d, want 1", cache.len())
+	}
+}
+
+func TestEvictCredentialDropsCachedProviders(t *testing.T) {
+	acme, globex := uuid.New(), uuid.New()
+	builds := 0
+	client, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"stub": func(ProviderInit) (Provider, error) {
+				builds++
+				return &stubProvider{name: "stub"}, nil
+			},
+		},
+		Credentials: mapResolver{
+			"acme":   {ID: acme, Secret: "acme-key"},
+			"globex": {ID: globex, Secret: "globex-key"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	call := func(opts UserOptions) {
+		t.Helper()
+		if _, err := client.Completion(context.Background(), opts, CompletionRequest{Prompt: "hi"}); err != nil {
+			t.Fatalf("Completion: %v", err)
+		}
+	}
+	call(UserOptions{APIKeyRef: "acme"})
+	call(UserOptions{APIKeyRef: "globex"})
+	call(UserOptions{APIKey: "direct", CredentialID: acme})
+
+	if removed := client.EvictCredential(acme); removed != 2 {
+		t.Fatalf("EvictCredential removed %d entries, want 2", removed)
+	}
+	call(UserOptions{APIKeyRef: "globex"})
+	call(UserOptions{APIKeyRef: "acme"})
+	if builds != 4 {
+		t.Fatalf("factory called %d times, want the acme provider rebuilt once", builds)
+	}
+}
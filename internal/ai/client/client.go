@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/JonMunkholm/RevProject1/internal/ai/credentials"
 	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
@@ -25,11 +28,14 @@ type Provider interface {
 // ProviderFactory builds a Provider instance using the supplied options.
 type ProviderFactory func(ProviderInit) (Provider, error)
 
-// ProviderInit carries runtime configuration for a provider instance.
+// Credential is a resolved provider credential: the API key plus its saved metadata.
+type Credential = credentials.Credential
+
+// ProviderInit carries runtime configuration for a provider instance. Factories let the
+// credential's base URL, model, organization and project override their static Config.
 type ProviderInit struct {
-	APIKey     string
+	Credential Credential
 	HTTPClient *http.Client
-	Metadata   map[string]any
 	Executor   *tool.Executor
 }
 
@@ -41,6 +47,13 @@ type Config struct {
 	Logger          Logger
 	Tools           []tool.Tool
 	Credentials     credentials.Resolver
+	Retry           RetryPolicy
+	Breaker         BreakerConfig
+	Cache           CacheConfig
+	Metrics         Metrics
+	Accountant      Accountant
+	// ModelPolicy, when set, limits each company to its allowed providers and models.
+	ModelPolicy ModelPolicy
 }
 
 // UserOptions describe the provider preferences for a specific request or user.
@@ -49,6 +62,16 @@ type UserOptions struct {
 	APIKey    string
 	APIKeyRef string
 	Metadata  map[string]any
+	// CredentialID names the stored credential APIKey came from, so the cached provider can
+	// be evicted when that credential is deleted or rotated.
+	CredentialID uuid.UUID
+	// Fallbacks are tried in order when the primary provider fails or its circuit is open.
+	Fallbacks []UserOptions
+	// CompanyID and UserID attribute token usage and budgets; Feature labels the call
+	// (chat, completion, document) in the usage ledger.
+	CompanyID uuid.UUID
+	UserID    uuid.UUID
+	Feature   string
 }
 
 // CompletionRequest encapsulates a text generation request.
@@ -59,21 +82,59 @@ type CompletionRequest struct {
 
 // CompletionResponse is the portable form of a provider response.
 type CompletionResponse struct {
-	Text string
-	Raw  any
+	Text     string
+	Usage    Usage
+	Warnings []string
+	Raw      any
 }
 
-// ConversationMessage represents a single message in a conversational flow.
+// Conversation roles shared by every provider. Providers map them onto their own
+// vocabulary (for example Gemini's "model").
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// ToolCall is a model's request to run a tool. Arguments holds the raw JSON input.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ConversationMessage represents a single message in a conversational flow. Assistant
+// messages may carry ToolCalls; tool messages answer one call via ToolCallID.
 type ConversationMessage struct {
-	Role     string
-	Content  string
-	Metadata map[string]any
+	Role       string
+	Content    string
+	Metadata   map[string]any
+	ToolCalls  []ToolCall
+	ToolCallID string
+	ToolName   string
 }
 
 // ConversationReply is the provider response for a conversation exchange.
 type ConversationReply struct {
 	Message ConversationMessage
-	Raw     any
+	// Turns holds the tool-call and tool-result messages exchanged before Message, in order.
+	Turns    []ConversationMessage
+	Usage    Usage
+	Warnings []string
+	Raw      any
+}
+
+// ChatRequest carries a complete role-tagged transcript. Providers keep no state
+// between chat calls, so callers replay the stored history every turn.
+type ChatRequest struct {
+	Messages []ConversationMessage
+	Metadata map[string]any
+}
+
+// ChatProvider is implemented by providers that accept a full transcript per call.
+type ChatProvider interface {
+	Chat(ctx context.Context, req ChatRequest) (ConversationReply, error)
 }
 
 // ConversationHandler handles conversational exchanges.
@@ -90,6 +151,7 @@ type DocumentRequest struct {
 // DocumentResponse represents the provider output for a document analysis request.
 type DocumentResponse struct {
 	Summary string
+	Usage   Usage
 	Raw     any
 }
 
@@ -98,6 +160,17 @@ type DocumentHandler interface {
 	Analyze(ctx context.Context, request DocumentRequest) (DocumentResponse, error)
 }
 
+// ModelLister is implemented by providers that can enumerate the models they serve.
+type ModelLister interface {
+	Models(ctx context.Context) ([]string, error)
+}
+
+// StatusChecker is implemented by providers that can cheaply confirm the API accepts
+// their credential, usually by listing models.
+type StatusChecker interface {
+	Ping(ctx context.Context) error
+}
+
 // Logger instruments AI client operations.
 type Logger interface {
 	Info(ctx context.Context, msg string, attrs ...any)
@@ -124,13 +197,20 @@ type Client struct {
 
 	mu        sync.RWMutex
 	factories map[string]ProviderFactory
-	cache     map[string]Provider
+	cache     *providerCache
 
 	logger Logger
 
 	tools *tool.Registry
 	exec  *tool.Executor
 	creds credentials.Resolver
+
+	metrics    Metrics
+	accountant Accountant
+	policy     ModelPolicy
+	breakerCfg BreakerConfig
+	breakerMu  sync.Mutex
+	breakers   map[string]*breaker
 }
 
 // NewClient builds a client from the supplied configuration.
@@ -150,9 +230,21 @@ func NewClient(cfg Config) (*Client, error) {
 		return nil, fmt.Errorf("ai: default provider %q not registered", defaultProvider)
 	}
 
-	client := cfg.HTTPClient
-	if client == nil {
-		client = http.DefaultClient
+	metrics := cfg.Metrics
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+
+	base := cfg.HTTPClient
+	if base == nil {
+		base = NewHTTPClient()
+	}
+	// Copy the caller's client so retries apply to provider traffic only.
+	client := &http.Client{
+		Transport:     newRetryTransport(base.Transport, cfg.Retry, metrics),
+		CheckRedirect: base.CheckRedirect,
+		Jar:           base.Jar,
+		Timeout:       base.Timeout,
 	}
 
 	logger := cfg.Logger
@@ -174,11 +266,16 @@ func NewClient(cfg Config) (*Client, error) {
 		httpClient:      client,
 		defaultProvider: defaultProvider,
 		factories:       cfg.Providers,
-		cache:           make(map[string]Provider),
+		cache:           newProviderCache(cfg.Cache, metrics),
 		logger:          logger,
 		tools:           registry,
-		exec:            tool.NewExecutor(registry, logger),
+		exec:            tool.NewExecutor(registry, logger).WithMetrics(metrics),
 		creds:           creds,
+		metrics:         metrics,
+		accountant:      cfg.Accountant,
+		policy:          cfg.ModelPolicy,
+		breakerCfg:      cfg.Breaker.normalized(),
+		breakers:        make(map[string]*breaker),
 	}
 
 	return c, nil
@@ -193,57 +290,169 @@ func (c *Client) RegisterProvider(id string, factory ProviderFactory) {
 		c.factories = make(map[string]ProviderFactory)
 	}
 	c.factories[id] = factory
-
-	prefix := id + "::"
-	for key := range c.cache {
-		if strings.HasPrefix(key, prefix) {
-			delete(c.cache, key)
-		}
-	}
+	c.cache.evict(func(entry *cacheEntry) bool { return entry.providerID == id })
 
 	c.logger.Info(context.Background(), "ai: provider registered", "provider", id)
 }
 
-// Completion dispatches the request to the appropriate provider based on the supplied user options.
+// EvictCredential drops cached providers built from the stored credential, so the next
+// call resolves it again. Call it when a credential is deleted or its key rotated.
+func (c *Client) EvictCredential(credentialID uuid.UUID) int {
+	if credentialID == uuid.Nil {
+		return 0
+	}
+	removed := c.cache.evict(func(entry *cacheEntry) bool { return entry.credentialID == credentialID })
+	if removed > 0 {
+		c.logger.Info(context.Background(), "ai: provider cache evicted", "credential_id", credentialID.String(), "entries", removed)
+	}
+	return removed
+}
+
+// Completion dispatches the request to the appropriate provider based on the supplied user options,
+// failing over to opts.Fallbacks when the primary provider is unavailable.
 func (c *Client) Completion(ctx context.Context, opts UserOptions, req CompletionRequest) (CompletionResponse, error) {
-	provider, err := c.providerFor(ctx, opts)
+	warning, err := c.checkBudget(ctx, opts)
 	if err != nil {
 		return CompletionResponse{}, err
 	}
-	return provider.Completion(ctx, req)
+
+	primary := c.resolveProviderID(opts.Provider)
+	var resp CompletionResponse
+	err = c.withFailover(ctx, opts, func(ctx context.Context, provider Provider) error {
+		request := req
+		request.Metadata = candidateMetadata(req.Metadata, primary, providerFromContext(ctx))
+		started := time.Now()
+		var err error
+		resp, err = provider.Completion(ctx, request)
+		c.observe(provider.Name(), started, resp.Usage, err)
+		if err == nil {
+			c.recordUsage(ctx, opts, provider.Name(), resp.Usage)
+		}
+		return err
+	})
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	if warning != "" {
+		resp.Warnings = append(resp.Warnings, warning)
+	}
+	return resp, nil
+}
+
+// Chat sends a full transcript to the chosen provider. Because chat calls are stateless
+// they fail over to opts.Fallbacks like completions.
+func (c *Client) Chat(ctx context.Context, opts UserOptions, req ChatRequest) (ConversationReply, error) {
+	warning, err := c.checkBudget(ctx, opts)
+	if err != nil {
+		return ConversationReply{}, err
+	}
+
+	primary := c.resolveProviderID(opts.Provider)
+	var reply ConversationReply
+	err = c.withFailover(ctx, opts, func(ctx context.Context, provider Provider) error {
+		chatter, ok := provider.(ChatProvider)
+		if !ok {
+			return fmt.Errorf("%w: %s chat", ErrCapabilityNotImplemented, provider.Name())
+		}
+		request := req
+		request.Metadata = candidateMetadata(req.Metadata, primary, providerFromContext(ctx))
+		started := time.Now()
+		var err error
+		reply, err = chatter.Chat(ctx, request)
+		c.observe(provider.Name(), started, reply.Usage, err)
+		if err == nil {
+			c.recordUsage(ctx, opts, provider.Name(), reply.Usage)
+		}
+		return err
+	})
+	if err != nil {
+		return ConversationReply{}, err
+	}
+	if warning != "" {
+		reply.Warnings = append(reply.Warnings, warning)
+	}
+	return reply, nil
 }
 
-// Conversation returns a conversation handler for the chosen provider.
+// Conversation returns a conversation handler for the first available provider in the chain.
 func (c *Client) Conversation(ctx context.Context, opts UserOptions) ConversationHandler {
-	provider, err := c.providerFor(ctx, opts)
+	provider, br, providerID, err := c.selectProvider(ctx, opts)
 	if err != nil {
 		return noopConversationHandler{}
 	}
 	if handler := provider.Conversation(ctx); handler != nil {
-		return handler
+		return &guardedConversation{inner: handler, client: c, breaker: br, provider: providerID, primary: c.resolveProviderID(opts.Provider), opts: opts}
 	}
+	br.release()
 	return noopConversationHandler{}
 }
 
-// Documents returns a document analysis handler for the chosen provider.
+// Documents returns a document analysis handler for the first available provider in the chain.
 func (c *Client) Documents(ctx context.Context, opts UserOptions) DocumentHandler {
-	provider, err := c.providerFor(ctx, opts)
+	provider, br, providerID, err := c.selectProvider(ctx, opts)
 	if err != nil {
 		return noopDocumentHandler{}
 	}
 	if handler := provider.Documents(ctx); handler != nil {
-		return handler
+		return &guardedDocuments{inner: handler, client: c, breaker: br, provider: providerID, primary: c.resolveProviderID(opts.Provider), opts: opts}
 	}
+	br.release()
 	return noopDocumentHandler{}
 }
 
+// Ping checks that the provider accepts opts.APIKey, honoring a base URL in opts.Metadata.
+// The provider is built just for the check and never cached, so keys that have not been
+// saved yet can be tested. Providers that implement neither StatusChecker nor ModelLister
+// return ErrCapabilityNotImplemented.
+func (c *Client) Ping(ctx context.Context, opts UserOptions) error {
+	providerID := c.resolveProviderID(opts.Provider)
+	c.mu.RLock()
+	factory, ok := c.factories[providerID]
+	c.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrProviderNotConfigured, providerID)
+	}
+
+	provider, err := factory(ProviderInit{
+		Credential: Credential{Secret: opts.APIKey, Metadata: opts.Metadata},
+		HTTPClient: c.httpClient,
+		Executor:   c.exec,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx = withProvider(ctx, provider.Name())
+	switch checker := provider.(type) {
+	case StatusChecker:
+		return checker.Ping(ctx)
+	case ModelLister:
+		_, err := checker.Models(ctx)
+		return err
+	}
+	return ErrCapabilityNotImplemented
+}
+
+// Models lists the models served by the chosen provider when it supports discovery.
+func (c *Client) Models(ctx context.Context, opts UserOptions) ([]string, error) {
+	provider, err := c.providerFor(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := provider.(ModelLister)
+	if !ok {
+		return nil, ErrCapabilityNotImplemented
+	}
+	return lister.Models(withProvider(ctx, provider.Name()))
+}
+
 // RegisterTool adds a new tool implementation to the registry.
 func (c *Client) RegisterTool(t tool.Tool) {
 	if t == nil {
 		return
 	}
 	c.tools.Register(t)
-	c.exec = tool.NewExecutor(c.tools, c.logger)
+	c.exec = tool.NewExecutor(c.tools, c.logger).WithMetrics(c.metrics)
 	c.logger.Info(context.Background(), "ai: tool registered", "tool", t.Name())
 }
 
@@ -286,32 +495,33 @@ func (c *Client) providerFor(ctx context.Context, opts UserOptions) (Provider, e
 		return nil, err
 	}
 
-	apiKey := opts.APIKey
-	if apiKey == "" && opts.APIKeyRef != "" {
+	allowed, err := c.allowedModels(ctx, opts, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := Credential{ID: opts.CredentialID, Secret: opts.APIKey}
+	if credential.Secret == "" && opts.APIKeyRef != "" {
 		resolved, err := c.creds.Resolve(ctx, opts.APIKeyRef)
 		if err != nil {
 			c.logger.Error(ctx, "ai: credential resolve failed", err, "provider", providerID)
 			return nil, err
 		}
-		apiKey = resolved
+		credential = resolved
 		c.creds.Audit(ctx, opts.APIKeyRef, map[string]any{"provider": providerID})
 	}
+	credential = credential.WithMetadata(opts.Metadata)
 
-	cacheKey := providerCacheKey(providerID, apiKey, opts.APIKeyRef)
-
-	c.mu.RLock()
-	provider, cached := c.cache[cacheKey]
-	c.mu.RUnlock()
+	cacheKey := providerCacheKey(providerID, credential, opts.APIKeyRef)
 
-	if cached {
+	if provider, cached := c.cache.get(cacheKey, providerID); cached {
 		c.logger.Info(ctx, "ai: provider cache hit", "provider", providerID)
-		return provider, nil
+		return restrictProvider(provider, allowed, credential.Metadata), nil
 	}
 
 	init := ProviderInit{
-		APIKey:     apiKey,
+		Credential: credential,
 		HTTPClient: c.httpClient,
-		Metadata:   opts.Metadata,
 		Executor:   c.exec,
 	}
 
@@ -321,20 +531,29 @@ func (c *Client) providerFor(ctx context.Context, opts UserOptions) (Provider, e
 		return nil, err
 	}
 
-	c.mu.Lock()
-	c.cache[cacheKey] = instance
-	c.mu.Unlock()
+	c.cache.put(cacheKey, providerID, credential.ID, instance)
 
 	c.logger.Info(ctx, "ai: provider initialised", "provider", providerID)
-	return instance, nil
+	return restrictProvider(instance, allowed, credential.Metadata), nil
+}
+
+func (c *Client) resolveProviderID(providerID string) string {
+	if providerID != "" {
+		return providerID
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.defaultProvider
 }
 
-func providerCacheKey(providerID, apiKey, apiKeyRef string) string {
+// providerCacheKey keys cached providers on the full credential, metadata included, so
+// companies sharing a key but not a base URL or model get separate instances.
+func providerCacheKey(providerID string, credential Credential, apiKeyRef string) string {
 	builder := strings.Builder{}
 	builder.WriteString(providerID)
 	builder.WriteString("::")
-	if apiKey != "" {
-		builder.WriteString(hashString(apiKey))
+	if !credential.IsZero() {
+		builder.WriteString(credential.Fingerprint())
 	} else if apiKeyRef != "" {
 		builder.WriteString("ref:")
 		builder.WriteString(hashString(apiKeyRef))
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+type mapResolver map[string]Credential
+
+func (r mapResolver) Resolve(_ context.Context, reference string) (Credential, error) {
+	return r[reference], nil
+}
+func (mapResolver) Rotate(context.Context, string) error          { return nil }
+func (mapResolver) Audit(context.Context, string, map[string]any) {}
+
+func TestProviderCacheKeyedOnCredential(t *testing.T) {
+	var inits []ProviderInit
+	client, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"stub": func(init ProviderInit) (Provider, error) {
+				inits = append(inits, init)
+				return &stubProvider{name: "stub"}, nil
+			},
+		},
+		DefaultProvider: "stub",
+		Credentials: mapResolver{
+			"acme":    {Secret: "shared", Metadata: map[string]any{"base_url": "https://acme.openai.azure.com"}},
+			"acme-2":  {Secret: "shared", Metadata: map[string]any{"base_url": "https://acme.openai.azure.com"}},
+			"globex":  {Secret: "shared", Metadata: map[string]any{"base_url": "https://globex.openai.azure.com"}},
+			"initech": {Secret: "shared", Metadata: map[string]any{"model": "gpt-4o"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	for _, ref := range []string{"acme", "acme-2", "globex", "initech", "acme"} {
+		if _, err := client.Completion(context.Background(), UserOptions{APIKeyRef: ref}, CompletionRequest{Prompt: "hi"}); err != nil {
+			t.Fatalf("Completion(%s): %v", ref, err)
+		}
+	}
+
+	if len(inits) != 3 {
+		t.Fatalf("factory called %d times, want one per distinct credential", len(inits))
+	}
+	if got := inits[0].Credential; got.Secret != "shared" || got.BaseURL() != "https://acme.openai.azure.com" {
+		t.Fatalf("init did not carry the resolved credential: %+v", got)
+	}
+	if got := inits[2].Credential.Model(); got != "gpt-4o" {
+		t.Fatalf("credential model = %q, want gpt-4o", got)
+	}
+}
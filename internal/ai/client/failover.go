@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Metrics records provider calls, resilience and instance cache events, and tool
+// invocations. Implementations must be safe for concurrent use.
+type Metrics interface {
+	Retry(providerID, reason string)
+	Failover(fromProvider, toProvider string)
+	BreakerState(providerID, state string)
+	CacheHit(providerID string)
+	CacheMiss(providerID string)
+	CacheEviction(providerID, reason string)
+	// ProviderCall observes one provider call. Status is one of the Call* constants.
+	ProviderCall(providerID, model, status string, latency time.Duration, promptTokens, completionTokens int)
+	// ToolInvocation lets the tool executor report through the same recorder.
+	ToolInvocation(name, status string, duration time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) Retry(string, string)         {}
+func (noopMetrics) Failover(string, string)      {}
+func (noopMetrics) BreakerState(string, string)  {}
+func (noopMetrics) CacheHit(string)              {}
+func (noopMetrics) CacheMiss(string)             {}
+func (noopMetrics) CacheEviction(string, string) {}
+
+func (noopMetrics) ProviderCall(string, string, string, time.Duration, int, int) {}
+func (noopMetrics) ToolInvocation(string, string, time.Duration)                 {}
+
+// NewNoopMetrics returns a Metrics implementation that discards every event.
+func NewNoopMetrics() Metrics { return noopMetrics{} }
+
+// failoverChain flattens opts and its fallbacks into an ordered, de-duplicated candidate list.
+func (c *Client) failoverChain(opts UserOptions) []UserOptions {
+	chain := make([]UserOptions, 0, 1+len(opts.Fallbacks))
+	seen := make(map[string]struct{}, 1+len(opts.Fallbacks))
+
+	primary := opts
+	primary.Fallbacks = nil
+	for _, candidate := range append([]UserOptions{primary}, opts.Fallbacks...) {
+		candidate.Provider = c.resolveProviderID(candidate.Provider)
+		candidate.Fallbacks = nil
+		if _, dup := seen[candidate.Provider]; dup {
+			continue
+		}
+		seen[candidate.Provider] = struct{}{}
+		chain = append(chain, candidate)
+	}
+	return chain
+}
+
+// withFailover runs call against each candidate provider in order until one succeeds.
+// Open circuits and candidates that cannot be built are skipped; provider failures
+// (throttling, 5xx, network) count against the provider's breaker and move on to the next
+// candidate. Errors the request itself caused, and caller cancellation, stop the chain.
+func (c *Client) withFailover(ctx context.Context, opts UserOptions, call func(context.Context, Provider) error) error {
+	var lastErr error
+	previous := ""
+
+	for _, candidate := range c.failoverChain(opts) {
+		providerID := candidate.Provider
+		br := c.breakerFor(providerID)
+		if !br.allow() {
+			c.logger.Info(ctx, "ai: provider circuit open", "provider", providerID)
+			lastErr = fmt.Errorf("%w: %s", ErrCircuitOpen, providerID)
+			continue
+		}
+
+		provider, err := c.providerFor(ctx, candidate)
+		if err != nil {
+			br.release()
+			lastErr = err
+			continue
+		}
+
+		if previous != "" {
+			c.metrics.Failover(previous, providerID)
+			c.logger.Info(ctx, "ai: failing over", "from", previous, "to", providerID)
+		}
+		previous = providerID
+
+		err = call(withProvider(ctx, providerID), provider)
+		c.record(br, err)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !shouldFailOver(err) {
+			return err
+		}
+		c.logger.Error(ctx, "ai: provider call failed", err, "provider", providerID)
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = ErrProviderNotConfigured
+	}
+	return lastErr
+}
+
+// selectProvider returns the first candidate whose circuit admits traffic, for stateful
+// handlers (conversations, documents) that cannot be replayed on another provider.
+func (c *Client) selectProvider(ctx context.Context, opts UserOptions) (Provider, *breaker, string, error) {
+	var lastErr error
+	chain := c.failoverChain(opts)
+
+	for i, candidate := range chain {
+		providerID := candidate.Provider
+		br := c.breakerFor(providerID)
+		if !br.allow() {
+			lastErr = fmt.Errorf("%w: %s", ErrCircuitOpen, providerID)
+			continue
+		}
+
+		provider, err := c.providerFor(ctx, candidate)
+		if err != nil {
+			br.release()
+			lastErr = err
+			continue
+		}
+		if i > 0 {
+			c.metrics.Failover(chain[0].Provider, providerID)
+			c.logger.Info(ctx, "ai: failing over", "from", chain[0].Provider, "to", providerID)
+		}
+		return provider, br, providerID, nil
+	}
+
+	if lastErr == nil {
+		lastErr = ErrProviderNotConfigured
+	}
+	return nil, nil, "", lastErr
+}
+
+// candidateMetadata drops a requested model when the call runs on a fallback provider.
+// Model names belong to the primary provider they were chosen for (a user preference or
+// session setting); sent to another provider they would turn a recoverable outage into a
+// rejected request, so fallbacks use their own default model instead.
+func candidateMetadata(metadata map[string]any, primary, providerID string) map[string]any {
+	if providerID == primary {
+		return metadata
+	}
+	if _, ok := metadata["model"]; !ok {
+		return metadata
+	}
+	out := make(map[string]any, len(metadata))
+	for key, value := range metadata {
+		if key != "model" {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+func (c *Client) record(br *breaker, err error) {
+	switch {
+	case err == nil:
+		br.success()
+	case isProviderFailure(err):
+		br.failure()
+	case errors.Is(err, context.Canceled):
+		br.release()
+	default:
+		// The provider answered; the request itself was rejected.
+		br.success()
+	}
+}
+
+// observe reports a provider call to Metrics. Failed calls carry no usage, so their model
+// is reported as unknown; requested model names are not used as labels.
+func (c *Client) observe(providerID string, started time.Time, usage Usage, err error) {
+	model := usage.Model
+	if model == "" {
+		model = "unknown"
+	}
+	c.metrics.ProviderCall(providerID, model, callStatus(err), time.Since(started), usage.PromptTokens, usage.CompletionTokens)
+}
+
+func (c *Client) breakerFor(providerID string) *breaker {
+	c.breakerMu.Lock()
+	defer c.breakerMu.Unlock()
+
+	br, ok := c.breakers[providerID]
+	if !ok {
+		br = newBreaker(providerID, c.breakerCfg, c.metrics)
+		c.breakers[providerID] = br
+	}
+	return br
+}
+
+// BreakerState reports the circuit state for a provider (closed, open, or half_open).
+func (c *Client) BreakerState(providerID string) string {
+	return c.breakerFor(c.resolveProviderID(providerID)).currentState()
+}
+
+// guardedConversation feeds conversation outcomes into the provider's breaker and
+// applies budget checks and usage accounting to every turn.
+type guardedConversation struct {
+	inner    ConversationHandler
+	client   *Client
+	breaker  *breaker
+	provider string
+	primary  string
+	opts     UserOptions
+}
+
+func (g *guardedConversation) Send(ctx context.Context, message ConversationMessage) (ConversationReply, error) {
+	warning, err := g.client.checkBudget(ctx, g.opts)
+	if err != nil {
+		return ConversationReply{}, err
+	}
+
+	message.Metadata = candidateMetadata(message.Metadata, g.primary, g.provider)
+	started := time.Now()
+	reply, err := g.inner.Send(withProvider(ctx, g.provider), message)
+	g.client.record(g.breaker, err)
+	g.client.observe(g.provider, started, reply.Usage, err)
+	if err != nil {
+		return reply, err
+	}
+
+	g.client.recordUsage(ctx, g.opts, g.provider, reply.Usage)
+	if warning != "" {
+		reply.Warnings = append(reply.Warnings, warning)
+	}
+	return reply, nil
+}
+
+type guardedDocuments struct {
+	inner    DocumentHandler
+	client   *Client
+	breaker  *breaker
+	provider string
+	primary  string
+	opts     UserOptions
+}
+
+func (g *guardedDocuments) Analyze(ctx context.Context, request DocumentRequest) (DocumentResponse, error) {
+	if _, err := g.client.checkBudget(ctx, g.opts); err != nil {
+		return DocumentResponse{}, err
+	}
+
+	request.Metadata = candidateMetadata(request.Metadata, g.primary, g.provider)
+	started := time.Now()
+	resp, err := g.inner.Analyze(withProvider(ctx, g.provider), request)
+	g.client.record(g.breaker, err)
+	g.client.observe(g.provider, started, resp.Usage, err)
+	if err == nil {
+		g.client.recordUsage(ctx, g.opts, g.provider, resp.Usage)
+	}
+	return resp, err
+}
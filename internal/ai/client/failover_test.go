@@ -0,0 +1,365 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	mu        sync.Mutex
+	retries   []string
+	failovers []string
+	states    []string
+	cache     []string
+	calls     []string
+}
+
+func (m *recordingMetrics) Retry(providerID, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries = append(m.retries, providerID+":"+reason)
+}
+
+func (m *recordingMetrics) Failover(from, to string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failovers = append(m.failovers, from+"->"+to)
+}
+
+func (m *recordingMetrics) BreakerState(providerID, state string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states = append(m.states, providerID+":"+state)
+}
+
+func (m *recordingMetrics) CacheHit(providerID string)  { m.cacheEvent(providerID + ":hit") }
+func (m *recordingMetrics) CacheMiss(providerID string) { m.cacheEvent(providerID + ":miss") }
+func (m *recordingMetrics) CacheEviction(providerID, reason string) {
+	m.cacheEvent(providerID + ":evict:" + reason)
+}
+
+func (m *recordingMetrics) ProviderCall(providerID, model, status string, _ time.Duration, promptTokens, completionTokens int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, fmt.Sprintf("%s:%s:%s:%d/%d", providerID, model, status, promptTokens, completionTokens))
+}
+
+func (m *recordingMetrics) ToolInvocation(string, string, time.Duration) {}
+
+func (m *recordingMetrics) cacheEvent(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cache = append(m.cache, event)
+}
+
+type stubProvider struct {
+	name   string
+	calls  int
+	err    error
+	models []string
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Completion(_ context.Context, req CompletionRequest) (CompletionResponse, error) {
+	p.calls++
+	p.models = append(p.models, metadataModel(req.Metadata))
+	if p.err != nil {
+		return CompletionResponse{}, p.err
+	}
+	return CompletionResponse{Text: p.name}, nil
+}
+
+func (p *stubProvider) Conversation(context.Context) ConversationHandler { return nil }
+func (p *stubProvider) Documents(context.Context) DocumentHandler        { return nil }
+
+func stubFactory(p *stubProvider) ProviderFactory {
+	return func(ProviderInit) (Provider, error) { return p, nil }
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d: unexpected body %q", attempts, body)
+		}
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	metrics := &recordingMetrics{}
+	transport := newRetryTransport(nil, RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}, metrics)
+	var slept []time.Duration
+	transport.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	req, _ := http.NewRequestWithContext(withProvider(context.Background(), "openai"), http.MethodPost, srv.URL, strings.NewReader("payload"))
+	originalBody := req.Body
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if req.Body != originalBody {
+		t.Fatal("retry replaced the caller's request body")
+	}
+
+	if resp.StatusCode != http.StatusOK || attempts != 2 {
+		t.Fatalf("expected success on second attempt, got status %d after %d attempts", resp.StatusCode, attempts)
+	}
+	if len(slept) != 1 || slept[0] < time.Second {
+		t.Fatalf("expected to wait at least Retry-After, slept %v", slept)
+	}
+	if len(metrics.retries) != 1 || metrics.retries[0] != "openai:429" {
+		t.Fatalf("unexpected retry metrics %v", metrics.retries)
+	}
+}
+
+func TestRetryTransportGivesUpWhenRetryAfterExceedsMaxDelay(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	transport := newRetryTransport(nil, RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}, noopMetrics{})
+	resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestCompletionFailsOverAndOpensBreaker(t *testing.T) {
+	primary := &stubProvider{name: "openai", err: &StatusError{Provider: "openai", StatusCode: http.StatusServiceUnavailable}}
+	fallback := &stubProvider{name: "gemini"}
+	metrics := &recordingMetrics{}
+
+	c, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"openai": stubFactory(primary),
+			"gemini": stubFactory(fallback),
+		},
+		DefaultProvider: "openai",
+		Breaker:         BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour},
+		Metrics:         metrics,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	opts := UserOptions{APIKey: "k1", Fallbacks: []UserOptions{{Provider: "gemini", APIKey: "k2"}}}
+	for i := 0; i < 3; i++ {
+		resp, err := c.Completion(context.Background(), opts, CompletionRequest{Prompt: "hi"})
+		if err != nil {
+			t.Fatalf("completion %d: %v", i, err)
+		}
+		if resp.Text != "gemini" {
+			t.Fatalf("completion %d: expected fallback response, got %q", i, resp.Text)
+		}
+	}
+
+	if primary.calls != 2 {
+		t.Fatalf("expected breaker to stop calls to primary after 2 failures, got %d calls", primary.calls)
+	}
+	if got := c.BreakerState("openai"); got != BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", got)
+	}
+	if len(metrics.failovers) != 2 {
+		t.Fatalf("expected 2 failover events, got %v", metrics.failovers)
+	}
+	wantCalls := []string{
+		"openai:unknown:server_error:0/0",
+		"gemini:unknown:ok:0/0",
+		"openai:unknown:server_error:0/0",
+		"gemini:unknown:ok:0/0",
+		"gemini:unknown:ok:0/0",
+	}
+	if strings.Join(metrics.calls, ",") != strings.Join(wantCalls, ",") {
+		t.Fatalf("provider calls = %v, want %v", metrics.calls, wantCalls)
+	}
+}
+
+func TestCompletionDoesNotTripBreakerOnClientErrors(t *testing.T) {
+	primary := &stubProvider{name: "openai", err: &StatusError{Provider: "openai", StatusCode: http.StatusBadRequest}}
+
+	c, err := NewClient(Config{
+		Providers:       map[string]ProviderFactory{"openai": stubFactory(primary)},
+		DefaultProvider: "openai",
+		Breaker:         BreakerConfig{FailureThreshold: 1, Cooldown: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	_, err = c.Completion(context.Background(), UserOptions{APIKey: "k"}, CompletionRequest{Prompt: "hi"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected bad request error, got %v", err)
+	}
+	if got := c.BreakerState("openai"); got != BreakerClosed {
+		t.Fatalf("expected closed breaker, got %s", got)
+	}
+}
+
+func TestFailoverDropsPrimaryModelOnFallbacks(t *testing.T) {
+	primary := &stubProvider{name: "openai", err: &StatusError{Provider: "openai", StatusCode: http.StatusServiceUnavailable}}
+	fallback := &stubProvider{name: "gemini"}
+
+	c, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"openai": stubFactory(primary),
+			"gemini": stubFactory(fallback),
+		},
+		DefaultProvider: "openai",
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	// The handler puts a user's preferred model in the request metadata.
+	opts := UserOptions{APIKey: "k1", Fallbacks: []UserOptions{{Provider: "gemini", APIKey: "k2"}}}
+	req := CompletionRequest{Prompt: "hi", Metadata: map[string]any{"model": "gpt-4o", "temperature": 0.2}}
+	if _, err := c.Completion(context.Background(), opts, req); err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+
+	if len(primary.models) != 1 || primary.models[0] != "gpt-4o" {
+		t.Fatalf("primary models = %v, want the preferred model", primary.models)
+	}
+	if len(fallback.models) != 1 || fallback.models[0] != "" {
+		t.Fatalf("fallback models = %v, want the provider default", fallback.models)
+	}
+	if req.Metadata["model"] != "gpt-4o" {
+		t.Fatal("caller's metadata was modified")
+	}
+}
+
+func TestFailoverStopsOnClientErrors(t *testing.T) {
+	primary := &stubProvider{name: "openai", err: &StatusError{Provider: "openai", StatusCode: http.StatusBadRequest}}
+	fallback := &stubProvider{name: "gemini"}
+	metrics := &recordingMetrics{}
+
+	c, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"openai": stubFactory(primary),
+			"gemini": stubFactory(fallback),
+		},
+		DefaultProvider: "openai",
+		Metrics:         metrics,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	opts := UserOptions{APIKey: "k1", Fallbacks: []UserOptions{{Provider: "gemini", APIKey: "k2"}}}
+	if _, err := c.Completion(context.Background(), opts, CompletionRequest{Prompt: "hi"}); err == nil {
+		t.Fatal("expected the bad request error")
+	}
+	if fallback.calls != 0 || len(metrics.failovers) != 0 {
+		t.Fatalf("bad request replayed on fallback: %d calls, failovers %v", fallback.calls, metrics.failovers)
+	}
+
+	// A candidate that cannot be built is skipped without counting as a failover.
+	opts = UserOptions{Provider: "missing", Fallbacks: []UserOptions{{Provider: "gemini", APIKey: "k2"}}}
+	if _, err := c.Completion(context.Background(), opts, CompletionRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if fallback.calls != 1 || len(metrics.failovers) != 0 {
+		t.Fatalf("expected fallback call without failover event, got %d calls, failovers %v", fallback.calls, metrics.failovers)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	now := time.Now()
+	br := newBreaker("openai", BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}, noopMetrics{})
+	br.now = func() time.Time { return now }
+
+	br.failure()
+	if br.allow() {
+		t.Fatal("expected open breaker to reject calls")
+	}
+
+	now = now.Add(time.Minute)
+	if !br.allow() {
+		t.Fatal("expected a probe after cooldown")
+	}
+	if br.allow() {
+		t.Fatal("expected only one concurrent probe")
+	}
+
+	br.success()
+	if br.currentState() != BreakerClosed || !br.allow() {
+		t.Fatal("expected breaker to close after a successful probe")
+	}
+}
+
+type pingingProvider struct {
+	stubProvider
+	pinged bool
+}
+
+func (p *pingingProvider) Ping(context.Context) error {
+	p.pinged = true
+	return p.err
+}
+
+func TestPingUsesStatusCheckerAndNeverCaches(t *testing.T) {
+	pinger := &pingingProvider{stubProvider: stubProvider{name: "pinger"}}
+	var inits []ProviderInit
+	client, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"pinger": func(init ProviderInit) (Provider, error) {
+				inits = append(inits, init)
+				return pinger, nil
+			},
+			"plain": stubFactory(&stubProvider{name: "plain"}),
+		},
+		DefaultProvider: "pinger",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	meta := map[string]any{"base_url": "https://proxy.example/v1"}
+	for range 2 {
+		if err := client.Ping(context.Background(), UserOptions{APIKey: "k", Metadata: meta}); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	}
+	if !pinger.pinged || len(inits) != 2 {
+		t.Fatalf("pinged=%v inits=%d, want a fresh provider per check", pinger.pinged, len(inits))
+	}
+	if inits[0].Credential.BaseURL() != "https://proxy.example/v1" || inits[0].HTTPClient == nil {
+		t.Fatalf("init did not carry metadata and the shared http client: %+v", inits[0])
+	}
+
+	if err := client.Ping(context.Background(), UserOptions{Provider: "plain"}); !errors.Is(err, ErrCapabilityNotImplemented) {
+		t.Fatalf("expected ErrCapabilityNotImplemented, got %v", err)
+	}
+	if err := client.Ping(context.Background(), UserOptions{Provider: "missing"}); !errors.Is(err, ErrProviderNotConfigured) {
+		t.Fatalf("expected ErrProviderNotConfigured, got %v", err)
+	}
+}
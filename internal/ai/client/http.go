@@ -0,0 +1,25 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	dialTimeout           = 10 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = 2 * time.Minute
+	requestTimeout        = 5 * time.Minute
+)
+
+// NewHTTPClient returns the client used for provider traffic when Config.HTTPClient is
+// nil. Unlike http.DefaultClient it bounds connecting, the TLS handshake and the whole
+// request, while leaving room for slow completions.
+func NewHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	return &http.Client{Transport: transport, Timeout: requestTimeout}
+}
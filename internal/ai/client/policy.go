@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProviderNotAllowed = errors.New("ai: provider not allowed for company")
+	ErrModelNotAllowed    = errors.New("ai: model not allowed for company")
+)
+
+// ModelPolicy restricts the providers and models a company may use. AllowedModels reports
+// whether providerID is allowed and, when it is, which models; nil models means any.
+type ModelPolicy interface {
+	AllowedModels(ctx context.Context, companyID uuid.UUID, providerID string) ([]string, bool, error)
+}
+
+// DefaultModeler is implemented by providers that can report the model they use when a
+// request does not name one.
+type DefaultModeler interface {
+	DefaultModel() string
+}
+
+// allowedModels consults the model policy for opts. Requests without a company are not
+// restricted. A nil slice with a nil error means every model is allowed.
+func (c *Client) allowedModels(ctx context.Context, opts UserOptions, providerID string) ([]string, error) {
+	if c.policy == nil || opts.CompanyID == uuid.Nil {
+		return nil, nil
+	}
+	models, ok, err := c.policy.AllowedModels(ctx, opts.CompanyID, providerID)
+	if err != nil {
+		c.logger.Error(ctx, "ai: model policy lookup failed", err, "provider", providerID)
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotAllowed, providerID)
+	}
+	return models, nil
+}
+
+// pickModel chooses the model to request under an allowlist. An explicitly requested model
+// must be allowed; otherwise the provider default is kept when allowed and the first
+// allowed model substituted when not. An empty result leaves the provider default in place.
+func pickModel(allowed []string, requested, providerDefault string) (string, error) {
+	if len(allowed) == 0 {
+		return requested, nil
+	}
+	if requested != "" {
+		if containsModel(allowed, requested) {
+			return requested, nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrModelNotAllowed, requested)
+	}
+	if providerDefault != "" && containsModel(allowed, providerDefault) {
+		return "", nil
+	}
+	return allowed[0], nil
+}
+
+func containsModel(models []string, model string) bool {
+	for _, candidate := range models {
+		if candidate == model {
+			return true
+		}
+	}
+	return false
+}
+
+func metadataModel(metadata map[string]any) string {
+	if value, ok := metadata["model"].(string); ok {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+// restrictedProvider applies a company's model allowlist to every call, rewriting the
+// request metadata so the provider only ever sees an allowed model.
+type restrictedProvider struct {
+	Provider
+	allowed   []string
+	initModel string
+}
+
+func restrictProvider(provider Provider, allowed []string, initMetadata map[string]any) Provider {
+	if len(allowed) == 0 {
+		return provider
+	}
+	return &restrictedProvider{Provider: provider, allowed: allowed, initModel: metadataModel(initMetadata)}
+}
+
+func (r *restrictedProvider) constrain(metadata map[string]any) (map[string]any, error) {
+	requested := metadataModel(metadata)
+	if requested == "" {
+		requested = r.initModel
+	}
+	providerDefault := ""
+	if defaulter, ok := r.Provider.(DefaultModeler); ok {
+		providerDefault = defaulter.DefaultModel()
+	}
+
+	model, err := pickModel(r.allowed, requested, providerDefault)
+	if err != nil || model == "" || model == metadataModel(metadata) {
+		return metadata, err
+	}
+	out := make(map[string]any, len(metadata)+1)
+	for key, value := range metadata {
+		out[key] = value
+	}
+	out["model"] = model
+	return out, nil
+}
+
+func (r *restrictedProvider) Completion(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	metadata, err := r.constrain(req.Metadata)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	req.Metadata = metadata
+	return r.Provider.Completion(ctx, req)
+}
+
+func (r *restrictedProvider) Chat(ctx context.Context, req ChatRequest) (ConversationReply, error) {
+	chatter, ok := r.Provider.(ChatProvider)
+	if !ok {
+		return ConversationReply{}, fmt.Errorf("%w: %s chat", ErrCapabilityNotImplemented, r.Name())
+	}
+	metadata, err := r.constrain(req.Metadata)
+	if err != nil {
+		return ConversationReply{}, err
+	}
+	req.Metadata = metadata
+	return chatter.Chat(ctx, req)
+}
+
+func (r *restrictedProvider) Conversation(ctx context.Context) ConversationHandler {
+	inner := r.Provider.Conversation(ctx)
+	if inner == nil {
+		return nil
+	}
+	return &restrictedConversation{inner: inner, provider: r}
+}
+
+func (r *restrictedProvider) Documents(ctx context.Context) DocumentHandler {
+	inner := r.Provider.Documents(ctx)
+	if inner == nil {
+		return nil
+	}
+	return &restrictedDocuments{inner: inner, provider: r}
+}
+
+// Models lists only the allowed models the provider actually serves.
+func (r *restrictedProvider) Models(ctx context.Context) ([]string, error) {
+	lister, ok := r.Provider.(ModelLister)
+	if !ok {
+		return nil, ErrCapabilityNotImplemented
+	}
+	models, err := lister.Models(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]string, 0, len(models))
+	for _, model := range models {
+		if containsModel(r.allowed, model) {
+			filtered = append(filtered, model)
+		}
+	}
+	return filtered, nil
+}
+
+type restrictedConversation struct {
+	inner    ConversationHandler
+	provider *restrictedProvider
+}
+
+func (c *restrictedConversation) Send(ctx context.Context, message ConversationMessage) (ConversationReply, error) {
+	metadata, err := c.provider.constrain(message.Metadata)
+	if err != nil {
+		return ConversationReply{}, err
+	}
+	message.Metadata = metadata
+	return c.inner.Send(ctx, message)
+}
+
+type restrictedDocuments struct {
+	inner    DocumentHandler
+	provider *restrictedProvider
+}
+
+func (d *restrictedDocuments) Analyze(ctx context.Context, request DocumentRequest) (DocumentResponse, error) {
+	metadata, err := d.provider.constrain(request.Metadata)
+	if err != nil {
+		return DocumentResponse{}, err
+	}
+	request.Metadata = metadata
+	return d.inner.Analyze(ctx, request)
+}
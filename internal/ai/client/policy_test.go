@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type stubPolicy map[string][]string
+
+func (p stubPolicy) AllowedModels(_ context.Context, _ uuid.UUID, providerID string) ([]string, bool, error) {
+	models, ok := p[providerID]
+	return models, ok, nil
+}
+
+type modelProvider struct {
+	stubProvider
+	defaultModel string
+	lastModel    string
+}
+
+func (p *modelProvider) DefaultModel() string { return p.defaultModel }
+
+func (p *modelProvider) Completion(_ context.Context, req CompletionRequest) (CompletionResponse, error) {
+	p.calls++
+	p.lastModel, _ = req.Metadata["model"].(string)
+	return CompletionResponse{Text: p.name}, nil
+}
+
+func TestModelPolicyEnforcesProviderAndModelAllowlist(t *testing.T) {
+	openai := &modelProvider{stubProvider: stubProvider{name: "openai"}, defaultModel: "gpt-4o"}
+	gemini := &stubProvider{name: "gemini"}
+	c, err := NewClient(Config{
+		Providers: map[string]ProviderFactory{
+			"openai": func(ProviderInit) (Provider, error) { return openai, nil },
+			"gemini": stubFactory(gemini),
+		},
+		DefaultProvider: "openai",
+		ModelPolicy:     stubPolicy{"openai": {"gpt-4o-mini"}},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+	company := uuid.New()
+
+	if _, err := c.Completion(ctx, UserOptions{Provider: "gemini", APIKey: "k", CompanyID: company}, CompletionRequest{}); !errors.Is(err, ErrProviderNotAllowed) {
+		t.Fatalf("expected ErrProviderNotAllowed for gemini, got %v", err)
+	}
+	if gemini.calls != 0 {
+		t.Fatalf("disallowed provider was called")
+	}
+
+	opts := UserOptions{Provider: "openai", APIKey: "k", CompanyID: company}
+	if _, err := c.Completion(ctx, opts, CompletionRequest{}); err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if openai.lastModel != "gpt-4o-mini" {
+		t.Fatalf("expected disallowed default to be replaced by gpt-4o-mini, got %q", openai.lastModel)
+	}
+
+	_, err = c.Completion(ctx, opts, CompletionRequest{Metadata: map[string]any{"model": "gpt-4o"}})
+	if !errors.Is(err, ErrModelNotAllowed) {
+		t.Fatalf("expected ErrModelNotAllowed, got %v", err)
+	}
+
+	// Requests without a company, such as background jobs, are not restricted.
+	if _, err := c.Completion(ctx, UserOptions{Provider: "gemini", APIKey: "k"}, CompletionRequest{}); err != nil {
+		t.Fatalf("expected unrestricted call without company, got %v", err)
+	}
+}
+
+func TestPickModelKeepsAllowedDefault(t *testing.T) {
+	cases := []struct {
+		allowed           []string
+		requested, def    string
+		want              string
+		wantNotAllowedErr bool
+	}{
+		{allowed: nil, requested: "any", want: "any"},
+		{allowed: []string{"a", "b"}, requested: "b", want: "b"},
+		{allowed: []string{"a", "b"}, def: "b", want: ""},
+		{allowed: []string{"a", "b"}, def: "c", want: "a"},
+		{allowed: []string{"a"}, requested: "c", wantNotAllowedErr: true},
+	}
+	for _, tc := range cases {
+		got, err := pickModel(tc.allowed, tc.requested, tc.def)
+		if tc.wantNotAllowedErr {
+			if !errors.Is(err, ErrModelNotAllowed) {
+				t.Errorf("pickModel(%v, %q, %q): expected ErrModelNotAllowed, got %v", tc.allowed, tc.requested, tc.def, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("pickModel(%v, %q, %q) = %q, %v; want %q", tc.allowed, tc.requested, tc.def, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how provider HTTP calls are retried on throttling and server errors.
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts after the first; negative disables retries.
+	MaxRetries int
+	// BaseDelay seeds the exponential backoff; each retry waits a random duration up to
+	// BaseDelay*2^attempt, capped at MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps backoff. A Retry-After longer than MaxDelay ends retries early so the
+	// caller can fail over instead of blocking.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used when Config.Retry is left empty.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: 2, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second}
+}
+
+func (p RetryPolicy) normalized() RetryPolicy {
+	if p == (RetryPolicy{}) {
+		return DefaultRetryPolicy()
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy().BaseDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return p
+}
+
+// backoff returns the jittered delay before retry number attempt (starting at 0).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// retryTransport wraps an http.RoundTripper so every provider request made through the
+// client's shared http.Client is retried without the providers having to know about it.
+type retryTransport struct {
+	base    http.RoundTripper
+	policy  RetryPolicy
+	metrics Metrics
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper, policy RetryPolicy, metrics Metrics) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, policy: policy.normalized(), metrics: metrics, sleep: sleepContext}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	provider := providerFromContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		// A RoundTripper must not modify the caller's request, so retries send a clone
+		// with a fresh body.
+		attemptReq := req
+		if attempt > 0 {
+			if req.Body != nil && req.GetBody == nil {
+				return nil, errors.New("ai: request body cannot be replayed for retry")
+			}
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= t.policy.MaxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		reason, delay, retry := t.classify(resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		t.metrics.Retry(provider, reason)
+
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *retryTransport) classify(resp *http.Response, err error, attempt int) (string, time.Duration, bool) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return "", 0, false
+		}
+		return "transport", t.policy.backoff(attempt), true
+	}
+	if resp == nil || !retryableStatus(resp.StatusCode) {
+		return "", 0, false
+	}
+
+	delay := t.policy.backoff(attempt)
+	if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+		if retryAfter > t.policy.MaxDelay {
+			return "", 0, false
+		}
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+	return strconv.Itoa(resp.StatusCode), delay, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type providerContextKey struct{}
+
+// withProvider tags ctx with the provider handling the request so transport-level
+// instrumentation can attribute retries.
+func withProvider(ctx context.Context, providerID string) context.Context {
+	return context.WithValue(ctx, providerContextKey{}, providerID)
+}
+
+func providerFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(providerContextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return "unknown"
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError reports a non-2xx response from a provider API.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+// NewStatusError builds a StatusError from a provider response and its (already read) body.
+func NewStatusError(provider string, resp *http.Response, body []byte) *StatusError {
+	err := &StatusError{Provider: provider, Body: strings.TrimSpace(string(body))}
+	if resp != nil {
+		err.StatusCode = resp.StatusCode
+		err.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return err
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the status indicates throttling or a server-side failure.
+func (e *StatusError) Temporary() bool {
+	return retryableStatus(e.StatusCode)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// parseRetryAfter understands both the delta-seconds and HTTP-date forms of Retry-After.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// isProviderFailure reports whether err indicates the provider itself is unhealthy, as
+// opposed to a bad request or a caller cancellation. Only these failures trip breakers.
+func isProviderFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// shouldFailOver reports whether a failed call is worth repeating on the next provider in
+// a fallback chain: provider failures, as for retries, and capabilities the provider
+// lacks. Rejected requests (bad input, models outside the allowlist) would fail the same
+// way everywhere.
+func shouldFailOver(err error) bool {
+	return isProviderFailure(err) || errors.Is(err, ErrCapabilityNotImplemented)
+}
+
+// Provider call statuses, as reported to Metrics.
+const (
+	CallOK          = "ok"
+	CallCanceled    = "canceled"
+	CallTimeout     = "timeout"
+	CallRateLimited = "rate_limited"
+	CallServerError = "server_error"
+	CallClientError = "client_error"
+	CallNetwork     = "network_error"
+	CallError       = "error"
+)
+
+// callStatus buckets err into one of the Call* statuses.
+func callStatus(err error) string {
+	if err == nil {
+		return CallOK
+	}
+	if errors.Is(err, context.Canceled) {
+		return CallCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CallTimeout
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return CallRateLimited
+		case statusErr.StatusCode >= http.StatusInternalServerError:
+			return CallServerError
+		default:
+			return CallClientError
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return CallNetwork
+	}
+	return CallError
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ErrBudgetExceeded is returned when a company has exhausted its monthly AI budget.
+var ErrBudgetExceeded = errors.New("ai: monthly budget exceeded")
+
+// Usage reports the tokens consumed by a provider call.
+type Usage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Add accumulates another call's token counts, keeping the first non-empty model.
+func (u Usage) Add(other Usage) Usage {
+	if u.Model == "" {
+		u.Model = other.Model
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	return u
+}
+
+// UsageRecord describes one billable provider call.
+type UsageRecord struct {
+	CompanyID uuid.UUID
+	UserID    uuid.UUID
+	Provider  string
+	Feature   string
+	Usage     Usage
+}
+
+// BudgetDecision is the outcome of a budget check. Warning is set once spend crosses the
+// company's soft threshold; Allowed is false once the hard limit is reached.
+type BudgetDecision struct {
+	Allowed bool
+	Warning string
+}
+
+// Accountant records token usage and enforces per-company budgets.
+type Accountant interface {
+	CheckBudget(ctx context.Context, companyID uuid.UUID) (BudgetDecision, error)
+	RecordUsage(ctx context.Context, record UsageRecord) error
+}
+
+// checkBudget enforces the company's hard stop and returns any soft warning.
+// Budget lookups that fail are logged and allowed so an accounting outage does not block users.
+func (c *Client) checkBudget(ctx context.Context, opts UserOptions) (string, error) {
+	if c.accountant == nil || opts.CompanyID == uuid.Nil {
+		return "", nil
+	}
+	decision, err := c.accountant.CheckBudget(ctx, opts.CompanyID)
+	if err != nil {
+		c.logger.Error(ctx, "ai: budget check failed", err, "company_id", opts.CompanyID.String())
+		return "", nil
+	}
+	if !decision.Allowed {
+		c.logger.Info(ctx, "ai: budget exceeded", "company_id", opts.CompanyID.String())
+		if decision.Warning != "" {
+			return "", fmt.Errorf("%w: %s", ErrBudgetExceeded, decision.Warning)
+		}
+		return "", ErrBudgetExceeded
+	}
+	if decision.Warning != "" {
+		c.logger.Info(ctx, "ai: budget warning", "company_id", opts.CompanyID.String(), "warning", decision.Warning)
+	}
+	return decision.Warning, nil
+}
+
+func (c *Client) recordUsage(ctx context.Context, opts UserOptions, provider string, usage Usage) {
+	if c.accountant == nil || opts.CompanyID == uuid.Nil {
+		return
+	}
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return
+	}
+	record := UsageRecord{
+		CompanyID: opts.CompanyID,
+		UserID:    opts.UserID,
+		Provider:  provider,
+		Feature:   opts.Feature,
+		Usage:     usage,
+	}
+	if err := c.accountant.RecordUsage(ctx, record); err != nil {
+		c.logger.Error(ctx, "ai: usage record failed", err, "provider", provider)
+	}
+}
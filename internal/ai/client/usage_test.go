@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type stubAccountant struct {
+	decision BudgetDecision
+	records  []UsageRecord
+}
+
+func (a *stubAccountant) CheckBudget(context.Context, uuid.UUID) (BudgetDecision, error) {
+	return a.decision, nil
+}
+
+func (a *stubAccountant) RecordUsage(_ context.Context, record UsageRecord) error {
+	a.records = append(a.records, record)
+	return nil
+}
+
+type usageProvider struct{ stubProvider }
+
+func (p *usageProvider) Completion(context.Context, CompletionRequest) (CompletionResponse, error) {
+	p.calls++
+	return CompletionResponse{Text: "ok", Usage: Usage{Model: "m", PromptTokens: 10, CompletionTokens: 5}}, nil
+}
+
+func TestCompletionRecordsUsageAndWarns(t *testing.T) {
+	provider := &usageProvider{stubProvider{name: "openai"}}
+	accountant := &stubAccountant{decision: BudgetDecision{Allowed: true, Warning: "at 90%"}}
+	c, err := NewClient(Config{
+		Providers:       map[string]ProviderFactory{"openai": func(ProviderInit) (Provider, error) { return provider, nil }},
+		DefaultProvider: "openai",
+		Accountant:      accountant,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	opts := UserOptions{APIKey: "k", CompanyID: uuid.New(), Feature: "completion"}
+	resp, err := c.Completion(context.Background(), opts, CompletionRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0] != "at 90%" {
+		t.Fatalf("expected budget warning, got %v", resp.Warnings)
+	}
+	if len(accountant.records) != 1 {
+		t.Fatalf("expected one usage record, got %d", len(accountant.records))
+	}
+	record := accountant.records[0]
+	if record.Provider != "openai" || record.Feature != "completion" || record.Usage.PromptTokens != 10 {
+		t.Fatalf("unexpected usage record %+v", record)
+	}
+}
+
+func TestCompletionStopsWhenBudgetExceeded(t *testing.T) {
+	provider := &usageProvider{stubProvider{name: "openai"}}
+	c, err := NewClient(Config{
+		Providers:       map[string]ProviderFactory{"openai": func(ProviderInit) (Provider, error) { return provider, nil }},
+		DefaultProvider: "openai",
+		Accountant:      &stubAccountant{decision: BudgetDecision{Allowed: false}},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	_, err = c.Completion(context.Background(), UserOptions{APIKey: "k", CompanyID: uuid.New()}, CompletionRequest{Prompt: "hi"})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if provider.calls != 0 {
+		t.Fatalf("expected no provider calls, got %d", provider.calls)
+	}
+}
@@ -14,6 +14,8 @@ import (
 type Store interface {
 	CreateSession(ctx context.Context, params CreateSessionParams) (Session, error)
 	UpdateSessionTitle(ctx context.Context, id uuid.UUID, companyID uuid.UUID, title string) error
+	// MergeSessionMetadata sets the top-level keys in patch, leaving other keys untouched.
+	MergeSessionMetadata(ctx context.Context, id uuid.UUID, companyID uuid.UUID, patch map[string]any) error
 	ListSessions(ctx context.Context, companyID uuid.UUID, limit, offset int32) ([]Session, error)
 	DeleteSession(ctx context.Context, id uuid.UUID, companyID uuid.UUID) error
 	InsertMessage(ctx context.Context, params CreateMessageParams) (Message, error)
@@ -78,7 +80,7 @@ func (s *Service) StartSession(ctx context.Context, params CreateSessionParams)
 	if err != nil {
 		return Session{}, err
 	}
-	s.logger.Info(ctx, "ai: conversation session created", map[string]any{"session_id": session.ID, "company_id": session.CompanyID})
+	s.logger.Info(ctx, "ai: conversation session created", "session_id", session.ID, "company_id", session.CompanyID)
 	return session, nil
 }
 
@@ -89,7 +91,16 @@ func (s *Service) RenameSession(ctx context.Context, companyID, sessionID uuid.U
 	if err := s.store.UpdateSessionTitle(ctx, sessionID, companyID, title); err != nil {
 		return err
 	}
-	s.logger.Info(ctx, "ai: conversation session renamed", map[string]any{"session_id": sessionID, "company_id": companyID})
+	s.logger.Info(ctx, "ai: conversation session renamed", "session_id", sessionID, "company_id", companyID)
+	return nil
+}
+
+// UpdateSessionMetadata sets the top-level keys in patch on a session's metadata.
+func (s *Service) UpdateSessionMetadata(ctx context.Context, companyID, sessionID uuid.UUID, patch map[string]any) error {
+	if err := s.store.MergeSessionMetadata(ctx, sessionID, companyID, patch); err != nil {
+		return err
+	}
+	s.logger.Info(ctx, "ai: conversation session metadata updated", "session_id", sessionID, "company_id", companyID)
 	return nil
 }
 
@@ -100,7 +111,7 @@ func (s *Service) RemoveSession(ctx context.Context, companyID, sessionID uuid.U
 	if err := s.store.DeleteSession(ctx, sessionID, companyID); err != nil {
 		return err
 	}
-	s.logger.Info(ctx, "ai: conversation session deleted", map[string]any{"session_id": sessionID, "company_id": companyID})
+	s.logger.Info(ctx, "ai: conversation session deleted", "session_id", sessionID, "company_id", companyID)
 	return nil
 }
 
@@ -109,7 +120,7 @@ func (s *Service) AppendMessage(ctx context.Context, params CreateMessageParams)
 	if err != nil {
 		return Message{}, err
 	}
-	s.logger.Info(ctx, "ai: conversation message appended", map[string]any{"session_id": params.SessionID, "role": params.Role})
+	s.logger.Info(ctx, "ai: conversation message appended", "session_id", params.SessionID, "role", params.Role)
 	return msg, nil
 }
 
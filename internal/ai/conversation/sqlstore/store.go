@@ -45,6 +45,18 @@ func (s *Store) UpdateSessionTitle(ctx context.Context, id, companyID uuid.UUID,
 	})
 }
 
+func (s *Store) MergeSessionMetadata(ctx context.Context, id, companyID uuid.UUID, patch map[string]any) error {
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	return s.queries.MergeAIConversationSessionMetadata(ctx, database.MergeAIConversationSessionMetadataParams{
+		Patch:     raw,
+		ID:        id,
+		CompanyID: companyID,
+	})
+}
+
 func (s *Store) ListSessions(ctx context.Context, companyID uuid.UUID, limit, offset int32) ([]conversation.Session, error) {
 	rows, err := s.queries.ListAIConversationSessionsByCompany(ctx, database.ListAIConversationSessionsByCompanyParams{
 		CompanyID: companyID,
@@ -0,0 +1,96 @@
+package conversation
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+// Metadata keys used to persist tool traffic alongside message content.
+const (
+	MetadataToolCalls  = "tool_calls"
+	MetadataToolCallID = "tool_call_id"
+	MetadataToolName   = "tool_name"
+)
+
+type storedToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Transcript converts stored messages into role-tagged turns for a chat request,
+// restoring tool calls and tool results from message metadata.
+func Transcript(messages []Message) []clientpkg.ConversationMessage {
+	out := make([]clientpkg.ConversationMessage, 0, len(messages))
+	for _, msg := range messages {
+		role := msg.Role
+		if role == "" {
+			role = clientpkg.RoleUser
+		}
+		turn := clientpkg.ConversationMessage{Role: role, Content: msg.Content}
+		switch role {
+		case clientpkg.RoleAssistant:
+			turn.ToolCalls = decodeToolCalls(msg.Metadata[MetadataToolCalls])
+		case clientpkg.RoleTool:
+			turn.ToolCallID, _ = msg.Metadata[MetadataToolCallID].(string)
+			turn.ToolName, _ = msg.Metadata[MetadataToolName].(string)
+		}
+		out = append(out, turn)
+	}
+	return out
+}
+
+// TurnParams builds the parameters to persist a generated turn, recording tool calls and
+// tool-result identifiers in metadata so Transcript can replay them.
+func TurnParams(sessionID uuid.UUID, turn clientpkg.ConversationMessage, metadata map[string]any) CreateMessageParams {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	if len(turn.ToolCalls) > 0 {
+		calls := make([]storedToolCall, 0, len(turn.ToolCalls))
+		for _, call := range turn.ToolCalls {
+			calls = append(calls, storedToolCall{ID: call.ID, Name: call.Name, Arguments: call.Arguments})
+		}
+		metadata[MetadataToolCalls] = calls
+	}
+	role := turn.Role
+	switch role {
+	case "", "model":
+		// Gemini answers as "model"; store one vocabulary regardless of provider.
+		role = clientpkg.RoleAssistant
+	case clientpkg.RoleTool:
+		metadata[MetadataToolCallID] = turn.ToolCallID
+		if turn.ToolName != "" {
+			metadata[MetadataToolName] = turn.ToolName
+		}
+	}
+	return CreateMessageParams{
+		SessionID: sessionID,
+		Role:      role,
+		Content:   turn.Content,
+		Metadata:  metadata,
+	}
+}
+
+// decodeToolCalls accepts tool calls as stored (after a JSON round trip) or in memory.
+func decodeToolCalls(value any) []clientpkg.ToolCall {
+	if value == nil {
+		return nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var stored []storedToolCall
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil
+	}
+	calls := make([]clientpkg.ToolCall, 0, len(stored))
+	for _, call := range stored {
+		calls = append(calls, clientpkg.ToolCall{ID: call.ID, Name: call.Name, Arguments: call.Arguments})
+	}
+	return calls
+}
@@ -0,0 +1,48 @@
+package conversation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+func TestTurnParamsRoundTripThroughTranscript(t *testing.T) {
+	sessionID := uuid.New()
+	turns := []clientpkg.ConversationMessage{
+		{Role: clientpkg.RoleAssistant, ToolCalls: []clientpkg.ToolCall{{ID: "call_1", Name: "lookup", Arguments: `{"q":"asc 606"}`}}},
+		{Role: clientpkg.RoleTool, ToolCallID: "call_1", ToolName: "lookup", Content: `{"ok":true}`},
+		{Role: "model", Content: "done"},
+	}
+
+	stored := make([]Message, 0, len(turns))
+	for _, turn := range turns {
+		params := TurnParams(sessionID, turn, nil)
+		// Simulate the JSONB round trip performed by the store.
+		raw, err := json.Marshal(params.Metadata)
+		if err != nil {
+			t.Fatalf("marshal metadata: %v", err)
+		}
+		var metadata map[string]any
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			t.Fatalf("unmarshal metadata: %v", err)
+		}
+		stored = append(stored, Message{Role: params.Role, Content: params.Content, Metadata: metadata})
+	}
+
+	replayed := Transcript(stored)
+	if len(replayed) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(replayed))
+	}
+	if calls := replayed[0].ToolCalls; len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Arguments != `{"q":"asc 606"}` {
+		t.Fatalf("tool calls not restored: %+v", replayed[0])
+	}
+	if replayed[1].ToolCallID != "call_1" || replayed[1].ToolName != "lookup" {
+		t.Fatalf("tool result not restored: %+v", replayed[1])
+	}
+	if replayed[2].Role != clientpkg.RoleAssistant {
+		t.Fatalf("expected model role normalised to assistant, got %q", replayed[2].Role)
+	}
+}
@@ -0,0 +1,246 @@
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+// MetadataSummary is the session metadata key holding the rolling summary of turns that
+// are no longer sent verbatim.
+const MetadataSummary = "context_summary"
+
+const (
+	// charsPerToken is a deliberately conservative estimate for English prose; real
+	// tokenizers average closer to four and a half characters.
+	charsPerToken = 4
+	// messageOverheadTokens covers the role and framing tokens providers add per message.
+	messageOverheadTokens = 4
+	// maxReserveTokens caps the share of the window held back for the system prompt, tool
+	// definitions, and the reply.
+	maxReserveTokens = 4096
+	// summaryTurnChars bounds how much of a single turn is shown to the summarizer.
+	summaryTurnChars = 2000
+)
+
+// RollingSummary condenses the oldest turns of a session. Through identifies the last
+// message folded into Text; later messages are still replayed verbatim.
+type RollingSummary struct {
+	Text      string    `json:"text"`
+	Through   uuid.UUID `json:"through"`
+	Messages  int       `json:"messages"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Summarizer extends previous with the supplied turns and returns the new summary text.
+type Summarizer func(ctx context.Context, previous string, turns []clientpkg.ConversationMessage) (string, error)
+
+// ContextOptions sizes the history sent with a chat request.
+type ContextOptions struct {
+	// Window is the model's context window in tokens.
+	Window int
+	// Reserve is held back for the system prompt, tools, and the reply. Zero reserves a
+	// quarter of the window, capped at maxReserveTokens.
+	Reserve int
+}
+
+func (o ContextOptions) budget() int {
+	reserve := o.Reserve
+	if reserve <= 0 {
+		reserve = o.Window / 4
+		if reserve > maxReserveTokens {
+			reserve = maxReserveTokens
+		}
+	}
+	return o.Window - reserve
+}
+
+// EstimateTokens approximates the token count of text without a provider tokenizer.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateTurnTokens approximates the tokens a turn occupies, including its tool calls.
+func EstimateTurnTokens(turn clientpkg.ConversationMessage) int {
+	tokens := messageOverheadTokens + EstimateTokens(turn.Content)
+	for _, call := range turn.ToolCalls {
+		tokens += messageOverheadTokens + EstimateTokens(call.Name) + EstimateTokens(call.Arguments)
+	}
+	return tokens
+}
+
+// BuildContext returns the turns to send for session: the stored rolling summary followed by
+// every later message verbatim. When that exceeds the budget the oldest turns are folded into
+// the summary, which is persisted so the next request starts from it. If summarizing fails
+// the folded turns are dropped for this request and the stored summary is left unchanged.
+func (s *Service) BuildContext(ctx context.Context, session Session, messages []Message, opts ContextOptions, summarize Summarizer) ([]clientpkg.ConversationMessage, error) {
+	summary, pending := summaryFromMetadata(session.Metadata), messages
+	if summary.Through != uuid.Nil {
+		if idx := indexOfMessage(messages, summary.Through); idx >= 0 {
+			pending = messages[idx+1:]
+		} else {
+			// The summarized messages are gone; start over rather than trust a stale summary.
+			summary = RollingSummary{}
+		}
+	}
+
+	turns := Transcript(pending)
+	budget := opts.budget()
+	total := EstimateTokens(summary.Text)
+	for _, turn := range turns {
+		total += EstimateTurnTokens(turn)
+	}
+	if opts.Window <= 0 || total <= budget {
+		return withSummary(summary.Text, turns), nil
+	}
+
+	split := splitForBudget(turns, budget*3/4)
+	if split == 0 {
+		return withSummary(summary.Text, turns), nil
+	}
+
+	attrs := []any{"session_id", session.ID, "folded", split, "estimated_tokens", total, "budget", budget}
+	if summarize == nil {
+		s.logger.Info(ctx, "ai: conversation history truncated", attrs...)
+		return withSummary(summary.Text, turns[split:]), nil
+	}
+
+	text, err := summarize(ctx, summary.Text, turns[:split])
+	if err != nil {
+		s.logger.Error(ctx, "ai: conversation summary failed", err, attrs...)
+		return withSummary(summary.Text, turns[split:]), nil
+	}
+
+	summary = RollingSummary{
+		Text:      strings.TrimSpace(text),
+		Through:   pending[split-1].ID,
+		Messages:  summary.Messages + split,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := s.store.MergeSessionMetadata(ctx, session.ID, session.CompanyID, map[string]any{MetadataSummary: summary}); err != nil {
+		s.logger.Error(ctx, "ai: conversation summary not saved", err, attrs...)
+	} else {
+		s.logger.Info(ctx, "ai: conversation summary updated", attrs...)
+	}
+	return withSummary(summary.Text, turns[split:]), nil
+}
+
+// splitForBudget returns how many leading turns to fold so the rest fits in keep tokens. The
+// kept history always starts at a user turn, so tool results stay with the call that produced
+// them and providers that require a leading user message accept it. The latest user turn is
+// always kept.
+func splitForBudget(turns []clientpkg.ConversationMessage, keep int) int {
+	lastUser := -1
+	for i := len(turns) - 1; i >= 0; i-- {
+		if turns[i].Role == clientpkg.RoleUser {
+			lastUser = i
+			break
+		}
+	}
+	if lastUser <= 0 {
+		return 0
+	}
+
+	split, used := len(turns), 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		used += EstimateTurnTokens(turns[i])
+		if used > keep {
+			break
+		}
+		split = i
+	}
+	for split < lastUser && turns[split].Role != clientpkg.RoleUser {
+		split++
+	}
+	if split > lastUser {
+		split = lastUser
+	}
+	return split
+}
+
+func withSummary(summary string, turns []clientpkg.ConversationMessage) []clientpkg.ConversationMessage {
+	if summary == "" {
+		return turns
+	}
+	out := make([]clientpkg.ConversationMessage, 0, len(turns)+1)
+	out = append(out, clientpkg.ConversationMessage{
+		Role:    clientpkg.RoleSystem,
+		Content: "Summary of the earlier conversation:\n" + summary,
+	})
+	return append(out, turns...)
+}
+
+func summaryFromMetadata(metadata map[string]any) RollingSummary {
+	value, ok := metadata[MetadataSummary]
+	if !ok || value == nil {
+		return RollingSummary{}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return RollingSummary{}
+	}
+	var summary RollingSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return RollingSummary{}
+	}
+	return summary
+}
+
+func indexOfMessage(messages []Message, id uuid.UUID) int {
+	for i, msg := range messages {
+		if msg.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// CompletionSummarizer builds a Summarizer that asks a model, through complete, to fold turns
+// into the running summary.
+func CompletionSummarizer(complete func(ctx context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error)) Summarizer {
+	return func(ctx context.Context, previous string, turns []clientpkg.ConversationMessage) (string, error) {
+		resp, err := complete(ctx, clientpkg.CompletionRequest{Prompt: summaryPrompt(previous, turns)})
+		if err != nil {
+			return "", err
+		}
+		text := strings.TrimSpace(resp.Text)
+		if text == "" {
+			return "", fmt.Errorf("ai: summarizer returned an empty summary")
+		}
+		return text, nil
+	}
+}
+
+func summaryPrompt(previous string, turns []clientpkg.ConversationMessage) string {
+	var b strings.Builder
+	b.WriteString("You maintain the running summary of a conversation between a user and an accounting assistant. ")
+	b.WriteString("Update the summary with the new turns below. Keep names, figures, dates, decisions, and open questions; ")
+	b.WriteString("drop pleasantries. Reply with the summary only, in at most 250 words.\n\n")
+	if previous != "" {
+		b.WriteString("Current summary:\n")
+		b.WriteString(previous)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("New turns:\n")
+	for _, turn := range turns {
+		content := turn.Content
+		if utf8.RuneCountInString(content) > summaryTurnChars {
+			content = string([]rune(content)[:summaryTurnChars]) + " …"
+		}
+		for _, call := range turn.ToolCalls {
+			content = strings.TrimSpace(content + fmt.Sprintf("\n[called %s]", call.Name))
+		}
+		if content == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", turn.Role, content)
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package conversation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+type metadataStore struct {
+	Store
+	patches []map[string]any
+}
+
+func (s *metadataStore) MergeSessionMetadata(_ context.Context, _, _ uuid.UUID, patch map[string]any) error {
+	s.patches = append(s.patches, patch)
+	return nil
+}
+
+func longMessages(n int) []Message {
+	messages := make([]Message, 0, n)
+	for i := 0; i < n; i++ {
+		role := clientpkg.RoleUser
+		if i%2 == 1 {
+			role = clientpkg.RoleAssistant
+		}
+		messages = append(messages, Message{ID: uuid.New(), Role: role, Content: strings.Repeat("x", 400)})
+	}
+	return messages
+}
+
+func TestBuildContextSendsShortHistoryVerbatim(t *testing.T) {
+	store := &metadataStore{}
+	svc := New(store, nil)
+	messages := longMessages(4)
+
+	summarize := func(context.Context, string, []clientpkg.ConversationMessage) (string, error) {
+		t.Fatal("summarizer should not run when history fits")
+		return "", nil
+	}
+	turns, err := svc.BuildContext(context.Background(), Session{ID: uuid.New()}, messages, ContextOptions{Window: 8192}, summarize)
+	if err != nil {
+		t.Fatalf("build context: %v", err)
+	}
+	if len(turns) != 4 || len(store.patches) != 0 {
+		t.Fatalf("expected 4 verbatim turns and no summary, got %d turns, %d patches", len(turns), len(store.patches))
+	}
+}
+
+func TestBuildContextFoldsOldTurnsIntoRollingSummary(t *testing.T) {
+	store := &metadataStore{}
+	svc := New(store, nil)
+	messages := longMessages(12)
+
+	var folded []clientpkg.ConversationMessage
+	summarize := func(_ context.Context, previous string, turns []clientpkg.ConversationMessage) (string, error) {
+		if previous != "earlier" {
+			t.Fatalf("expected previous summary, got %q", previous)
+		}
+		folded = turns
+		return "condensed", nil
+	}
+	session := Session{ID: uuid.New(), Metadata: map[string]any{
+		MetadataSummary: map[string]any{"text": "earlier", "through": messages[1].ID.String(), "messages": 2},
+	}}
+
+	// 1000 tokens leaves 750 for history: each 400-char turn costs 104 tokens.
+	turns, err := svc.BuildContext(context.Background(), session, messages, ContextOptions{Window: 1000, Reserve: 250}, summarize)
+	if err != nil {
+		t.Fatalf("build context: %v", err)
+	}
+
+	if turns[0].Role != clientpkg.RoleSystem || !strings.Contains(turns[0].Content, "condensed") {
+		t.Fatalf("expected summary system turn first, got %+v", turns[0])
+	}
+	if turns[1].Role != clientpkg.RoleUser {
+		t.Fatalf("kept history must start with a user turn, got %q", turns[1].Role)
+	}
+	if len(folded)+len(turns)-1 != 10 {
+		t.Fatalf("expected the 10 unsummarized messages to be folded or kept, got %d folded and %d kept", len(folded), len(turns)-1)
+	}
+
+	if len(store.patches) != 1 {
+		t.Fatalf("expected summary to be persisted once, got %d", len(store.patches))
+	}
+	saved, ok := store.patches[0][MetadataSummary].(RollingSummary)
+	if !ok {
+		t.Fatalf("unexpected patch %+v", store.patches[0])
+	}
+	if saved.Through != messages[1+len(folded)].ID || saved.Messages != 2+len(folded) {
+		t.Fatalf("unexpected saved summary %+v", saved)
+	}
+}
+
+func TestSplitForBudgetKeepsToolResultsWithTheirCall(t *testing.T) {
+	turns := []clientpkg.ConversationMessage{
+		{Role: clientpkg.RoleUser, Content: strings.Repeat("a", 400)},
+		{Role: clientpkg.RoleAssistant, ToolCalls: []clientpkg.ToolCall{{ID: "1", Name: "lookup"}}},
+		{Role: clientpkg.RoleTool, ToolCallID: "1", Content: strings.Repeat("b", 400)},
+		{Role: clientpkg.RoleAssistant, Content: "answer"},
+		{Role: clientpkg.RoleUser, Content: "next"},
+	}
+
+	split := splitForBudget(turns, 120)
+	if split != 4 {
+		t.Fatalf("expected to fold through the tool exchange, got split %d", split)
+	}
+}
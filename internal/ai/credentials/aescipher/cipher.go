@@ -1,33 +1,50 @@
 package aescipher
 
 import (
+	"bytes"
 	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"sort"
+	"strings"
 )
 
+// DefaultKeyID identifies the key passed to New, and the active key when no ID is configured.
+const DefaultKeyID = "v1"
+
 var (
 	ErrInvalidKeyLength = errors.New("aescipher: key must be 16, 24, or 32 bytes")
+	ErrInvalidKeyID     = errors.New("aescipher: key id must be 1-32 letters, digits, '.', '_' or '-'")
+	ErrUnknownKey       = errors.New("aescipher: ciphertext key is not in the key ring")
 	nonceSize           = 12
+	validKeyID          = regexp.MustCompile(`^[A-Za-z0-9._-]{1,32}$`)
 )
 
-// Cipher provides AES-GCM based encryption for provider credentials.
+// headerMagic starts every ciphertext written with a key ring. The layout is
+//
+//	"rvk1" || len(keyID) || keyID || nonce || sealed
+//
+// and the header is authenticated as GCM additional data. Ciphertexts without the header
+// predate key rings and are tried against every key.
+var headerMagic = []byte("rvk1")
+
+// Cipher provides AES-GCM based encryption for provider credentials. It encrypts with the
+// active key and decrypts with any key in the ring, so retired keys keep working until
+// every stored secret has been re-encrypted.
 type Cipher struct {
-	key []byte
+	active string
+	keys   map[string]cipher.AEAD
 }
 
-// New constructs a Cipher using the supplied AES key.
+// New constructs a Cipher using the supplied AES key as DefaultKeyID.
 func New(key []byte) (*Cipher, error) {
-	if !validKeyLength(len(key)) {
-		return nil, ErrInvalidKeyLength
-	}
-	buf := make([]byte, len(key))
-	copy(buf, key)
-	return &Cipher{key: buf}, nil
+	return NewKeyRing(DefaultKeyID, map[string][]byte{DefaultKeyID: key})
 }
 
 // NewFromBase64 creates a cipher from a base64 encoded key.
@@ -39,41 +56,194 @@ func NewFromBase64(encoded string) (*Cipher, error) {
 	return New(raw)
 }
 
-func (c *Cipher) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(c.key)
+// NewKeyRing constructs a Cipher that encrypts with keys[activeID] and decrypts with any key.
+func NewKeyRing(activeID string, keys map[string][]byte) (*Cipher, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("aescipher: active key %q not in key ring", activeID)
+	}
+	ring := &Cipher{active: activeID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if !validKeyID.MatchString(id) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyID, id)
+		}
+		if !validKeyLength(len(key)) {
+			return nil, fmt.Errorf("%w: key %q", ErrInvalidKeyLength, id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[id] = gcm
+	}
+	return ring, nil
+}
+
+// Load builds a key ring from configuration values: the base64 active key, its ID
+// (DefaultKeyID when blank), and retired keys as comma-separated "id:base64" pairs.
+func Load(activeKey, activeID, retired string) (*Cipher, error) {
+	activeID = strings.TrimSpace(activeID)
+	if activeID == "" {
+		activeID = DefaultKeyID
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(activeKey))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("aescipher: decode active key: %w", err)
 	}
-	gcm, err := cipher.NewGCM(block)
+	keys, err := ParseKeys(retired)
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := keys[activeID]; ok {
+		return nil, fmt.Errorf("aescipher: key %q is both active and retired", activeID)
+	}
+	keys[activeID] = raw
+	return NewKeyRing(activeID, keys)
+}
+
+// ParseKeys parses comma-separated "id:base64" pairs.
+func ParseKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("aescipher: key entry %q must be id:base64", entry)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("aescipher: duplicate key id %q", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("aescipher: decode key %q: %w", id, err)
+		}
+		keys[id] = raw
+	}
+	return keys, nil
+}
+
+// ActiveKeyID returns the ID of the key new ciphertexts are written with.
+func (c *Cipher) ActiveKeyID() string { return c.active }
+
+// KeyIDs returns every key ID in the ring, sorted.
+func (c *Cipher) KeyIDs() []string {
+	ids := make([]string, 0, len(c.keys))
+	for id := range c.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// KeyID returns the key ID recorded in a ciphertext header. Ciphertexts written before
+// key rings have no header and report false.
+func KeyID(ciphertext []byte) (string, bool) {
+	id, _, ok := parseHeader(ciphertext)
+	return id, ok
+}
+
+// NeedsRotation reports whether ciphertext was not written with the active key.
+func (c *Cipher) NeedsRotation(ciphertext []byte) bool {
+	id, ok := KeyID(ciphertext)
+	return !ok || id != c.active
+}
+
+func (c *Cipher) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	gcm := c.keys[c.active]
 	nonce := make([]byte, nonceSize)
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, err
 	}
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
-	out := make([]byte, len(nonce)+len(ciphertext))
-	copy(out, nonce)
-	copy(out[len(nonce):], ciphertext)
-	return out, nil
+	header := make([]byte, 0, len(headerMagic)+1+len(c.active))
+	header = append(header, headerMagic...)
+	header = append(header, byte(len(c.active)))
+	header = append(header, c.active...)
+
+	out := make([]byte, 0, len(header)+nonceSize+len(plaintext)+gcm.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, header), nil
 }
 
 func (c *Cipher) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
-	if len(ciphertext) < nonceSize {
-		return nil, errors.New("aescipher: ciphertext too short")
+	id, headerLen, ok := parseHeader(ciphertext)
+	if !ok {
+		return c.decryptLegacy(ciphertext)
 	}
-	block, err := aes.NewCipher(c.key)
+	plaintext, err := c.decryptWith(id, ciphertext, headerLen)
 	if err != nil {
+		// A legacy nonce can begin with the magic bytes by chance.
+		if legacy, legacyErr := c.decryptLegacy(ciphertext); legacyErr == nil {
+			return legacy, nil
+		}
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
+	return plaintext, nil
+}
+
+func (c *Cipher) decryptWith(id string, ciphertext []byte, headerLen int) ([]byte, error) {
+	gcm, known := c.keys[id]
+	if !known {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	body := ciphertext[headerLen:]
+	if len(body) < nonceSize {
+		return nil, errors.New("aescipher: ciphertext too short")
+	}
+	return gcm.Open(nil, body[:nonceSize], body[nonceSize:], ciphertext[:headerLen])
+}
+
+// decryptLegacy opens nonce||ciphertext written without a key ID by trying every key,
+// active first.
+func (c *Cipher) decryptLegacy(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("aescipher: ciphertext too short")
 	}
 	nonce := ciphertext[:nonceSize]
 	data := ciphertext[nonceSize:]
-	return gcm.Open(nil, nonce, data, nil)
+	var lastErr error
+	for _, id := range c.tryOrder() {
+		plaintext, err := c.keys[id].Open(nil, nonce, data, nil)
+		if err == nil {
+			return plaintext, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *Cipher) tryOrder() []string {
+	ids := []string{c.active}
+	for _, id := range c.KeyIDs() {
+		if id != c.active {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseHeader returns the key ID and header length of a key-ring ciphertext.
+func parseHeader(ciphertext []byte) (string, int, bool) {
+	if !bytes.HasPrefix(ciphertext, headerMagic) || len(ciphertext) <= len(headerMagic) {
+		return "", 0, false
+	}
+	idLen := int(ciphertext[len(headerMagic)])
+	headerLen := len(headerMagic) + 1 + idLen
+	if idLen == 0 || len(ciphertext) < headerLen {
+		return "", 0, false
+	}
+	id := string(ciphertext[len(headerMagic)+1 : headerLen])
+	if !validKeyID.MatchString(id) {
+		return "", 0, false
+	}
+	return id, headerLen, true
 }
 
 func validKeyLength(length int) bool {
@@ -0,0 +1,118 @@
+package aescipher
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKey(fill byte) []byte { return bytes.Repeat([]byte{fill}, 32) }
+
+func TestRetiredKeysStillDecrypt(t *testing.T) {
+	ctx := context.Background()
+	old, err := New(testKey(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sealed, err := old.Encrypt(ctx, []byte("sk-secret"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if id, ok := KeyID(sealed); !ok || id != DefaultKeyID {
+		t.Fatalf("KeyID = %q, %v; want %q", id, ok, DefaultKeyID)
+	}
+
+	ring, err := NewKeyRing("v2", map[string][]byte{"v1": testKey(1), "v2": testKey(2)})
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+	if !ring.NeedsRotation(sealed) {
+		t.Fatal("ciphertext under a retired key should need rotation")
+	}
+	plaintext, err := ring.Decrypt(ctx, sealed)
+	if err != nil || string(plaintext) != "sk-secret" {
+		t.Fatalf("Decrypt retired = %q, %v", plaintext, err)
+	}
+
+	rotated, err := ring.Encrypt(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if ring.NeedsRotation(rotated) {
+		t.Fatal("ciphertext under the active key should not need rotation")
+	}
+	if _, err := old.Decrypt(ctx, rotated); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey from a ring without v2, got %v", err)
+	}
+}
+
+func TestDecryptsLegacyCiphertext(t *testing.T) {
+	// nonce||ciphertext as written before key IDs were embedded.
+	block, _ := aes.NewCipher(testKey(1))
+	gcm, _ := cipher.NewGCM(block)
+	nonce := bytes.Repeat([]byte{7}, nonceSize)
+	legacy := append(append([]byte{}, nonce...), gcm.Seal(nil, nonce, []byte("legacy"), nil)...)
+
+	ring, err := NewKeyRing("v2", map[string][]byte{"v1": testKey(1), "v2": testKey(2)})
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+	if _, ok := KeyID(legacy); ok || !ring.NeedsRotation(legacy) {
+		t.Fatal("legacy ciphertext should have no key id and need rotation")
+	}
+	plaintext, err := ring.Decrypt(context.Background(), legacy)
+	if err != nil || string(plaintext) != "legacy" {
+		t.Fatalf("Decrypt legacy = %q, %v", plaintext, err)
+	}
+}
+
+func TestHeaderIsAuthenticated(t *testing.T) {
+	ring, err := NewKeyRing("v1", map[string][]byte{"v1": testKey(1), "v2": testKey(1)})
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+	sealed, err := ring.Encrypt(context.Background(), []byte("secret"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	// Relabel the ciphertext as v2, which holds the same key material.
+	tampered := append([]byte{}, sealed...)
+	tampered[len(headerMagic)+2] = '2'
+	if _, err := ring.Decrypt(context.Background(), tampered); err == nil {
+		t.Fatal("expected relabelled ciphertext to fail authentication")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	encode := func(key []byte) string { return base64.StdEncoding.EncodeToString(key) }
+
+	ring, err := Load(encode(testKey(3)), "2025-10", "v1:"+encode(testKey(1))+", 2025-01:"+encode(testKey(2)))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if ring.ActiveKeyID() != "2025-10" || len(ring.KeyIDs()) != 3 {
+		t.Fatalf("active %q keys %v", ring.ActiveKeyID(), ring.KeyIDs())
+	}
+
+	if ring, err := Load(encode(testKey(3)), "", ""); err != nil || ring.ActiveKeyID() != DefaultKeyID {
+		t.Fatalf("Load without id = %v, %v", ring, err)
+	}
+
+	for name, retired := range map[string]string{
+		"missing id":     ":" + encode(testKey(1)),
+		"duplicate":      "v0:" + encode(testKey(1)) + ",v0:" + encode(testKey(2)),
+		"active retired": "v1:" + encode(testKey(1)),
+		"bad length":     "v0:" + encode([]byte("short")),
+		"invalid id":     "v 0:" + encode(testKey(1)),
+		"invalid base64": "v0:not base64",
+		"no separator":   "v0",
+	} {
+		if _, err := Load(encode(testKey(3)), "v1", retired); err == nil {
+			t.Errorf("%s: expected error for %q", name, retired)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package credentials
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Binding identifies the stored row a secret belongs to. Ciphers that support it use
+// the binding as additional authenticated data so a ciphertext copied onto another
+// company, provider or credential row fails to decrypt.
+type Binding struct {
+	CompanyID    uuid.UUID
+	ProviderID   string
+	CredentialID uuid.UUID
+}
+
+// Valid reports whether every field of the binding is set.
+func (b Binding) Valid() bool {
+	return b.CompanyID != uuid.Nil && b.ProviderID != "" && b.CredentialID != uuid.Nil
+}
+
+// AdditionalData encodes the binding as GCM additional data.
+func (b Binding) AdditionalData() []byte {
+	return []byte("company=" + b.CompanyID.String() + ";provider=" + b.ProviderID + ";credential=" + b.CredentialID.String())
+}
+
+type bindingKey struct{}
+
+// WithBinding returns a context carrying the binding for the credential being encrypted
+// or decrypted.
+func WithBinding(ctx context.Context, b Binding) context.Context {
+	return context.WithValue(ctx, bindingKey{}, b)
+}
+
+// BindingFromContext returns the binding attached by WithBinding.
+func BindingFromContext(ctx context.Context) (Binding, bool) {
+	b, ok := ctx.Value(bindingKey{}).(Binding)
+	return b, ok
+}
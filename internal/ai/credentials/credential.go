@@ -0,0 +1,86 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Metadata keys a credential may carry to override provider configuration.
+const (
+	MetadataModel        = "model"
+	MetadataBaseURL      = "base_url"
+	MetadataOrganization = "organization"
+	MetadataProject      = "project"
+)
+
+// Credential is a resolved provider credential: the secret plus the metadata saved with
+// it, such as an Azure-style base URL or the model the company picked. ID names the stored
+// row and is nil for keys that did not come from the credential store.
+type Credential struct {
+	ID       uuid.UUID
+	Secret   string
+	Metadata map[string]any
+}
+
+// IsZero reports whether the credential carries neither a secret nor metadata.
+func (c Credential) IsZero() bool {
+	return c.Secret == "" && len(c.Metadata) == 0
+}
+
+// Model returns the model override, if any.
+func (c Credential) Model() string { return c.metadataString(MetadataModel) }
+
+// BaseURL returns the base URL override, accepting both the stored key (base_url) and the
+// form field name (baseUrl).
+func (c Credential) BaseURL() string { return c.metadataString(MetadataBaseURL, "baseUrl") }
+
+// Organization returns the organization to send with each request, if any.
+func (c Credential) Organization() string { return c.metadataString(MetadataOrganization) }
+
+// Project returns the project to send with each request, if any.
+func (c Credential) Project() string { return c.metadataString(MetadataProject) }
+
+func (c Credential) metadataString(keys ...string) string {
+	for _, key := range keys {
+		if value, ok := c.Metadata[key].(string); ok {
+			if trimmed := strings.TrimSpace(value); trimmed != "" {
+				return trimmed
+			}
+		}
+	}
+	return ""
+}
+
+// WithMetadata returns a copy of c with metadata layered over the credential's own.
+func (c Credential) WithMetadata(metadata map[string]any) Credential {
+	if len(metadata) == 0 {
+		return c
+	}
+	merged := make(map[string]any, len(c.Metadata)+len(metadata))
+	for key, value := range c.Metadata {
+		merged[key] = value
+	}
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	return Credential{ID: c.ID, Secret: c.Secret, Metadata: merged}
+}
+
+// Fingerprint identifies the credential without revealing it: a SHA-256 over the secret
+// and the metadata, so two credentials sharing a key but not a base URL or model differ.
+func (c Credential) Fingerprint() string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Secret))
+	hash.Write([]byte{0})
+	if len(c.Metadata) > 0 {
+		// encoding/json sorts map keys, which keeps the fingerprint stable.
+		if raw, err := json.Marshal(c.Metadata); err == nil {
+			hash.Write(raw)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -18,6 +18,8 @@ var (
 )
 
 // Cipher is responsible for encrypting/decrypting provider secrets before they are persisted.
+// Callers attach the row's credentials.Binding to ctx so binding-aware ciphers can
+// authenticate the ciphertext against it.
 type Cipher interface {
 	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
 	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
@@ -52,6 +54,17 @@ type Record struct {
 	UpdatedAt        time.Time
 	LastUsedAt       *time.Time
 	RotatedAt        *time.Time
+	ExpiresAt        *time.Time
+	HealthCheckedAt  *time.Time
+	// HealthStatus is "ok" or "failure" after a background health check, empty before the
+	// first check and after the secret changes.
+	HealthStatus string
+	HealthError  string
+}
+
+// Binding identifies the row for ciphers that authenticate ciphertext against it.
+func (r Record) Binding() credentials.Binding {
+	return credentials.Binding{CompanyID: r.CompanyID, ProviderID: r.ProviderID, CredentialID: r.ID}
 }
 
 // Reference decomposes the credential reference string passed around the system.
@@ -102,30 +115,33 @@ func New(store CredentialStore, cipher Cipher, logger credentials.Logger) *DBRes
 	return &DBResolver{store: store, cipher: cipher, logger: logger}
 }
 
-func (r *DBResolver) Resolve(ctx context.Context, reference string) (string, error) {
+// Resolve decrypts the referenced credential and returns it with its stored metadata.
+func (r *DBResolver) Resolve(ctx context.Context, reference string) (credentials.Credential, error) {
 	ref, err := ParseReference(reference)
 	if err != nil {
-		return "", err
+		return credentials.Credential{}, err
 	}
 
 	rec, err := r.store.ResolveCredential(ctx, ref.CompanyID, nullableUUID(ref.UserID), ref.ProviderID)
 	if err != nil {
-		return "", err
+		return credentials.Credential{}, err
 	}
 
 	if err := r.store.TouchCredential(ctx, rec.ID); err != nil {
 		r.logger.Warn(ctx, "ai: failed to touch credential", err, map[string]any{"reference": reference})
 	}
 
-	plaintext, err := r.cipher.Decrypt(ctx, rec.CredentialCipher)
+	plaintext, err := r.cipher.Decrypt(credentials.WithBinding(ctx, rec.Binding()), rec.CredentialCipher)
 	if err != nil {
-		return "", fmt.Errorf("decrypt credential: %w", err)
+		return credentials.Credential{}, fmt.Errorf("decrypt credential: %w", err)
 	}
 
 	r.logger.Info(ctx, "ai: credential resolved", map[string]any{"reference": reference})
-	return string(plaintext), nil
+	return credentials.Credential{ID: rec.ID, Secret: string(plaintext), Metadata: rec.Metadata}, nil
 }
 
+// Rotate re-encrypts the referenced credential, which moves it onto the cipher's active
+// key when the master key has been rotated.
 func (r *DBResolver) Rotate(ctx context.Context, reference string) error {
 	ref, err := ParseReference(reference)
 	if err != nil {
@@ -137,12 +153,13 @@ func (r *DBResolver) Rotate(ctx context.Context, reference string) error {
 		return err
 	}
 
-	plaintext, err := r.cipher.Decrypt(ctx, rec.CredentialCipher)
+	bound := credentials.WithBinding(ctx, rec.Binding())
+	plaintext, err := r.cipher.Decrypt(bound, rec.CredentialCipher)
 	if err != nil {
 		return fmt.Errorf("decrypt credential: %w", err)
 	}
 
-	ciphertext, err := r.cipher.Encrypt(ctx, plaintext)
+	ciphertext, err := r.cipher.Encrypt(bound, plaintext)
 	if err != nil {
 		return fmt.Errorf("encrypt credential: %w", err)
 	}
@@ -0,0 +1,184 @@
+// Package envelope implements credential encryption with per-secret data keys wrapped by a
+// key-encryption key held in a KMS.
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+)
+
+var (
+	// ErrUnbound is returned when ctx carries no credentials.Binding for the row.
+	ErrUnbound = errors.New("envelope: credential binding missing from context")
+	// ErrNotEnvelope is returned for ciphertexts that were not written by this package
+	// when no legacy cipher is configured.
+	ErrNotEnvelope = errors.New("envelope: ciphertext is not envelope encrypted")
+	ErrMalformed   = errors.New("envelope: malformed ciphertext")
+)
+
+const (
+	dataKeySize = 32
+	nonceSize   = 12
+)
+
+// magic starts every envelope ciphertext. The layout is
+//
+//	"rve1" || uint16 len(wrappedKey) || wrappedKey || nonce || sealed
+//
+// The header plus the row binding are authenticated as GCM additional data.
+var magic = []byte("rve1")
+
+// KeyManager wraps and unwraps data keys with a key-encryption key that never leaves the KMS.
+type KeyManager interface {
+	// KeyID names the key-encryption key new data keys are wrapped with.
+	KeyID() string
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Cipher implements dbresolver.Cipher with envelope encryption. Each Encrypt generates a
+// fresh data key, seals the secret with it, and stores the KMS-wrapped data key alongside.
+// Both calls require a credentials.Binding in ctx so a ciphertext only opens for the
+// company, provider and credential row it was written for.
+type Cipher struct {
+	kms    KeyManager
+	legacy dbresolver.Cipher
+}
+
+// New returns a Cipher backed by kms. legacy, when non-nil, decrypts ciphertexts written
+// before envelope encryption was enabled so they can be migrated with cmd/rekey.
+func New(kms KeyManager, legacy dbresolver.Cipher) (*Cipher, error) {
+	if kms == nil {
+		return nil, errors.New("envelope: key manager is required")
+	}
+	return &Cipher{kms: kms, legacy: legacy}, nil
+}
+
+// ActiveKeyID returns the KMS key new secrets are wrapped with.
+func (c *Cipher) ActiveKeyID() string { return c.kms.KeyID() }
+
+// NeedsRotation reports whether ciphertext predates envelope encryption.
+func (c *Cipher) NeedsRotation(ciphertext []byte) bool {
+	_, _, _, err := parse(ciphertext)
+	return err != nil
+}
+
+// Encrypt seals plaintext under a new data key bound to the row in ctx.
+func (c *Cipher) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	binding, err := bindingFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	wrapped, err := c.kms.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: wrap data key: %w", err)
+	}
+	if len(wrapped) > 0xFFFF {
+		return nil, errors.New("envelope: wrapped data key too large")
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(magic)+2+len(wrapped))
+	header = append(header, magic...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(header, nonce...)
+	return gcm.Seal(out, nonce, plaintext, additionalData(header, binding)), nil
+}
+
+// Decrypt unwraps the data key through the KMS and opens ciphertext for the row in ctx.
+// Ciphertexts without the envelope header go to the legacy cipher.
+func (c *Cipher) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	header, nonce, sealed, err := parse(ciphertext)
+	if err != nil {
+		if c.legacy != nil {
+			return c.legacy.Decrypt(ctx, ciphertext)
+		}
+		return nil, err
+	}
+
+	binding, err := bindingFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := c.kms.UnwrapKey(ctx, header[len(magic)+2:])
+	if err != nil {
+		return nil, fmt.Errorf("envelope: unwrap data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, sealed, additionalData(header, binding))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: open ciphertext: %w", err)
+	}
+	return plaintext, nil
+}
+
+func parse(ciphertext []byte) (header, nonce, sealed []byte, err error) {
+	if !bytes.HasPrefix(ciphertext, magic) {
+		return nil, nil, nil, ErrNotEnvelope
+	}
+	rest := ciphertext[len(magic):]
+	if len(rest) < 2 {
+		return nil, nil, nil, ErrMalformed
+	}
+	n := int(binary.BigEndian.Uint16(rest))
+	end := len(magic) + 2 + n
+	if n == 0 || len(ciphertext) < end+nonceSize {
+		return nil, nil, nil, ErrMalformed
+	}
+	return ciphertext[:end], ciphertext[end : end+nonceSize], ciphertext[end+nonceSize:], nil
+}
+
+func bindingFrom(ctx context.Context) (credentials.Binding, error) {
+	binding, ok := credentials.BindingFromContext(ctx)
+	if !ok || !binding.Valid() {
+		return credentials.Binding{}, ErrUnbound
+	}
+	return binding, nil
+}
+
+func additionalData(header []byte, binding credentials.Binding) []byte {
+	aad := append([]byte(nil), header...)
+	return append(aad, binding.AdditionalData()...)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
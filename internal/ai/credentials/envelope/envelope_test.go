@@ -0,0 +1,218 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/aescipher"
+)
+
+func bound(b credentials.Binding) context.Context {
+	return credentials.WithBinding(context.Background(), b)
+}
+
+func testBinding() credentials.Binding {
+	return credentials.Binding{CompanyID: uuid.New(), ProviderID: "openai", CredentialID: uuid.New()}
+}
+
+func TestCipherBindsCiphertextToRow(t *testing.T) {
+	kms, err := NewFileKeyManager(filepath.Join(t.TempDir(), "kek"))
+	if err != nil {
+		t.Fatalf("NewFileKeyManager: %v", err)
+	}
+	c, err := New(kms, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := testBinding()
+	sealed, err := c.Encrypt(bound(row), []byte("sk-secret"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	again, _ := c.Encrypt(bound(row), []byte("sk-secret"))
+	if bytes.Equal(sealed, again) {
+		t.Fatal("expected a fresh data key and nonce per encryption")
+	}
+
+	plaintext, err := c.Decrypt(bound(row), sealed)
+	if err != nil || string(plaintext) != "sk-secret" {
+		t.Fatalf("Decrypt = %q, %v", plaintext, err)
+	}
+
+	swapped := map[string]credentials.Binding{
+		"company":    {CompanyID: uuid.New(), ProviderID: row.ProviderID, CredentialID: row.CredentialID},
+		"provider":   {CompanyID: row.CompanyID, ProviderID: "gemini", CredentialID: row.CredentialID},
+		"credential": {CompanyID: row.CompanyID, ProviderID: row.ProviderID, CredentialID: uuid.New()},
+	}
+	for name, other := range swapped {
+		if _, err := c.Decrypt(bound(other), sealed); err == nil {
+			t.Fatalf("ciphertext opened under a different %s", name)
+		}
+	}
+
+	if _, err := c.Encrypt(context.Background(), []byte("x")); !errors.Is(err, ErrUnbound) {
+		t.Fatalf("Encrypt without binding err = %v, want ErrUnbound", err)
+	}
+	if _, err := c.Decrypt(context.Background(), sealed); !errors.Is(err, ErrUnbound) {
+		t.Fatalf("Decrypt without binding err = %v, want ErrUnbound", err)
+	}
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(magic)+3] ^= 0xFF
+	if _, err := c.Decrypt(bound(row), tampered); err == nil {
+		t.Fatal("expected tampered wrapped key to fail")
+	}
+}
+
+func TestCipherMigratesLegacyCiphertext(t *testing.T) {
+	legacy, _ := aescipher.New(bytes.Repeat([]byte{7}, 32))
+	old, _ := legacy.Encrypt(context.Background(), []byte("sk-old"))
+
+	kms, _ := NewStaticKeyManager(bytes.Repeat([]byte{9}, 32))
+	c, _ := New(kms, legacy)
+	row := testBinding()
+
+	plaintext, err := c.Decrypt(bound(row), old)
+	if err != nil || string(plaintext) != "sk-old" {
+		t.Fatalf("legacy Decrypt = %q, %v", plaintext, err)
+	}
+	if !c.NeedsRotation(old) {
+		t.Fatal("legacy ciphertext should need rotation")
+	}
+	sealed, _ := c.Encrypt(bound(row), plaintext)
+	if c.NeedsRotation(sealed) {
+		t.Fatal("envelope ciphertext should not need rotation")
+	}
+
+	strict, _ := New(kms, nil)
+	if _, err := strict.Decrypt(bound(row), old); !errors.Is(err, ErrNotEnvelope) {
+		t.Fatalf("Decrypt without legacy cipher err = %v, want ErrNotEnvelope", err)
+	}
+}
+
+func TestFileKeyManagerPersistsKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "kek")
+	first, err := NewFileKeyManager(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyManager: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("key file mode = %v, want 0600", perm)
+	}
+
+	wrapped, err := first.WrapKey(context.Background(), []byte("data-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewFileKeyManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.KeyID() != first.KeyID() {
+		t.Fatalf("key id changed across loads: %s vs %s", first.KeyID(), second.KeyID())
+	}
+	if key, err := second.UnwrapKey(context.Background(), wrapped); err != nil || string(key) != "data-key" {
+		t.Fatalf("UnwrapKey = %q, %v", key, err)
+	}
+}
+
+// fakeTransit mimics the Vault transit encrypt/decrypt endpoints for a single key.
+type fakeTransit struct {
+	mu      sync.Mutex
+	keys    map[string][]byte
+	token   string
+	path    string
+	failing bool
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != f.token {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	if f.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"errors":["Vault is sealed"]}`))
+		return
+	}
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case f.path + "/encrypt/credentials":
+		key, _ := base64.StdEncoding.DecodeString(body["plaintext"])
+		ciphertext := "vault:v1:" + uuid.NewString()
+		f.keys[ciphertext] = key
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": ciphertext}})
+	case f.path + "/decrypt/credentials":
+		key, ok := f.keys[body["ciphertext"]]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["cipher: message authentication failed"]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestVaultTransitRoundTrip(t *testing.T) {
+	fake := &fakeTransit{keys: make(map[string][]byte), token: "s.test", path: "/v1/secrets/transit"}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	kms, err := NewVaultTransit(VaultConfig{Address: srv.URL + "/", Token: "s.test", Mount: "/secrets/transit/", KeyName: "credentials"})
+	if err != nil {
+		t.Fatalf("NewVaultTransit: %v", err)
+	}
+	if kms.KeyID() != "vault:secrets/transit/credentials" {
+		t.Fatalf("KeyID = %q", kms.KeyID())
+	}
+
+	c, _ := New(kms, nil)
+	row := testBinding()
+	sealed, err := c.Encrypt(bound(row), []byte("sk-vault"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !bytes.Contains(sealed, []byte("vault:v1:")) {
+		t.Fatal("expected the Vault ciphertext to be stored as the wrapped key")
+	}
+	plaintext, err := c.Decrypt(bound(row), sealed)
+	if err != nil || string(plaintext) != "sk-vault" {
+		t.Fatalf("Decrypt = %q, %v", plaintext, err)
+	}
+
+	fake.failing = true
+	_, err = c.Decrypt(bound(row), sealed)
+	if err == nil || !strings.Contains(err.Error(), "Vault is sealed") {
+		t.Fatalf("expected Vault error to surface, got %v", err)
+	}
+
+	denied, _ := NewVaultTransit(VaultConfig{Address: srv.URL, Token: "wrong", Mount: "secrets/transit", KeyName: "credentials"})
+	if _, err := denied.WrapKey(context.Background(), []byte("k")); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileKeyManager is a KeyManager whose key-encryption key lives in a local file. It is meant
+// for development and tests; production deployments should use a real KMS.
+type FileKeyManager struct {
+	id  string
+	gcm cipher.AEAD
+}
+
+// NewFileKeyManager loads the base64 AES-256 key stored at path, generating the file with
+// 0600 permissions when it does not exist yet.
+func NewFileKeyManager(path string) (*FileKeyManager, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		data, err = createKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("envelope: read key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: decode key file: %w", err)
+	}
+	return NewStaticKeyManager(key)
+}
+
+// NewStaticKeyManager wraps data keys with an in-memory AES-256 key.
+func NewStaticKeyManager(key []byte) (*FileKeyManager, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("envelope: key-encryption key must be %d bytes", dataKeySize)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &FileKeyManager{id: "file:" + hex.EncodeToString(sum[:4]), gcm: gcm}, nil
+}
+
+// KeyID returns "file:" plus a short fingerprint of the key.
+func (m *FileKeyManager) KeyID() string { return m.id }
+
+func (m *FileKeyManager) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return m.gcm.Seal(nonce, nonce, dataKey, []byte(m.id)), nil
+}
+
+func (m *FileKeyManager) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < nonceSize {
+		return nil, ErrMalformed
+	}
+	return m.gcm.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], []byte(m.id))
+}
+
+func createKeyFile(path string) ([]byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	data := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("envelope: create key dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: create key file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("envelope: write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("envelope: write key file: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,142 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTransitMount = "transit"
+
+// VaultConfig configures a VaultTransit key manager.
+type VaultConfig struct {
+	// Address is the Vault server URL, e.g. https://vault.internal:8200.
+	Address string
+	Token   string
+	// Namespace is sent as X-Vault-Namespace when set (Vault Enterprise / HCP).
+	Namespace string
+	// Mount is the transit secrets engine mount path. Defaults to "transit".
+	Mount string
+	// KeyName is the transit key that wraps data keys.
+	KeyName    string
+	HTTPClient *http.Client
+}
+
+// VaultTransit is a KeyManager backed by the HashiCorp Vault transit secrets engine
+// (or any server that speaks its encrypt/decrypt API).
+type VaultTransit struct {
+	cfg  VaultConfig
+	base *url.URL
+}
+
+// NewVaultTransit validates cfg and returns a transit key manager.
+func NewVaultTransit(cfg VaultConfig) (*VaultTransit, error) {
+	if cfg.Address == "" || cfg.Token == "" || cfg.KeyName == "" {
+		return nil, errors.New("envelope: vault address, token and key name are required")
+	}
+	base, err := url.Parse(strings.TrimRight(cfg.Address, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("envelope: parse vault address: %w", err)
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = defaultTransitMount
+	}
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &VaultTransit{cfg: cfg, base: base}, nil
+}
+
+// KeyID returns "vault:<mount>/<key>".
+func (v *VaultTransit) KeyID() string {
+	return "vault:" + v.cfg.Mount + "/" + v.cfg.KeyName
+}
+
+// WrapKey encrypts dataKey with the transit key. The result is Vault's "vault:vN:..."
+// ciphertext, which records the key version for later decryption.
+func (v *VaultTransit) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := v.call(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(dataKey),
+	}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Ciphertext == "" {
+		return nil, errors.New("vault transit: empty ciphertext in response")
+	}
+	return []byte(resp.Ciphertext), nil
+}
+
+// UnwrapKey decrypts a data key previously returned by WrapKey.
+func (v *VaultTransit) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := v.call(ctx, "decrypt", map[string]string{"ciphertext": string(wrapped)}, &resp); err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("vault transit: decode plaintext: %w", err)
+	}
+	return key, nil
+}
+
+func (v *VaultTransit) call(ctx context.Context, op string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	endpoint := v.base.JoinPath("v1", v.cfg.Mount, op, v.cfg.KeyName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", v.cfg.Token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	res, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", op, err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("vault transit %s: read response: %w", op, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &failure) == nil && len(failure.Errors) > 0 {
+			return fmt.Errorf("vault transit %s: %s: %s", op, res.Status, strings.Join(failure.Errors, "; "))
+		}
+		return fmt.Errorf("vault transit %s: %s", op, res.Status)
+	}
+
+	var wrapper struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return fmt.Errorf("vault transit %s: decode response: %w", op, err)
+	}
+	if err := json.Unmarshal(wrapper.Data, out); err != nil {
+		return fmt.Errorf("vault transit %s: decode response data: %w", op, err)
+	}
+	return nil
+}
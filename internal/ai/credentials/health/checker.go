@@ -0,0 +1,265 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+	"github.com/JonMunkholm/RevProject1/internal/ai/metrics"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+// ErrUnsupported is returned by a PingFunc for providers without a status check. Such
+// credentials are skipped rather than marked as failing.
+var ErrUnsupported = errors.New("ai: provider status check not implemented")
+
+// EventAction is the credential event recorded for health check failures and reminders.
+const EventAction = "health_check"
+
+// PingFunc checks that the provider accepts apiKey, the same way the settings status
+// endpoint does.
+type PingFunc func(ctx context.Context, providerID, apiKey string, metadata map[string]any) error
+
+// Store claims credentials that are due for a check and records the outcome.
+type Store interface {
+	ClaimHealthChecks(ctx context.Context, checkedBefore time.Time, limit int32) ([]dbresolver.Record, error)
+	RecordHealth(ctx context.Context, id uuid.UUID, checkErr error) error
+}
+
+// PolicySource returns a company's credential rotation policy.
+type PolicySource interface {
+	CredentialPolicy(ctx context.Context, companyID uuid.UUID) (settings.CredentialPolicy, error)
+}
+
+// EventRecorder persists credential events.
+type EventRecorder interface {
+	Insert(ctx context.Context, params database.InsertAIProviderCredentialEventParams) error
+}
+
+// Config tunes the checker. Zero values fall back to the defaults below.
+type Config struct {
+	// Interval is how often each default credential is checked.
+	Interval time.Duration
+	// PollInterval is how often the checker looks for credentials that are due.
+	PollInterval time.Duration
+	// Timeout bounds a single provider ping.
+	Timeout   time.Duration
+	BatchSize int
+
+	Policies PolicySource
+	Events   EventRecorder
+	Metrics  metrics.CredentialMetrics
+	Logger   credentials.Logger
+}
+
+const (
+	defaultInterval     = 6 * time.Hour
+	defaultPollInterval = 5 * time.Minute
+	defaultTimeout      = 15 * time.Second
+	defaultBatchSize    = 25
+)
+
+// Result counts the outcome of one RunOnce pass.
+type Result struct {
+	Checked int
+	Failed  int
+	Skipped int
+}
+
+// Checker periodically pings the provider for every default credential, updating
+// last_tested_at and recording failures as credential events. Claims use SKIP LOCKED, so
+// several app instances can run a checker without checking a credential twice.
+type Checker struct {
+	store  Store
+	cipher dbresolver.Cipher
+	ping   PingFunc
+	cfg    Config
+	now    func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewChecker constructs a Checker. Call Start to run it in the background.
+func NewChecker(store Store, cipher dbresolver.Cipher, ping PingFunc, cfg Config) *Checker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = min(defaultPollInterval, cfg.Interval)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = credentials.NewNoopLogger()
+	}
+	return &Checker{
+		store:  store,
+		cipher: cipher,
+		ping:   ping,
+		cfg:    cfg,
+		now:    time.Now,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start runs checks in the background until ctx ends or Stop is called.
+func (c *Checker) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.stop:
+				return
+			case <-timer.C:
+			}
+			if _, err := c.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				c.cfg.Logger.Warn(ctx, "ai: credential health check failed", err, nil)
+			}
+			timer.Reset(c.cfg.PollInterval)
+		}
+	}()
+}
+
+// Stop halts the background loop and waits for an in-flight pass to finish.
+func (c *Checker) Stop() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.wg.Wait()
+}
+
+// RunOnce checks every default credential that is due, in batches.
+func (c *Checker) RunOnce(ctx context.Context) (Result, error) {
+	var result Result
+	for {
+		batch, err := c.store.ClaimHealthChecks(ctx, c.now().Add(-c.cfg.Interval), int32(c.cfg.BatchSize))
+		if err != nil {
+			return result, err
+		}
+		for _, rec := range batch {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			c.check(ctx, rec, &result)
+		}
+		if len(batch) < c.cfg.BatchSize {
+			return result, nil
+		}
+	}
+}
+
+func (c *Checker) check(ctx context.Context, rec dbresolver.Record, result *Result) {
+	c.remind(ctx, rec)
+
+	plaintext, err := c.cipher.Decrypt(credentials.WithBinding(ctx, rec.Binding()), rec.CredentialCipher)
+	apiKey := strings.TrimSpace(string(plaintext))
+	if err == nil {
+		pingCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		err = c.ping(pingCtx, rec.ProviderID, apiKey, rec.Metadata)
+		cancel()
+	}
+	if errors.Is(err, ErrUnsupported) {
+		result.Skipped++
+		return
+	}
+	if err != nil && apiKey != "" {
+		// Some providers echo the key back (Gemini puts it in the request URL).
+		err = errors.New(strings.ReplaceAll(err.Error(), apiKey, "REDACTED"))
+	}
+
+	result.Checked++
+	if recordErr := c.store.RecordHealth(ctx, rec.ID, err); recordErr != nil {
+		c.cfg.Logger.Warn(ctx, "ai: failed to record credential health", recordErr, attrs(rec))
+	}
+
+	switch {
+	case err != nil:
+		result.Failed++
+		if c.cfg.Metrics != nil {
+			c.cfg.Metrics.CredentialTestFailure(rec.CompanyID, rec.ProviderID)
+		}
+		meta := eventMetadata(rec, "failure")
+		meta["error"] = err.Error()
+		c.recordEvent(ctx, rec, meta)
+		c.cfg.Logger.Warn(ctx, "ai: credential health check failed", err, attrs(rec))
+	case rec.HealthStatus == "failure":
+		c.recordEvent(ctx, rec, eventMetadata(rec, "recovered"))
+	}
+}
+
+// remind records an event when a credential enters an expiring or stale state. A check
+// runs about once per Interval, so comparing against the state one Interval ago reports
+// each transition once without storing it.
+func (c *Checker) remind(ctx context.Context, rec dbresolver.Record) {
+	if c.cfg.Policies == nil {
+		return
+	}
+	policy, err := c.cfg.Policies.CredentialPolicy(ctx, rec.CompanyID)
+	if err != nil {
+		c.cfg.Logger.Warn(ctx, "ai: failed to load credential policy", err, attrs(rec))
+		return
+	}
+	now := c.now()
+	current := Assess(rec, policy, now)
+	if current.Status == StatusOK || current.Status == Assess(rec, policy, now.Add(-c.cfg.Interval)).Status {
+		return
+	}
+	meta := eventMetadata(rec, string(current.Status))
+	meta["due_at"] = current.Due.UTC().Format(time.RFC3339)
+	c.recordEvent(ctx, rec, meta)
+}
+
+func (c *Checker) recordEvent(ctx context.Context, rec dbresolver.Record, meta map[string]any) {
+	if c.cfg.Events == nil {
+		return
+	}
+	err := c.cfg.Events.Insert(ctx, database.InsertAIProviderCredentialEventParams{
+		CompanyID:        rec.CompanyID,
+		UserID:           rec.UserID,
+		ProviderID:       rec.ProviderID,
+		Action:           EventAction,
+		MetadataSnapshot: meta,
+	})
+	if err != nil {
+		c.cfg.Logger.Warn(ctx, "ai: failed to record credential health event", err, attrs(rec))
+	}
+}
+
+func eventMetadata(rec dbresolver.Record, status string) map[string]any {
+	meta := map[string]any{
+		"status":        status,
+		"credential_id": rec.ID.String(),
+		"source":        "health_check",
+	}
+	if rec.Fingerprint != "" {
+		meta["fingerprint"] = rec.Fingerprint
+	}
+	if rec.UserID.Valid {
+		meta["user_id"] = rec.UserID.UUID.String()
+	}
+	return meta
+}
+
+func attrs(rec dbresolver.Record) map[string]any {
+	return map[string]any{
+		"credential_id": rec.ID.String(),
+		"company_id":    rec.CompanyID.String(),
+		"provider":      rec.ProviderID,
+	}
+}
@@ -0,0 +1,66 @@
+// Package health tracks the lifecycle of stored provider credentials: expiry dates,
+// company rotation policies, and periodic checks that the provider still accepts them.
+package health
+
+import (
+	"time"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
+)
+
+// Status summarises where a credential is in its lifecycle.
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusRotationDue means the rotation deadline falls within the warning window.
+	StatusRotationDue Status = "rotation_due"
+	// StatusStale means the credential is older than the company's rotation period.
+	StatusStale    Status = "stale"
+	StatusExpiring Status = "expiring"
+	StatusExpired  Status = "expired"
+)
+
+// Assessment describes a credential's lifecycle state at a point in time.
+type Assessment struct {
+	Status Status
+	// Due is the expiry or rotation deadline behind Status; nil for StatusOK.
+	Due *time.Time
+	// Failing reports whether the last background health check failed.
+	Failing bool
+}
+
+// Assess evaluates rec against policy at now. Expiry takes precedence over rotation, and
+// the rotation clock starts at the last rotation or, failing that, creation.
+func Assess(rec dbresolver.Record, policy settings.CredentialPolicy, now time.Time) Assessment {
+	out := Assessment{Status: StatusOK, Failing: rec.HealthStatus == "failure"}
+	warning := time.Duration(policy.WarningDays) * 24 * time.Hour
+
+	if rec.ExpiresAt != nil {
+		expires := *rec.ExpiresAt
+		switch {
+		case !now.Before(expires):
+			out.Status, out.Due = StatusExpired, &expires
+			return out
+		case expires.Sub(now) <= warning:
+			out.Status, out.Due = StatusExpiring, &expires
+			return out
+		}
+	}
+
+	if policy.RotationDays > 0 {
+		since := rec.CreatedAt
+		if rec.RotatedAt != nil {
+			since = *rec.RotatedAt
+		}
+		due := since.Add(time.Duration(policy.RotationDays) * 24 * time.Hour)
+		switch {
+		case !now.Before(due):
+			out.Status, out.Due = StatusStale, &due
+		case due.Sub(now) <= warning:
+			out.Status, out.Due = StatusRotationDue, &due
+		}
+	}
+	return out
+}
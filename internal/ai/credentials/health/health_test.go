@@ -0,0 +1,175 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/aescipher"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+var now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func days(n int) time.Duration { return time.Duration(n) * 24 * time.Hour }
+
+func at(d time.Duration) *time.Time {
+	t := now.Add(d)
+	return &t
+}
+
+func TestAssess(t *testing.T) {
+	policy := settings.CredentialPolicy{RotationDays: 90, WarningDays: 14}
+	cases := []struct {
+		name   string
+		rec    dbresolver.Record
+		policy settings.CredentialPolicy
+		want   Status
+	}{
+		{"fresh", dbresolver.Record{CreatedAt: now.Add(-days(10))}, policy, StatusOK},
+		{"no policy", dbresolver.Record{CreatedAt: now.Add(-days(400))}, settings.CredentialPolicy{WarningDays: 14}, StatusOK},
+		{"rotation due", dbresolver.Record{CreatedAt: now.Add(-days(80))}, policy, StatusRotationDue},
+		{"stale", dbresolver.Record{CreatedAt: now.Add(-days(91))}, policy, StatusStale},
+		{"rotation resets clock", dbresolver.Record{CreatedAt: now.Add(-days(200)), RotatedAt: at(-days(5))}, policy, StatusOK},
+		{"expiring", dbresolver.Record{CreatedAt: now, ExpiresAt: at(days(3))}, policy, StatusExpiring},
+		{"expired beats stale", dbresolver.Record{CreatedAt: now.Add(-days(200)), ExpiresAt: at(-time.Hour)}, policy, StatusExpired},
+		{"distant expiry", dbresolver.Record{CreatedAt: now, ExpiresAt: at(days(60))}, policy, StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Assess(tc.rec, tc.policy, now)
+			if got.Status != tc.want {
+				t.Fatalf("status = %s, want %s", got.Status, tc.want)
+			}
+			if (got.Due == nil) != (tc.want == StatusOK) {
+				t.Fatalf("due = %v for status %s", got.Due, got.Status)
+			}
+		})
+	}
+}
+
+type memoryStore struct {
+	due     []dbresolver.Record
+	results map[uuid.UUID]error
+}
+
+func (s *memoryStore) ClaimHealthChecks(_ context.Context, _ time.Time, limit int32) ([]dbresolver.Record, error) {
+	n := min(int(limit), len(s.due))
+	batch := s.due[:n]
+	s.due = s.due[n:]
+	return batch, nil
+}
+
+func (s *memoryStore) RecordHealth(_ context.Context, id uuid.UUID, checkErr error) error {
+	s.results[id] = checkErr
+	return nil
+}
+
+type memoryEvents []database.InsertAIProviderCredentialEventParams
+
+func (e *memoryEvents) Insert(_ context.Context, params database.InsertAIProviderCredentialEventParams) error {
+	*e = append(*e, params)
+	return nil
+}
+
+type countingMetrics struct{ failures int }
+
+func (m *countingMetrics) CredentialMissing(uuid.UUID, string, string) {}
+func (m *countingMetrics) CredentialTestFailure(uuid.UUID, string)     { m.failures++ }
+func (m *countingMetrics) CredentialResolveFailure(uuid.UUID, string)  {}
+
+type fixedPolicy settings.CredentialPolicy
+
+func (p fixedPolicy) CredentialPolicy(context.Context, uuid.UUID) (settings.CredentialPolicy, error) {
+	return settings.CredentialPolicy(p), nil
+}
+
+func TestCheckerRunOnce(t *testing.T) {
+	ctx := context.Background()
+	cipher, _ := aescipher.New(bytes.Repeat([]byte{3}, 32))
+	store := &memoryStore{results: make(map[uuid.UUID]error)}
+	add := func(provider, secret string, mutate func(*dbresolver.Record)) uuid.UUID {
+		sealed, _ := cipher.Encrypt(ctx, []byte(secret))
+		rec := dbresolver.Record{ID: uuid.New(), CompanyID: uuid.New(), ProviderID: provider, CredentialCipher: sealed, CreatedAt: now}
+		if mutate != nil {
+			mutate(&rec)
+		}
+		store.due = append(store.due, rec)
+		return rec.ID
+	}
+	healthy := add("openai", "sk-good", nil)
+	recovered := add("openai", "sk-good", func(r *dbresolver.Record) { r.HealthStatus = "failure" })
+	revoked := add("gemini", "AIza-revoked", nil)
+	unsupported := add("anthropic", "sk-ant", nil)
+	// Crossed the rotation deadline since the previous check, so one reminder is due.
+	stale := add("openai", "sk-good", func(r *dbresolver.Record) { r.CreatedAt = now.Add(-days(30) - time.Hour) })
+
+	ping := func(_ context.Context, providerID, apiKey string, _ map[string]any) error {
+		switch {
+		case providerID == "anthropic":
+			return ErrUnsupported
+		case apiKey == "AIza-revoked":
+			return errors.New(`Get "https://example.test/models?key=AIza-revoked": 403 Forbidden`)
+		}
+		return nil
+	}
+
+	var events memoryEvents
+	metrics := &countingMetrics{}
+	checker := NewChecker(store, cipher, ping, Config{
+		Interval:  24 * time.Hour,
+		BatchSize: 2,
+		Policies:  fixedPolicy{RotationDays: 30, WarningDays: 7},
+		Events:    &events,
+		Metrics:   metrics,
+	})
+	checker.now = func() time.Time { return now }
+
+	result, err := checker.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if want := (Result{Checked: 4, Failed: 1, Skipped: 1}); result != want {
+		t.Fatalf("result = %+v, want %+v", result, want)
+	}
+	if metrics.failures != 1 {
+		t.Fatalf("CredentialTestFailure calls = %d, want 1", metrics.failures)
+	}
+
+	for _, id := range []uuid.UUID{healthy, recovered, stale} {
+		if err, ok := store.results[id]; !ok || err != nil {
+			t.Fatalf("credential %s recorded %v (%t), want ok", id, err, ok)
+		}
+	}
+	if _, ok := store.results[unsupported]; ok {
+		t.Fatal("unsupported providers must not record a health result")
+	}
+	failure := store.results[revoked]
+	if failure == nil || strings.Contains(failure.Error(), "AIza-revoked") {
+		t.Fatalf("failure must be recorded with the key redacted, got %v", failure)
+	}
+
+	statuses := map[string]int{}
+	for _, event := range events {
+		if event.Action != EventAction {
+			t.Fatalf("unexpected action %q", event.Action)
+		}
+		statuses[event.MetadataSnapshot.(map[string]any)["status"].(string)]++
+	}
+	want := map[string]int{"failure": 1, "recovered": 1, "stale": 1}
+	if len(statuses) != len(want) {
+		t.Fatalf("event statuses = %v, want %v", statuses, want)
+	}
+	for status, n := range want {
+		if statuses[status] != n {
+			t.Fatalf("event statuses = %v, want %v", statuses, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package rekey re-encrypts stored provider credentials with the active master key so
+// retired keys can be removed from the key ring.
+package rekey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/aescipher"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+// EventAction is the credential event recorded for each re-encrypted row.
+const EventAction = "reencrypt"
+
+const defaultBatchSize = 100
+
+// Store lists credentials in ID order and swaps their ciphertext.
+type Store interface {
+	ListCredentialsAfter(ctx context.Context, after uuid.UUID, limit int32) ([]dbresolver.Record, error)
+	// ReplaceCredentialCipher reports false when the stored ciphertext no longer matches
+	// oldCipher because the credential changed concurrently.
+	ReplaceCredentialCipher(ctx context.Context, id uuid.UUID, oldCipher, newCipher []byte) (bool, error)
+}
+
+// Cipher is a key ring that can tell which ciphertexts predate its active key.
+type Cipher interface {
+	dbresolver.Cipher
+	ActiveKeyID() string
+	NeedsRotation(ciphertext []byte) bool
+}
+
+// EventRecorder persists credential events.
+type EventRecorder interface {
+	Insert(ctx context.Context, params database.InsertAIProviderCredentialEventParams) error
+}
+
+// Options tunes a run.
+type Options struct {
+	// BatchSize is the number of rows read per query. Zero means 100.
+	BatchSize int
+	// DryRun counts the rows that would be migrated without writing.
+	DryRun bool
+	Logger credentials.Logger
+}
+
+// Stats summarises a run.
+type Stats struct {
+	Scanned int
+	// Migrated counts rows re-encrypted with the active key (or that would be, in a dry run).
+	Migrated int
+	// Current counts rows already encrypted with the active key.
+	Current int
+	// Conflicts counts rows that changed while being migrated; a later run picks them up.
+	Conflicts int
+	// Failed counts rows that could not be decrypted or written.
+	Failed int
+}
+
+// Run walks every stored credential in batches and re-encrypts those not written with the
+// active key, recording a credential event per migrated row. Individual row failures are
+// logged and counted so one bad row does not block the rest; Run returns an error only
+// when listing fails or ctx ends.
+func Run(ctx context.Context, store Store, cipher Cipher, events EventRecorder, opts Options) (Stats, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Logger == nil {
+		opts.Logger = credentials.NewNoopLogger()
+	}
+
+	var stats Stats
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		batch, err := store.ListCredentialsAfter(ctx, after, int32(opts.BatchSize))
+		if err != nil {
+			return stats, fmt.Errorf("list credentials: %w", err)
+		}
+		for _, rec := range batch {
+			stats.Scanned++
+			after = rec.ID
+			if !cipher.NeedsRotation(rec.CredentialCipher) {
+				stats.Current++
+				continue
+			}
+			switch err := migrate(ctx, store, cipher, events, rec, opts); {
+			case err == nil:
+				stats.Migrated++
+			case errors.Is(err, errConflict):
+				stats.Conflicts++
+				opts.Logger.Warn(ctx, "ai: credential changed during re-encryption", err, attrs(rec))
+			default:
+				stats.Failed++
+				opts.Logger.Warn(ctx, "ai: credential re-encryption failed", err, attrs(rec))
+			}
+		}
+		if len(batch) < opts.BatchSize {
+			return stats, nil
+		}
+	}
+}
+
+var errConflict = errors.New("rekey: credential modified concurrently")
+
+func migrate(ctx context.Context, store Store, cipher Cipher, events EventRecorder, rec dbresolver.Record, opts Options) error {
+	bound := credentials.WithBinding(ctx, rec.Binding())
+	plaintext, err := cipher.Decrypt(bound, rec.CredentialCipher)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	ciphertext, err := cipher.Encrypt(bound, plaintext)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	check, err := cipher.Decrypt(bound, ciphertext)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if !bytes.Equal(check, plaintext) {
+		return errors.New("verify: re-encrypted secret does not round-trip")
+	}
+
+	replaced, err := store.ReplaceCredentialCipher(ctx, rec.ID, rec.CredentialCipher, ciphertext)
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	if !replaced {
+		return errConflict
+	}
+
+	fromKey, ok := aescipher.KeyID(rec.CredentialCipher)
+	if !ok {
+		fromKey = "legacy"
+	}
+	metadata := map[string]any{
+		"credential_id": rec.ID.String(),
+		"from_key":      fromKey,
+		"to_key":        cipher.ActiveKeyID(),
+		"source":        "rekey",
+	}
+	if events != nil {
+		if err := events.Insert(ctx, database.InsertAIProviderCredentialEventParams{
+			CompanyID:        rec.CompanyID,
+			UserID:           rec.UserID,
+			ProviderID:       rec.ProviderID,
+			Action:           EventAction,
+			MetadataSnapshot: metadata,
+		}); err != nil {
+			opts.Logger.Warn(ctx, "ai: failed to record re-encryption event", err, attrs(rec))
+		}
+	}
+	opts.Logger.Info(ctx, "ai: credential re-encrypted", metadata)
+	return nil
+}
+
+func attrs(rec dbresolver.Record) map[string]any {
+	return map[string]any{
+		"credential_id": rec.ID.String(),
+		"company_id":    rec.CompanyID.String(),
+		"provider":      rec.ProviderID,
+	}
+}
@@ -0,0 +1,123 @@
+package rekey
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/aescipher"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/dbresolver"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+type memoryStore struct {
+	records map[uuid.UUID]dbresolver.Record
+	// editOnReplace simulates a user saving a new key mid-migration.
+	editOnReplace uuid.UUID
+}
+
+func (s *memoryStore) ListCredentialsAfter(_ context.Context, after uuid.UUID, limit int32) ([]dbresolver.Record, error) {
+	var out []dbresolver.Record
+	for _, rec := range s.records {
+		if bytes.Compare(rec.ID[:], after[:]) > 0 {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return bytes.Compare(out[i].ID[:], out[j].ID[:]) < 0 })
+	if len(out) > int(limit) {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *memoryStore) ReplaceCredentialCipher(_ context.Context, id uuid.UUID, oldCipher, newCipher []byte) (bool, error) {
+	rec := s.records[id]
+	if id == s.editOnReplace {
+		rec.CredentialCipher = []byte("edited")
+		s.records[id] = rec
+	}
+	if !bytes.Equal(rec.CredentialCipher, oldCipher) {
+		return false, nil
+	}
+	rec.CredentialCipher = newCipher
+	s.records[id] = rec
+	return true, nil
+}
+
+type memoryEvents []database.InsertAIProviderCredentialEventParams
+
+func (e *memoryEvents) Insert(_ context.Context, params database.InsertAIProviderCredentialEventParams) error {
+	*e = append(*e, params)
+	return nil
+}
+
+func key(fill byte) []byte { return bytes.Repeat([]byte{fill}, 32) }
+
+func TestRunMigratesToActiveKey(t *testing.T) {
+	ctx := context.Background()
+	oldRing, _ := aescipher.New(key(1))
+	ring, err := aescipher.NewKeyRing("v2", map[string][]byte{"v1": key(1), "v2": key(2)})
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+
+	store := &memoryStore{records: make(map[uuid.UUID]dbresolver.Record)}
+	add := func(c *aescipher.Cipher, secret string) uuid.UUID {
+		sealed, err := c.Encrypt(ctx, []byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := uuid.New()
+		store.records[id] = dbresolver.Record{ID: id, CompanyID: uuid.New(), ProviderID: "openai", CredentialCipher: sealed}
+		return id
+	}
+	retired := []uuid.UUID{add(oldRing, "sk-1"), add(oldRing, "sk-2"), add(oldRing, "sk-3")}
+	current := add(ring, "sk-4")
+	corrupt := uuid.New()
+	store.records[corrupt] = dbresolver.Record{ID: corrupt, CompanyID: uuid.New(), ProviderID: "gemini", CredentialCipher: []byte("not a ciphertext")}
+	store.editOnReplace = retired[2]
+
+	dry, err := Run(ctx, store, ring, nil, Options{BatchSize: 2, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.Migrated != 3 || dry.Current != 1 || dry.Failed != 1 {
+		t.Fatalf("dry run stats = %+v", dry)
+	}
+	if ring.NeedsRotation(store.records[current].CredentialCipher) || !ring.NeedsRotation(store.records[retired[0]].CredentialCipher) {
+		t.Fatal("dry run must not write")
+	}
+
+	var events memoryEvents
+	stats, err := Run(ctx, store, ring, &events, Options{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := Stats{Scanned: 5, Migrated: 2, Current: 1, Conflicts: 1, Failed: 1}
+	if stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+
+	for i, id := range retired[:2] {
+		rec := store.records[id]
+		if keyID, _ := aescipher.KeyID(rec.CredentialCipher); keyID != "v2" {
+			t.Fatalf("record %d key = %q, want v2", i, keyID)
+		}
+		plaintext, err := ring.Decrypt(ctx, rec.CredentialCipher)
+		if err != nil || string(plaintext) != []string{"sk-1", "sk-2"}[i] {
+			t.Fatalf("record %d decrypts to %q, %v", i, plaintext, err)
+		}
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected an event per migrated row, got %d", len(events))
+	}
+	for _, event := range events {
+		meta := event.MetadataSnapshot.(map[string]any)
+		if event.Action != EventAction || meta["from_key"] != "v1" || meta["to_key"] != "v2" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+}
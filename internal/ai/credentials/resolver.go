@@ -4,7 +4,7 @@ import "context"
 
 // Resolver retrieves and manages stored credentials such as per-user LLM API keys.
 type Resolver interface {
-	Resolve(ctx context.Context, reference string) (string, error)
+	Resolve(ctx context.Context, reference string) (Credential, error)
 	Rotate(ctx context.Context, reference string) error
 	Audit(ctx context.Context, reference string, metadata map[string]any)
 }
@@ -16,9 +16,9 @@ type Logger interface {
 
 type noopResolver struct{}
 
-func (noopResolver) Resolve(context.Context, string) (string, error) { return "", nil }
-func (noopResolver) Rotate(context.Context, string) error            { return nil }
-func (noopResolver) Audit(context.Context, string, map[string]any)   {}
+func (noopResolver) Resolve(context.Context, string) (Credential, error) { return Credential{}, nil }
+func (noopResolver) Rotate(context.Context, string) error                { return nil }
+func (noopResolver) Audit(context.Context, string, map[string]any)       {}
 
 // NewNoopResolver returns a resolver that performs no operations.
 func NewNoopResolver() Resolver { return noopResolver{} }
@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -30,7 +31,16 @@ type EventStore struct {
 
 func NewEventStore(q *database.Queries) *EventStore { return &EventStore{queries: q} }
 
+// Insert records an event. A map metadata snapshot is encoded as JSON, which the driver
+// cannot do itself.
 func (s *EventStore) Insert(ctx context.Context, params database.InsertAIProviderCredentialEventParams) error {
+	if snapshot, ok := params.MetadataSnapshot.(map[string]any); ok {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		params.MetadataSnapshot = string(data)
+	}
 	return s.queries.InsertAIProviderCredentialEvent(ctx, params)
 }
 
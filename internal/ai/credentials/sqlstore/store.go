@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -51,7 +52,9 @@ func (s *Store) TouchCredential(ctx context.Context, id uuid.UUID) error {
 	return s.queries.TouchAIProviderCredentialByID(ctx, id)
 }
 
-// UpsertCredential inserts or updates the credential payload and metadata.
+// UpsertCredential updates the credential with record.ID, or inserts it when no such row
+// exists. Callers that bind ciphertext to the row set ID before encrypting a new credential;
+// a zero ID lets the database assign one.
 func (s *Store) UpsertCredential(ctx context.Context, record dbresolver.Record) (dbresolver.Record, error) {
 	metadata, err := encodeJSON(record.Metadata)
 	if err != nil {
@@ -66,12 +69,15 @@ func (s *Store) UpsertCredential(ctx context.Context, record dbresolver.Record)
 			Label:            toNullString(record.Label),
 			IsDefault:        sql.NullBool{Bool: record.IsDefault, Valid: true},
 			LastTestedAt:     toNullTime(record.LastTestedAt),
+			ExpiresAt:        toNullTime(record.ExpiresAt),
 			ID:               record.ID,
 		})
-		if err != nil {
+		if err == nil {
+			return mapRecord(row)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
 			return dbresolver.Record{}, err
 		}
-		return mapRecord(row)
 	}
 
 	row, err := s.queries.InsertAIProviderCredential(ctx, database.InsertAIProviderCredentialParams{
@@ -84,6 +90,8 @@ func (s *Store) UpsertCredential(ctx context.Context, record dbresolver.Record)
 		Label:            toNullString(record.Label),
 		IsDefault:        record.IsDefault,
 		LastTestedAt:     toNullTime(record.LastTestedAt),
+		ID:               uuid.NullUUID{UUID: record.ID, Valid: record.ID != uuid.Nil},
+		ExpiresAt:        toNullTime(record.ExpiresAt),
 	})
 	if err != nil {
 		return dbresolver.Record{}, err
@@ -122,6 +130,59 @@ func (s *Store) DeleteCredential(ctx context.Context, id uuid.UUID) error {
 	return s.queries.DeleteAIProviderCredentialByID(ctx, id)
 }
 
+// ListCredentialsAfter returns up to limit credentials with IDs greater than after, in ID
+// order, across all companies.
+func (s *Store) ListCredentialsAfter(ctx context.Context, after uuid.UUID, limit int32) ([]dbresolver.Record, error) {
+	rows, err := s.queries.ListAIProviderCredentialsAfter(ctx, database.ListAIProviderCredentialsAfterParams{
+		After: after,
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapRecords(rows)
+}
+
+// ReplaceCredentialCipher swaps the ciphertext if it still equals oldCipher, leaving the
+// credential's timestamps untouched.
+func (s *Store) ReplaceCredentialCipher(ctx context.Context, id uuid.UUID, oldCipher, newCipher []byte) (bool, error) {
+	affected, err := s.queries.ReplaceAIProviderCredentialCipher(ctx, database.ReplaceAIProviderCredentialCipherParams{
+		NewCipher: newCipher,
+		ID:        id,
+		OldCipher: oldCipher,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ClaimHealthChecks marks up to limit default credentials last checked before
+// checkedBefore as checked now and returns them.
+func (s *Store) ClaimHealthChecks(ctx context.Context, checkedBefore time.Time, limit int32) ([]dbresolver.Record, error) {
+	rows, err := s.queries.ClaimAIProviderCredentialsForHealthCheck(ctx, database.ClaimAIProviderCredentialsForHealthCheckParams{
+		CheckedBefore: checkedBefore,
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapRecords(rows)
+}
+
+// RecordHealth stores the outcome of a health check; a nil checkErr marks the credential ok.
+func (s *Store) RecordHealth(ctx context.Context, id uuid.UUID, checkErr error) error {
+	params := database.RecordAIProviderCredentialHealthParams{
+		HealthStatus: sql.NullString{String: "ok", Valid: true},
+		ID:           id,
+	}
+	if checkErr != nil {
+		params.HealthStatus.String = "failure"
+		params.HealthError = sql.NullString{String: checkErr.Error(), Valid: true}
+	}
+	return s.queries.RecordAIProviderCredentialHealth(ctx, params)
+}
+
 // ClearDefault resets the default flag for the given scope.
 func (s *Store) ClearDefault(ctx context.Context, companyID uuid.UUID, providerID string, userID uuid.NullUUID) error {
 	return s.queries.ClearDefaultAIProviderCredentials(ctx, database.ClearDefaultAIProviderCredentialsParams{
@@ -166,6 +227,18 @@ func mapRecord(row database.AiProviderCredential) (dbresolver.Record, error) {
 		fingerprint = row.Fingerprint.String
 	}
 
+	var expires *time.Time
+	if row.ExpiresAt.Valid {
+		t := row.ExpiresAt.Time
+		expires = &t
+	}
+
+	var healthChecked *time.Time
+	if row.HealthCheckedAt.Valid {
+		t := row.HealthCheckedAt.Time
+		healthChecked = &t
+	}
+
 	return dbresolver.Record{
 		ID:               row.ID,
 		CompanyID:        row.CompanyID,
@@ -182,6 +255,10 @@ func mapRecord(row database.AiProviderCredential) (dbresolver.Record, error) {
 		UpdatedAt:        row.UpdatedAt,
 		LastUsedAt:       lastUsed,
 		RotatedAt:        rotated,
+		ExpiresAt:        expires,
+		HealthCheckedAt:  healthChecked,
+		HealthStatus:     row.HealthStatus.String,
+		HealthError:      row.HealthError.String,
 	}, nil
 }
 
@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("new local: %v", err)
+	}
+
+	if _, err := store.Put(ctx, "company/upload.pdf", strings.NewReader("contract")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	rc, err := store.Open(ctx, "/company/upload.pdf")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "contract" {
+		t.Fatalf("unexpected contents %q", data)
+	}
+
+	if err := store.Delete(ctx, "company/upload.pdf"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Open(ctx, "company/upload.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	parent := t.TempDir()
+	store, err := NewLocal(filepath.Join(parent, "blobs"))
+	if err != nil {
+		t.Fatalf("new local: %v", err)
+	}
+	secret := filepath.Join(parent, "secret.txt")
+	if err := os.WriteFile(secret, []byte("keep out"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", ".", "..", "../secret.txt", "company/../../secret.txt", "/../secret.txt"} {
+		if _, err := store.Put(ctx, key, strings.NewReader("overwritten")); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if rc, err := store.Open(ctx, key); err == nil {
+			rc.Close()
+			t.Errorf("Open(%q) succeeded", key)
+		}
+		if err := store.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+
+	if data, err := os.ReadFile(secret); err != nil || string(data) != "keep out" {
+		t.Fatalf("file outside the root was touched: %q, %v", data, err)
+	}
+}
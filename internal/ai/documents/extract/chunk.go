@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk splits text into pieces of at most size runes, preferring paragraph, then line,
+// then word boundaries. Consecutive chunks share up to overlap runes so facts that straddle
+// a boundary appear whole in at least one chunk.
+func Chunk(text string, size, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 || utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+	if overlap < 0 || overlap >= size/2 {
+		overlap = size / 10
+	}
+
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, strings.TrimSpace(string(runes[start:])))
+			break
+		}
+		end = breakPoint(runes, start, end)
+		chunks = append(chunks, strings.TrimSpace(string(runes[start:end])))
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		// Start the overlap on a word boundary.
+		for next < end && !isBreak(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint returns the best split at or before end, looking back no further than half a
+// chunk so chunks stay reasonably full.
+func breakPoint(runes []rune, start, end int) int {
+	floor := start + (end-start)/2
+	for _, sep := range []string{"\n\n", "\n", " "} {
+		sepRunes := []rune(sep)
+		for i := end; i-len(sepRunes) >= floor; i-- {
+			if string(runes[i-len(sepRunes):i]) == sep {
+				return i
+			}
+		}
+	}
+	return end
+}
+
+func isBreak(r rune) bool {
+	return r == ' ' || r == '\n' || r == '\t'
+}
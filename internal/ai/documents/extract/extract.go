@@ -0,0 +1,147 @@
+// Package extract turns uploaded documents into plain text for document jobs. It handles
+// plain text, CSV, XLSX workbooks, and PDFs that carry a text layer; scanned PDFs and other
+// binary formats are rejected rather than guessed at.
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupported is returned for file types without an extractor.
+	ErrUnsupported = errors.New("extract: unsupported file type")
+	// ErrNoText is returned when a supported file yields no text, e.g. a scanned PDF.
+	ErrNoText = errors.New("extract: no extractable text")
+	// ErrTooLarge is returned when a compressed document expands past maxTextBytes.
+	ErrTooLarge = errors.New("extract: extracted text is too large")
+)
+
+// maxTextBytes bounds the text extracted from one PDF or workbook, whose compressed
+// content can expand far beyond the upload limit.
+const maxTextBytes = 16 << 20
+
+// Kind identifies a supported document format.
+type Kind string
+
+const (
+	KindText Kind = "text"
+	KindCSV  Kind = "csv"
+	KindXLSX Kind = "xlsx"
+	KindPDF  Kind = "pdf"
+)
+
+// Detect chooses an extractor from the file extension, falling back to the content type
+// and finally to sniffing the leading bytes.
+func Detect(filename, contentType string, data []byte) (Kind, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".txt", ".md", ".text", ".log", ".json":
+		return KindText, nil
+	case ".csv", ".tsv":
+		return KindCSV, nil
+	case ".xlsx":
+		return KindXLSX, nil
+	case ".pdf":
+		return KindPDF, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "text/tab-separated-values":
+		return KindCSV, nil
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return KindXLSX, nil
+	case "application/pdf":
+		return KindPDF, nil
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return KindText, nil
+	}
+
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return KindPDF, nil
+	}
+	if utf8.Valid(data) && !bytes.ContainsRune(data, 0) {
+		return KindText, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupported, filename)
+}
+
+// Text extracts the readable text of a document.
+func Text(filename, contentType string, data []byte) (string, error) {
+	kind, err := Detect(filename, contentType, data)
+	if err != nil {
+		return "", err
+	}
+
+	var text string
+	switch kind {
+	case KindText:
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%w: %s is not valid UTF-8 text", ErrUnsupported, filename)
+		}
+		text = string(data)
+	case KindCSV:
+		text, err = csvText(filename, data)
+	case KindXLSX:
+		text, err = xlsxText(data)
+	case KindPDF:
+		text, err = pdfText(data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// csvText renders rows with " | " separators so column boundaries survive in the prompt.
+func csvText(filename string, data []byte) (string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if strings.EqualFold(filepath.Ext(filename), ".tsv") {
+		reader.Comma = '\t'
+	}
+
+	var b strings.Builder
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("extract: parse csv: %w", err)
+		}
+		writeRow(&b, record)
+	}
+	return b.String(), nil
+}
+
+func writeRow(b *strings.Builder, cells []string) {
+	last := len(cells) - 1
+	for last >= 0 && strings.TrimSpace(cells[last]) == "" {
+		last--
+	}
+	if last < 0 {
+		return
+	}
+	for i := 0; i <= last; i++ {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		b.WriteString(strings.TrimSpace(cells[i]))
+	}
+	b.WriteByte('\n')
+}
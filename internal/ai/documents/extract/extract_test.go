@@ -0,0 +1,148 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTextCSV(t *testing.T) {
+	got, err := Text("schedule.csv", "", []byte("Customer,Amount,\nAcme,\"1,200\",\n"))
+	if err != nil {
+		t.Fatalf("text: %v", err)
+	}
+	if got != "Customer | Amount\nAcme | 1,200" {
+		t.Fatalf("unexpected csv text %q", got)
+	}
+}
+
+// buildXLSX zips a single-sheet workbook named Billing around sheetData rows.
+func buildXLSX(t *testing.T, rows string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Billing" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>Milestone</t></si><si><r><t>Go-</t></r><r><t>live</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + rows + `</sheetData></worksheet>`,
+	}
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprint(w, body)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTextXLSX(t *testing.T) {
+	data := buildXLSX(t, `<row><c r="A1" t="s"><v>0</v></c><c r="C1"><v>5000</v></c></row>`+
+		`<row><c r="A2" t="s"><v>1</v></c><c r="B2" t="inlineStr"><is><t>done</t></is></c></row>`)
+
+	got, err := Text("billing.xlsx", "", data)
+	if err != nil {
+		t.Fatalf("text: %v", err)
+	}
+	want := "## Sheet: Billing\nMilestone |  | 5000\nGo-live | done"
+	if got != want {
+		t.Fatalf("unexpected xlsx text %q", got)
+	}
+}
+
+func TestTextXLSXSkipsColumnsPastXFD(t *testing.T) {
+	// ZZZZZZZ1 would otherwise pad the row with billions of empty cells.
+	data := buildXLSX(t, `<row><c r="A1" t="s"><v>0</v></c><c r="ZZZZZZZ1"><v>1</v></c><c r="XFE1"><v>2</v></c></row>`+
+		`<row><c r="A2"><v>3</v></c><c r="XFD2"><v>4</v></c></row>`)
+
+	got, err := Text("billing.xlsx", "", data)
+	if err != nil {
+		t.Fatalf("text: %v", err)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 || lines[1] != "Milestone" {
+		t.Fatalf("unexpected xlsx text %q", got)
+	}
+	if cells := strings.Split(lines[2], " | "); len(cells) != maxXLSXColumns || cells[0] != "3" || cells[len(cells)-1] != "4" {
+		t.Fatalf("expected XFD to be the last column, got %d cells", len(cells))
+	}
+}
+
+func TestTextPDF(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	fmt.Fprint(zw, "BT /F1 12 Tf 72 720 Td (Term: 12 months) Tj T* [(Fee) -250 (\\050annual\\051)] TJ ET")
+	zw.Close()
+
+	var pdf bytes.Buffer
+	fmt.Fprint(&pdf, "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	fmt.Fprint(&pdf, "\nendstream\nendobj\n")
+	fmt.Fprint(&pdf, "5 0 obj << /Subtype /Image /Length 4 >>\nstream\nBT!!\nendstream\nendobj\n")
+	pdf.WriteString("%%EOF")
+
+	got, err := Text("contract.pdf", "application/pdf", pdf.Bytes())
+	if err != nil {
+		t.Fatalf("text: %v", err)
+	}
+	if got != "Term: 12 months\nFee (annual)" {
+		t.Fatalf("unexpected pdf text %q", got)
+	}
+}
+
+func TestTextPDFCapsExtractedText(t *testing.T) {
+	// A few KB of Flate data expands to more text than maxTextBytes.
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	fmt.Fprintf(zw, "BT (%s) Tj ET", strings.Repeat("a", maxTextBytes+1))
+	zw.Close()
+
+	var pdf bytes.Buffer
+	fmt.Fprintf(&pdf, "%%PDF-1.4\n4 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n%%EOF")
+
+	if _, err := Text("bomb.pdf", "application/pdf", pdf.Bytes()); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestTextRejectsUnsupportedAndEmpty(t *testing.T) {
+	if _, err := Text("image.png", "image/png", []byte{0x89, 'P', 'N', 'G', 0}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := Text("scan.pdf", "", []byte("%PDF-1.7\n%%EOF\n")); !errors.Is(err, ErrNoText) {
+		t.Fatalf("expected ErrNoText, got %v", err)
+	}
+}
+
+func TestChunkOverlapsOnWordBoundaries(t *testing.T) {
+	text := strings.Repeat("revenue is recognised over time ", 40)
+	chunks := Chunk(text, 200, 40)
+	if len(chunks) < 6 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > 200 {
+			t.Fatalf("chunk %d has %d runes", i, n)
+		}
+		if !strings.HasPrefix(chunk, "revenue") && !strings.HasPrefix(chunk, "is") &&
+			!strings.HasPrefix(chunk, "recognised") && !strings.HasPrefix(chunk, "over") && !strings.HasPrefix(chunk, "time") {
+			t.Fatalf("chunk %d starts mid-word: %q", i, chunk[:20])
+		}
+	}
+	if got := Chunk("short", 200, 40); len(got) != 1 || got[0] != "short" {
+		t.Fatalf("unexpected short chunking %v", got)
+	}
+}
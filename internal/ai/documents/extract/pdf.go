@@ -0,0 +1,269 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxPDFStreamBytes bounds each decompressed content stream.
+const maxPDFStreamBytes = 32 << 20
+
+// pdfText pulls text-showing operators (Tj, TJ, ', ") out of a PDF's content streams. It
+// reads uncompressed and Flate-compressed streams and single-byte font encodings, which
+// covers PDFs exported from office and accounting software. Scanned pages, other filters,
+// and CID-keyed fonts produce no text and surface as ErrNoText.
+func pdfText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return "", fmt.Errorf("%w: not a PDF document", ErrUnsupported)
+	}
+
+	var b strings.Builder
+	for offset := 0; offset < len(data); {
+		idx := bytes.Index(data[offset:], []byte("stream"))
+		if idx < 0 {
+			break
+		}
+		pos := offset + idx
+		offset = pos + len("stream")
+		if pos >= 3 && string(data[pos-3:pos]) == "end" {
+			continue
+		}
+
+		// The stream dictionary sits between the object header and the keyword.
+		dict := data[:pos]
+		if header := bytes.LastIndex(dict, []byte("obj")); header >= 0 {
+			dict = dict[header:]
+		}
+		start := offset
+		if start < len(data) && data[start] == '\r' {
+			start++
+		}
+		if start < len(data) && data[start] == '\n' {
+			start++
+		}
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		offset = start + end + len("endstream")
+
+		content, ok := decodeStream(string(dict), data[start:start+end])
+		if !ok || !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		if text := contentText(content); strings.TrimSpace(text) != "" {
+			b.WriteString(text)
+			b.WriteString("\n\n")
+		}
+		if b.Len() > maxTextBytes {
+			return "", ErrTooLarge
+		}
+	}
+	return b.String(), nil
+}
+
+func decodeStream(dict string, raw []byte) ([]byte, bool) {
+	switch {
+	case strings.Contains(dict, "/Subtype/Image"), strings.Contains(dict, "/Subtype /Image"):
+		return nil, false
+	case strings.Contains(dict, "/FlateDecode"):
+		if strings.Count(dict, "Decode")-strings.Count(dict, "DecodeParms") > 1 {
+			// Chained filters (e.g. ASCII85 then Flate) are not supported.
+			return nil, false
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, false
+		}
+		defer zr.Close()
+		out, err := io.ReadAll(io.LimitReader(zr, maxPDFStreamBytes))
+		if err != nil && len(out) == 0 {
+			return nil, false
+		}
+		return out, true
+	case strings.Contains(dict, "/Filter"):
+		return nil, false
+	default:
+		return raw, true
+	}
+}
+
+// contentText walks a content stream, collecting string operands and emitting them when a
+// text-showing operator consumes them. Positioning operators become line breaks.
+func contentText(content []byte) string {
+	var (
+		b       strings.Builder
+		operand []string
+		inText  bool
+	)
+	flushLine := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, next := readLiteral(content, i)
+			operand = append(operand, s)
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			s, next := readHex(content, i)
+			operand = append(operand, s)
+			i = next
+		case c == '[':
+			i++
+		case c == ']':
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isPDFSpace(c):
+			i++
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i < len(content) && (content[i] == '-' || content[i] == '.' || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			// Large negative kerning inside a TJ array is how PDFs encode word gaps.
+			if n, err := strconv.ParseFloat(string(content[start:i]), 64); err == nil && n < -200 && inText {
+				operand = append(operand, " ")
+			}
+		default:
+			start := i
+			for i < len(content) && !isPDFSpace(content[i]) && !strings.ContainsRune("()<>[]/%", rune(content[i])) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			switch op := string(content[start:i]); op {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				flushLine()
+			case "Tj", "TJ":
+				b.WriteString(strings.Join(operand, ""))
+			case "'", "\"":
+				flushLine()
+				b.WriteString(strings.Join(operand, ""))
+			case "T*", "Td", "TD", "Tm":
+				flushLine()
+			}
+			operand = operand[:0]
+		}
+	}
+	return b.String()
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+// readLiteral decodes a (possibly nested) literal string starting at content[i] == '('.
+func readLiteral(content []byte, i int) (string, int) {
+	var out []byte
+	depth := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch e := content[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					end := i
+					for end < len(content) && end < i+3 && content[end] >= '0' && content[end] <= '7' {
+						end++
+					}
+					v, _ := strconv.ParseUint(string(content[i:end]), 8, 8)
+					out = append(out, byte(v))
+					i = end - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return decodePDFBytes(out), i + 1
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+		i++
+	}
+	return decodePDFBytes(out), i
+}
+
+// readHex decodes a hex string starting at content[i] == '<'.
+func readHex(content []byte, i int) (string, int) {
+	end := bytes.IndexByte(content[i:], '>')
+	if end < 0 {
+		return "", len(content)
+	}
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, string(content[i+1:i+end]))
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for j := 0; j+1 < len(digits); j += 2 {
+		v, err := strconv.ParseUint(digits[j:j+2], 16, 8)
+		if err != nil {
+			return "", i + end + 1
+		}
+		out = append(out, byte(v))
+	}
+	return decodePDFBytes(out), i + end + 1
+}
+
+// decodePDFBytes maps single-byte (WinAnsi/Latin-1) text to UTF-8 and drops strings that are
+// mostly control bytes, which indicates a CID-keyed font we cannot decode without its CMap.
+func decodePDFBytes(raw []byte) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	control := 0
+	runes := make([]rune, 0, len(raw))
+	for _, c := range raw {
+		if c < 0x20 && c != '\n' && c != '\t' {
+			control++
+			continue
+		}
+		runes = append(runes, rune(c))
+	}
+	if control*2 > len(raw) {
+		return ""
+	}
+	return string(runes)
+}
@@ -0,0 +1,178 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxXLSXPartBytes guards against zip bombs hidden in workbook parts.
+	maxXLSXPartBytes = 64 << 20
+	// maxXLSXColumns is Excel's column limit (XFD); references past it are malformed.
+	maxXLSXColumns = 16384
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r xlsxRichText) String() string {
+	if len(r.Runs) == 0 {
+		return r.Text
+	}
+	var b strings.Builder
+	for _, run := range r.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// xlsxText renders each worksheet as a heading followed by " | " separated rows. Formulas
+// contribute their cached values; styles and number formats are ignored.
+func xlsxText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("extract: open xlsx: %w", err)
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodePart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var rels xlsxRelationships
+	if err := decodePart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := strings.TrimPrefix(rel.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(parts, "xl/sharedStrings.xml", &shared); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	for _, sheetRef := range workbook.Sheets {
+		var sheet xlsxSheet
+		if err := decodePart(parts, targets[sheetRef.RID], &sheet); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "## Sheet: %s\n", sheetRef.Name)
+		for _, row := range sheet.Rows {
+			var cells []string
+			for _, cell := range row.Cells {
+				value := cell.Value
+				switch cell.Type {
+				case "s":
+					if idx, err := strconv.Atoi(cell.Value); err == nil && idx >= 0 && idx < len(shared.Items) {
+						value = shared.Items[idx].String()
+					}
+				case "inlineStr":
+					value = cell.Inline.String()
+				case "b":
+					value = map[string]string{"0": "FALSE", "1": "TRUE"}[cell.Value]
+				}
+				col, ok := columnIndex(cell.Ref)
+				if !ok {
+					continue
+				}
+				if col >= len(cells) {
+					cells = append(cells, make([]string, col-len(cells))...)
+				}
+				cells = append(cells, value)
+			}
+			writeRow(&b, cells)
+			if b.Len() > maxTextBytes {
+				return "", ErrTooLarge
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func decodePart(parts map[string]*zip.File, name string, v any) error {
+	f, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("extract: xlsx is missing %q", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartBytes)).Decode(v); err != nil {
+		return fmt.Errorf("extract: parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// columnIndex converts the letters of a cell reference ("C7") to a zero-based column. It
+// returns -1 when the reference is absent, in which case cells are simply appended, and
+// false when the reference is past column XFD, in which case the cell is skipped.
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	for i, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if i == 3 {
+			return 0, false
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	if col > maxXLSXColumns {
+		return 0, false
+	}
+	return col - 1, true
+}
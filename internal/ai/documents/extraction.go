@@ -0,0 +1,245 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// JobTypeSummary is the default free-text analysis job.
+	JobTypeSummary = "summary"
+	// JobTypeContractExtraction asks the model for structured contract terms and turns them
+	// into draft contracts and performance obligations.
+	JobTypeContractExtraction = "contract_extraction"
+
+	extractionDateLayout = "2006-01-02"
+)
+
+var (
+	// ErrInvalidExtraction is returned when a model reply does not match the extraction schema.
+	ErrInvalidExtraction = errors.New("documents: invalid contract extraction")
+	// ErrCustomerUnmatched is returned by a DraftWriter when the extracted customer does not
+	// exist for the company and no customer was chosen on the job.
+	ErrCustomerUnmatched = errors.New("documents: customer not found")
+)
+
+// JobType reports the kind of job a request describes, defaulting to JobTypeSummary.
+func JobType(request map[string]any) string {
+	if t, ok := request["type"].(string); ok && strings.TrimSpace(t) != "" {
+		return strings.TrimSpace(t)
+	}
+	return JobTypeSummary
+}
+
+// ValidJobType reports whether t names a supported job type.
+func ValidJobType(t string) bool {
+	return t == JobTypeSummary || t == JobTypeContractExtraction
+}
+
+// ContractExtraction is the structured form of a customer contract. Dates use YYYY-MM-DD and
+// amounts are in major currency units (e.g. dollars).
+type ContractExtraction struct {
+	Customer              string                  `json:"customer"`
+	StartDate             string                  `json:"start_date"`
+	EndDate               string                  `json:"end_date"`
+	Currency              string                  `json:"currency"`
+	LineItems             []ExtractedLineItem     `json:"line_items"`
+	Obligations           []ExtractedObligation   `json:"performance_obligations"`
+	VariableConsideration []VariableConsideration `json:"variable_consideration"`
+}
+
+type ExtractedLineItem struct {
+	Description string   `json:"description"`
+	Quantity    *float64 `json:"quantity"`
+	UnitPrice   *float64 `json:"unit_price"`
+	Amount      float64  `json:"amount"`
+}
+
+// ExtractedObligation is a candidate performance obligation. Discount is a fraction between
+// 0 and 1; dates fall back to the contract's when omitted.
+type ExtractedObligation struct {
+	Name             string  `json:"name"`
+	Description      string  `json:"description"`
+	Recognition      string  `json:"recognition"`
+	StartDate        *string `json:"start_date"`
+	EndDate          *string `json:"end_date"`
+	TransactionPrice float64 `json:"transaction_price"`
+	Discount         float64 `json:"discount"`
+}
+
+type VariableConsideration struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Amount      *float64 `json:"amount"`
+}
+
+// Dates parses the contract term.
+func (e ContractExtraction) Dates() (time.Time, time.Time, error) {
+	start, err := time.Parse(extractionDateLayout, e.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: start_date %q is not YYYY-MM-DD", ErrInvalidExtraction, e.StartDate)
+	}
+	end, err := time.Parse(extractionDateLayout, e.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: end_date %q is not YYYY-MM-DD", ErrInvalidExtraction, e.EndDate)
+	}
+	return start, end, nil
+}
+
+// ObligationDates parses an obligation's term, defaulting each side to the contract's.
+func (e ContractExtraction) ObligationDates(o ExtractedObligation) (time.Time, time.Time, error) {
+	start, end, err := e.Dates()
+	if err != nil {
+		return start, end, err
+	}
+	if o.StartDate != nil && *o.StartDate != "" {
+		if start, err = time.Parse(extractionDateLayout, *o.StartDate); err != nil {
+			return start, end, fmt.Errorf("%w: obligation %q start_date is not YYYY-MM-DD", ErrInvalidExtraction, o.Name)
+		}
+	}
+	if o.EndDate != nil && *o.EndDate != "" {
+		if end, err = time.Parse(extractionDateLayout, *o.EndDate); err != nil {
+			return start, end, fmt.Errorf("%w: obligation %q end_date is not YYYY-MM-DD", ErrInvalidExtraction, o.Name)
+		}
+	}
+	return start, end, nil
+}
+
+// MinorUnits converts a major-unit amount to the integer minor units stored on obligations.
+func MinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// Drafts records what a DraftWriter created.
+type Drafts struct {
+	CustomerID  uuid.UUID   `json:"customer_id"`
+	ContractID  uuid.UUID   `json:"contract_id"`
+	Obligations []uuid.UUID `json:"obligation_ids"`
+}
+
+// DraftWriter turns an extraction into non-final contract records for a person to review.
+// customerID is uuid.Nil when the job did not name a customer, in which case the writer
+// matches the extracted name and returns ErrCustomerUnmatched if there is none. Jobs can
+// be retried or re-claimed after a lost lease, so writers must be idempotent per jobID:
+// a replayed job gets back the drafts it already created.
+type DraftWriter interface {
+	CreateDrafts(ctx context.Context, jobID, companyID, customerID uuid.UUID, extraction ContractExtraction) (Drafts, error)
+}
+
+// ExtractionResponseFormat is the OpenAI-style response_format requesting JSON that matches
+// the extraction schema. Providers without schema support receive the schema in the prompt.
+func ExtractionResponseFormat() map[string]any {
+	return map[string]any{
+		"type": "json_schema",
+		"json_schema": map[string]any{
+			"name":   "contract_extraction",
+			"strict": true,
+			"schema": extractionSchema(),
+		},
+	}
+}
+
+func extractionSchema() map[string]any {
+	str := map[string]any{"type": "string"}
+	num := map[string]any{"type": "number"}
+	nullableStr := map[string]any{"type": []string{"string", "null"}}
+	nullableNum := map[string]any{"type": []string{"number", "null"}}
+	object := func(props map[string]any) map[string]any {
+		required := make([]string, 0, len(props))
+		for name := range props {
+			required = append(required, name)
+		}
+		sort.Strings(required)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	array := func(items map[string]any) map[string]any {
+		return map[string]any{"type": "array", "items": items}
+	}
+
+	return object(map[string]any{
+		"customer":   str,
+		"start_date": str,
+		"end_date":   str,
+		"currency":   str,
+		"line_items": array(object(map[string]any{
+			"description": str,
+			"quantity":    nullableNum,
+			"unit_price":  nullableNum,
+			"amount":      num,
+		})),
+		"performance_obligations": array(object(map[string]any{
+			"name":              str,
+			"description":       str,
+			"recognition":       map[string]any{"type": "string", "enum": []string{"over_time", "point_in_time"}},
+			"start_date":        nullableStr,
+			"end_date":          nullableStr,
+			"transaction_price": num,
+			"discount":          num,
+		})),
+		"variable_consideration": array(object(map[string]any{
+			"type":        str,
+			"description": str,
+			"amount":      nullableNum,
+		})),
+	})
+}
+
+// ParseContractExtraction decodes a model reply, tolerating surrounding prose or code fences,
+// and checks the fields every draft depends on.
+func ParseContractExtraction(text string) (ContractExtraction, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return ContractExtraction{}, fmt.Errorf("%w: reply contains no JSON object", ErrInvalidExtraction)
+	}
+
+	var extraction ContractExtraction
+	if err := json.Unmarshal([]byte(text[start:end+1]), &extraction); err != nil {
+		return ContractExtraction{}, fmt.Errorf("%w: %v", ErrInvalidExtraction, err)
+	}
+
+	extraction.Customer = strings.TrimSpace(extraction.Customer)
+	extraction.Currency = strings.ToUpper(strings.TrimSpace(extraction.Currency))
+	if extraction.Customer == "" {
+		return extraction, fmt.Errorf("%w: customer is required", ErrInvalidExtraction)
+	}
+	if _, _, err := extraction.Dates(); err != nil {
+		return extraction, err
+	}
+	for _, obligation := range extraction.Obligations {
+		if _, _, err := extraction.ObligationDates(obligation); err != nil {
+			return extraction, err
+		}
+	}
+	return extraction, nil
+}
+
+func buildExtractionPrompt(job Job, files []fileSection) string {
+	builder := strings.Builder{}
+	builder.WriteString("You are an expert revenue-recognition assistant.\n")
+	builder.WriteString("Extract the contract terms from the documents below as a single JSON object with these fields:\n")
+	builder.WriteString("- customer: the customer's legal name\n")
+	builder.WriteString("- start_date, end_date: the contract term as YYYY-MM-DD\n")
+	builder.WriteString("- currency: ISO 4217 code\n")
+	builder.WriteString("- line_items: [{description, quantity, unit_price, amount}]\n")
+	builder.WriteString("- performance_obligations: [{name, description, recognition (over_time or point_in_time), start_date, end_date, transaction_price, discount}] ")
+	builder.WriteString("where discount is a fraction between 0 and 1 and dates may be null when they match the contract term\n")
+	builder.WriteString("- variable_consideration: [{type, description, amount}] for rebates, credits, bonuses, penalties, and similar clauses\n")
+	builder.WriteString("Amounts are plain numbers in the contract currency. Use null for unknown optional values and empty arrays when nothing applies. ")
+	builder.WriteString("Reply with the JSON object only.\n\n")
+	writeDocumentSections(&builder, job, files)
+	return builder.String()
+}
@@ -0,0 +1,74 @@
+package documents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseContractExtraction(t *testing.T) {
+	reply := "```json\n" + `{
+		"customer": " Acme Corp ",
+		"start_date": "2025-01-01",
+		"end_date": "2025-12-31",
+		"currency": "usd",
+		"line_items": [{"description": "Platform licence", "quantity": 1, "unit_price": 12000, "amount": 12000}],
+		"performance_obligations": [
+			{"name": "Licence", "description": "", "recognition": "over_time", "start_date": null, "end_date": null, "transaction_price": 12000, "discount": 0},
+			{"name": "Onboarding", "description": "", "recognition": "point_in_time", "start_date": "2025-01-01", "end_date": "2025-02-01", "transaction_price": 1500.25, "discount": 0.1}
+		],
+		"variable_consideration": [{"type": "rebate", "description": "5% rebate above 1M calls", "amount": null}]
+	}` + "\n```"
+
+	extraction, err := ParseContractExtraction(reply)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if extraction.Customer != "Acme Corp" || extraction.Currency != "USD" {
+		t.Fatalf("expected normalised customer and currency, got %q %q", extraction.Customer, extraction.Currency)
+	}
+
+	start, end, err := extraction.ObligationDates(extraction.Obligations[0])
+	if err != nil {
+		t.Fatalf("obligation dates: %v", err)
+	}
+	if !start.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected contract term fallback, got %s - %s", start, end)
+	}
+	if got := MinorUnits(extraction.Obligations[1].TransactionPrice); got != 150025 {
+		t.Fatalf("expected 150025 minor units, got %d", got)
+	}
+}
+
+func TestParseContractExtractionRejectsInvalidReplies(t *testing.T) {
+	cases := map[string]string{
+		"no json":      "I could not find a contract.",
+		"no customer":  `{"customer": "", "start_date": "2025-01-01", "end_date": "2025-12-31"}`,
+		"bad date":     `{"customer": "Acme", "start_date": "Jan 1 2025", "end_date": "2025-12-31"}`,
+		"bad ob date":  `{"customer": "Acme", "start_date": "2025-01-01", "end_date": "2025-12-31", "performance_obligations": [{"name": "X", "end_date": "soon"}]}`,
+		"wrong shapes": `{"customer": "Acme", "line_items": "none"}`,
+	}
+	for name, reply := range cases {
+		if _, err := ParseContractExtraction(reply); !errors.Is(err, ErrInvalidExtraction) {
+			t.Errorf("%s: expected ErrInvalidExtraction, got %v", name, err)
+		}
+	}
+}
+
+func TestExtractionSchemaRequiresEveryProperty(t *testing.T) {
+	schema := ExtractionResponseFormat()["json_schema"].(map[string]any)["schema"].(map[string]any)
+	props := schema["properties"].(map[string]any)
+	required := schema["required"].([]string)
+	if len(required) != len(props) {
+		t.Fatalf("strict schemas must require every property: %v", required)
+	}
+	for _, name := range required {
+		if _, ok := props[name]; !ok {
+			t.Fatalf("required %q is not a property", name)
+		}
+	}
+	if !strings.Contains(strings.Join(required, ","), "variable_consideration") {
+		t.Fatalf("expected variable consideration in schema, got %v", required)
+	}
+}
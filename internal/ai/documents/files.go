@@ -0,0 +1,232 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents/blob"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents/extract"
+)
+
+const (
+	// MaxUploadBytes caps a single uploaded file.
+	MaxUploadBytes = 20 << 20
+	// ChunkSize is the largest slice of extracted text (in runes) sent in one prompt,
+	// roughly 3k tokens.
+	ChunkSize    = 12000
+	chunkOverlap = 400
+)
+
+var (
+	ErrFilesNotConfigured = errors.New("documents: file storage not configured")
+	ErrFileTooLarge       = fmt.Errorf("documents: file exceeds %d MB", MaxUploadBytes>>20)
+	ErrFileNotFound       = errors.New("documents: file not found")
+)
+
+// FileStore persists uploaded file records.
+type FileStore interface {
+	InsertFile(ctx context.Context, params CreateFileParams) (File, error)
+	GetFile(ctx context.Context, companyID, fileID uuid.UUID) (File, error)
+	ListFilesByID(ctx context.Context, companyID uuid.UUID, ids []uuid.UUID) ([]File, error)
+	ListFiles(ctx context.Context, companyID uuid.UUID, limit, offset int32) ([]File, error)
+	DeleteFile(ctx context.Context, companyID, fileID uuid.UUID) error
+}
+
+// File is an uploaded document and the text extracted from it.
+type File struct {
+	ID          uuid.UUID
+	CompanyID   uuid.UUID
+	UserID      uuid.UUID
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	SHA256      string
+	StorageKey  string
+	Text        string
+	Chunks      int
+	CreatedAt   time.Time
+}
+
+type CreateFileParams struct {
+	CompanyID   uuid.UUID
+	UserID      uuid.UUID
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	SHA256      string
+	StorageKey  string
+	Text        string
+	Chunks      int
+}
+
+// UploadParams describes a file received from a client.
+type UploadParams struct {
+	CompanyID   uuid.UUID
+	UserID      uuid.UUID
+	Filename    string
+	ContentType string
+	Body        io.Reader
+}
+
+// SetFileStorage enables uploads, persisting records in files and bytes in blobs.
+func (s *Service) SetFileStorage(files FileStore, blobs blob.Store) {
+	s.files = files
+	s.blobs = blobs
+}
+
+// Upload stores the original bytes, extracts their text, and records the file. Unsupported
+// or textless files are rejected before anything is stored.
+func (s *Service) Upload(ctx context.Context, params UploadParams) (File, error) {
+	if s.files == nil || s.blobs == nil {
+		return File{}, ErrFilesNotConfigured
+	}
+
+	data, err := io.ReadAll(io.LimitReader(params.Body, MaxUploadBytes+1))
+	if err != nil {
+		return File{}, err
+	}
+	if len(data) > MaxUploadBytes {
+		return File{}, ErrFileTooLarge
+	}
+
+	filename := strings.TrimSpace(filepath.Base(params.Filename))
+	if filename == "" || filename == "." || filename == string(filepath.Separator) {
+		filename = "upload"
+	}
+	text, err := extract.Text(filename, params.ContentType, data)
+	if err != nil {
+		return File{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/%s%s", params.CompanyID, uuid.New(), strings.ToLower(filepath.Ext(filename)))
+	size, err := s.blobs.Put(ctx, key, bytes.NewReader(data))
+	if err != nil {
+		return File{}, fmt.Errorf("documents: store upload: %w", err)
+	}
+
+	file, err := s.files.InsertFile(ctx, CreateFileParams{
+		CompanyID:   params.CompanyID,
+		UserID:      params.UserID,
+		Filename:    filename,
+		ContentType: params.ContentType,
+		SizeBytes:   size,
+		SHA256:      hex.EncodeToString(sum[:]),
+		StorageKey:  key,
+		Text:        text,
+		Chunks:      len(extract.Chunk(text, ChunkSize, chunkOverlap)),
+	})
+	if err != nil {
+		if delErr := s.blobs.Delete(ctx, key); delErr != nil {
+			s.logger.Error(ctx, "ai: orphaned document upload", delErr, "storage_key", key)
+		}
+		return File{}, err
+	}
+	s.logger.Info(ctx, "ai: document file uploaded", "file_id", file.ID, "company_id", file.CompanyID, "bytes", file.SizeBytes, "chunks", file.Chunks)
+	return file, nil
+}
+
+// Files returns the company's files with the given IDs, failing if any are missing so a
+// job never runs against a partial document set.
+func (s *Service) Files(ctx context.Context, companyID uuid.UUID, ids []uuid.UUID) ([]File, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if s.files == nil {
+		return nil, ErrFilesNotConfigured
+	}
+	files, err := s.files.ListFilesByID(ctx, companyID, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uuid.UUID]File, len(files))
+	for _, file := range files {
+		found[file.ID] = file
+	}
+	ordered := make([]File, 0, len(ids))
+	for _, id := range ids {
+		file, ok := found[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrFileNotFound, id)
+		}
+		ordered = append(ordered, file)
+	}
+	return ordered, nil
+}
+
+// ListFiles lists a company's uploads, newest first.
+func (s *Service) ListFiles(ctx context.Context, companyID uuid.UUID, limit, offset int32) ([]File, error) {
+	if s.files == nil {
+		return nil, ErrFilesNotConfigured
+	}
+	return s.files.ListFiles(ctx, companyID, limit, offset)
+}
+
+// RemoveFile deletes the record and then the stored bytes.
+func (s *Service) RemoveFile(ctx context.Context, companyID, fileID uuid.UUID) error {
+	if s.files == nil || s.blobs == nil {
+		return ErrFilesNotConfigured
+	}
+	file, err := s.files.GetFile(ctx, companyID, fileID)
+	if err != nil {
+		return err
+	}
+	if err := s.files.DeleteFile(ctx, companyID, fileID); err != nil {
+		return err
+	}
+	if err := s.blobs.Delete(ctx, file.StorageKey); err != nil {
+		s.logger.Error(ctx, "ai: orphaned document upload", err, "storage_key", file.StorageKey)
+	}
+	s.logger.Info(ctx, "ai: document file deleted", "file_id", fileID, "company_id", companyID)
+	return nil
+}
+
+// FileIDs reads the file references from a job request. References are stored as
+// {"id": ..., "filename": ...} objects; bare ID strings are accepted too.
+func FileIDs(request map[string]any) []uuid.UUID {
+	items, _ := request["files"].([]any)
+	if refs, ok := request["files"].([]FileRef); ok {
+		for _, ref := range refs {
+			items = append(items, map[string]any{"id": ref.ID.String()})
+		}
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		var raw string
+		switch v := item.(type) {
+		case string:
+			raw = v
+		case map[string]any:
+			raw, _ = v["id"].(string)
+		}
+		if id, err := uuid.Parse(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// FileRef is how a job request records an attached file.
+type FileRef struct {
+	ID       uuid.UUID `json:"id"`
+	Filename string    `json:"filename"`
+}
+
+// FileRefs builds the request entries for files.
+func FileRefs(files []File) []FileRef {
+	refs := make([]FileRef, 0, len(files))
+	for _, file := range files {
+		refs = append(refs, FileRef{ID: file.ID, Filename: file.Filename})
+	}
+	return refs
+}
@@ -2,23 +2,58 @@ package documents
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
 
 	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents/extract"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
 )
 
 // CredentialResolver resolves stored provider credentials.
 type CredentialResolver interface {
-	Resolve(ctx context.Context, reference string) (string, error)
+	Resolve(ctx context.Context, reference string) (clientpkg.Credential, error)
+}
+
+// FallbackSource supplies a company's ordered provider fallback chain.
+type FallbackSource interface {
+	FallbackProviders(ctx context.Context, companyID uuid.UUID) ([]string, error)
+}
+
+// FileSource loads the uploaded files a job references.
+type FileSource interface {
+	Files(ctx context.Context, companyID uuid.UUID, ids []uuid.UUID) ([]File, error)
+}
+
+// PromptSource resolves the company's document prompt template: the version pinned on the
+// job, or else the company default.
+type PromptSource interface {
+	Resolve(ctx context.Context, companyID uuid.UUID, kind string, pinned uuid.UUID) (prompts.Version, error)
 }
 
+// FeatureDocument labels document job usage in the AI usage ledger.
+const FeatureDocument = "document"
+
+// maxInlineChars is the most extracted text sent in a single prompt; larger jobs are
+// condensed chunk by chunk first.
+const maxInlineChars = 4 * ChunkSize
+
 // AIProcessor uses the shared AI client to execute document jobs.
 type AIProcessor struct {
 	client          *clientpkg.Client
 	resolver        CredentialResolver
 	defaultAPIKey   string
 	defaultProvider string
+	fallbacks       FallbackSource
+	files           FileSource
+	drafts          DraftWriter
+	prompts         PromptSource
 }
 
 // NewAIProcessor constructs a processor that delegates to the AI client.
@@ -31,9 +66,29 @@ func NewAIProcessor(client *clientpkg.Client, resolver CredentialResolver, defau
 	}
 }
 
+// SetFallbackSource enables failover to the company's fallback providers.
+func (p *AIProcessor) SetFallbackSource(source FallbackSource) {
+	p.fallbacks = source
+}
+
+// SetFileSource enables jobs that reference uploaded files.
+func (p *AIProcessor) SetFileSource(source FileSource) {
+	p.files = source
+}
+
+// SetDraftWriter enables draft contract creation for contract extraction jobs.
+func (p *AIProcessor) SetDraftWriter(writer DraftWriter) {
+	p.drafts = writer
+}
+
+// SetPromptSource lets companies replace the built-in summary prompt with a template.
+func (p *AIProcessor) SetPromptSource(source PromptSource) {
+	p.prompts = source
+}
+
 func (p *AIProcessor) Process(ctx context.Context, job Job) (map[string]any, error) {
 	if p == nil || p.client == nil {
-		return nil, fmt.Errorf("documents: ai client not configured")
+		return nil, Permanent(fmt.Errorf("documents: ai client not configured"))
 	}
 
 	providerID := job.ProviderID
@@ -41,46 +96,285 @@ func (p *AIProcessor) Process(ctx context.Context, job Job) (map[string]any, err
 		providerID = p.defaultProvider
 	}
 
-	opts := clientpkg.UserOptions{Provider: providerID}
-	if p.defaultAPIKey != "" {
-		opts.APIKey = p.defaultAPIKey
+	// Providers that need a key reject an empty one in their factory; self-hosted
+	// providers may legitimately run without one.
+	opts := p.optionsFor(ctx, job, providerID)
+	if p.fallbacks != nil {
+		chain, err := p.fallbacks.FallbackProviders(ctx, job.CompanyID)
+		if err != nil {
+			return nil, fmt.Errorf("documents: load fallback providers: %w", err)
+		}
+		for _, fallback := range chain {
+			if fallback != providerID {
+				opts.Fallbacks = append(opts.Fallbacks, p.optionsFor(ctx, job, fallback))
+			}
+		}
 	}
-	if opts.APIKey == "" && p.resolver != nil {
-		reference := fmt.Sprintf("%s:%s:%s", job.CompanyID, job.UserID, providerID)
-		if key, err := p.resolver.Resolve(ctx, reference); err == nil && key != "" {
-			opts.APIKey = key
+
+	if len(FileIDs(job.Request)) > 0 {
+		ReportProgress(ctx, StageLoadingFiles, "")
+	}
+	files, err := p.jobFiles(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage clientpkg.Usage
+	var warnings []string
+	complete := func(prompt string, metadata map[string]any) (string, error) {
+		resp, err := p.client.Completion(ctx, opts, clientpkg.CompletionRequest{Prompt: prompt, Metadata: metadata})
+		if err != nil {
+			return "", err
+		}
+		usage = usage.Add(resp.Usage)
+		for _, warning := range resp.Warnings {
+			if !slices.Contains(warnings, warning) {
+				warnings = append(warnings, warning)
+			}
 		}
+		return resp.Text, nil
 	}
-	if opts.APIKey == "" {
-		return nil, fmt.Errorf("documents: no api key configured for provider %s", providerID)
+
+	sections, err := condenseFiles(ctx, files, complete)
+	if err != nil {
+		return nil, err
 	}
 
-	prompt := buildDocumentPrompt(job)
 	metadata := map[string]any{}
 	if addendum, ok := job.Request["instructions"].(string); ok && addendum != "" {
 		metadata = withSystemAddendum(metadata, addendum)
 	}
 
-	resp, err := p.client.Completion(ctx, opts, clientpkg.CompletionRequest{
-		Prompt:   prompt,
-		Metadata: metadata,
-	})
+	result := map[string]any{}
+	switch JobType(job.Request) {
+	case JobTypeContractExtraction:
+		metadata["response_format"] = ExtractionResponseFormat()
+		ReportProgress(ctx, StageExtracting, "")
+		text, err := complete(buildExtractionPrompt(job, sections), metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.applyExtraction(ctx, job, text, result); err != nil {
+			return nil, err
+		}
+	default:
+		prompt, version, err := p.summaryPrompt(ctx, job, sections)
+		if err != nil {
+			return nil, err
+		}
+		if version.ID != uuid.Nil {
+			result[prompts.MetadataTemplate] = version.Ref()
+		}
+		ReportProgress(ctx, StageAnalyzing, "")
+		text, err := complete(prompt, metadata)
+		if err != nil {
+			return nil, err
+		}
+		result["summary"] = text
+	}
+
+	result["usage"] = map[string]any{
+		"model":             usage.Model,
+		"prompt_tokens":     usage.PromptTokens,
+		"completion_tokens": usage.CompletionTokens,
+	}
+	if len(files) > 0 {
+		result["files"] = FileRefs(files)
+	}
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+	return result, nil
+}
+
+// summaryPrompt renders the job's pinned template or the company's default summary template,
+// falling back to the built-in prompt when the company has none. A pinned version that is
+// missing or of another kind fails the job permanently.
+func (p *AIProcessor) summaryPrompt(ctx context.Context, job Job, sections []fileSection) (string, prompts.Version, error) {
+	if p.prompts == nil {
+		return buildDocumentPrompt(job, sections), prompts.Version{}, nil
+	}
+	pinned := prompts.PinnedVersion(job.Request)
+	version, err := p.prompts.Resolve(ctx, job.CompanyID, prompts.KindDocumentSummary, pinned)
+	switch {
+	case errors.Is(err, prompts.ErrNotFound) && pinned == uuid.Nil:
+		return buildDocumentPrompt(job, sections), prompts.Version{}, nil
+	case errors.Is(err, prompts.ErrNotFound), errors.Is(err, prompts.ErrKindMismatch):
+		return "", prompts.Version{}, Permanent(fmt.Errorf("documents: prompt template: %w", err))
+	case err != nil:
+		return "", prompts.Version{}, fmt.Errorf("documents: resolve prompt template: %w", err)
+	}
+
+	sources := strings.Builder{}
+	writeSourceSections(&sources, job, sections)
+	data := promptData(job)
+	data.Documents = strings.TrimSpace(sources.String())
+	text, err := version.Render(data)
 	if err != nil {
-		return nil, err
+		return "", prompts.Version{}, Permanent(fmt.Errorf("documents: render prompt template %q v%d: %w", version.Name, version.Version, err))
 	}
+	if !prompts.UsesDocuments(version.Body) && data.Documents != "" {
+		text += "\n\n" + data.Documents
+	}
+	return text, version, nil
+}
 
-	return map[string]any{
-		"summary": resp.Text,
-	}, nil
+// promptData maps the job request onto template variables.
+func promptData(job Job) prompts.Data {
+	data := prompts.Data{Today: time.Now().Format(time.DateOnly)}
+	data.CustomerName, _ = job.Request["customer_name"].(string)
+	data.Instructions, _ = job.Request["instructions"].(string)
+	contracts, _ := job.Request["contracts"].([]any)
+	for _, raw := range contracts {
+		contract, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		entry := prompts.ContractData{}
+		entry.ID, _ = contract["id"].(string)
+		entry.StartDate, _ = contract["start_date"].(string)
+		entry.EndDate, _ = contract["end_date"].(string)
+		data.Contracts = append(data.Contracts, entry)
+	}
+	return data
 }
 
-func buildDocumentPrompt(job Job) string {
-	docs := extractDocuments(job.Request)
-	instructions, _ := job.Request["instructions"].(string)
+// applyExtraction records the parsed extraction and any drafts created from it. A reply that
+// does not match the schema fails the job; problems creating drafts are reported on the
+// result so the extraction is still available for review.
+func (p *AIProcessor) applyExtraction(ctx context.Context, job Job, text string, result map[string]any) error {
+	extraction, err := ParseContractExtraction(text)
+	if err != nil {
+		return err
+	}
+	result["extraction"] = extraction
+
+	if p.drafts == nil {
+		result["draft_error"] = "draft creation is not configured"
+		return nil
+	}
+	customerID, _ := uuid.Parse(fmt.Sprint(job.Request["customer_id"]))
+	ReportProgress(ctx, StageCreatingDrafts, "")
+	drafts, err := p.drafts.CreateDrafts(ctx, job.ID, job.CompanyID, customerID, extraction)
+	switch {
+	case errors.Is(err, ErrCustomerUnmatched):
+		result["draft_error"] = fmt.Sprintf("customer %q was not found; create it or choose a customer and re-run the job", extraction.Customer)
+	case err != nil:
+		result["draft_error"] = err.Error()
+	default:
+		result["drafts"] = drafts
+	}
+	return nil
+}
+
+func (p *AIProcessor) optionsFor(ctx context.Context, job Job, providerID string) clientpkg.UserOptions {
+	opts := clientpkg.UserOptions{
+		Provider:  providerID,
+		CompanyID: job.CompanyID,
+		UserID:    job.UserID,
+		Feature:   FeatureDocument,
+	}
+	if p.defaultAPIKey != "" && providerID == p.defaultProvider {
+		opts.APIKey = p.defaultAPIKey
+	}
+	if opts.APIKey == "" && p.resolver != nil {
+		reference := fmt.Sprintf("%s:%s:%s", job.CompanyID, job.UserID, providerID)
+		if credential, err := p.resolver.Resolve(ctx, reference); err == nil && !credential.IsZero() {
+			opts.APIKey = credential.Secret
+			opts.Metadata = credential.Metadata
+			opts.CredentialID = credential.ID
+		}
+	}
+	return opts
+}
+
+func (p *AIProcessor) jobFiles(ctx context.Context, job Job) ([]File, error) {
+	ids := FileIDs(job.Request)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if p.files == nil {
+		return nil, ErrFilesNotConfigured
+	}
+	return p.files.Files(ctx, job.CompanyID, ids)
+}
+
+// fileSection is the text of one file as it appears in the final prompt.
+type fileSection struct {
+	Filename  string
+	Text      string
+	Condensed bool
+}
+
+// condenseFiles inlines file text when the job is small enough. Otherwise each chunk of the
+// larger files is reduced to notes first, so the final prompt stays within maxInlineChars.
+func condenseFiles(ctx context.Context, files []File, complete func(prompt string, metadata map[string]any) (string, error)) ([]fileSection, error) {
+	total := 0
+	for _, file := range files {
+		total += utf8.RuneCountInString(file.Text)
+	}
+
+	sections := make([]fileSection, 0, len(files))
+	for _, file := range files {
+		if total <= maxInlineChars || utf8.RuneCountInString(file.Text) <= ChunkSize {
+			sections = append(sections, fileSection{Filename: file.Filename, Text: file.Text})
+			continue
+		}
+
+		chunks := extract.Chunk(file.Text, ChunkSize, chunkOverlap)
+		notes := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			ReportProgress(ctx, StageCondensing, fmt.Sprintf("%s part %d/%d", file.Filename, i+1, len(chunks)))
+			note, err := complete(buildChunkPrompt(file.Filename, i+1, len(chunks), chunk), nil)
+			if err != nil {
+				return nil, fmt.Errorf("documents: condense %s part %d: %w", file.Filename, i+1, err)
+			}
+			notes = append(notes, fmt.Sprintf("Part %d/%d:\n%s", i+1, len(chunks), strings.TrimSpace(note)))
+		}
+		sections = append(sections, fileSection{Filename: file.Filename, Text: strings.Join(notes, "\n\n"), Condensed: true})
+	}
+	return sections, nil
+}
+
+func buildChunkPrompt(filename string, part, parts int, chunk string) string {
+	builder := strings.Builder{}
+	builder.WriteString("You are an expert revenue-recognition assistant.\n")
+	builder.WriteString(fmt.Sprintf("The following is part %d of %d of the file %q. ", part, parts, filename))
+	builder.WriteString("Extract the facts relevant to revenue recognition: parties, dates, amounts, performance obligations, ")
+	builder.WriteString("pricing and payment terms, variable consideration, and anything unusual. Reply with concise notes only.\n\n")
+	builder.WriteString(chunk)
+	return builder.String()
+}
 
+func buildDocumentPrompt(job Job, files []fileSection) string {
 	builder := strings.Builder{}
 	builder.WriteString("You are an expert revenue-recognition assistant.\n")
 	builder.WriteString("Analyze the following documents and provide key findings, risks, and recommended next steps.\n\n")
+	writeDocumentSections(&builder, job, files)
+	builder.WriteString("Provide a concise summary, highlight revenue recognition considerations, and suggest next steps.")
+	return builder.String()
+}
+
+// writeDocumentSections appends the job's records, document list, file text, and
+// instructions.
+func writeDocumentSections(builder *strings.Builder, job Job, files []fileSection) {
+	writeSourceSections(builder, job, files)
+	if instructions, _ := job.Request["instructions"].(string); instructions != "" {
+		builder.WriteString("Additional instructions: ")
+		builder.WriteString(instructions)
+		builder.WriteString("\n\n")
+	}
+}
+
+// writeSourceSections appends the material to analyse: records, document list, and file text.
+func writeSourceSections(builder *strings.Builder, job Job, files []fileSection) {
+	docs := extractDocuments(job.Request)
+
+	if records, _ := job.Request["records"].(string); records != "" {
+		builder.WriteString("Records from the revenue system:\n")
+		builder.WriteString(records)
+		builder.WriteString("\n\n")
+	}
 	if len(docs) > 0 {
 		builder.WriteString("Documents:\n")
 		for i, doc := range docs {
@@ -88,13 +382,15 @@ func buildDocumentPrompt(job Job) string {
 		}
 		builder.WriteString("\n")
 	}
-	if instructions != "" {
-		builder.WriteString("Additional instructions: ")
-		builder.WriteString(instructions)
+	for _, file := range files {
+		if file.Condensed {
+			builder.WriteString(fmt.Sprintf("File %q (condensed notes):\n", file.Filename))
+		} else {
+			builder.WriteString(fmt.Sprintf("File %q:\n", file.Filename))
+		}
+		builder.WriteString(file.Text)
 		builder.WriteString("\n\n")
 	}
-	builder.WriteString("Provide a concise summary, highlight revenue recognition considerations, and suggest next steps.")
-	return builder.String()
 }
 
 func extractDocuments(request map[string]any) []string {
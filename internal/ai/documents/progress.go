@@ -0,0 +1,30 @@
+package documents
+
+import "context"
+
+// Progress stages recorded on a job while it runs.
+const (
+	StageLoadingFiles   = "loading_files"
+	StageCondensing     = "condensing"
+	StageAnalyzing      = "analyzing"
+	StageExtracting     = "extracting"
+	StageCreatingDrafts = "creating_drafts"
+)
+
+// ProgressFunc records the stage a running job has reached.
+type ProgressFunc func(stage, detail string)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose ReportProgress calls are delivered to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress records the stage of the job running under ctx. It does nothing when ctx
+// did not come from a worker.
+func ReportProgress(ctx context.Context, stage, detail string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(stage, detail)
+	}
+}
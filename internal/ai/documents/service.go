@@ -2,11 +2,14 @@ package documents
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 
 	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents/blob"
 )
 
 // Store describes the persistence requirements for document jobs.
@@ -17,9 +20,40 @@ type Store interface {
 	GetJob(ctx context.Context, companyID, jobID uuid.UUID) (Job, error)
 	ListJobs(ctx context.Context, companyID uuid.UUID, limit, offset int32) ([]Job, error)
 	DeleteJob(ctx context.Context, companyID, jobID uuid.UUID) error
-	NextQueuedJob(ctx context.Context) (Job, error)
+	// CancelJob cancels a queued job outright and flags a processing one for its worker,
+	// returning sql.ErrNoRows when the job is missing or already finished.
+	CancelJob(ctx context.Context, companyID, jobID uuid.UUID) (Job, error)
+	// ClaimJob atomically leases the next runnable job to workerID, returning sql.ErrNoRows
+	// when the queue is empty.
+	ClaimJob(ctx context.Context, workerID string, lease time.Duration) (Job, error)
+	// ExtendLease reports whether the worker still holds the job and whether a user has
+	// asked for it to be cancelled.
+	ExtendLease(ctx context.Context, jobID uuid.UUID, workerID string, lease time.Duration) (held, cancelRequested bool, err error)
+	UpdateProgress(ctx context.Context, jobID uuid.UUID, workerID, stage, detail string) (bool, error)
+	CompleteJob(ctx context.Context, jobID uuid.UUID, workerID string, response map[string]any) (bool, error)
+	RetryJob(ctx context.Context, jobID uuid.UUID, workerID, errorMessage string, delay time.Duration) (bool, error)
+	FailJob(ctx context.Context, jobID uuid.UUID, workerID, status, errorMessage string) (bool, error)
+	// RecoverExpiredLeases requeues (or dead-letters) jobs whose worker stopped renewing.
+	RecoverExpiredLeases(ctx context.Context) (int64, error)
+	CountJobsByStatus(ctx context.Context) (map[string]int64, error)
 }
 
+// Job statuses.
+const (
+	StatusQueued     = "queued"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	// StatusFailed marks a job that failed with an error retrying cannot fix.
+	StatusFailed = "failed"
+	// StatusDeadLetter marks a job that exhausted its attempts.
+	StatusDeadLetter = "dead_letter"
+	// StatusCancelled marks a job stopped at a user's request.
+	StatusCancelled = "cancelled"
+)
+
+// ErrJobNotCancellable is returned when cancelling a job that has already finished.
+var ErrJobNotCancellable = errors.New("documents: job already finished")
+
 // Job represents a document analysis task.
 type Job struct {
 	ID           uuid.UUID
@@ -33,6 +67,19 @@ type Job struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	CompletedAt  *time.Time
+	// Attempts counts claims, including the one in progress.
+	Attempts    int
+	MaxAttempts int
+	RunAfter    time.Time
+	// ProgressStage and ProgressDetail describe the step the current attempt is on.
+	ProgressStage     string
+	ProgressDetail    string
+	CancelRequestedAt *time.Time
+}
+
+// Active reports whether the job may still change state.
+func (j Job) Active() bool {
+	return j.Status == StatusQueued || j.Status == StatusProcessing
 }
 
 type CreateJobParams struct {
@@ -47,6 +94,8 @@ type CreateJobParams struct {
 type Service struct {
 	store  Store
 	logger clientpkg.Logger
+	files  FileStore
+	blobs  blob.Store
 }
 
 func New(store Store, logger clientpkg.Logger) *Service {
@@ -59,13 +108,13 @@ func New(store Store, logger clientpkg.Logger) *Service {
 // Enqueue adds a new job to the queue.
 func (s *Service) Enqueue(ctx context.Context, params CreateJobParams) (Job, error) {
 	if params.Status == "" {
-		params.Status = "queued"
+		params.Status = StatusQueued
 	}
 	job, err := s.store.InsertJob(ctx, params)
 	if err != nil {
 		return Job{}, err
 	}
-	s.logger.Info(ctx, "ai: document job created", map[string]any{"job_id": job.ID, "company_id": job.CompanyID})
+	s.logger.Info(ctx, "ai: document job created", "job_id", job.ID, "company_id", job.CompanyID)
 	return job, nil
 }
 
@@ -74,7 +123,7 @@ func (s *Service) MarkStatus(ctx context.Context, companyID, jobID uuid.UUID, st
 	if err := s.store.UpdateJobStatus(ctx, companyID, jobID, status, errMessage); err != nil {
 		return err
 	}
-	s.logger.Info(ctx, "ai: document job status update", map[string]any{"job_id": jobID, "status": status})
+	s.logger.Info(ctx, "ai: document job status update", "job_id", jobID, "status", status)
 	return nil
 }
 
@@ -83,7 +132,7 @@ func (s *Service) Complete(ctx context.Context, companyID, jobID uuid.UUID, resp
 	if err := s.store.UpdateJobResponse(ctx, companyID, jobID, response); err != nil {
 		return err
 	}
-	s.logger.Info(ctx, "ai: document job completed", map[string]any{"job_id": jobID})
+	s.logger.Info(ctx, "ai: document job completed", "job_id", jobID)
 	return nil
 }
 
@@ -97,16 +146,29 @@ func (s *Service) Jobs(ctx context.Context, companyID uuid.UUID, limit, offset i
 	return s.store.ListJobs(ctx, companyID, limit, offset)
 }
 
-// NextQueuedJob retrieves the next job in the queued state across all companies.
-func (s *Service) NextQueuedJob(ctx context.Context) (Job, error) {
-	return s.store.NextQueuedJob(ctx)
+// Cancel stops a job. Queued jobs are cancelled immediately; a running job is flagged and
+// its worker cancels the attempt at the next lease renewal.
+func (s *Service) Cancel(ctx context.Context, companyID, jobID uuid.UUID) (Job, error) {
+	job, err := s.store.CancelJob(ctx, companyID, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, getErr := s.store.GetJob(ctx, companyID, jobID); getErr != nil {
+			return Job{}, getErr
+		}
+		return Job{}, ErrJobNotCancellable
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	s.logger.Info(ctx, "ai: document job cancel requested", "job_id", jobID, "company_id", companyID, "status", job.Status)
+	return job, nil
 }
 
-// Remove deletes a job and its data.
+// Remove deletes a job and its data. Deleting a running job also stops it, as its worker
+// loses the lease.
 func (s *Service) Remove(ctx context.Context, companyID, jobID uuid.UUID) error {
 	if err := s.store.DeleteJob(ctx, companyID, jobID); err != nil {
 		return err
 	}
-	s.logger.Info(ctx, "ai: document job deleted", map[string]any{"job_id": jobID, "company_id": companyID})
+	s.logger.Info(ctx, "ai: document job deleted", "job_id", jobID, "company_id", companyID)
 	return nil
 }
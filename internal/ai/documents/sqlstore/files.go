@@ -0,0 +1,92 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+func (s *Store) InsertFile(ctx context.Context, params documents.CreateFileParams) (documents.File, error) {
+	row, err := s.queries.InsertAIDocumentFile(ctx, database.InsertAIDocumentFileParams{
+		CompanyID:     params.CompanyID,
+		UserID:        params.UserID,
+		Filename:      params.Filename,
+		ContentType:   params.ContentType,
+		SizeBytes:     params.SizeBytes,
+		Sha256:        params.SHA256,
+		StorageKey:    params.StorageKey,
+		ExtractedText: params.Text,
+		ChunkCount:    int32(params.Chunks),
+	})
+	if err != nil {
+		return documents.File{}, err
+	}
+	return mapFile(row), nil
+}
+
+func (s *Store) GetFile(ctx context.Context, companyID, fileID uuid.UUID) (documents.File, error) {
+	row, err := s.queries.GetAIDocumentFile(ctx, database.GetAIDocumentFileParams{ID: fileID, CompanyID: companyID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return documents.File{}, documents.ErrFileNotFound
+	}
+	if err != nil {
+		return documents.File{}, err
+	}
+	return mapFile(row), nil
+}
+
+func (s *Store) ListFilesByID(ctx context.Context, companyID uuid.UUID, ids []uuid.UUID) ([]documents.File, error) {
+	rows, err := s.queries.ListAIDocumentFilesByIDs(ctx, database.ListAIDocumentFilesByIDsParams{
+		CompanyID: companyID,
+		Column2:   ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapFiles(rows), nil
+}
+
+func (s *Store) ListFiles(ctx context.Context, companyID uuid.UUID, limit, offset int32) ([]documents.File, error) {
+	rows, err := s.queries.ListAIDocumentFilesByCompany(ctx, database.ListAIDocumentFilesByCompanyParams{
+		CompanyID: companyID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapFiles(rows), nil
+}
+
+func (s *Store) DeleteFile(ctx context.Context, companyID, fileID uuid.UUID) error {
+	return s.queries.DeleteAIDocumentFile(ctx, database.DeleteAIDocumentFileParams{ID: fileID, CompanyID: companyID})
+}
+
+func mapFiles(rows []database.AiDocumentFile) []documents.File {
+	files := make([]documents.File, 0, len(rows))
+	for _, row := range rows {
+		files = append(files, mapFile(row))
+	}
+	return files
+}
+
+func mapFile(row database.AiDocumentFile) documents.File {
+	return documents.File{
+		ID:          row.ID,
+		CompanyID:   row.CompanyID,
+		UserID:      row.UserID,
+		Filename:    row.Filename,
+		ContentType: row.ContentType,
+		SizeBytes:   row.SizeBytes,
+		SHA256:      row.Sha256,
+		StorageKey:  row.StorageKey,
+		Text:        row.ExtractedText,
+		Chunks:      int(row.ChunkCount),
+		CreatedAt:   row.CreatedAt,
+	}
+}
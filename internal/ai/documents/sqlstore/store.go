@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -49,13 +50,9 @@ func (s *Store) UpdateJobStatus(ctx context.Context, companyID, jobID uuid.UUID,
 }
 
 func (s *Store) UpdateJobResponse(ctx context.Context, companyID, jobID uuid.UUID, response map[string]any) error {
-	var payload pqtype.NullRawMessage
-	if response != nil {
-		bytes, err := json.Marshal(response)
-		if err != nil {
-			return err
-		}
-		payload = pqtype.NullRawMessage{RawMessage: bytes, Valid: true}
+	payload, err := marshalResponse(response)
+	if err != nil {
+		return err
 	}
 	return s.queries.UpdateAIDocumentJobResponse(ctx, database.UpdateAIDocumentJobResponseParams{
 		ID:        jobID,
@@ -96,14 +93,115 @@ func (s *Store) DeleteJob(ctx context.Context, companyID, jobID uuid.UUID) error
 	return s.queries.DeleteAIDocumentJob(ctx, database.DeleteAIDocumentJobParams{ID: jobID, CompanyID: companyID})
 }
 
-func (s *Store) NextQueuedJob(ctx context.Context) (documents.Job, error) {
-	row, err := s.queries.GetNextQueuedAIDocumentJob(ctx)
+func (s *Store) ClaimJob(ctx context.Context, workerID string, lease time.Duration) (documents.Job, error) {
+	row, err := s.queries.ClaimAIDocumentJob(ctx, database.ClaimAIDocumentJobParams{
+		LockedBy:     workerID,
+		LeaseSeconds: seconds(lease),
+	})
+	if err != nil {
+		return documents.Job{}, err
+	}
+	return mapJob(row)
+}
+
+func (s *Store) ExtendLease(ctx context.Context, jobID uuid.UUID, workerID string, lease time.Duration) (bool, bool, error) {
+	cancelRequested, err := s.queries.ExtendAIDocumentJobLease(ctx, database.ExtendAIDocumentJobLeaseParams{
+		LeaseSeconds: seconds(lease),
+		ID:           jobID,
+		LockedBy:     workerID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, cancelRequested, nil
+}
+
+func (s *Store) UpdateProgress(ctx context.Context, jobID uuid.UUID, workerID, stage, detail string) (bool, error) {
+	n, err := s.queries.UpdateAIDocumentJobProgress(ctx, database.UpdateAIDocumentJobProgressParams{
+		ProgressStage:  stage,
+		ProgressDetail: detail,
+		ID:             jobID,
+		LockedBy:       workerID,
+	})
+	return n > 0, err
+}
+
+func (s *Store) CancelJob(ctx context.Context, companyID, jobID uuid.UUID) (documents.Job, error) {
+	row, err := s.queries.CancelAIDocumentJob(ctx, database.CancelAIDocumentJobParams{ID: jobID, CompanyID: companyID})
 	if err != nil {
 		return documents.Job{}, err
 	}
 	return mapJob(row)
 }
 
+func (s *Store) CompleteJob(ctx context.Context, jobID uuid.UUID, workerID string, response map[string]any) (bool, error) {
+	payload, err := marshalResponse(response)
+	if err != nil {
+		return false, err
+	}
+	n, err := s.queries.CompleteAIDocumentJob(ctx, database.CompleteAIDocumentJobParams{
+		Response: payload,
+		ID:       jobID,
+		LockedBy: workerID,
+	})
+	return n > 0, err
+}
+
+func (s *Store) RetryJob(ctx context.Context, jobID uuid.UUID, workerID, errorMessage string, delay time.Duration) (bool, error) {
+	n, err := s.queries.RetryAIDocumentJob(ctx, database.RetryAIDocumentJobParams{
+		ErrorMessage: errorMessage,
+		DelaySeconds: seconds(delay),
+		ID:           jobID,
+		LockedBy:     workerID,
+	})
+	return n > 0, err
+}
+
+func (s *Store) FailJob(ctx context.Context, jobID uuid.UUID, workerID, status, errorMessage string) (bool, error) {
+	n, err := s.queries.FailAIDocumentJob(ctx, database.FailAIDocumentJobParams{
+		Status:       status,
+		ErrorMessage: errorMessage,
+		ID:           jobID,
+		LockedBy:     workerID,
+	})
+	return n > 0, err
+}
+
+func (s *Store) RecoverExpiredLeases(ctx context.Context) (int64, error) {
+	return s.queries.RecoverExpiredAIDocumentJobs(ctx)
+}
+
+func (s *Store) CountJobsByStatus(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.queries.CountAIDocumentJobsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Jobs
+	}
+	return counts, nil
+}
+
+// seconds rounds a duration up to whole seconds for interval arithmetic in SQL.
+func seconds(d time.Duration) int32 {
+	return int32((d + time.Second - 1) / time.Second)
+}
+
+func marshalResponse(response map[string]any) (pqtype.NullRawMessage, error) {
+	if response == nil {
+		return pqtype.NullRawMessage{}, nil
+	}
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+	return pqtype.NullRawMessage{RawMessage: bytes, Valid: true}, nil
+}
+
 func mapJob(row database.AiDocumentJob) (documents.Job, error) {
 	request := map[string]any{}
 	if len(row.Request) > 0 {
@@ -131,18 +229,30 @@ func mapJob(row database.AiDocumentJob) (documents.Job, error) {
 		completed = &t
 	}
 
+	var cancelRequested *time.Time
+	if row.CancelRequestedAt.Valid {
+		t := row.CancelRequestedAt.Time
+		cancelRequested = &t
+	}
+
 	return documents.Job{
-		ID:           row.ID,
-		CompanyID:    row.CompanyID,
-		UserID:       row.UserID,
-		ProviderID:   row.ProviderID,
-		Status:       row.Status,
-		Request:      request,
-		Response:     response,
-		ErrorMessage: errMessage,
-		CreatedAt:    row.CreatedAt,
-		UpdatedAt:    row.UpdatedAt,
-		CompletedAt:  completed,
+		ID:                row.ID,
+		CompanyID:         row.CompanyID,
+		UserID:            row.UserID,
+		ProviderID:        row.ProviderID,
+		Status:            row.Status,
+		Request:           request,
+		Response:          response,
+		ErrorMessage:      errMessage,
+		CreatedAt:         row.CreatedAt,
+		UpdatedAt:         row.UpdatedAt,
+		CompletedAt:       completed,
+		Attempts:          int(row.Attempts),
+		MaxAttempts:       int(row.MaxAttempts),
+		RunAfter:          row.RunAfter,
+		ProgressStage:     row.ProgressStage,
+		ProgressDetail:    row.ProgressDetail,
+		CancelRequestedAt: cancelRequested,
 	}, nil
 }
 
@@ -4,9 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/logging"
 )
 
 // Processor defines the execution contract for document jobs.
@@ -14,101 +21,379 @@ type Processor interface {
 	Process(ctx context.Context, job Job) (map[string]any, error)
 }
 
-// Worker coordinates background processing of queued document jobs.
+// WorkerMetrics receives queue depth and latency observations from the worker.
+type WorkerMetrics interface {
+	QueueDepth(status string, jobs int64)
+	JobClaimed(wait time.Duration)
+	JobFinished(outcome string, duration time.Duration)
+	LeasesRecovered(jobs int64)
+}
+
+type noopWorkerMetrics struct{}
+
+func (noopWorkerMetrics) QueueDepth(string, int64)          {}
+func (noopWorkerMetrics) JobClaimed(time.Duration)          {}
+func (noopWorkerMetrics) JobFinished(string, time.Duration) {}
+func (noopWorkerMetrics) LeasesRecovered(int64)             {}
+
+// Outcomes reported to WorkerMetrics.JobFinished.
+const (
+	OutcomeCompleted   = "completed"
+	OutcomeRetried     = "retried"
+	OutcomeFailed      = "failed"
+	OutcomeDeadLetter  = "dead_letter"
+	OutcomeLeaseLost   = "lease_lost"
+	OutcomeInterrupted = "interrupted"
+	OutcomeCancelled   = "cancelled"
+)
+
+// WorkerConfig tunes the worker pool. Zero values select the defaults.
+type WorkerConfig struct {
+	// ID identifies this instance in job leases; it defaults to the hostname plus a random
+	// suffix so restarts never reuse a lease owner.
+	ID string
+	// Concurrency is the number of jobs processed at once.
+	Concurrency int
+	// PollInterval is how long an idle slot waits before checking the queue again.
+	PollInterval time.Duration
+	// Lease is how long a claim lasts without renewal. Workers renew at a third of it (at
+	// most every cancelCheckInterval, which is also how quickly cancellations take effect),
+	// and jobs whose lease lapses (e.g. after a crash) are returned to the queue.
+	Lease time.Duration
+	// BaseBackoff and MaxBackoff bound the exponential delay between attempts.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Metrics     WorkerMetrics
+}
+
+const (
+	defaultInterval     = 3 * time.Second
+	defaultConcurrency  = 2
+	defaultLease        = 2 * time.Minute
+	defaultBaseBackoff  = 30 * time.Second
+	defaultMaxBackoff   = 30 * time.Minute
+	bookkeepingTimeout  = 10 * time.Second
+	cancelCheckInterval = 5 * time.Second
+)
+
+var (
+	errLeaseLost = errors.New("documents: job lease lost")
+	errCancelled = errors.New("documents: job cancelled by user")
+)
+
+// Worker coordinates background processing of queued document jobs. Several workers, in
+// one process or many, can share a queue: each claim is an atomic lease.
 type Worker struct {
 	service   *Service
 	processor Processor
 	logger    clientpkg.Logger
-	interval  time.Duration
+	metrics   WorkerMetrics
+	cfg       WorkerConfig
 
-	stop    chan struct{}
-	stopped chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
-const defaultInterval = 3 * time.Second
-
 // NewWorker constructs a worker using the supplied service and processor.
-func NewWorker(service *Service, processor Processor, logger clientpkg.Logger, interval time.Duration) *Worker {
+func NewWorker(service *Service, processor Processor, logger clientpkg.Logger, cfg WorkerConfig) *Worker {
 	if logger == nil {
 		logger = clientpkg.NewNoopLogger()
 	}
-	if interval <= 0 {
-		interval = defaultInterval
+	if cfg.ID == "" {
+		host, _ := os.Hostname()
+		cfg.ID = fmt.Sprintf("%s-%s", host, uuid.NewString()[:8])
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultLease
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = max(defaultMaxBackoff, cfg.BaseBackoff)
+	}
+	metrics := cfg.Metrics
+	if metrics == nil {
+		metrics = noopWorkerMetrics{}
 	}
 	return &Worker{
 		service:   service,
 		processor: processor,
 		logger:    logger,
-		interval:  interval,
+		metrics:   metrics,
+		cfg:       cfg,
 		stop:      make(chan struct{}),
-		stopped:   make(chan struct{}),
 	}
 }
 
-// Start launches the worker loop in a separate goroutine.
+// Start launches the worker pool and the lease recovery loop.
 func (w *Worker) Start(ctx context.Context) {
-	go w.run(ctx)
+	w.wg.Add(1)
+	go w.maintain(ctx)
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		w.wg.Add(1)
+		go w.run(ctx, fmt.Sprintf("%s/%d", w.cfg.ID, i))
+	}
 }
 
-// Stop requests the worker to halt and waits for termination.
+// Stop requests the worker to halt and waits for in-flight jobs to finish.
 func (w *Worker) Stop() {
-	close(w.stop)
-	<-w.stopped
+	w.stopOnce.Do(func() { close(w.stop) })
+	w.wg.Wait()
 }
 
-// SetProcessor swaps the processor implementation used for future jobs.
+// SetProcessor swaps the processor implementation. Call it before Start.
 func (w *Worker) SetProcessor(p Processor) {
 	w.processor = p
 }
 
-func (w *Worker) run(ctx context.Context) {
-	ticker := time.NewTicker(w.interval)
-	defer func() {
-		ticker.Stop()
-		close(w.stopped)
-	}()
+func (w *Worker) run(ctx context.Context, slot string) {
+	defer w.wg.Done()
 
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 	for {
-		w.processOnce(ctx)
-
 		select {
 		case <-ctx.Done():
 			return
 		case <-w.stop:
 			return
-		case <-ticker.C:
+		case <-timer.C:
+		}
+
+		// Keep draining while there is work; back off to the poll interval once idle.
+		next := w.cfg.PollInterval
+		if w.processOnce(ctx, slot) {
+			next = 0
 		}
+		timer.Reset(next)
 	}
 }
 
-func (w *Worker) processOnce(ctx context.Context) {
+// processOnce claims and runs a single job, reporting whether one was found.
+func (w *Worker) processOnce(ctx context.Context, slot string) bool {
 	if w.service == nil || w.processor == nil {
-		return
+		return false
 	}
 
-	job, err := w.service.NextQueuedJob(ctx)
+	job, err := w.service.store.ClaimJob(ctx, slot, w.cfg.Lease)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+			w.logger.Error(ctx, "ai: worker failed to claim job", err, "worker", slot)
+		}
+		return false
+	}
+
+	// Jobs run outside any request, so attribute their logs to the job's company and user.
+	ctx = logging.WithAttribution(ctx, job.CompanyID, job.UserID)
+	started := time.Now()
+	w.metrics.JobClaimed(max(started.Sub(job.RunAfter), 0))
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	jobCtx = WithProgress(jobCtx, func(stage, detail string) {
+		w.recordProgress(jobCtx, job, slot, stage, detail)
+	})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		w.renewLease(jobCtx, cancel, job, slot)
+	}()
+
+	response, err := w.processor.Process(jobCtx, job)
+	cancel(nil)
+	<-renewed
+
+	outcome := w.settle(ctx, job, slot, response, err, context.Cause(jobCtx))
+	w.metrics.JobFinished(outcome, time.Since(started))
+	return true
+}
+
+// recordProgress stores the job's current stage. Progress is informational, so failures are
+// only logged.
+func (w *Worker) recordProgress(ctx context.Context, job Job, slot, stage, detail string) {
+	if _, err := w.service.store.UpdateProgress(ctx, job.ID, slot, stage, detail); err != nil && ctx.Err() == nil {
+		w.logger.Error(ctx, "ai: worker failed to record progress", err, "job_id", job.ID, "stage", stage)
+	}
+}
+
+// renewLease extends the job's lease until ctx ends, cancelling the job if another worker
+// has taken it over or a user has cancelled it.
+func (w *Worker) renewLease(ctx context.Context, cancel context.CancelCauseFunc, job Job, slot string) {
+	ticker := time.NewTicker(min(w.cfg.Lease/3, cancelCheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case <-ticker.C:
 		}
-		w.logger.Error(ctx, "ai: worker failed to fetch job", err)
-		return
+		held, cancelRequested, err := w.service.store.ExtendLease(ctx, job.ID, slot, w.cfg.Lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Error(ctx, "ai: worker failed to renew lease", err, "job_id", job.ID)
+			}
+			continue
+		}
+		switch {
+		case !held:
+			cancel(errLeaseLost)
+			return
+		case cancelRequested:
+			cancel(errCancelled)
+			return
+		}
+	}
+}
+
+// settle records the result of an attempt. Bookkeeping runs on a detached context so a
+// shutdown mid-job still releases the lease instead of waiting for it to expire.
+func (w *Worker) settle(parent context.Context, job Job, slot string, response map[string]any, procErr, cause error) string {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), bookkeepingTimeout)
+	defer cancel()
+
+	if errors.Is(cause, errLeaseLost) {
+		w.logger.Error(ctx, "ai: document job lease lost", errLeaseLost, "job_id", job.ID, "worker", slot)
+		return OutcomeLeaseLost
+	}
+
+	var (
+		held    bool
+		err     error
+		outcome string
+	)
+	switch {
+	case errors.Is(cause, errCancelled):
+		outcome = OutcomeCancelled
+		held, err = w.service.store.FailJob(ctx, job.ID, slot, StatusCancelled, "cancelled by user")
+	case procErr == nil:
+		outcome = OutcomeCompleted
+		held, err = w.service.store.CompleteJob(ctx, job.ID, slot, response)
+	case parent.Err() != nil:
+		// Shutting down: hand the job straight back. The attempt still counts, which bounds
+		// jobs that repeatedly outlive a deploy.
+		outcome = OutcomeInterrupted
+		held, err = w.service.store.RetryJob(ctx, job.ID, slot, procErr.Error(), 0)
+	case IsPermanent(procErr):
+		outcome = OutcomeFailed
+		held, err = w.service.store.FailJob(ctx, job.ID, slot, StatusFailed, procErr.Error())
+	case job.Attempts >= job.MaxAttempts:
+		outcome = OutcomeDeadLetter
+		held, err = w.service.store.FailJob(ctx, job.ID, slot, StatusDeadLetter, procErr.Error())
+	default:
+		outcome = OutcomeRetried
+		held, err = w.service.store.RetryJob(ctx, job.ID, slot, procErr.Error(), w.backoff(job.Attempts))
+	}
+
+	attrs := []any{"job_id", job.ID, "company_id", job.CompanyID, "attempt", job.Attempts, "outcome", outcome}
+	switch {
+	case err != nil:
+		w.logger.Error(ctx, "ai: document job bookkeeping failed", err, attrs...)
+	case !held:
+		w.logger.Error(ctx, "ai: document job lease lost", errLeaseLost, attrs...)
+		return OutcomeLeaseLost
+	case outcome == OutcomeCancelled:
+		w.logger.Info(ctx, "ai: document job cancelled", attrs...)
+	case procErr != nil:
+		w.logger.Error(ctx, "ai: document job attempt failed", procErr, attrs...)
+	default:
+		w.logger.Info(ctx, "ai: document job completed", attrs...)
+	}
+	return outcome
+}
+
+// backoff returns the delay before the next attempt: BaseBackoff doubled per attempt,
+// capped at MaxBackoff, with up to 20% jitter so retries from a burst spread out.
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := w.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < w.cfg.MaxBackoff; i++ {
+		delay *= 2
 	}
+	delay = min(delay, w.cfg.MaxBackoff)
+	return delay + rand.N(delay/5+1)
+}
+
+// maintain periodically requeues jobs with expired leases and samples queue depth.
+func (w *Worker) maintain(ctx context.Context) {
+	defer w.wg.Done()
 
-	if err := w.service.MarkStatus(ctx, job.CompanyID, job.ID, "processing", nil); err != nil {
-		w.logger.Error(ctx, "ai: worker failed to mark processing", err, map[string]any{"job_id": job.ID})
+	ticker := time.NewTicker(max(w.cfg.Lease/2, w.cfg.PollInterval))
+	defer ticker.Stop()
+	for {
+		w.recover(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+var jobStatuses = []string{StatusQueued, StatusProcessing, StatusCompleted, StatusFailed, StatusDeadLetter, StatusCancelled}
+
+func (w *Worker) recover(ctx context.Context) {
+	if w.service == nil {
 		return
 	}
+	recovered, err := w.service.store.RecoverExpiredLeases(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Error(ctx, "ai: worker failed to recover expired leases", err)
+		}
+		return
+	}
+	if recovered > 0 {
+		w.metrics.LeasesRecovered(recovered)
+		w.logger.Info(ctx, "ai: requeued document jobs with expired leases", "jobs", recovered)
+	}
 
-	response, err := w.processor.Process(ctx, job)
+	counts, err := w.service.store.CountJobsByStatus(ctx)
 	if err != nil {
-		msg := err.Error()
-		_ = w.service.MarkStatus(ctx, job.CompanyID, job.ID, "failed", &msg)
-		w.logger.Error(ctx, "ai: document job failed", err, map[string]any{"job_id": job.ID})
+		if ctx.Err() == nil {
+			w.logger.Error(ctx, "ai: worker failed to count jobs", err)
+		}
 		return
 	}
+	for _, status := range jobStatuses {
+		w.metrics.QueueDepth(status, counts[status])
+	}
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the worker fails the job instead of retrying it.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
 
-	if err := w.service.Complete(ctx, job.CompanyID, job.ID, response); err != nil {
-		w.logger.Error(ctx, "ai: document job completion failed", err, map[string]any{"job_id": job.ID})
+// IsPermanent reports whether err should fail a job without further attempts.
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	switch {
+	case errors.As(err, &permanent):
+		return true
+	case errors.Is(err, ErrFilesNotConfigured), errors.Is(err, ErrFileNotFound):
+		return true
+	case errors.Is(err, clientpkg.ErrProviderNotConfigured),
+		errors.Is(err, clientpkg.ErrCapabilityNotImplemented),
+		errors.Is(err, clientpkg.ErrBudgetExceeded):
+		return true
 	}
+	return false
 }
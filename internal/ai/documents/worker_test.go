@@ -0,0 +1,235 @@
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore is a single-process stand-in for the SQL queue.
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*Job
+	// owner and delay record the lease holder and last retry delay per job.
+	owner map[uuid.UUID]string
+	delay map[uuid.UUID]time.Duration
+}
+
+func newMemoryStore(jobs ...Job) *memoryStore {
+	s := &memoryStore{jobs: map[uuid.UUID]*Job{}, owner: map[uuid.UUID]string{}, delay: map[uuid.UUID]time.Duration{}}
+	for i := range jobs {
+		job := jobs[i]
+		s.jobs[job.ID] = &job
+	}
+	return s
+}
+
+func (s *memoryStore) ClaimJob(_ context.Context, workerID string, _ time.Duration) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.Status == StatusQueued && !job.RunAfter.After(time.Now()) {
+			job.Status = StatusProcessing
+			job.Attempts++
+			s.owner[job.ID] = workerID
+			return *job, nil
+		}
+	}
+	return Job{}, sql.ErrNoRows
+}
+
+func (s *memoryStore) settle(jobID uuid.UUID, workerID string, update func(*Job)) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok || job.Status != StatusProcessing || s.owner[jobID] != workerID {
+		return false, nil
+	}
+	update(job)
+	delete(s.owner, jobID)
+	return true, nil
+}
+
+func (s *memoryStore) ExtendLease(_ context.Context, jobID uuid.UUID, workerID string, _ time.Duration) (bool, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner[jobID] != workerID {
+		return false, false, nil
+	}
+	return true, s.jobs[jobID].CancelRequestedAt != nil, nil
+}
+
+func (s *memoryStore) UpdateProgress(_ context.Context, jobID uuid.UUID, workerID, stage, detail string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner[jobID] != workerID {
+		return false, nil
+	}
+	s.jobs[jobID].ProgressStage, s.jobs[jobID].ProgressDetail = stage, detail
+	return true, nil
+}
+
+func (s *memoryStore) CancelJob(_ context.Context, _ uuid.UUID, jobID uuid.UUID) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok || !job.Active() {
+		return Job{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	job.CancelRequestedAt = &now
+	if job.Status == StatusQueued {
+		job.Status = StatusCancelled
+	}
+	return *job, nil
+}
+
+func (s *memoryStore) CompleteJob(_ context.Context, jobID uuid.UUID, workerID string, response map[string]any) (bool, error) {
+	return s.settle(jobID, workerID, func(job *Job) {
+		job.Status = StatusCompleted
+		job.Response = response
+	})
+}
+
+func (s *memoryStore) RetryJob(_ context.Context, jobID uuid.UUID, workerID, msg string, delay time.Duration) (bool, error) {
+	return s.settle(jobID, workerID, func(job *Job) {
+		s.delay[jobID] = delay
+		job.Status = StatusQueued
+		job.ErrorMessage = &msg
+		// Make the job immediately runnable so tests need not wait out the backoff.
+		job.RunAfter = time.Now()
+	})
+}
+
+func (s *memoryStore) FailJob(_ context.Context, jobID uuid.UUID, workerID, status, msg string) (bool, error) {
+	return s.settle(jobID, workerID, func(job *Job) {
+		job.Status = status
+		job.ErrorMessage = &msg
+	})
+}
+
+func (s *memoryStore) RecoverExpiredLeases(context.Context) (int64, error)         { return 0, nil }
+func (s *memoryStore) CountJobsByStatus(context.Context) (map[string]int64, error) { return nil, nil }
+func (s *memoryStore) InsertJob(context.Context, CreateJobParams) (Job, error)     { return Job{}, nil }
+func (s *memoryStore) UpdateJobStatus(context.Context, uuid.UUID, uuid.UUID, string, *string) error {
+	return nil
+}
+func (s *memoryStore) UpdateJobResponse(context.Context, uuid.UUID, uuid.UUID, map[string]any) error {
+	return nil
+}
+func (s *memoryStore) GetJob(_ context.Context, _ uuid.UUID, jobID uuid.UUID) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[jobID]; ok {
+		return *job, nil
+	}
+	return Job{}, sql.ErrNoRows
+}
+func (s *memoryStore) ListJobs(context.Context, uuid.UUID, int32, int32) ([]Job, error) {
+	return nil, nil
+}
+func (s *memoryStore) DeleteJob(context.Context, uuid.UUID, uuid.UUID) error { return nil }
+
+type processorFunc func(ctx context.Context, job Job) (map[string]any, error)
+
+func (f processorFunc) Process(ctx context.Context, job Job) (map[string]any, error) {
+	return f(ctx, job)
+}
+
+func TestWorkerRetriesThenDeadLetters(t *testing.T) {
+	job := Job{ID: uuid.New(), Status: StatusQueued, MaxAttempts: 3}
+	store := newMemoryStore(job)
+	calls := 0
+	worker := NewWorker(New(store, nil), processorFunc(func(context.Context, Job) (map[string]any, error) {
+		calls++
+		return nil, errors.New("provider timeout")
+	}), nil, WorkerConfig{ID: "test", BaseBackoff: time.Second, MaxBackoff: time.Minute})
+
+	for worker.processOnce(context.Background(), "test/0") {
+	}
+
+	got := store.jobs[job.ID]
+	if calls != 3 || got.Status != StatusDeadLetter || got.Attempts != 3 {
+		t.Fatalf("expected 3 attempts ending in dead_letter, got %d calls, status %s, attempts %d", calls, got.Status, got.Attempts)
+	}
+	if d := store.delay[job.ID]; d < 2*time.Second || d > 2*time.Second+2*time.Second/5 {
+		t.Fatalf("expected second retry delay of ~2s, got %s", d)
+	}
+}
+
+func TestWorkerFailsPermanentErrorsImmediately(t *testing.T) {
+	job := Job{ID: uuid.New(), Status: StatusQueued, MaxAttempts: 5}
+	store := newMemoryStore(job)
+	worker := NewWorker(New(store, nil), processorFunc(func(context.Context, Job) (map[string]any, error) {
+		return nil, ErrFileNotFound
+	}), nil, WorkerConfig{ID: "test"})
+
+	worker.processOnce(context.Background(), "test/0")
+	if got := store.jobs[job.ID]; got.Status != StatusFailed || got.Attempts != 1 {
+		t.Fatalf("expected a single failed attempt, got status %s, attempts %d", got.Status, got.Attempts)
+	}
+}
+
+func TestWorkerCancelsJobWhenLeaseIsLost(t *testing.T) {
+	job := Job{ID: uuid.New(), Status: StatusQueued, MaxAttempts: 5}
+	store := newMemoryStore(job)
+	worker := NewWorker(New(store, nil), processorFunc(func(ctx context.Context, job Job) (map[string]any, error) {
+		// Simulate lease recovery handing the job to another instance mid-run.
+		store.mu.Lock()
+		store.owner[job.ID] = "other"
+		store.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), nil, WorkerConfig{ID: "test", Lease: 30 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		worker.processOnce(context.Background(), "test/0")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job was not cancelled after losing its lease")
+	}
+	if got := store.jobs[job.ID]; got.Status != StatusProcessing {
+		t.Fatalf("expected the new owner's claim to be untouched, got %s", got.Status)
+	}
+}
+
+func TestWorkerStopsCancelledJobs(t *testing.T) {
+	job := Job{ID: uuid.New(), Status: StatusQueued, MaxAttempts: 5}
+	store := newMemoryStore(job)
+	service := New(store, nil)
+	worker := NewWorker(service, processorFunc(func(ctx context.Context, job Job) (map[string]any, error) {
+		ReportProgress(ctx, StageAnalyzing, "")
+		if _, err := service.Cancel(ctx, job.CompanyID, job.ID); err != nil {
+			t.Errorf("cancel running job: %v", err)
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), nil, WorkerConfig{ID: "test", Lease: 30 * time.Millisecond})
+
+	worker.processOnce(context.Background(), "test/0")
+	got := store.jobs[job.ID]
+	if got.Status != StatusCancelled || got.ProgressStage != StageAnalyzing {
+		t.Fatalf("expected a cancelled job at the analyzing stage, got %s at %q", got.Status, got.ProgressStage)
+	}
+	if _, err := service.Cancel(context.Background(), job.CompanyID, job.ID); !errors.Is(err, ErrJobNotCancellable) {
+		t.Fatalf("expected ErrJobNotCancellable for a finished job, got %v", err)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	worker := NewWorker(nil, nil, nil, WorkerConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	for attempt := 1; attempt <= 20; attempt++ {
+		if d := worker.backoff(attempt); d > 12*time.Second {
+			t.Fatalf("attempt %d backoff %s exceeds cap plus jitter", attempt, d)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package eval scores chat and document prompts against fixed test cases so prompt,
+// template, and model changes can be compared run to run. Cases are JSON files; answers come
+// from a live provider or a recording of an earlier run; checks are deterministic with an
+// optional model acting as judge.
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Case kinds.
+const (
+	KindChat     = "chat"
+	KindDocument = "document"
+)
+
+// Case is a single evaluation input with the facts a good answer states and the claims it
+// must not make.
+type Case struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+	// Input is the user message for chat cases and the instructions for document cases.
+	Input string `json:"input"`
+	// History holds earlier chat turns replayed before Input.
+	History []Turn `json:"history,omitempty"`
+	// Documents and Records are the material a document case analyses.
+	Documents []string `json:"documents,omitempty"`
+	Records   string   `json:"records,omitempty"`
+	// Template overrides the prompt template for this case: the chat system addendum or the
+	// document summary template body.
+	Template string `json:"template,omitempty"`
+	// ExpectFacts must each appear in the answer. Alternatives are separated by "|", e.g.
+	// "five steps|5 steps". Matching ignores case and repeated whitespace.
+	ExpectFacts []string `json:"expect_facts,omitempty"`
+	// Forbidden claims fail the case when any alternative appears in the answer.
+	Forbidden []string `json:"forbidden,omitempty"`
+	// Rubric adds case-specific guidance for the judge.
+	Rubric string `json:"rubric,omitempty"`
+}
+
+// Turn is a prior message in a chat case.
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// LoadCases reads cases from a JSON file or every *.json file in a directory. A file holds
+// one case object or an array of cases. Cases are returned sorted by ID.
+func LoadCases(path string) ([]Case, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+	}
+
+	var cases []Case
+	seen := make(map[string]string)
+	for _, file := range files {
+		loaded, err := loadCaseFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("eval: %s: %w", file, err)
+		}
+		for _, c := range loaded {
+			if err := c.validate(); err != nil {
+				return nil, fmt.Errorf("eval: %s: %w", file, err)
+			}
+			if prev, ok := seen[c.ID]; ok {
+				return nil, fmt.Errorf("eval: duplicate case %q in %s and %s", c.ID, prev, file)
+			}
+			seen[c.ID] = file
+			cases = append(cases, c)
+		}
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("eval: no cases found in %s", path)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].ID < cases[j].ID })
+	return cases, nil
+}
+
+func loadCaseFile(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var cases []Case
+		err := json.Unmarshal(data, &cases)
+		return cases, err
+	}
+	var c Case
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return []Case{c}, nil
+}
+
+func (c *Case) validate() error {
+	c.ID = strings.TrimSpace(c.ID)
+	if c.ID == "" {
+		return errors.New("case id is required")
+	}
+	if c.Kind == "" {
+		c.Kind = KindChat
+	}
+	if c.Kind != KindChat && c.Kind != KindDocument {
+		return fmt.Errorf("case %q: unknown kind %q", c.ID, c.Kind)
+	}
+	if strings.TrimSpace(c.Input) == "" && c.Kind == KindChat {
+		return fmt.Errorf("case %q: input is required", c.ID)
+	}
+	if c.Kind == KindDocument && len(c.Documents) == 0 && strings.TrimSpace(c.Records) == "" {
+		return fmt.Errorf("case %q: documents or records are required", c.ID)
+	}
+	if len(c.ExpectFacts) == 0 && len(c.Forbidden) == 0 && c.Rubric == "" {
+		return fmt.Errorf("case %q: add expect_facts, forbidden, or a rubric", c.ID)
+	}
+	return nil
+}
+
+// Check is the outcome of one deterministic check.
+type Check struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Passed bool   `json:"passed"`
+}
+
+// Check types.
+const (
+	CheckFact      = "fact"
+	CheckForbidden = "forbidden"
+)
+
+// Score runs the case's deterministic checks against answer.
+func Score(c Case, answer string) []Check {
+	normalized := normalize(answer)
+	checks := make([]Check, 0, len(c.ExpectFacts)+len(c.Forbidden))
+	for _, fact := range c.ExpectFacts {
+		checks = append(checks, Check{Type: CheckFact, Text: fact, Passed: containsAny(normalized, fact)})
+	}
+	for _, claim := range c.Forbidden {
+		checks = append(checks, Check{Type: CheckForbidden, Text: claim, Passed: !containsAny(normalized, claim)})
+	}
+	return checks
+}
+
+func containsAny(normalized, alternatives string) bool {
+	for _, alt := range strings.Split(alternatives, "|") {
+		if alt = normalize(alt); alt != "" && strings.Contains(normalized, alt) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
@@ -0,0 +1,197 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+type cannedProvider struct {
+	calls int
+}
+
+func (p *cannedProvider) Name() string { return "canned" }
+
+func (p *cannedProvider) Completion(_ context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error) {
+	p.calls++
+	if strings.Contains(req.Prompt, "You are grading") {
+		return clientpkg.CompletionResponse{Text: "```json\n{\"score\": 4, \"reason\": \"Mostly complete.\"}\n```"}, nil
+	}
+	return clientpkg.CompletionResponse{
+		Text:  "The contract has two performance obligations; revenue is recognized over time.",
+		Usage: clientpkg.Usage{Model: "canned-1", PromptTokens: 100, CompletionTokens: 20},
+	}, nil
+}
+
+func (p *cannedProvider) Chat(context.Context, clientpkg.ChatRequest) (clientpkg.ConversationReply, error) {
+	p.calls++
+	return clientpkg.ConversationReply{
+		Message: clientpkg.ConversationMessage{Role: clientpkg.RoleAssistant, Content: "ASC 606 uses a five-step model. Revenue is always recognized upfront."},
+		Usage:   clientpkg.Usage{Model: "canned-1", PromptTokens: 50, CompletionTokens: 10},
+	}, nil
+}
+
+func (p *cannedProvider) Conversation(context.Context) clientpkg.ConversationHandler { return nil }
+func (p *cannedProvider) Documents(context.Context) clientpkg.DocumentHandler        { return nil }
+
+func newTestClient(t *testing.T, factory clientpkg.ProviderFactory) *clientpkg.Client {
+	t.Helper()
+	client, err := clientpkg.NewClient(clientpkg.Config{
+		Providers:       map[string]clientpkg.ProviderFactory{"openai": factory},
+		DefaultProvider: "openai",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+var testCases = []Case{
+	{
+		ID:          "chat-five-steps",
+		Kind:        KindChat,
+		Input:       "How does ASC 606 recognise revenue?",
+		ExpectFacts: []string{"five-step|5-step|five steps"},
+		Forbidden:   []string{"always recognized upfront"},
+	},
+	{
+		ID:          "doc-obligations",
+		Kind:        KindDocument,
+		Input:       "Identify the performance obligations.",
+		Documents:   []string{"Master services agreement with implementation and support."},
+		ExpectFacts: []string{"two performance obligations", "over time"},
+	},
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	ctx := context.Background()
+	rec := NewRecording()
+	live := &cannedProvider{}
+	liveClient := newTestClient(t, RecordFactory(func(clientpkg.ProviderInit) (clientpkg.Provider, error) { return live, nil }, rec))
+
+	runner := &Runner{
+		Client:  liveClient,
+		Options: clientpkg.UserOptions{Provider: "openai"},
+		Judge:   &Judge{Client: liveClient, Options: clientpkg.UserOptions{Provider: "openai"}},
+	}
+	first := runner.Run(ctx, testCases)
+	if live.calls != 4 {
+		t.Fatalf("live calls = %d, want 2 answers and 2 judgements", live.calls)
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording: %v", err)
+	}
+	replayClient := newTestClient(t, ReplayFactory("openai", loaded))
+	runner.Client = replayClient
+	runner.Judge.Client = replayClient
+	second := runner.Run(ctx, testCases)
+
+	if first.Summary != second.Summary {
+		t.Fatalf("replayed summary %+v differs from live %+v", second.Summary, first.Summary)
+	}
+	s := second.Summary
+	if s.Cases != 2 || s.Passed != 1 || s.Failed != 1 || s.Errors != 0 {
+		t.Fatalf("summary = %+v, want 1 passed and 1 failed", s)
+	}
+	if s.ForbiddenHits != 1 || s.FactRecall != 1 || s.JudgeMean != 4 {
+		t.Fatalf("summary = %+v, want 1 forbidden hit, full recall, judge mean 4", s)
+	}
+	if second.Results[1].Usage.PromptTokens != 100 {
+		t.Fatalf("document usage = %+v, want replayed token counts", second.Results[1].Usage)
+	}
+}
+
+func TestReplayMissingResponse(t *testing.T) {
+	runner := &Runner{
+		Client:  newTestClient(t, ReplayFactory("openai", NewRecording())),
+		Options: clientpkg.UserOptions{Provider: "openai"},
+	}
+	report := runner.Run(context.Background(), testCases[:1])
+	if report.Summary.Errors != 1 || !strings.Contains(report.Results[0].Error, ErrNotRecorded.Error()) {
+		t.Fatalf("result = %+v, want not-recorded error", report.Results[0])
+	}
+}
+
+func TestScoreNormalizesAnswers(t *testing.T) {
+	c := Case{ExpectFacts: []string{"Over  Time", "point in time|point-in-time"}, Forbidden: []string{"guaranteed"}}
+	checks := Score(c, "Revenue is recognized\nover time for support.")
+	got := []bool{checks[0].Passed, checks[1].Passed, checks[2].Passed}
+	want := []bool{true, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("check %d (%s) passed = %v, want %v", i, checks[i].Text, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadCases(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.json", `[{"id":"b","input":"q","expect_facts":["x"]},{"id":"a","kind":"document","documents":["d"],"forbidden":["y"]}]`)
+	write("b.json", `{"id":"c","input":"q","rubric":"be brief"}`)
+	write("notes.txt", `ignored`)
+
+	cases, err := LoadCases(dir)
+	if err != nil {
+		t.Fatalf("LoadCases: %v", err)
+	}
+	if len(cases) != 3 || cases[0].ID != "a" || cases[1].Kind != KindChat {
+		t.Fatalf("cases = %+v", cases)
+	}
+
+	write("c.json", `{"id":"a","input":"q","rubric":"dup"}`)
+	if _, err := LoadCases(dir); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("LoadCases duplicate error = %v", err)
+	}
+}
+
+func TestParseVerdict(t *testing.T) {
+	if _, err := parseVerdict("no json here"); err == nil {
+		t.Fatal("expected error for reply without JSON")
+	}
+	if _, err := parseVerdict(`{"score": 9}`); err == nil {
+		t.Fatal("expected error for out-of-range score")
+	}
+	verdict, err := parseVerdict(`Sure: {"score": 2, "reason": "Misses the support obligation."}`)
+	if err != nil || verdict.Score != 2 {
+		t.Fatalf("parseVerdict = %+v, %v", verdict, err)
+	}
+}
+
+func TestMarkdownComparesWithBaseline(t *testing.T) {
+	baseline := Report{Provider: "openai", Results: []Result{{CaseID: "a", Passed: true}, {CaseID: "b", Passed: true}}}
+	baseline.Summary = summarize(baseline.Results)
+	report := Report{Provider: "openai", Model: "gpt-test", Results: []Result{
+		{CaseID: "a", Passed: true},
+		{CaseID: "b", Checks: []Check{{Type: CheckFact, Text: "over time"}}},
+		{CaseID: "c", Error: errors.New("timeout").Error()},
+	}}
+	report.Summary = summarize(report.Results)
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, report, &baseline); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"| Pass rate | 33% | 100% | -67 pts |", "| b | ", "**was pass**", "| c |", " new |", "Missing fact: over time", "Error: timeout"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
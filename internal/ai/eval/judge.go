@@ -0,0 +1,99 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+// MaxJudgeScore is the top of the judge's 1–5 scale.
+const MaxJudgeScore = 5
+
+// Judge asks a model to grade answers against the case's facts, forbidden claims, and
+// rubric. Use a different model from the one under test where possible.
+type Judge struct {
+	Client  *clientpkg.Client
+	Options clientpkg.UserOptions
+	// Model labels the report.
+	Model string
+}
+
+// Verdict is the judge's grade for one answer.
+type Verdict struct {
+	Score  int    `json:"score,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Grade scores answer from 1 (wrong or harmful) to 5 (complete and accurate).
+func (j *Judge) Grade(ctx context.Context, c Case, answer string) (*Verdict, error) {
+	resp, err := j.Client.Completion(ctx, j.Options, clientpkg.CompletionRequest{
+		Prompt:   judgePrompt(c, answer),
+		Metadata: j.Options.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseVerdict(resp.Text)
+}
+
+func (j *Judge) label() string {
+	if j.Model == "" {
+		return j.Options.Provider
+	}
+	return j.Options.Provider + "/" + j.Model
+}
+
+func judgePrompt(c Case, answer string) string {
+	builder := strings.Builder{}
+	builder.WriteString("You are grading an answer from a revenue-recognition assistant.\n")
+	builder.WriteString("Score it from 1 (wrong, misleading, or unsupported) to 5 (accurate, complete, and well supported).\n\n")
+	builder.WriteString("Question or instructions:\n")
+	builder.WriteString(c.Input)
+	builder.WriteString("\n\n")
+	if len(c.ExpectFacts) > 0 {
+		builder.WriteString("The answer should state:\n")
+		for _, fact := range c.ExpectFacts {
+			builder.WriteString("- " + fact + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	if len(c.Forbidden) > 0 {
+		builder.WriteString("The answer must not claim:\n")
+		for _, claim := range c.Forbidden {
+			builder.WriteString("- " + claim + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	if c.Rubric != "" {
+		builder.WriteString("Grading notes: ")
+		builder.WriteString(c.Rubric)
+		builder.WriteString("\n\n")
+	}
+	builder.WriteString("Answer to grade:\n")
+	builder.WriteString(answer)
+	builder.WriteString("\n\nReply with only a JSON object: {\"score\": <1-5>, \"reason\": \"<one sentence>\"}")
+	return builder.String()
+}
+
+// parseVerdict reads the first JSON object in the judge's reply, tolerating code fences
+// and surrounding prose.
+func parseVerdict(text string) (*Verdict, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("eval: judge reply has no JSON object")
+	}
+	var verdict Verdict
+	if err := json.Unmarshal([]byte(text[start:end+1]), &verdict); err != nil {
+		return nil, fmt.Errorf("eval: parse judge reply: %w", err)
+	}
+	if verdict.Score < 1 || verdict.Score > MaxJudgeScore {
+		return nil, fmt.Errorf("eval: judge score %d out of range", verdict.Score)
+	}
+	return &verdict, nil
+}
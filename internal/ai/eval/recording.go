@@ -0,0 +1,219 @@
+package eval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+// ErrNotRecorded is returned when replaying a request the recording does not contain.
+var ErrNotRecorded = errors.New("eval: response not recorded")
+
+// Recording maps provider requests to the responses a live run received, so later runs can
+// replay them offline. Requests are keyed by a hash of the model, system addendum, and
+// prompt or transcript.
+type Recording struct {
+	mu        sync.Mutex
+	Responses map[string]RecordedResponse `json:"responses"`
+}
+
+// RecordedResponse is a stored provider answer.
+type RecordedResponse struct {
+	Text             string `json:"text"`
+	Model            string `json:"model,omitempty"`
+	PromptTokens     int    `json:"promptTokens,omitempty"`
+	CompletionTokens int    `json:"completionTokens,omitempty"`
+}
+
+// NewRecording returns an empty recording.
+func NewRecording() *Recording {
+	return &Recording{Responses: make(map[string]RecordedResponse)}
+}
+
+// LoadRecording reads a recording written by Save.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := NewRecording()
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("eval: parse recording %s: %w", path, err)
+	}
+	if rec.Responses == nil {
+		rec.Responses = make(map[string]RecordedResponse)
+	}
+	return rec, nil
+}
+
+// Save writes the recording as indented JSON.
+func (r *Recording) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (r *Recording) get(key string) (RecordedResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resp, ok := r.Responses[key]
+	return resp, ok
+}
+
+func (r *Recording) put(key string, resp RecordedResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Responses[key] = resp
+}
+
+// ReplayFactory serves recorded responses under the provider name, without network access.
+func ReplayFactory(name string, rec *Recording) clientpkg.ProviderFactory {
+	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
+		return &replayProvider{name: name, rec: rec, metadata: init.Credential.Metadata}, nil
+	}
+}
+
+// RecordFactory wraps a live provider factory, storing each successful response in rec.
+func RecordFactory(inner clientpkg.ProviderFactory, rec *Recording) clientpkg.ProviderFactory {
+	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
+		provider, err := inner(init)
+		if err != nil {
+			return nil, err
+		}
+		return &recordingProvider{Provider: provider, rec: rec, metadata: init.Credential.Metadata}, nil
+	}
+}
+
+type replayProvider struct {
+	name     string
+	rec      *Recording
+	metadata map[string]any
+}
+
+func (p *replayProvider) Name() string { return p.name }
+
+func (p *replayProvider) Completion(_ context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error) {
+	key := completionKey(p.metadata, req)
+	resp, ok := p.rec.get(key)
+	if !ok {
+		return clientpkg.CompletionResponse{}, fmt.Errorf("%w: completion %s", ErrNotRecorded, key[:12])
+	}
+	return clientpkg.CompletionResponse{Text: resp.Text, Usage: resp.usage()}, nil
+}
+
+func (p *replayProvider) Chat(_ context.Context, req clientpkg.ChatRequest) (clientpkg.ConversationReply, error) {
+	key := chatKey(p.metadata, req)
+	resp, ok := p.rec.get(key)
+	if !ok {
+		return clientpkg.ConversationReply{}, fmt.Errorf("%w: chat %s", ErrNotRecorded, key[:12])
+	}
+	return clientpkg.ConversationReply{
+		Message: clientpkg.ConversationMessage{Role: clientpkg.RoleAssistant, Content: resp.Text},
+		Usage:   resp.usage(),
+	}, nil
+}
+
+func (p *replayProvider) Conversation(context.Context) clientpkg.ConversationHandler { return nil }
+func (p *replayProvider) Documents(context.Context) clientpkg.DocumentHandler        { return nil }
+
+type recordingProvider struct {
+	clientpkg.Provider
+	rec      *Recording
+	metadata map[string]any
+}
+
+func (p *recordingProvider) Completion(ctx context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error) {
+	resp, err := p.Provider.Completion(ctx, req)
+	if err == nil {
+		p.rec.put(completionKey(p.metadata, req), recorded(resp.Text, resp.Usage))
+	}
+	return resp, err
+}
+
+func (p *recordingProvider) Chat(ctx context.Context, req clientpkg.ChatRequest) (clientpkg.ConversationReply, error) {
+	chatter, ok := p.Provider.(clientpkg.ChatProvider)
+	if !ok {
+		return clientpkg.ConversationReply{}, fmt.Errorf("%w: %s chat", clientpkg.ErrCapabilityNotImplemented, p.Name())
+	}
+	reply, err := chatter.Chat(ctx, req)
+	if err == nil {
+		p.rec.put(chatKey(p.metadata, req), recorded(reply.Message.Content, reply.Usage))
+	}
+	return reply, err
+}
+
+func recorded(text string, usage clientpkg.Usage) RecordedResponse {
+	return RecordedResponse{
+		Text:             text,
+		Model:            usage.Model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+}
+
+func (r RecordedResponse) usage() clientpkg.Usage {
+	return clientpkg.Usage{Model: r.Model, PromptTokens: r.PromptTokens, CompletionTokens: r.CompletionTokens}
+}
+
+func completionKey(initMetadata map[string]any, req clientpkg.CompletionRequest) string {
+	return requestKey("completion", initMetadata, req.Metadata, req.Prompt)
+}
+
+func chatKey(initMetadata map[string]any, req clientpkg.ChatRequest) string {
+	parts := make([]string, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		parts = append(parts, msg.Role+": "+msg.Content)
+	}
+	return requestKey("chat", initMetadata, req.Metadata, parts...)
+}
+
+// requestKey hashes the parts of a request that determine the answer. Metadata other than
+// the model, response format, and system addendum is ignored so bookkeeping keys do not
+// invalidate recordings.
+func requestKey(call string, initMetadata, metadata map[string]any, parts ...string) string {
+	model, _ := metadata["model"].(string)
+	if model == "" {
+		model, _ = initMetadata["model"].(string)
+	}
+	var format string
+	if raw, ok := metadata["response_format"]; ok {
+		data, _ := json.Marshal(raw)
+		format = string(data)
+	}
+
+	hash := sha256.New()
+	for _, part := range append([]string{call, model, format, systemAddendum(metadata)}, parts...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func systemAddendum(metadata map[string]any) string {
+	switch v := metadata["system_addendum"].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, "\n")
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,315 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Report is the outcome of one evaluation run. It is written as JSON so a later run can be
+// compared against it.
+type Report struct {
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model,omitempty"`
+	Judge     string    `json:"judge,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  Duration  `json:"duration"`
+	Summary   Summary   `json:"summary"`
+	Results   []Result  `json:"results"`
+}
+
+// Result is the outcome of one case. Passed reports whether every deterministic check
+// passed; cases that errored are not passed.
+type Result struct {
+	CaseID    string   `json:"caseId"`
+	Kind      string   `json:"kind"`
+	Passed    bool     `json:"passed"`
+	Answer    string   `json:"answer,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Checks    []Check  `json:"checks,omitempty"`
+	Judge     *Verdict `json:"judge,omitempty"`
+	Usage     Usage    `json:"usage"`
+	LatencyMS int64    `json:"latencyMs"`
+}
+
+// Usage is the token usage for one case, excluding judging.
+type Usage struct {
+	Model            string `json:"model,omitempty"`
+	PromptTokens     int    `json:"promptTokens"`
+	CompletionTokens int    `json:"completionTokens"`
+}
+
+// Summary aggregates a run.
+type Summary struct {
+	Cases  int `json:"cases"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	Errors int `json:"errors"`
+	// FactRecall is the share of expected facts found across all answered cases.
+	FactRecall float64 `json:"factRecall"`
+	// ForbiddenHits counts forbidden claims that appeared in answers.
+	ForbiddenHits int `json:"forbiddenHits"`
+	// JudgeMean is the mean judge score over graded cases; zero when no case was graded.
+	JudgeMean        float64 `json:"judgeMean,omitempty"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+}
+
+// PassRate is the share of cases that passed.
+func (s Summary) PassRate() float64 {
+	if s.Cases == 0 {
+		return 0
+	}
+	return float64(s.Passed) / float64(s.Cases)
+}
+
+// Duration marshals as a Go duration string such as "1m2.5s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Round(time.Millisecond).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func summarize(results []Result) Summary {
+	summary := Summary{Cases: len(results)}
+	var facts, found, graded, judgeTotal int
+	for _, result := range results {
+		summary.PromptTokens += result.Usage.PromptTokens
+		summary.CompletionTokens += result.Usage.CompletionTokens
+		switch {
+		case result.Error != "":
+			summary.Errors++
+		case result.Passed:
+			summary.Passed++
+		default:
+			summary.Failed++
+		}
+		for _, check := range result.Checks {
+			switch check.Type {
+			case CheckFact:
+				facts++
+				if check.Passed {
+					found++
+				}
+			case CheckForbidden:
+				if !check.Passed {
+					summary.ForbiddenHits++
+				}
+			}
+		}
+		if result.Judge != nil && result.Judge.Score > 0 {
+			graded++
+			judgeTotal += result.Judge.Score
+		}
+	}
+	if facts > 0 {
+		summary.FactRecall = float64(found) / float64(facts)
+	}
+	if graded > 0 {
+		summary.JudgeMean = float64(judgeTotal) / float64(graded)
+	}
+	return summary
+}
+
+// LoadReport reads a report written by WriteJSON.
+func LoadReport(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return Report{}, fmt.Errorf("eval: parse report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, report Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteMarkdown writes a human-readable report. With a baseline it adds the change in each
+// summary metric and marks cases whose outcome changed.
+func WriteMarkdown(w io.Writer, report Report, baseline *Report) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# AI evaluation: %s\n\n", runLabel(report))
+	fmt.Fprintf(b, "Run %s, took %s.", report.StartedAt.Format(time.RFC3339), time.Duration(report.Duration).Round(time.Second))
+	if report.Judge != "" {
+		fmt.Fprintf(b, " Judged by %s.", report.Judge)
+	}
+	if baseline != nil {
+		fmt.Fprintf(b, " Compared with %s from %s.", runLabel(*baseline), baseline.StartedAt.Format(time.RFC3339))
+	}
+	b.WriteString("\n\n## Summary\n\n")
+
+	s := report.Summary
+	if baseline == nil {
+		b.WriteString("| Metric | Value |\n|---|---|\n")
+	} else {
+		b.WriteString("| Metric | Value | Baseline | Change |\n|---|---|---|---|\n")
+	}
+	var base Summary
+	if baseline != nil {
+		base = baseline.Summary
+	}
+	summaryRow(b, baseline != nil, "Pass rate", percent(s.PassRate()), percent(base.PassRate()), pointsDelta(s.PassRate(), base.PassRate()))
+	summaryRow(b, baseline != nil, "Passed / failed / errors",
+		fmt.Sprintf("%d / %d / %d", s.Passed, s.Failed, s.Errors),
+		fmt.Sprintf("%d / %d / %d", base.Passed, base.Failed, base.Errors), "")
+	summaryRow(b, baseline != nil, "Fact recall", percent(s.FactRecall), percent(base.FactRecall), pointsDelta(s.FactRecall, base.FactRecall))
+	summaryRow(b, baseline != nil, "Forbidden claims", fmt.Sprint(s.ForbiddenHits), fmt.Sprint(base.ForbiddenHits), intDelta(s.ForbiddenHits, base.ForbiddenHits))
+	if s.JudgeMean > 0 || base.JudgeMean > 0 {
+		summaryRow(b, baseline != nil, "Judge mean", fmt.Sprintf("%.2f", s.JudgeMean), fmt.Sprintf("%.2f", base.JudgeMean), fmt.Sprintf("%+.2f", s.JudgeMean-base.JudgeMean))
+	}
+	summaryRow(b, baseline != nil, "Tokens (prompt / completion)",
+		fmt.Sprintf("%d / %d", s.PromptTokens, s.CompletionTokens),
+		fmt.Sprintf("%d / %d", base.PromptTokens, base.CompletionTokens), "")
+
+	previous := make(map[string]Result)
+	if baseline != nil {
+		for _, result := range baseline.Results {
+			previous[result.CaseID] = result
+		}
+	}
+
+	b.WriteString("\n## Cases\n\n")
+	if baseline == nil {
+		b.WriteString("| Case | Kind | Outcome | Checks | Judge |\n|---|---|---|---|---|\n")
+	} else {
+		b.WriteString("| Case | Kind | Outcome | Checks | Judge | Baseline |\n|---|---|---|---|---|---|\n")
+	}
+	for _, result := range report.Results {
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |", cell(result.CaseID), result.Kind, outcome(result), checkCount(result), judgeCell(result.Judge))
+		if baseline != nil {
+			prev, ok := previous[result.CaseID]
+			switch {
+			case !ok:
+				b.WriteString(" new |")
+			case outcome(prev) != outcome(result):
+				fmt.Fprintf(b, " **was %s** |", outcome(prev))
+			default:
+				b.WriteString(" unchanged |")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	var details []Result
+	for _, result := range report.Results {
+		if !result.Passed || (result.Judge != nil && result.Judge.Score > 0 && result.Judge.Score < MaxJudgeScore-1) {
+			details = append(details, result)
+		}
+	}
+	if len(details) > 0 {
+		b.WriteString("\n## Problems\n")
+		for _, result := range details {
+			fmt.Fprintf(b, "\n### %s\n\n", result.CaseID)
+			if result.Error != "" {
+				fmt.Fprintf(b, "Error: %s\n", result.Error)
+				continue
+			}
+			for _, check := range result.Checks {
+				if check.Passed {
+					continue
+				}
+				if check.Type == CheckFact {
+					fmt.Fprintf(b, "- Missing fact: %s\n", check.Text)
+				} else {
+					fmt.Fprintf(b, "- Forbidden claim: %s\n", check.Text)
+				}
+			}
+			if result.Judge != nil && result.Judge.Reason != "" {
+				fmt.Fprintf(b, "- Judge (%d/%d): %s\n", result.Judge.Score, MaxJudgeScore, result.Judge.Reason)
+			}
+			fmt.Fprintf(b, "\n<details><summary>Answer</summary>\n\n```\n%s\n```\n\n</details>\n", result.Answer)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func runLabel(report Report) string {
+	if report.Model == "" {
+		return report.Provider
+	}
+	return report.Provider + "/" + report.Model
+}
+
+func summaryRow(b *strings.Builder, compare bool, name, value, baseline, change string) {
+	if compare {
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", name, value, baseline, change)
+		return
+	}
+	fmt.Fprintf(b, "| %s | %s |\n", name, value)
+}
+
+func outcome(result Result) string {
+	switch {
+	case result.Error != "":
+		return "error"
+	case result.Passed:
+		return "pass"
+	default:
+		return "fail"
+	}
+}
+
+func checkCount(result Result) string {
+	if len(result.Checks) == 0 {
+		return "–"
+	}
+	passed := 0
+	for _, check := range result.Checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	return fmt.Sprintf("%d/%d", passed, len(result.Checks))
+}
+
+func judgeCell(verdict *Verdict) string {
+	switch {
+	case verdict == nil:
+		return "–"
+	case verdict.Error != "":
+		return "error"
+	default:
+		return fmt.Sprintf("%d/%d", verdict.Score, MaxJudgeScore)
+	}
+}
+
+func percent(value float64) string {
+	return fmt.Sprintf("%.0f%%", value*100)
+}
+
+func pointsDelta(value, baseline float64) string {
+	return fmt.Sprintf("%+.0f pts", (value-baseline)*100)
+}
+
+func intDelta(value, baseline int) string {
+	return fmt.Sprintf("%+d", value-baseline)
+}
+
+func cell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
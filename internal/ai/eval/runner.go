@@ -0,0 +1,181 @@
+package eval
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
+)
+
+// Runner answers cases through the shared AI client: chat cases via Client.Chat and
+// document cases via the document job processor, so both use the production prompts.
+type Runner struct {
+	Client *clientpkg.Client
+	// Options selects the provider and key under test.
+	Options clientpkg.UserOptions
+	// Model labels the report; the provider factory must already be configured with it.
+	Model string
+	// ChatTemplate and DocumentTemplate apply to cases without their own template.
+	ChatTemplate     string
+	DocumentTemplate string
+	// Judge, when set, grades each answer in addition to the deterministic checks.
+	Judge *Judge
+	// Timeout bounds each case, including judging. Zero means two minutes.
+	Timeout time.Duration
+}
+
+// Run evaluates every case in order and returns the report.
+func (r *Runner) Run(ctx context.Context, cases []Case) Report {
+	report := Report{
+		Provider:  r.Options.Provider,
+		Model:     r.Model,
+		StartedAt: time.Now().UTC(),
+	}
+	if r.Judge != nil {
+		report.Judge = r.Judge.label()
+	}
+
+	for _, c := range cases {
+		report.Results = append(report.Results, r.runCase(ctx, c))
+	}
+	report.Duration = Duration(time.Since(report.StartedAt))
+	report.Summary = summarize(report.Results)
+	return report
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case) Result {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := Result{CaseID: c.ID, Kind: c.Kind}
+	started := time.Now()
+	var (
+		answer string
+		usage  clientpkg.Usage
+		err    error
+	)
+	switch c.Kind {
+	case KindDocument:
+		answer, usage, err = r.document(ctx, c)
+	default:
+		answer, usage, err = r.chat(ctx, c)
+	}
+	result.LatencyMS = time.Since(started).Milliseconds()
+	result.Usage = Usage{Model: usage.Model, PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Answer = strings.TrimSpace(answer)
+	result.Checks = Score(c, result.Answer)
+	result.Passed = true
+	for _, check := range result.Checks {
+		result.Passed = result.Passed && check.Passed
+	}
+	if r.Judge != nil {
+		verdict, err := r.Judge.Grade(ctx, c, result.Answer)
+		if err != nil {
+			verdict = &Verdict{Error: err.Error()}
+		}
+		result.Judge = verdict
+	}
+	return result
+}
+
+func (r *Runner) chat(ctx context.Context, c Case) (string, clientpkg.Usage, error) {
+	messages := make([]clientpkg.ConversationMessage, 0, len(c.History)+1)
+	for _, turn := range c.History {
+		messages = append(messages, clientpkg.ConversationMessage{Role: turn.Role, Content: turn.Content})
+	}
+	messages = append(messages, clientpkg.ConversationMessage{Role: clientpkg.RoleUser, Content: c.Input})
+
+	metadata := copyMetadata(r.Options.Metadata)
+	template := firstNonBlank(c.Template, r.ChatTemplate)
+	if template != "" {
+		addendum, err := prompts.Render(template, prompts.Data{Today: time.Now().Format(time.DateOnly)})
+		if err != nil {
+			return "", clientpkg.Usage{}, err
+		}
+		metadata["system_addendum"] = addendum
+	}
+
+	opts := r.Options
+	opts.Metadata = metadata
+	reply, err := r.Client.Chat(ctx, opts, clientpkg.ChatRequest{Messages: messages, Metadata: metadata})
+	if err != nil {
+		return "", clientpkg.Usage{}, err
+	}
+	return reply.Message.Content, reply.Usage, nil
+}
+
+func (r *Runner) document(ctx context.Context, c Case) (string, clientpkg.Usage, error) {
+	processor := documents.NewAIProcessor(r.Client, nil, r.Options.APIKey, r.Options.Provider)
+	if template := firstNonBlank(c.Template, r.DocumentTemplate); template != "" {
+		processor.SetPromptSource(staticPrompt(template))
+	}
+
+	request := map[string]any{
+		"type":      documents.JobTypeSummary,
+		"documents": c.Documents,
+	}
+	if c.Input != "" {
+		request["instructions"] = c.Input
+	}
+	if c.Records != "" {
+		request["records"] = c.Records
+	}
+	result, err := processor.Process(ctx, documents.Job{
+		ID:         uuid.New(),
+		ProviderID: r.Options.Provider,
+		Request:    request,
+	})
+	if err != nil {
+		return "", clientpkg.Usage{}, err
+	}
+
+	summary, _ := result["summary"].(string)
+	var usage clientpkg.Usage
+	if raw, ok := result["usage"].(map[string]any); ok {
+		usage.Model, _ = raw["model"].(string)
+		usage.PromptTokens, _ = raw["prompt_tokens"].(int)
+		usage.CompletionTokens, _ = raw["completion_tokens"].(int)
+	}
+	return summary, usage, nil
+}
+
+// staticPrompt serves one template body as the document summary prompt.
+type staticPrompt string
+
+func (s staticPrompt) Resolve(_ context.Context, _ uuid.UUID, kind string, _ uuid.UUID) (prompts.Version, error) {
+	if err := prompts.Validate(string(s)); err != nil {
+		return prompts.Version{}, err
+	}
+	return prompts.Version{ID: uuid.NewSHA1(uuid.NameSpaceOID, []byte(s)), Name: "eval", Kind: kind, Version: 1, Body: string(s)}, nil
+}
+
+func copyMetadata(src map[string]any) map[string]any {
+	dst := make(map[string]any, len(src)+1)
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func firstNonBlank(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+// Package grounding ties chat to the ASC guidance corpus: it retrieves relevant paragraphs
+// for each user turn, exposes the same search as a tool, and extracts citations from replies.
+package grounding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/retrieval"
+)
+
+// MetadataCitations is the message metadata key holding an assistant reply's citations.
+const MetadataCitations = "citations"
+
+const (
+	defaultContextLimit = 4
+	// minContextScore drops weak matches from automatic context; the tool returns everything
+	// so the model can judge relevance itself.
+	minContextScore = 0.3
+	// maxParagraphChars bounds each paragraph injected into the system prompt.
+	maxParagraphChars = 1500
+)
+
+// Searcher ranks ASC paragraphs for a query; *retrieval.Service satisfies it.
+type Searcher interface {
+	Search(ctx context.Context, params retrieval.QueryParams) ([]retrieval.Result, error)
+}
+
+// Citation links part of an answer to the paragraph that supports it.
+type Citation struct {
+	Reference   string    `json:"reference"`
+	ParagraphID uuid.UUID `json:"paragraph_id"`
+	Score       float64   `json:"score,omitempty"`
+}
+
+// URL returns the in-app page showing the cited paragraph.
+func (c Citation) URL() string {
+	return ParagraphURL(c.ParagraphID)
+}
+
+// ParagraphURL returns the in-app page for a paragraph.
+func ParagraphURL(id uuid.UUID) string {
+	return "/app/guidance/" + id.String()
+}
+
+// Context is the automatic grounding for one user turn.
+type Context struct {
+	// Addendum is appended to the system prompt; empty when nothing relevant was found.
+	Addendum  string
+	Citations []Citation
+}
+
+// Service retrieves guidance for chat turns.
+type Service struct {
+	searcher Searcher
+	limit    int
+}
+
+// New returns a Service backed by searcher.
+func New(searcher Searcher) *Service {
+	return &Service{searcher: searcher, limit: defaultContextLimit}
+}
+
+// Context searches for paragraphs relevant to query and formats the strongest matches as a
+// system addendum that asks the model to cite them by reference.
+func (s *Service) Context(ctx context.Context, query string) (Context, error) {
+	if s == nil || s.searcher == nil || strings.TrimSpace(query) == "" {
+		return Context{}, nil
+	}
+	results, err := s.searcher.Search(ctx, retrieval.QueryParams{Query: query, Limit: s.limit})
+	if err != nil {
+		return Context{}, err
+	}
+
+	var b strings.Builder
+	citations := make([]Citation, 0, len(results))
+	for _, result := range results {
+		if result.Score < minContextScore {
+			continue
+		}
+		if len(citations) == 0 {
+			b.WriteString("Relevant ASC guidance follows. Ground your answer in it where it applies and cite ")
+			b.WriteString("each paragraph you rely on by its reference in square brackets, e.g. [ASC 606-10-25-1]. ")
+			b.WriteString("Do not cite references that are not listed here or returned by the search_asc_guidance tool.\n")
+		}
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", result.ASCReference, truncate(result.Content, maxParagraphChars))
+		citations = append(citations, citationFor(result))
+	}
+	if len(citations) == 0 {
+		return Context{}, nil
+	}
+	return Context{Addendum: b.String(), Citations: citations}, nil
+}
+
+// FromTurns collects the paragraphs returned by search_asc_guidance calls in generated turns.
+func FromTurns(turns []clientpkg.ConversationMessage) []Citation {
+	var citations []Citation
+	for _, turn := range turns {
+		if turn.Role != clientpkg.RoleTool || turn.ToolName != ToolName {
+			continue
+		}
+		var output struct {
+			Results []Citation `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(turn.Content), &output); err != nil {
+			continue
+		}
+		citations = append(citations, output.Results...)
+	}
+	return citations
+}
+
+// Cited returns the candidates referenced in answer, deduplicated in order of appearance in
+// candidates. An answer that names none of them cites nothing, even though the candidates
+// were in context.
+func Cited(answer string, candidates []Citation) []Citation {
+	unique := make([]Citation, 0, len(candidates))
+	seen := make(map[uuid.UUID]struct{}, len(candidates))
+	for _, citation := range candidates {
+		if citation.ParagraphID == uuid.Nil || citation.Reference == "" {
+			continue
+		}
+		if _, ok := seen[citation.ParagraphID]; ok {
+			continue
+		}
+		seen[citation.ParagraphID] = struct{}{}
+		unique = append(unique, citation)
+	}
+
+	referenced := make([]Citation, 0, len(unique))
+	for _, citation := range unique {
+		if mentions(answer, citation.Reference) {
+			referenced = append(referenced, citation)
+		}
+	}
+	return referenced
+}
+
+// mentions reports whether reference occurs in text as a whole token, so "606-10-25-1" is
+// not found inside "606-10-25-19".
+func mentions(text, reference string) bool {
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], reference)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(reference)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// CitationsFromMetadata decodes citations stored in message metadata.
+func CitationsFromMetadata(metadata map[string]any) []Citation {
+	value, ok := metadata[MetadataCitations]
+	if !ok || value == nil {
+		return nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var citations []Citation
+	if err := json.Unmarshal(raw, &citations); err != nil {
+		return nil
+	}
+	return citations
+}
+
+func citationFor(result retrieval.Result) Citation {
+	return Citation{Reference: result.ASCReference, ParagraphID: result.ParagraphID, Score: result.Score}
+}
+
+func truncate(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit]) + "…"
+}
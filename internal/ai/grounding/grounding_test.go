@@ -0,0 +1,78 @@
+package grounding
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/retrieval"
+)
+
+type stubSearcher struct{ results []retrieval.Result }
+
+func (s stubSearcher) Search(context.Context, retrieval.QueryParams) ([]retrieval.Result, error) {
+	return s.results, nil
+}
+
+func TestContextInjectsRelevantParagraphs(t *testing.T) {
+	strong := retrieval.Result{ParagraphID: uuid.New(), ASCReference: "ASC 606-10-25-1", Content: "An entity shall account for a contract...", Score: 0.72}
+	weak := retrieval.Result{ParagraphID: uuid.New(), ASCReference: "ASC 842-10-15-3", Content: "A contract is or contains a lease...", Score: 0.1}
+
+	got, err := New(stubSearcher{results: []retrieval.Result{strong, weak}}).Context(context.Background(), "when is a contract valid?")
+	if err != nil {
+		t.Fatalf("context: %v", err)
+	}
+	if !strings.Contains(got.Addendum, "[ASC 606-10-25-1]") || strings.Contains(got.Addendum, "ASC 842") {
+		t.Fatalf("unexpected addendum %q", got.Addendum)
+	}
+	if len(got.Citations) != 1 || got.Citations[0].ParagraphID != strong.ParagraphID {
+		t.Fatalf("unexpected citations %+v", got.Citations)
+	}
+}
+
+func TestCitedPrefersReferencesNamedInAnswer(t *testing.T) {
+	toolResult := retrieval.Result{ParagraphID: uuid.New(), ASCReference: "ASC 606-10-55-36", Score: 0.6}
+	output, err := SearchTool{Searcher: stubSearcher{results: []retrieval.Result{toolResult}}}.NewHandler().
+		Invoke(context.Background(), map[string]any{"query": "principal versus agent"})
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	content, _ := json.Marshal(output.Output)
+	turns := []clientpkg.ConversationMessage{
+		{Role: clientpkg.RoleAssistant, ToolCalls: []clientpkg.ToolCall{{ID: "1", Name: ToolName}}},
+		{Role: clientpkg.RoleTool, ToolCallID: "1", ToolName: ToolName, Content: string(content)},
+	}
+
+	automatic := Citation{Reference: "ASC 606-10-25-1", ParagraphID: uuid.New()}
+	candidates := append([]Citation{automatic}, FromTurns(turns)...)
+
+	cited := Cited("The entity is an agent [ASC 606-10-55-36].", candidates)
+	if len(cited) != 1 || cited[0].ParagraphID != toolResult.ParagraphID {
+		t.Fatalf("expected only the referenced paragraph, got %+v", cited)
+	}
+	if none := Cited("No references here.", candidates); len(none) != 0 {
+		t.Fatalf("expected no citations when none are named, got %+v", none)
+	}
+}
+
+func TestCitedMatchesWholeReferences(t *testing.T) {
+	short := Citation{Reference: "ASC 606-10-25-1", ParagraphID: uuid.New()}
+	long := Citation{Reference: "ASC 606-10-25-19", ParagraphID: uuid.New()}
+	candidates := []Citation{short, long}
+
+	cited := Cited("A service is distinct under ASC 606-10-25-19.", candidates)
+	if len(cited) != 1 || cited[0].ParagraphID != long.ParagraphID {
+		t.Fatalf("expected only the longer reference, got %+v", cited)
+	}
+	cited = Cited("See ASC 606-10-25-19 and [ASC 606-10-25-1].", candidates)
+	if len(cited) != 2 {
+		t.Fatalf("expected both references, got %+v", cited)
+	}
+	if cited := Cited("See ASC 606-10-25-1a.", candidates); len(cited) != 0 {
+		t.Fatalf("expected no match inside a longer token, got %+v", cited)
+	}
+}
@@ -0,0 +1,82 @@
+package grounding
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
+	"github.com/JonMunkholm/RevProject1/internal/retrieval"
+)
+
+// ToolName is the name models use to search ASC guidance.
+const ToolName = "search_asc_guidance"
+
+const maxToolResults = 8
+
+// SearchTool lets a model search the ASC guidance corpus mid-conversation.
+type SearchTool struct {
+	Searcher Searcher
+}
+
+func (SearchTool) Name() string { return ToolName }
+func (SearchTool) Summary() string {
+	return "Search authoritative ASC accounting guidance and return matching paragraphs with their references"
+}
+func (SearchTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "Accounting question or topic to search for, e.g. \"principal versus agent considerations\"",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of paragraphs to return (1-8)",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+func (t SearchTool) NewHandler() tool.Handler { return searchHandler{searcher: t.Searcher} }
+
+type searchHandler struct {
+	searcher Searcher
+}
+
+func (h searchHandler) Invoke(ctx context.Context, input map[string]any) (tool.Result, error) {
+	if h.searcher == nil {
+		return tool.Result{}, errors.New("grounding: guidance search is not configured")
+	}
+	query, _ := input["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return tool.Result{}, errors.New("grounding: query is required")
+	}
+	limit := 5
+	// JSON numbers decode as float64.
+	if value, ok := input["limit"].(float64); ok && value >= 1 {
+		limit = int(math.Min(value, maxToolResults))
+	}
+
+	results, err := h.searcher.Search(ctx, retrieval.QueryParams{Query: query, Limit: limit})
+	if err != nil {
+		return tool.Result{}, err
+	}
+
+	items := make([]map[string]any, 0, len(results))
+	for _, result := range results {
+		items = append(items, map[string]any{
+			"reference":    result.ASCReference,
+			"paragraph_id": result.ParagraphID.String(),
+			"score":        result.Score,
+			"content":      truncate(result.Content, maxParagraphChars),
+		})
+	}
+	return tool.Result{
+		Output: map[string]any{"results": items},
+		Raw:    results,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/health"
+	credentialsqlstore "github.com/JonMunkholm/RevProject1/internal/ai/credentials/sqlstore"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+type (
+	CredentialPolicy        = settings.CredentialPolicy
+	CredentialAssessment    = health.Assessment
+	CredentialStatus        = health.Status
+	CredentialHealthConfig  = health.Config
+	CredentialHealthChecker = health.Checker
+	CredentialPingFunc      = health.PingFunc
+)
+
+const (
+	CredentialStatusOK          = health.StatusOK
+	CredentialStatusRotationDue = health.StatusRotationDue
+	CredentialStatusStale       = health.StatusStale
+	CredentialStatusExpiring    = health.StatusExpiring
+	CredentialStatusExpired     = health.StatusExpired
+
+	DefaultCredentialWarningDays = settings.DefaultCredentialWarningDays
+)
+
+var (
+	// ErrCredentialCheckUnsupported is returned for providers without a status check.
+	ErrCredentialCheckUnsupported = health.ErrUnsupported
+	ErrInvalidCredentialPolicy    = settings.ErrInvalidCredentialPolicy
+)
+
+// AssessCredential reports whether rec is expiring, expired or due for rotation.
+func AssessCredential(rec CredentialRecord, policy CredentialPolicy, now time.Time) CredentialAssessment {
+	return health.Assess(rec, policy, now)
+}
+
+// NewCredentialHealthChecker pings the provider for each default credential on a
+// schedule, recording the outcome on the row and failures as credential events.
+func NewCredentialHealthChecker(q *database.Queries, cipher CredentialCipher, ping CredentialPingFunc, cfg CredentialHealthConfig) *CredentialHealthChecker {
+	return health.NewChecker(credentialsqlstore.New(q), cipher, ping, cfg)
+}
@@ -0,0 +1,202 @@
+// Package logging backs the AI stack's Logger interfaces with log/slog. Records are
+// attributed with the request ID and the company and user they were logged for, and API
+// keys and prompt content are redacted before they reach the output.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+// Config configures the slog logger built by New.
+type Config struct {
+	// Level is the minimum level logged. The zero value is slog.LevelInfo.
+	Level slog.Level
+	// ContentLevel is the least severe level whose records keep prompt and reply content;
+	// more severe records log the content's length instead. Nil keeps content only in
+	// debug records.
+	ContentLevel slog.Leveler
+	// JSON selects the JSON handler; otherwise records use slog's text format.
+	JSON bool
+	// Output defaults to os.Stderr.
+	Output io.Writer
+}
+
+// New builds a slog logger that attributes and redacts every record.
+func New(cfg Config) *slog.Logger {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	var inner slog.Handler = slog.NewTextHandler(out, opts)
+	if cfg.JSON {
+		inner = slog.NewJSONHandler(out, opts)
+	}
+	contentLevel := slog.LevelDebug
+	if cfg.ContentLevel != nil {
+		contentLevel = cfg.ContentLevel.Level()
+	}
+	return slog.New(NewHandler(inner, contentLevel))
+}
+
+// ParseLevel reads "debug", "info", "warn" or "error", case-insensitively. Unknown values
+// return fallback.
+func ParseLevel(raw string, fallback slog.Level) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(raw))); err != nil {
+		return fallback
+	}
+	return level
+}
+
+type attributionKey struct{}
+
+type attribution struct {
+	companyID uuid.UUID
+	userID    uuid.UUID
+}
+
+// WithAttribution records the company and user that work on ctx is done for. Requests are
+// attributed from their auth session automatically; background jobs call this instead.
+func WithAttribution(ctx context.Context, companyID, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, attributionKey{}, attribution{companyID: companyID, userID: userID})
+}
+
+func attributionFrom(ctx context.Context) (attribution, bool) {
+	if ctx == nil {
+		return attribution{}, false
+	}
+	if attr, ok := ctx.Value(attributionKey{}).(attribution); ok {
+		return attr, true
+	}
+	if session, ok := auth.SessionFromContext(ctx); ok {
+		return attribution{companyID: session.CompanyID, userID: session.UserID}, true
+	}
+	return attribution{}, false
+}
+
+// Handler wraps another slog.Handler, adding request and tenant attribution and
+// redacting secrets and prompt content.
+type Handler struct {
+	inner        slog.Handler
+	contentLevel slog.Level
+}
+
+// NewHandler wraps inner. Prompt content is kept only in records at or below contentLevel.
+func NewHandler(inner slog.Handler, contentLevel slog.Level) *Handler {
+	return &Handler{inner: inner, contentLevel: contentLevel}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	keepContent := record.Level <= h.contentLevel
+	out := slog.NewRecord(record.Time, record.Level, redactString(record.Message), record.PC)
+
+	if id := middleware.GetReqID(ctx); id != "" {
+		out.AddAttrs(slog.String("request_id", id))
+	}
+	if attr, ok := attributionFrom(ctx); ok {
+		if attr.companyID != uuid.Nil {
+			out.AddAttrs(slog.String("company_id", attr.companyID.String()))
+		}
+		if attr.userID != uuid.Nil {
+			out.AddAttrs(slog.String("user_id", attr.userID.String()))
+		}
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		out.AddAttrs(redactAttr(attr, keepContent))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+// WithAttrs redacts content unconditionally, since the level of later records is unknown.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	list := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		list[i] = redactAttr(attr, false)
+	}
+	return &Handler{inner: h.inner.WithAttrs(list), contentLevel: h.contentLevel}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), contentLevel: h.contentLevel}
+}
+
+// Logger implements the client and tool Logger interfaces on top of slog.
+type Logger struct {
+	logger *slog.Logger
+}
+
+// NewLogger adapts logger for the AI client, tools and services. A nil logger uses
+// slog.Default.
+func NewLogger(logger *slog.Logger) *Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Logger{logger: logger}
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, attrs ...any) {
+	l.logger.InfoContext(ctx, msg, attrs...)
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, err error, attrs ...any) {
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.logger.ErrorContext(ctx, msg, attrs...)
+}
+
+// CredentialLogger implements credentials.Logger on top of slog.
+type CredentialLogger struct {
+	logger *slog.Logger
+}
+
+// NewCredentialLogger adapts logger for the credential resolver. A nil logger uses
+// slog.Default.
+func NewCredentialLogger(logger *slog.Logger) *CredentialLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &CredentialLogger{logger: logger}
+}
+
+func (l *CredentialLogger) Info(ctx context.Context, msg string, attrs map[string]any) {
+	l.logger.LogAttrs(ctx, slog.LevelInfo, msg, mapAttrs(attrs)...)
+}
+
+func (l *CredentialLogger) Warn(ctx context.Context, msg string, err error, attrs map[string]any) {
+	list := mapAttrs(attrs)
+	if err != nil {
+		list = append(list, slog.Any("error", err))
+	}
+	l.logger.LogAttrs(ctx, slog.LevelWarn, msg, list...)
+}
+
+// mapAttrs converts attrs to slog attributes in key order, so output is stable.
+func mapAttrs(attrs map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, slog.Any(key, attrs[key]))
+	}
+	return out
+}
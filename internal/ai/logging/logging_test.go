@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestLoggerRedactsSecretsAndContent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(New(Config{Level: slog.LevelDebug, JSON: true, Output: &buf}))
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-42")
+	companyID, userID := uuid.New(), uuid.New()
+	ctx = WithAttribution(ctx, companyID, userID)
+
+	logger.Info(ctx, "ai: chat", "api_key", "sk-live-abcdefghijklmnop", "prompt", "summarise the contract", "model", "gpt-4o")
+	logger.Error(ctx, "ai: provider failed", errors.New(`Post "https://example.test/v1beta/models/x:generateContent?key=AIzaSyA-secret-value-1234567890abcd": timeout`))
+
+	records := decodeLines(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	info := records[0]
+	if info["request_id"] != "req-42" || info["company_id"] != companyID.String() || info["user_id"] != userID.String() {
+		t.Fatalf("missing attribution: %v", info)
+	}
+	if info["api_key"] != redacted {
+		t.Fatalf("api_key = %v, want redacted", info["api_key"])
+	}
+	if info["prompt"] != "[REDACTED 22 chars]" || info["model"] != "gpt-4o" {
+		t.Fatalf("prompt = %v, model = %v", info["prompt"], info["model"])
+	}
+
+	errText, _ := records[1]["error"].(string)
+	if strings.Contains(errText, "AIza") || !strings.Contains(errText, "key="+redacted) {
+		t.Fatalf("error not scrubbed: %q", errText)
+	}
+}
+
+func TestContentLevelKeepsPromptsInVerboseRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(Config{Level: slog.LevelDebug, ContentLevel: slog.LevelInfo, JSON: true, Output: &buf})
+
+	logger.Info("kept", "prompt", "hello")
+	logger.Warn("dropped", "prompt", "hello")
+
+	records := decodeLines(t, &buf)
+	if records[0]["prompt"] != "hello" || records[1]["prompt"] != "[REDACTED 5 chars]" {
+		t.Fatalf("unexpected prompts: %v, %v", records[0]["prompt"], records[1]["prompt"])
+	}
+}
+
+func TestCredentialLoggerExpandsAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewCredentialLogger(New(Config{JSON: true, Output: &buf}))
+
+	logger.Warn(context.Background(), "ai: credential audit", errors.New("boom"), map[string]any{
+		"reference": "company:user:openai",
+		"metadata":  map[string]any{"secret": "hunter2", "provider": "openai"},
+	})
+
+	record := decodeLines(t, &buf)[0]
+	metadata, _ := record["metadata"].(map[string]any)
+	if record["reference"] != "company:user:openai" || record["error"] != "boom" {
+		t.Fatalf("unexpected record: %v", record)
+	}
+	if metadata["secret"] != redacted || metadata["provider"] != "openai" {
+		t.Fatalf("metadata not redacted: %v", metadata)
+	}
+}
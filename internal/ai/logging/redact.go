@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys name attributes whose values are always withheld. Keys are compared
+// lower-cased with '_' and '-' removed.
+var secretKeys = map[string]bool{
+	"apikey":        true,
+	"xapikey":       true,
+	"xgoogapikey":   true,
+	"secret":        true,
+	"clientsecret":  true,
+	"password":      true,
+	"token":         true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"authorization": true,
+	"credential":    true,
+}
+
+// contentKeys name attributes carrying prompt or reply text, withheld above the content
+// level.
+var contentKeys = map[string]bool{
+	"prompt":         true,
+	"systemprompt":   true,
+	"systemaddendum": true,
+	"content":        true,
+	"messages":       true,
+	"completion":     true,
+	"reply":          true,
+	"text":           true,
+	"arguments":      true,
+	"input":          true,
+	"output":         true,
+	"query":          true,
+}
+
+// secretPattern matches API keys that leak into free text such as error messages: OpenAI
+// and Anthropic keys, Google API keys, bearer tokens and Gemini's key query parameter.
+var secretPattern = regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}|AIza[0-9A-Za-z_\-]{30,}|(?i:bearer)\s+[A-Za-z0-9._\-]{16,}|([?&]key=)[^&\s"]+`)
+
+func redactString(value string) string {
+	return secretPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if i := strings.Index(match, "key="); i >= 0 && (match[0] == '?' || match[0] == '&') {
+			return match[:i+len("key=")] + redacted
+		}
+		return redacted
+	})
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer("_", "", "-", "").Replace(key)
+}
+
+func redactAttr(attr slog.Attr, keepContent bool) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	key := normalizeKey(attr.Key)
+
+	if secretKeys[key] {
+		return slog.String(attr.Key, redacted)
+	}
+	if contentKeys[key] && !keepContent {
+		if attr.Value.Kind() == slog.KindString {
+			return slog.String(attr.Key, fmt.Sprintf("[REDACTED %d chars]", len(attr.Value.String())))
+		}
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, redactString(attr.Value.String()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		out := make([]any, len(group))
+		for i, member := range group {
+			out[i] = redactAttr(member, keepContent)
+		}
+		return slog.Group(attr.Key, out...)
+	case slog.KindAny:
+		switch value := attr.Value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, redactString(value.Error()))
+		case map[string]any:
+			members := mapAttrs(value)
+			out := make([]any, len(members))
+			for i, member := range members {
+				out[i] = redactAttr(member, keepContent)
+			}
+			return slog.Group(attr.Key, out...)
+		}
+	}
+	return attr
+}
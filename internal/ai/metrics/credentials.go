@@ -13,54 +13,88 @@ type CredentialMetrics interface {
 	CredentialResolveFailure(companyID uuid.UUID, providerID string)
 }
 
+// CredentialOption configures NewCredentialMetrics.
+type CredentialOption func(*credentialOptions)
+
+type credentialOptions struct {
+	companyLabels bool
+}
+
+// WithCompanyLabels adds a company_id label to every credential counter. Each tenant
+// becomes its own series, so enable it only for deployments with few companies.
+func WithCompanyLabels() CredentialOption {
+	return func(o *credentialOptions) { o.companyLabels = true }
+}
+
 type prometheusCredentialMetrics struct {
+	companyLabels  bool
 	missing        *prometheus.CounterVec
 	testFailures   *prometheus.CounterVec
 	resolveFailure *prometheus.CounterVec
 }
 
 // NewCredentialMetrics constructs a Prometheus-backed metrics recorder. If reg is nil
-// the default Prometheus registerer is used.
-func NewCredentialMetrics(reg prometheus.Registerer) CredentialMetrics {
+// the default Prometheus registerer is used. Counters are labelled by provider only unless
+// WithCompanyLabels is given.
+func NewCredentialMetrics(reg prometheus.Registerer, opts ...CredentialOption) CredentialMetrics {
 	if reg == nil {
 		reg = prometheus.DefaultRegisterer
 	}
+	var options credentialOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	labels := func(names ...string) []string {
+		if options.companyLabels {
+			return append([]string{"company_id"}, names...)
+		}
+		return names
+	}
 	return &prometheusCredentialMetrics{
+		companyLabels: options.companyLabels,
 		missing: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "ai",
 			Name:      "credentials_missing_total",
 			Help:      "Number of times credential resolution failed for a company/provider scope.",
-		}, []string{"company_id", "provider_id", "scope"}),
+		}, labels("provider_id", "scope")),
 		testFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "ai",
 			Name:      "credential_test_failures_total",
 			Help:      "Number of times credential test operations failed.",
-		}, []string{"company_id", "provider_id"}),
+		}, labels("provider_id")),
 		resolveFailure: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "ai",
 			Name:      "credential_resolve_failures_total",
 			Help:      "Number of times credential resolution returned an error.",
-		}, []string{"company_id", "provider_id"}),
+		}, labels("provider_id")),
+	}
+}
+
+// labelValues prefixes values with the company ID when company labels are enabled.
+func (m *prometheusCredentialMetrics) labelValues(companyID uuid.UUID, values ...string) []string {
+	if m.companyLabels {
+		return append([]string{companyID.String()}, values...)
 	}
+	return values
 }
 
 func (m *prometheusCredentialMetrics) CredentialMissing(companyID uuid.UUID, providerID, scope string) {
 	if m == nil {
 		return
 	}
-	m.missing.WithLabelValues(companyID.String(), providerID, scope).Inc()
+	m.missing.WithLabelValues(m.labelValues(companyID, providerID, scope)...).Inc()
 }
 
 func (m *prometheusCredentialMetrics) CredentialTestFailure(companyID uuid.UUID, providerID string) {
 	if m == nil {
 		return
 	}
-	m.testFailures.WithLabelValues(companyID.String(), providerID).Inc()
+	m.testFailures.WithLabelValues(m.labelValues(companyID, providerID)...).Inc()
 }
 
 func (m *prometheusCredentialMetrics) CredentialResolveFailure(companyID uuid.UUID, providerID string) {
 	if m == nil {
 		return
 	}
-	m.resolveFailure.WithLabelValues(companyID.String(), providerID).Inc()
+	m.resolveFailure.WithLabelValues(m.labelValues(companyID, providerID)...).Inc()
 }
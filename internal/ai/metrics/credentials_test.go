@@ -10,7 +10,7 @@ import (
 
 func TestCredentialMetricsCounters(t *testing.T) {
 	reg := prometheus.NewRegistry()
-	cm := NewCredentialMetrics(reg)
+	cm := NewCredentialMetrics(reg, WithCompanyLabels())
 
 	prom, ok := cm.(*prometheusCredentialMetrics)
 	if !ok {
@@ -37,3 +37,19 @@ func TestCredentialMetricsCounters(t *testing.T) {
 		t.Fatalf("expected resolve failure counter to be 2, got %v", got)
 	}
 }
+
+func TestCredentialMetricsOmitCompanyByDefault(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cm := NewCredentialMetrics(reg)
+
+	cm.CredentialMissing(uuid.New(), "openai", "user")
+	cm.CredentialMissing(uuid.New(), "openai", "user")
+
+	prom := cm.(*prometheusCredentialMetrics)
+	if got := testutil.ToFloat64(prom.missing.WithLabelValues("openai", "user")); got != 2 {
+		t.Fatalf("expected companies to share one series with count 2, got %v", got)
+	}
+	if got := testutil.CollectAndCount(prom.missing); got != 1 {
+		t.Fatalf("expected 1 series, got %d", got)
+	}
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DocumentJobMetrics captures queue depth and processing latency for document jobs.
+type DocumentJobMetrics interface {
+	QueueDepth(status string, jobs int64)
+	JobClaimed(wait time.Duration)
+	JobFinished(outcome string, duration time.Duration)
+	LeasesRecovered(jobs int64)
+}
+
+type prometheusDocumentJobMetrics struct {
+	depth     *prometheus.GaugeVec
+	wait      prometheus.Histogram
+	duration  *prometheus.HistogramVec
+	recovered prometheus.Counter
+}
+
+// NewDocumentJobMetrics constructs a Prometheus-backed document job recorder. If reg is
+// nil the default Prometheus registerer is used.
+func NewDocumentJobMetrics(reg prometheus.Registerer) DocumentJobMetrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	return &prometheusDocumentJobMetrics{
+		depth: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ai",
+			Name:      "document_jobs",
+			Help:      "Number of document jobs by status, sampled by the worker.",
+		}, []string{"status"}),
+		wait: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Namespace: "ai",
+			Name:      "document_job_queue_wait_seconds",
+			Help:      "Time a document job waited between becoming runnable and being claimed.",
+			Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+		}),
+		duration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ai",
+			Name:      "document_job_duration_seconds",
+			Help:      "Time spent processing a document job attempt, by outcome.",
+			Buckets:   []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"outcome"}),
+		recovered: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "document_job_leases_recovered_total",
+			Help:      "Number of document jobs requeued or dead-lettered after their worker lease expired.",
+		}),
+	}
+}
+
+func (m *prometheusDocumentJobMetrics) QueueDepth(status string, jobs int64) {
+	if m == nil {
+		return
+	}
+	m.depth.WithLabelValues(status).Set(float64(jobs))
+}
+
+func (m *prometheusDocumentJobMetrics) JobClaimed(wait time.Duration) {
+	if m == nil {
+		return
+	}
+	m.wait.Observe(wait.Seconds())
+}
+
+func (m *prometheusDocumentJobMetrics) JobFinished(outcome string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.duration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+func (m *prometheusDocumentJobMetrics) LeasesRecovered(jobs int64) {
+	if m == nil || jobs <= 0 {
+		return
+	}
+	m.recovered.Add(float64(jobs))
+}
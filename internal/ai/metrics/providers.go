@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ProviderMetrics captures call latency and token usage, retry, failover, circuit breaker
+// and instance cache activity for AI providers, and invocations of the tools they call.
+type ProviderMetrics interface {
+	Retry(providerID, reason string)
+	Failover(fromProvider, toProvider string)
+	BreakerState(providerID, state string)
+	CacheHit(providerID string)
+	CacheMiss(providerID string)
+	CacheEviction(providerID, reason string)
+	ProviderCall(providerID, model, status string, latency time.Duration, promptTokens, completionTokens int)
+	ToolInvocation(name, status string, duration time.Duration)
+}
+
+var breakerStates = []string{"closed", "half_open", "open"}
+
+type prometheusProviderMetrics struct {
+	retries     *prometheus.CounterVec
+	failovers   *prometheus.CounterVec
+	breakerOpen *prometheus.CounterVec
+	breaker     *prometheus.GaugeVec
+	cacheHits   *prometheus.CounterVec
+	cacheMisses *prometheus.CounterVec
+	evictions   *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	tokens      *prometheus.HistogramVec
+	tools       *prometheus.CounterVec
+	toolLatency *prometheus.HistogramVec
+}
+
+// NewProviderMetrics constructs a Prometheus-backed provider metrics recorder. If reg is
+// nil the default Prometheus registerer is used.
+func NewProviderMetrics(reg prometheus.Registerer) ProviderMetrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	return &prometheusProviderMetrics{
+		retries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "provider_retries_total",
+			Help:      "Number of provider HTTP calls retried, by status code or transport failure.",
+		}, []string{"provider_id", "reason"}),
+		failovers: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "provider_failovers_total",
+			Help:      "Number of times a request moved to the next provider in a fallback chain.",
+		}, []string{"from_provider", "to_provider"}),
+		breakerOpen: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "provider_circuit_opened_total",
+			Help:      "Number of times a provider circuit breaker opened.",
+		}, []string{"provider_id"}),
+		breaker: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "ai",
+			Name:      "provider_circuit_state",
+			Help:      "Current provider circuit breaker state (1 for the active state).",
+		}, []string{"provider_id", "state"}),
+		cacheHits: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "provider_cache_hits_total",
+			Help:      "Number of calls served by a cached provider instance.",
+		}, []string{"provider_id"}),
+		cacheMisses: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "provider_cache_misses_total",
+			Help:      "Number of calls that had to build a provider instance.",
+		}, []string{"provider_id"}),
+		evictions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "provider_cache_evictions_total",
+			Help:      "Number of provider instances dropped from the cache, by capacity, expiry or invalidation.",
+		}, []string{"provider_id", "reason"}),
+		latency: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ai",
+			Name:      "provider_request_duration_seconds",
+			Help:      "Latency of provider calls, by provider, model and outcome.",
+			Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80, 160},
+		}, []string{"provider_id", "model", "status"}),
+		tokens: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ai",
+			Name:      "provider_tokens",
+			Help:      "Tokens reported per provider call, by provider, model, outcome and kind (prompt or completion).",
+			Buckets:   prometheus.ExponentialBuckets(16, 4, 8),
+		}, []string{"provider_id", "model", "status", "kind"}),
+		tools: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ai",
+			Name:      "tool_invocations_total",
+			Help:      "Number of tool invocations, by tool and outcome.",
+		}, []string{"tool", "status"}),
+		toolLatency: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ai",
+			Name:      "tool_invocation_duration_seconds",
+			Help:      "Latency of tool invocations, by tool.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"tool"}),
+	}
+}
+
+func (m *prometheusProviderMetrics) Retry(providerID, reason string) {
+	if m == nil {
+		return
+	}
+	m.retries.WithLabelValues(providerID, reason).Inc()
+}
+
+func (m *prometheusProviderMetrics) Failover(fromProvider, toProvider string) {
+	if m == nil {
+		return
+	}
+	m.failovers.WithLabelValues(fromProvider, toProvider).Inc()
+}
+
+func (m *prometheusProviderMetrics) BreakerState(providerID, state string) {
+	if m == nil {
+		return
+	}
+	if state == "open" {
+		m.breakerOpen.WithLabelValues(providerID).Inc()
+	}
+	for _, candidate := range breakerStates {
+		value := 0.0
+		if candidate == state {
+			value = 1
+		}
+		m.breaker.WithLabelValues(providerID, candidate).Set(value)
+	}
+}
+
+func (m *prometheusProviderMetrics) CacheHit(providerID string) {
+	if m == nil {
+		return
+	}
+	m.cacheHits.WithLabelValues(providerID).Inc()
+}
+
+func (m *prometheusProviderMetrics) CacheMiss(providerID string) {
+	if m == nil {
+		return
+	}
+	m.cacheMisses.WithLabelValues(providerID).Inc()
+}
+
+func (m *prometheusProviderMetrics) CacheEviction(providerID, reason string) {
+	if m == nil {
+		return
+	}
+	m.evictions.WithLabelValues(providerID, reason).Inc()
+}
+
+// ProviderCall records latency for every call and token counts for calls that reported
+// usage.
+func (m *prometheusProviderMetrics) ProviderCall(providerID, model, status string, latency time.Duration, promptTokens, completionTokens int) {
+	if m == nil {
+		return
+	}
+	m.latency.WithLabelValues(providerID, model, status).Observe(latency.Seconds())
+	if promptTokens > 0 {
+		m.tokens.WithLabelValues(providerID, model, status, "prompt").Observe(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		m.tokens.WithLabelValues(providerID, model, status, "completion").Observe(float64(completionTokens))
+	}
+}
+
+func (m *prometheusProviderMetrics) ToolInvocation(name, status string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.tools.WithLabelValues(name, status).Inc()
+	m.toolLatency.WithLabelValues(name).Observe(duration.Seconds())
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestProviderCallAndToolMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	pm := NewProviderMetrics(reg)
+	prom := pm.(*prometheusProviderMetrics)
+
+	pm.ProviderCall("openai", "gpt-4o", "ok", 1200*time.Millisecond, 300, 40)
+	pm.ProviderCall("openai", "unknown", "rate_limited", 80*time.Millisecond, 0, 0)
+	pm.ToolInvocation("guidance_search", "ok", 30*time.Millisecond)
+	pm.ToolInvocation("guidance_search", "error", 10*time.Millisecond)
+	pm.ToolInvocation("guidance_search", "ok", 20*time.Millisecond)
+
+	if got := testutil.CollectAndCount(prom.latency); got != 2 {
+		t.Fatalf("expected 2 latency series, got %d", got)
+	}
+	// Failed calls report no usage and must not add zero-token observations.
+	if got := testutil.CollectAndCount(prom.tokens); got != 2 {
+		t.Fatalf("expected prompt and completion token series only, got %d", got)
+	}
+	if got := testutil.ToFloat64(prom.tools.WithLabelValues("guidance_search", "ok")); got != 2 {
+		t.Fatalf("expected 2 successful tool invocations, got %v", got)
+	}
+}
@@ -0,0 +1,174 @@
+// Package preferences stores each user's default AI provider, model, temperature and
+// system addendum. Preferences sit beneath conversation-level overrides and above the
+// company and provider defaults.
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+// Metadata keys understood by providers and conversation metadata.
+const (
+	MetadataModel          = "model"
+	MetadataTemperature    = "temperature"
+	MetadataSystemAddendum = "system_addendum"
+)
+
+// MaxSystemAddendum bounds the personal system addendum, in characters.
+const MaxSystemAddendum = 2000
+
+// ErrInvalidPreference is returned when a preference fails validation.
+var ErrInvalidPreference = errors.New("preferences: invalid preference")
+
+// Store exposes the subset of database.Queries needed for user preferences.
+type Store interface {
+	GetAIUserPreference(ctx context.Context, arg database.GetAIUserPreferenceParams) (database.AiUserPreference, error)
+	UpsertAIUserPreference(ctx context.Context, arg database.UpsertAIUserPreferenceParams) (database.AiUserPreference, error)
+	DeleteAIUserPreference(ctx context.Context, arg database.DeleteAIUserPreferenceParams) error
+}
+
+// Preference is a user's AI defaults within one company. The zero value means the user
+// has not chosen anything and company defaults apply.
+type Preference struct {
+	ProviderID     string   `json:"providerId"`
+	Model          string   `json:"model,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	SystemAddendum string   `json:"systemAddendum,omitempty"`
+}
+
+// IsZero reports whether the user has saved no preference.
+func (p Preference) IsZero() bool {
+	return p.ProviderID == "" && p.Model == "" && p.Temperature == nil && p.SystemAddendum == ""
+}
+
+// Apply layers the preference beneath metadata: keys already present in metadata (set on
+// the conversation or the request) win. The preferred model only applies when providerID
+// is the preferred provider, since model names are provider-specific.
+func (p Preference) Apply(metadata map[string]any, providerID string) map[string]any {
+	out := make(map[string]any, len(metadata)+3)
+	for key, value := range metadata {
+		out[key] = value
+	}
+	if p.Model != "" && providerID == p.ProviderID && !hasString(out, MetadataModel) {
+		out[MetadataModel] = p.Model
+	}
+	if p.Temperature != nil {
+		if _, ok := out[MetadataTemperature]; !ok {
+			out[MetadataTemperature] = *p.Temperature
+		}
+	}
+	if p.SystemAddendum != "" && !hasString(out, MetadataSystemAddendum) {
+		out[MetadataSystemAddendum] = p.SystemAddendum
+	}
+	return out
+}
+
+func hasString(metadata map[string]any, key string) bool {
+	value, ok := metadata[key].(string)
+	return ok && strings.TrimSpace(value) != ""
+}
+
+// Service reads and writes user preferences.
+type Service struct {
+	store Store
+}
+
+// New constructs a preferences Service backed by store.
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Get returns the user's preference. Users without one get the zero Preference.
+func (s *Service) Get(ctx context.Context, companyID, userID uuid.UUID) (Preference, error) {
+	if s == nil || s.store == nil || userID == uuid.Nil {
+		return Preference{}, nil
+	}
+	row, err := s.store.GetAIUserPreference(ctx, database.GetAIUserPreferenceParams{CompanyID: companyID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Preference{}, nil
+	}
+	if err != nil {
+		return Preference{}, err
+	}
+	return fromRow(row)
+}
+
+// Save validates and stores the user's preference, replacing any earlier one.
+func (s *Service) Save(ctx context.Context, companyID, userID uuid.UUID, pref Preference) (Preference, error) {
+	if s == nil || s.store == nil {
+		return Preference{}, errors.New("preferences: store not configured")
+	}
+	pref.ProviderID = strings.TrimSpace(pref.ProviderID)
+	pref.Model = strings.TrimSpace(pref.Model)
+	pref.SystemAddendum = strings.TrimSpace(pref.SystemAddendum)
+	switch {
+	case pref.ProviderID == "":
+		return Preference{}, fmt.Errorf("%w: provider is required", ErrInvalidPreference)
+	case pref.Temperature != nil && (*pref.Temperature < 0 || *pref.Temperature > 2):
+		return Preference{}, fmt.Errorf("%w: temperature must be between 0 and 2", ErrInvalidPreference)
+	case utf8.RuneCountInString(pref.SystemAddendum) > MaxSystemAddendum:
+		return Preference{}, fmt.Errorf("%w: system addendum is too long", ErrInvalidPreference)
+	}
+
+	meta := map[string]any{}
+	if pref.Temperature != nil {
+		meta[MetadataTemperature] = *pref.Temperature
+	}
+	if pref.SystemAddendum != "" {
+		meta[MetadataSystemAddendum] = pref.SystemAddendum
+	}
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return Preference{}, err
+	}
+
+	row, err := s.store.UpsertAIUserPreference(ctx, database.UpsertAIUserPreferenceParams{
+		CompanyID:  companyID,
+		UserID:     userID,
+		ProviderID: pref.ProviderID,
+		Model:      sql.NullString{String: pref.Model, Valid: pref.Model != ""},
+		Column5:    raw,
+	})
+	if err != nil {
+		return Preference{}, err
+	}
+	return fromRow(row)
+}
+
+// Delete clears the user's preference so company defaults apply again.
+func (s *Service) Delete(ctx context.Context, companyID, userID uuid.UUID) error {
+	if s == nil || s.store == nil {
+		return errors.New("preferences: store not configured")
+	}
+	return s.store.DeleteAIUserPreference(ctx, database.DeleteAIUserPreferenceParams{CompanyID: companyID, UserID: userID})
+}
+
+func fromRow(row database.AiUserPreference) (Preference, error) {
+	pref := Preference{ProviderID: row.ProviderID}
+	if row.Model.Valid {
+		pref.Model = row.Model.String
+	}
+	if len(row.Metadata) == 0 {
+		return pref, nil
+	}
+	var meta struct {
+		Temperature    *float64 `json:"temperature"`
+		SystemAddendum string   `json:"system_addendum"`
+	}
+	if err := json.Unmarshal(row.Metadata, &meta); err != nil {
+		return Preference{}, err
+	}
+	pref.Temperature = meta.Temperature
+	pref.SystemAddendum = meta.SystemAddendum
+	return pref, nil
+}
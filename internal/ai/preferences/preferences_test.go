@@ -0,0 +1,83 @@
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+type memoryStore struct {
+	row *database.AiUserPreference
+}
+
+func (s *memoryStore) GetAIUserPreference(context.Context, database.GetAIUserPreferenceParams) (database.AiUserPreference, error) {
+	if s.row == nil {
+		return database.AiUserPreference{}, sql.ErrNoRows
+	}
+	return *s.row, nil
+}
+
+func (s *memoryStore) UpsertAIUserPreference(_ context.Context, arg database.UpsertAIUserPreferenceParams) (database.AiUserPreference, error) {
+	raw, _ := arg.Column5.([]byte)
+	s.row = &database.AiUserPreference{CompanyID: arg.CompanyID, UserID: arg.UserID, ProviderID: arg.ProviderID, Model: arg.Model, Metadata: raw}
+	return *s.row, nil
+}
+
+func (s *memoryStore) DeleteAIUserPreference(context.Context, database.DeleteAIUserPreferenceParams) error {
+	s.row = nil
+	return nil
+}
+
+func TestApplyLayersBeneathConversationMetadata(t *testing.T) {
+	temperature := 0.2
+	pref := Preference{ProviderID: "openai", Model: "gpt-4o-mini", Temperature: &temperature, SystemAddendum: "Be brief."}
+
+	got := pref.Apply(map[string]any{"model": "gpt-4o"}, "openai")
+	if got["model"] != "gpt-4o" || got["temperature"] != 0.2 || got["system_addendum"] != "Be brief." {
+		t.Fatalf("unexpected layering %v", got)
+	}
+
+	got = pref.Apply(nil, "anthropic")
+	if _, ok := got["model"]; ok {
+		t.Fatalf("preferred model must not apply to another provider, got %v", got)
+	}
+	if got["temperature"] != 0.2 {
+		t.Fatalf("expected temperature to apply across providers, got %v", got)
+	}
+}
+
+func TestSaveRoundTripsAndValidates(t *testing.T) {
+	store := &memoryStore{}
+	svc := New(store)
+	ctx := context.Background()
+	company, user := uuid.New(), uuid.New()
+
+	hot := 3.0
+	if _, err := svc.Save(ctx, company, user, Preference{ProviderID: "openai", Temperature: &hot}); !errors.Is(err, ErrInvalidPreference) {
+		t.Fatalf("expected ErrInvalidPreference for temperature 3, got %v", err)
+	}
+	if _, err := svc.Save(ctx, company, user, Preference{Model: "gpt-4o"}); !errors.Is(err, ErrInvalidPreference) {
+		t.Fatalf("expected ErrInvalidPreference without provider, got %v", err)
+	}
+
+	temperature := 0.7
+	saved, err := svc.Save(ctx, company, user, Preference{ProviderID: " openai ", Model: "gpt-4o", Temperature: &temperature, SystemAddendum: " Cite sources. "})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if saved.ProviderID != "openai" || saved.Model != "gpt-4o" || saved.Temperature == nil || *saved.Temperature != 0.7 || saved.SystemAddendum != "Cite sources." {
+		t.Fatalf("unexpected saved preference %+v", saved)
+	}
+
+	if err := svc.Delete(ctx, company, user); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if pref, err := svc.Get(ctx, company, user); err != nil || !pref.IsZero() {
+		t.Fatalf("expected no preference after delete, got %+v, %v", pref, err)
+	}
+}
@@ -0,0 +1,500 @@
+// Package prompts stores named, versioned prompt templates per company. Templates are Go
+// text/template bodies rendered against Data; editing a body adds a version so sessions,
+// jobs, and messages can refer to the exact text they used.
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+// Template kinds. Chat templates add company instructions to the system prompt of every
+// conversation; document templates replace the built-in document analysis prompt.
+const (
+	KindChat            = "chat"
+	KindDocumentSummary = "document_summary"
+)
+
+// Kinds lists the supported template kinds in display order.
+var Kinds = []string{KindChat, KindDocumentSummary}
+
+// Metadata keys used to pin a session or job to a version and to record the version that
+// produced a message or job result.
+const (
+	MetadataPinnedVersion = "prompt_template_version_id"
+	MetadataTemplate      = "prompt_template"
+)
+
+const maxBodyBytes = 32 << 10
+
+var (
+	// ErrInvalidTemplate is returned for blank names, unknown kinds, and bodies that do not
+	// parse or reference unknown variables.
+	ErrInvalidTemplate = errors.New("prompts: invalid template")
+	// ErrNotFound is returned when a template or version does not exist for the company.
+	ErrNotFound = errors.New("prompts: template not found")
+	// ErrKindMismatch is returned when a pinned version belongs to a different kind.
+	ErrKindMismatch = errors.New("prompts: template kind mismatch")
+)
+
+// Store exposes the subset of database.Queries needed for prompt templates. WithTx binds
+// the queries to a transaction for writes that span several statements.
+type Store interface {
+	WithTx(tx *sql.Tx) *database.Queries
+	InsertAIPromptTemplate(ctx context.Context, arg database.InsertAIPromptTemplateParams) (database.AiPromptTemplate, error)
+	GetAIPromptTemplate(ctx context.Context, arg database.GetAIPromptTemplateParams) (database.AiPromptTemplate, error)
+	ListAIPromptTemplatesByCompany(ctx context.Context, companyID uuid.UUID) ([]database.AiPromptTemplate, error)
+	UpdateAIPromptTemplate(ctx context.Context, arg database.UpdateAIPromptTemplateParams) (database.AiPromptTemplate, error)
+	ArchiveAIPromptTemplate(ctx context.Context, arg database.ArchiveAIPromptTemplateParams) (int64, error)
+	ClearAIPromptTemplateDefault(ctx context.Context, arg database.ClearAIPromptTemplateDefaultParams) error
+	SetAIPromptTemplateDefault(ctx context.Context, arg database.SetAIPromptTemplateDefaultParams) (int64, error)
+	InsertAIPromptTemplateVersion(ctx context.Context, arg database.InsertAIPromptTemplateVersionParams) (database.AiPromptTemplateVersion, error)
+	ListAIPromptTemplateVersions(ctx context.Context, arg database.ListAIPromptTemplateVersionsParams) ([]database.AiPromptTemplateVersion, error)
+	GetAIPromptTemplateVersion(ctx context.Context, arg database.GetAIPromptTemplateVersionParams) (database.GetAIPromptTemplateVersionRow, error)
+	GetLatestAIPromptTemplateVersion(ctx context.Context, arg database.GetLatestAIPromptTemplateVersionParams) (database.GetLatestAIPromptTemplateVersionRow, error)
+	GetDefaultAIPromptTemplateVersion(ctx context.Context, arg database.GetDefaultAIPromptTemplateVersionParams) (database.GetDefaultAIPromptTemplateVersionRow, error)
+}
+
+// Template is a named prompt and its current version.
+type Template struct {
+	ID          uuid.UUID
+	CompanyID   uuid.UUID
+	Name        string
+	Kind        string
+	Description string
+	IsDefault   bool
+	Latest      Version
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Version is an immutable revision of a template body.
+type Version struct {
+	ID         uuid.UUID
+	TemplateID uuid.UUID
+	Name       string
+	Kind       string
+	Version    int
+	Body       string
+	CreatedBy  uuid.UUID
+	CreatedAt  time.Time
+}
+
+// Ref identifies the version for metadata on messages and job results.
+func (v Version) Ref() map[string]any {
+	return map[string]any{
+		"id":          v.ID.String(),
+		"template_id": v.TemplateID.String(),
+		"name":        v.Name,
+		"version":     v.Version,
+	}
+}
+
+// Render executes the version's body against data.
+func (v Version) Render(data Data) (string, error) {
+	return Render(v.Body, data)
+}
+
+// Data holds the variables available to templates, e.g. {{.CustomerName}} or
+// {{range .Contracts}}{{.StartDate}}{{end}}. Chat templates only receive Today; the
+// customer and document fields are filled for document jobs and empty otherwise.
+type Data struct {
+	Today        string
+	CustomerName string
+	Contracts    []ContractData
+	Instructions string
+	// Documents is the text to analyse in document templates: records, document names, and
+	// file contents. It is appended after the rendered text when the body does not use it.
+	Documents string
+}
+
+// ContractData describes a contract available to templates. Dates are YYYY-MM-DD.
+type ContractData struct {
+	ID        string
+	StartDate string
+	EndDate   string
+}
+
+// UsesDocuments reports whether a body places {{.Documents}} itself.
+func UsesDocuments(body string) bool {
+	return strings.Contains(body, ".Documents")
+}
+
+// Render parses body and executes it against data.
+func Render(body string, data Data) (string, error) {
+	tmpl, err := parse(body)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Validate checks that body parses and only references known variables by rendering it
+// against sample data.
+func Validate(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidTemplate)
+	}
+	if len(body) > maxBodyBytes {
+		return fmt.Errorf("%w: body exceeds %d KB", ErrInvalidTemplate, maxBodyBytes>>10)
+	}
+	_, err := Render(body, sampleData)
+	return err
+}
+
+var sampleData = Data{
+	Today:        "2025-01-01",
+	CustomerName: "Example Customer",
+	Contracts:    []ContractData{{ID: "00000000-0000-0000-0000-000000000000", StartDate: "2025-01-01", EndDate: "2025-12-31"}},
+	Instructions: "Example instructions",
+	Documents:    "Example documents",
+}
+
+func parse(body string) (*template.Template, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// ValidKind reports whether kind is a supported template kind.
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Service manages a company's prompt templates.
+type Service struct {
+	store Store
+	conn  *sql.DB
+}
+
+// New constructs a Service backed by store; conn opens the transactions for multi-step
+// writes.
+func New(store Store, conn *sql.DB) *Service {
+	return &Service{store: store, conn: conn}
+}
+
+// inTx runs fn against the store bound to a single transaction, committing when fn
+// succeeds.
+func (s *Service) inTx(ctx context.Context, fn func(Store) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(s.store.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateParams describes a new template and its first version.
+type CreateParams struct {
+	Name        string
+	Kind        string
+	Description string
+	Body        string
+	Default     bool
+}
+
+// UpdateParams changes a template's details. A body that differs from the latest version
+// adds a new version.
+type UpdateParams struct {
+	Name        string
+	Description string
+	Body        string
+}
+
+// List returns the company's templates with their latest versions.
+func (s *Service) List(ctx context.Context, companyID uuid.UUID) ([]Template, error) {
+	rows, err := s.store.ListAIPromptTemplatesByCompany(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]Template, 0, len(rows))
+	for _, row := range rows {
+		latest, err := s.store.GetLatestAIPromptTemplateVersion(ctx, database.GetLatestAIPromptTemplateVersionParams{TemplateID: row.ID, CompanyID: companyID})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		templates = append(templates, templateFromRow(row, joinedVersion(database.GetAIPromptTemplateVersionRow(latest))))
+	}
+	return templates, nil
+}
+
+// Template returns a single template with its latest version.
+func (s *Service) Template(ctx context.Context, companyID, templateID uuid.UUID) (Template, error) {
+	row, err := s.store.GetAIPromptTemplate(ctx, database.GetAIPromptTemplateParams{ID: templateID, CompanyID: companyID})
+	if err != nil {
+		return Template{}, notFound(err)
+	}
+	latest, err := s.store.GetLatestAIPromptTemplateVersion(ctx, database.GetLatestAIPromptTemplateVersionParams{TemplateID: row.ID, CompanyID: companyID})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Template{}, err
+	}
+	return templateFromRow(row, joinedVersion(database.GetAIPromptTemplateVersionRow(latest))), nil
+}
+
+// Create stores a new template as version 1.
+func (s *Service) Create(ctx context.Context, companyID, userID uuid.UUID, params CreateParams) (Template, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	params.Description = strings.TrimSpace(params.Description)
+	if params.Name == "" {
+		return Template{}, fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	}
+	if !ValidKind(params.Kind) {
+		return Template{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidTemplate, params.Kind)
+	}
+	if err := Validate(params.Body); err != nil {
+		return Template{}, err
+	}
+
+	// The template, its first version, and the default flag are written together so a
+	// failure never leaves a template without a version.
+	var templateID uuid.UUID
+	err := s.inTx(ctx, func(store Store) error {
+		row, err := store.InsertAIPromptTemplate(ctx, database.InsertAIPromptTemplateParams{
+			CompanyID:   companyID,
+			Name:        params.Name,
+			Kind:        params.Kind,
+			Description: params.Description,
+		})
+		if err != nil {
+			return err
+		}
+		templateID = row.ID
+		if _, err := addVersion(ctx, store, row.ID, userID, params.Body); err != nil {
+			return err
+		}
+		if params.Default {
+			return setDefault(ctx, store, companyID, row.ID, row.Kind)
+		}
+		return nil
+	})
+	if err != nil {
+		return Template{}, err
+	}
+	return s.Template(ctx, companyID, templateID)
+}
+
+// Update renames or re-describes a template and adds a version when the body changed.
+func (s *Service) Update(ctx context.Context, companyID, templateID, userID uuid.UUID, params UpdateParams) (Template, error) {
+	current, err := s.Template(ctx, companyID, templateID)
+	if err != nil {
+		return Template{}, err
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		name = current.Name
+	}
+	if params.Body != "" && params.Body != current.Latest.Body {
+		if err := Validate(params.Body); err != nil {
+			return Template{}, err
+		}
+	}
+
+	err = s.inTx(ctx, func(store Store) error {
+		if _, err := store.UpdateAIPromptTemplate(ctx, database.UpdateAIPromptTemplateParams{
+			ID:          templateID,
+			CompanyID:   companyID,
+			Name:        name,
+			Description: strings.TrimSpace(params.Description),
+		}); err != nil {
+			return notFound(err)
+		}
+		if params.Body != "" && params.Body != current.Latest.Body {
+			if _, err := addVersion(ctx, store, templateID, userID, params.Body); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Template{}, err
+	}
+	return s.Template(ctx, companyID, templateID)
+}
+
+// Archive removes a template from use. Its versions remain so pinned sessions and recorded
+// messages still resolve.
+func (s *Service) Archive(ctx context.Context, companyID, templateID uuid.UUID) error {
+	n, err := s.store.ArchiveAIPromptTemplate(ctx, database.ArchiveAIPromptTemplateParams{ID: templateID, CompanyID: companyID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetDefault makes a template the one used for its kind when nothing is pinned.
+func (s *Service) SetDefault(ctx context.Context, companyID, templateID uuid.UUID) error {
+	tmpl, err := s.Template(ctx, companyID, templateID)
+	if err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(store Store) error {
+		return setDefault(ctx, store, companyID, templateID, tmpl.Kind)
+	})
+}
+
+// setDefault moves the kind's default flag to templateID. Callers run it in a transaction
+// so the company is never left without a default.
+func setDefault(ctx context.Context, store Store, companyID, templateID uuid.UUID, kind string) error {
+	if err := store.ClearAIPromptTemplateDefault(ctx, database.ClearAIPromptTemplateDefaultParams{CompanyID: companyID, Kind: kind}); err != nil {
+		return err
+	}
+	n, err := store.SetAIPromptTemplateDefault(ctx, database.SetAIPromptTemplateDefaultParams{ID: templateID, CompanyID: companyID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Versions lists a template's versions, newest first.
+func (s *Service) Versions(ctx context.Context, companyID, templateID uuid.UUID) ([]Version, error) {
+	tmpl, err := s.Template(ctx, companyID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.store.ListAIPromptTemplateVersions(ctx, database.ListAIPromptTemplateVersionsParams{TemplateID: templateID, CompanyID: companyID})
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]Version, 0, len(rows))
+	for _, row := range rows {
+		version := versionFromRow(row)
+		version.Name, version.Kind = tmpl.Name, tmpl.Kind
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// Version returns a single version by ID, including versions of archived templates.
+func (s *Service) Version(ctx context.Context, companyID, versionID uuid.UUID) (Version, error) {
+	row, err := s.store.GetAIPromptTemplateVersion(ctx, database.GetAIPromptTemplateVersionParams{ID: versionID, CompanyID: companyID})
+	if err != nil {
+		return Version{}, notFound(err)
+	}
+	return joinedVersion(row), nil
+}
+
+// Resolve returns the version to use for kind: the pinned version when set, otherwise the
+// latest version of the company's default template. It returns ErrNotFound when the
+// company has no default.
+func (s *Service) Resolve(ctx context.Context, companyID uuid.UUID, kind string, pinned uuid.UUID) (Version, error) {
+	if s == nil || s.store == nil {
+		return Version{}, ErrNotFound
+	}
+	if pinned != uuid.Nil {
+		version, err := s.Version(ctx, companyID, pinned)
+		if err != nil {
+			return Version{}, err
+		}
+		if version.Kind != kind {
+			return Version{}, fmt.Errorf("%w: version %s is a %s template", ErrKindMismatch, pinned, version.Kind)
+		}
+		return version, nil
+	}
+	row, err := s.store.GetDefaultAIPromptTemplateVersion(ctx, database.GetDefaultAIPromptTemplateVersionParams{CompanyID: companyID, Kind: kind})
+	if err != nil {
+		return Version{}, notFound(err)
+	}
+	return joinedVersion(database.GetAIPromptTemplateVersionRow(row)), nil
+}
+
+// PinnedVersion reads a pinned version ID from session or job metadata.
+func PinnedVersion(metadata map[string]any) uuid.UUID {
+	raw, _ := metadata[MetadataPinnedVersion].(string)
+	id, err := uuid.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+func addVersion(ctx context.Context, store Store, templateID, userID uuid.UUID, body string) (Version, error) {
+	row, err := store.InsertAIPromptTemplateVersion(ctx, database.InsertAIPromptTemplateVersionParams{
+		TemplateID: templateID,
+		Body:       body,
+		CreatedBy:  uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil},
+	})
+	if err != nil {
+		return Version{}, err
+	}
+	return versionFromRow(row), nil
+}
+
+func templateFromRow(row database.AiPromptTemplate, latest Version) Template {
+	latest.Name, latest.Kind = row.Name, row.Kind
+	return Template{
+		ID:          row.ID,
+		CompanyID:   row.CompanyID,
+		Name:        row.Name,
+		Kind:        row.Kind,
+		Description: row.Description,
+		IsDefault:   row.IsDefault,
+		Latest:      latest,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+func versionFromRow(row database.AiPromptTemplateVersion) Version {
+	return Version{
+		ID:         row.ID,
+		TemplateID: row.TemplateID,
+		Version:    int(row.Version),
+		Body:       row.Body,
+		CreatedBy:  row.CreatedBy.UUID,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+// joinedVersion converts the version queries that also select the template's name and kind.
+func joinedVersion(row database.GetAIPromptTemplateVersionRow) Version {
+	if row.ID == uuid.Nil {
+		return Version{}
+	}
+	return Version{
+		ID:         row.ID,
+		TemplateID: row.TemplateID,
+		Name:       row.Name,
+		Kind:       row.Kind,
+		Version:    int(row.Version),
+		Body:       row.Body,
+		CreatedBy:  row.CreatedBy.UUID,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
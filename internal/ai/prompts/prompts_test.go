@@ -0,0 +1,54 @@
+package prompts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRenderSubstitutesVariables(t *testing.T) {
+	body := `Reviewing {{.CustomerName}}.
+{{range .Contracts}}- {{.ID}}: {{.StartDate}} to {{.EndDate}}
+{{end}}`
+	got, err := Render(body, Data{
+		CustomerName: "Acme",
+		Contracts:    []ContractData{{ID: "c1", StartDate: "2025-01-01", EndDate: "2025-12-31"}},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "Reviewing Acme.\n- c1: 2025-01-01 to 2025-12-31"
+	if got != want {
+		t.Fatalf("Render = %q, want %q", got, want)
+	}
+}
+
+func TestValidateRejectsBadTemplates(t *testing.T) {
+	cases := map[string]string{
+		"blank":            "   ",
+		"syntax":           "{{if .CustomerName}}unterminated",
+		"unknown variable": "Hello {{.Customer}}",
+	}
+	for name, body := range cases {
+		if err := Validate(body); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%s: Validate error = %v, want ErrInvalidTemplate", name, err)
+		}
+	}
+	if err := Validate("Summarise on {{.Today}}:\n{{.Documents}}"); err != nil {
+		t.Fatalf("Validate valid body: %v", err)
+	}
+}
+
+func TestPinnedVersion(t *testing.T) {
+	id := uuid.New()
+	if got := PinnedVersion(map[string]any{MetadataPinnedVersion: id.String()}); got != id {
+		t.Fatalf("PinnedVersion = %s, want %s", got, id)
+	}
+	if got := PinnedVersion(map[string]any{MetadataPinnedVersion: "nope"}); got != uuid.Nil {
+		t.Fatalf("PinnedVersion invalid = %s, want nil", got)
+	}
+	if got := PinnedVersion(nil); got != uuid.Nil {
+		t.Fatalf("PinnedVersion nil = %s, want nil", got)
+	}
+}
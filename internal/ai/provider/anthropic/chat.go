@@ -0,0 +1,152 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+// Chat replays a role-tagged transcript through the Messages API. System messages are
+// folded into the top-level system prompt and tool results travel as user turns.
+func (p *Provider) Chat(ctx context.Context, req clientpkg.ChatRequest) (clientpkg.ConversationReply, error) {
+	metadata := mergeMetadata(p.metadata, sanitizeMetadata(req.Metadata))
+	system, history := buildChatMessages(buildSystemPrompt(p.systemPrompt, metadata), req.Messages)
+	start := len(history)
+
+	resp, err := p.exchange(ctx, system, &history, metadata)
+	if err != nil {
+		return clientpkg.ConversationReply{}, err
+	}
+
+	// exchange appends every generated message; the last one is the final answer.
+	toolNames := make(map[string]string)
+	var turns []clientpkg.ConversationMessage
+	for _, msg := range history[start : len(history)-1] {
+		turns = append(turns, fromMessage(msg, toolNames)...)
+	}
+
+	return clientpkg.ConversationReply{
+		Message: clientpkg.ConversationMessage{
+			Role:     roleAssistant,
+			Content:  resp.Text(),
+			Metadata: map[string]any{"finish_reason": resp.StopReason, "usage": resp.Usage},
+		},
+		Turns: turns,
+		Usage: resp.Usage.toClient(resp.Model, pickModel(p.model, metadata)),
+		Raw:   resp,
+	}, nil
+}
+
+// buildChatMessages converts a portable transcript into Messages API turns, merging
+// consecutive turns from the same role and returning the combined system prompt.
+func buildChatMessages(system string, messages []clientpkg.ConversationMessage) (string, []message) {
+	systemParts := make([]string, 0, 1)
+	if system != "" {
+		systemParts = append(systemParts, system)
+	}
+
+	out := make([]message, 0, len(messages))
+	appendBlocks := func(role string, blocks ...contentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+			return
+		}
+		out = append(out, message{Role: role, Content: blocks})
+	}
+
+	for _, msg := range messages {
+		switch strings.ToLower(msg.Role) {
+		case clientpkg.RoleSystem:
+			if content := strings.TrimSpace(msg.Content); content != "" {
+				systemParts = append(systemParts, content)
+			}
+		case clientpkg.RoleAssistant, "model":
+			blocks := make([]contentBlock, 0, 1+len(msg.ToolCalls))
+			if msg.Content != "" {
+				blocks = append(blocks, contentBlock{Type: blockText, Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := json.RawMessage(call.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, contentBlock{Type: blockToolUse, ID: call.ID, Name: call.Name, Input: input})
+			}
+			appendBlocks(roleAssistant, blocks...)
+		case clientpkg.RoleTool:
+			appendBlocks(roleUser, contentBlock{Type: blockToolResult, ToolUseID: msg.ToolCallID, Content: msg.Content})
+		default:
+			if msg.Content != "" {
+				appendBlocks(roleUser, contentBlock{Type: blockText, Text: msg.Content})
+			}
+		}
+	}
+	return strings.Join(systemParts, "\n"), out
+}
+
+// fromMessage converts a generated Messages API turn back into portable messages. A user
+// turn of tool results becomes one tool message per result.
+func fromMessage(msg message, toolNames map[string]string) []clientpkg.ConversationMessage {
+	if msg.Role == roleAssistant {
+		out := clientpkg.ConversationMessage{Role: roleAssistant}
+		for _, block := range msg.Content {
+			switch block.Type {
+			case blockText:
+				out.Content += block.Text
+			case blockToolUse:
+				toolNames[block.ID] = block.Name
+				out.ToolCalls = append(out.ToolCalls, clientpkg.ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+			}
+		}
+		return []clientpkg.ConversationMessage{out}
+	}
+
+	out := make([]clientpkg.ConversationMessage, 0, len(msg.Content))
+	for _, block := range msg.Content {
+		if block.Type != blockToolResult {
+			continue
+		}
+		out = append(out, clientpkg.ConversationMessage{
+			Role:       clientpkg.RoleTool,
+			Content:    block.Content,
+			ToolCallID: block.ToolUseID,
+			ToolName:   toolNames[block.ToolUseID],
+		})
+	}
+	return out
+}
+
+// conversationHandler keeps the transcript for the stateful Conversation API and
+// replays it through Chat. System and assistant messages only extend the history.
+type conversationHandler struct {
+	provider *Provider
+	messages []clientpkg.ConversationMessage
+}
+
+func (h *conversationHandler) Send(ctx context.Context, msg clientpkg.ConversationMessage) (clientpkg.ConversationReply, error) {
+	if msg.Role == "" {
+		msg.Role = roleUser
+	}
+	h.messages = append(h.messages, msg)
+
+	switch strings.ToLower(msg.Role) {
+	case clientpkg.RoleSystem:
+		return clientpkg.ConversationReply{Message: clientpkg.ConversationMessage{Role: clientpkg.RoleSystem, Content: msg.Content}}, nil
+	case roleAssistant, "model":
+		return clientpkg.ConversationReply{Message: clientpkg.ConversationMessage{Role: roleAssistant, Content: msg.Content}}, nil
+	}
+
+	reply, err := h.provider.Chat(ctx, clientpkg.ChatRequest{Messages: h.messages, Metadata: msg.Metadata})
+	if err != nil {
+		return clientpkg.ConversationReply{}, err
+	}
+
+	h.messages = append(h.messages, reply.Turns...)
+	h.messages = append(h.messages, reply.Message)
+	return reply, nil
+}
@@ -0,0 +1,85 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
+)
+
+func TestChatReplaysToolTurns(t *testing.T) {
+	srv := &recordingServer{responses: []func(http.ResponseWriter){textReply("answer")}}
+	provider := newTestProvider(t, srv, Config{SystemPrompt: "base"}, clientpkg.ProviderInit{})
+
+	transcript := []clientpkg.ConversationMessage{
+		{Role: clientpkg.RoleSystem, Content: "be brief"},
+		{Role: clientpkg.RoleUser, Content: "echo ping"},
+		{Role: clientpkg.RoleAssistant, ToolCalls: []clientpkg.ToolCall{{ID: "toolu_1", Name: "echo", Arguments: `{"value":"ping"}`}}},
+		{Role: clientpkg.RoleTool, ToolCallID: "toolu_1", ToolName: "echo", Content: `{"echo":"ping"}`},
+		{Role: clientpkg.RoleAssistant, Content: "ping"},
+		{Role: clientpkg.RoleUser, Content: "again"},
+	}
+	reply, err := provider.(clientpkg.ChatProvider).Chat(context.Background(), clientpkg.ChatRequest{Messages: transcript})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if reply.Message.Content != "answer" || len(reply.Turns) != 0 {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+
+	req := srv.requests[0]
+	if req.System != "base\nbe brief" {
+		t.Fatalf("unexpected system prompt %q", req.System)
+	}
+	// user, assistant(tool_use), user(tool_result), assistant, user
+	if len(req.Messages) != 5 {
+		t.Fatalf("expected 5 messages, got %d: %+v", len(req.Messages), req.Messages)
+	}
+	if use := req.Messages[1].Content[0]; use.Type != blockToolUse || use.ID != "toolu_1" || string(use.Input) != `{"value":"ping"}` {
+		t.Fatalf("unexpected tool_use block %+v", use)
+	}
+	if result := req.Messages[2]; result.Role != roleUser || result.Content[0].Type != blockToolResult || result.Content[0].ToolUseID != "toolu_1" {
+		t.Fatalf("unexpected tool_result message %+v", result)
+	}
+}
+
+func TestChatReturnsGeneratedToolTurns(t *testing.T) {
+	registry := tool.NewRegistry()
+	registry.Register(echoTool{})
+	executor := tool.NewExecutor(registry, nil)
+
+	srv := &recordingServer{responses: []func(http.ResponseWriter){
+		jsonResponse(http.StatusOK, messagesResponse{
+			Role:       roleAssistant,
+			Content:    []contentBlock{{Type: blockToolUse, ID: "toolu_1", Name: "echo", Input: json.RawMessage(`{"value":"ping"}`)}},
+			StopReason: stopReasonToolUse,
+			Usage:      usage{InputTokens: 7, OutputTokens: 3},
+		}),
+		textReply("done"),
+	}}
+	provider := newTestProvider(t, srv, Config{}, clientpkg.ProviderInit{Executor: executor})
+
+	reply, err := provider.(clientpkg.ChatProvider).Chat(context.Background(), clientpkg.ChatRequest{
+		Messages: []clientpkg.ConversationMessage{{Role: clientpkg.RoleUser, Content: "echo ping"}},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	if len(reply.Turns) != 2 {
+		t.Fatalf("expected tool call and result turns, got %+v", reply.Turns)
+	}
+	call, result := reply.Turns[0], reply.Turns[1]
+	if call.Role != roleAssistant || len(call.ToolCalls) != 1 || call.ToolCalls[0].Name != "echo" {
+		t.Fatalf("unexpected tool call turn %+v", call)
+	}
+	if result.Role != clientpkg.RoleTool || result.ToolCallID != "toolu_1" || result.ToolName != "echo" || result.Content != `{"echo":"ping"}` {
+		t.Fatalf("unexpected tool result turn %+v", result)
+	}
+	if reply.Usage.PromptTokens != 17 || reply.Usage.CompletionTokens != 8 {
+		t.Fatalf("expected usage summed across the tool loop, got %+v", reply.Usage)
+	}
+}
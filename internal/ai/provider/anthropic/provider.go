@@ -0,0 +1,413 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
+)
+
+var (
+	ErrMissingAPIKey     = errors.New("ai: anthropic api key not provided")
+	ErrEmptyResponse     = errors.New("ai: anthropic returned no content")
+	ErrToolLoopExhausted = errors.New("ai: anthropic tool execution exceeded retries")
+)
+
+// Config captures optional provider settings such as base URL or model name.
+type Config struct {
+	BaseURL      string
+	Model        string
+	SystemPrompt string
+	MaxTokens    int
+	Logger       clientpkg.Logger
+}
+
+// Provider implements the client.Provider interface for the Anthropic Messages API.
+type Provider struct {
+	httpClient   *http.Client
+	executor     *tool.Executor
+	logger       clientpkg.Logger
+	config       Config
+	apiKey       string
+	model        string
+	baseURL      string
+	maxTokens    int
+	metadata     map[string]any
+	systemPrompt string
+}
+
+// Factory constructs an Anthropic provider compatible with the AI client. A model or base
+// URL saved with the credential takes precedence over cfg.
+func Factory(cfg Config) clientpkg.ProviderFactory {
+	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
+		credential := init.Credential
+		if strings.TrimSpace(credential.Secret) == "" {
+			return nil, ErrMissingAPIKey
+		}
+
+		httpClient := init.HTTPClient
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+
+		logger := cfg.Logger
+		if logger == nil {
+			logger = clientpkg.NewNoopLogger()
+		}
+
+		model := credential.Model()
+		if model == "" {
+			model = cfg.Model
+		}
+		if model == "" {
+			model = defaultModel
+		}
+
+		baseURL := credential.BaseURL()
+		if baseURL == "" {
+			baseURL = cfg.BaseURL
+		}
+		if baseURL == "" {
+			baseURL = defaultBaseURL
+		}
+
+		maxTokens := cfg.MaxTokens
+		if maxTokens <= 0 {
+			maxTokens = defaultMaxTokens
+		}
+
+		return &Provider{
+			httpClient:   httpClient,
+			executor:     init.Executor,
+			logger:       logger,
+			config:       cfg,
+			apiKey:       credential.Secret,
+			model:        model,
+			baseURL:      strings.TrimRight(baseURL, "/"),
+			maxTokens:    maxTokens,
+			metadata:     sanitizeMetadata(credential.Metadata),
+			systemPrompt: cfg.SystemPrompt,
+		}, nil
+	}
+}
+
+func (p *Provider) Name() string { return "anthropic" }
+
+// DefaultModel reports the model used when a request does not name one.
+func (p *Provider) DefaultModel() string { return p.model }
+
+func (p *Provider) Completion(ctx context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error) {
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		return clientpkg.CompletionResponse{}, errors.New("anthropic: prompt is required")
+	}
+
+	metadata := mergeMetadata(p.metadata, sanitizeMetadata(req.Metadata))
+	history := []message{textMessage(roleUser, prompt)}
+
+	resp, err := p.exchange(ctx, buildSystemPrompt(p.systemPrompt, metadata), &history, metadata)
+	if err != nil {
+		return clientpkg.CompletionResponse{}, err
+	}
+
+	return clientpkg.CompletionResponse{
+		Text:  resp.Text(),
+		Usage: resp.Usage.toClient(resp.Model, pickModel(p.model, metadata)),
+		Raw:   resp,
+	}, nil
+}
+
+func (p *Provider) Conversation(context.Context) clientpkg.ConversationHandler {
+	return &conversationHandler{provider: p}
+}
+
+func (p *Provider) Documents(context.Context) clientpkg.DocumentHandler {
+	return nil
+}
+
+func (p *Provider) performMessages(ctx context.Context, payload messagesRequest) (messagesResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	endpoint := p.baseURL + messagesPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+
+	started := time.Now()
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return messagesResponse{}, clientpkg.NewStatusError("anthropic", resp, []byte(describeError(data)))
+	}
+
+	var out messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return messagesResponse{}, err
+	}
+
+	p.logger.Info(ctx, "anthropic: messages", "model", payload.Model, "latency", time.Since(started).String())
+
+	return out, nil
+}
+
+// Ping confirms the API accepts the key by listing a single model.
+func (p *Provider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+modelsPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return clientpkg.NewStatusError("anthropic", resp, []byte(describeError(data)))
+	}
+	return nil
+}
+
+// exchange sends the history to the Messages API, executing any requested tools and
+// replaying their results until the model produces a final answer.
+func (p *Provider) exchange(ctx context.Context, system string, history *[]message, metadata map[string]any) (messagesResponse, error) {
+	const maxToolIterations = 3
+	var total usage
+
+	for i := 0; i < maxToolIterations; i++ {
+		payload := messagesRequest{
+			Model:    pickModel(p.model, metadata),
+			System:   system,
+			Messages: *history,
+			Tools:    convertToolDescriptors(p.executor),
+		}
+		applyRequestOptions(&payload, p.maxTokens, metadata)
+
+		resp, err := p.performMessages(ctx, payload)
+		if err != nil {
+			return messagesResponse{}, err
+		}
+		total = total.add(resp.Usage)
+		resp.Usage = total
+		if len(resp.Content) == 0 {
+			return messagesResponse{}, ErrEmptyResponse
+		}
+
+		*history = append(*history, message{Role: roleAssistant, Content: resp.Content})
+
+		calls := resp.ToolUses()
+		if resp.StopReason != stopReasonToolUse || len(calls) == 0 || p.executor == nil {
+			return resp, nil
+		}
+
+		*history = append(*history, p.handleToolUses(ctx, calls))
+	}
+
+	return messagesResponse{}, ErrToolLoopExhausted
+}
+
+func (p *Provider) handleToolUses(ctx context.Context, calls []contentBlock) message {
+	results := make([]contentBlock, 0, len(calls))
+	for _, call := range calls {
+		invocation := tool.Invocation{Name: call.Name, Input: make(map[string]any)}
+		if len(call.Input) > 0 {
+			var input map[string]any
+			if err := json.Unmarshal(call.Input, &input); err == nil && input != nil {
+				invocation.Input = input
+			}
+		}
+
+		block := contentBlock{Type: blockToolResult, ToolUseID: call.ID}
+		result, err := p.executor.Execute(ctx, invocation)
+		if err != nil {
+			payload, _ := json.Marshal(map[string]any{"error": err.Error()})
+			block.Content = string(payload)
+			block.IsError = true
+		} else {
+			payload, _ := json.Marshal(result.Output)
+			block.Content = string(payload)
+		}
+		results = append(results, block)
+	}
+	return message{Role: roleUser, Content: results}
+}
+
+func textMessage(role, text string) message {
+	return message{Role: role, Content: []contentBlock{{Type: blockText, Text: text}}}
+}
+
+func applyRequestOptions(req *messagesRequest, defaultMaxTokens int, metadata map[string]any) {
+	req.MaxTokens = defaultMaxTokens
+	if maxTokens := intPointer(metadata["max_tokens"]); maxTokens != nil && *maxTokens > 0 {
+		req.MaxTokens = *maxTokens
+	}
+	req.Temperature = floatPointer(metadata["temperature"])
+	req.TopP = floatPointer(metadata["top_p"])
+	req.TopK = intPointer(metadata["top_k"])
+	if choice, ok := metadata["tool_choice"]; ok && choice != nil && len(req.Tools) > 0 {
+		req.ToolChoice = choice
+	}
+	switch stops := metadata["stop_sequences"].(type) {
+	case []string:
+		req.StopSequences = stops
+	case []any:
+		for _, item := range stops {
+			if s, ok := item.(string); ok {
+				req.StopSequences = append(req.StopSequences, s)
+			}
+		}
+	}
+}
+
+func buildSystemPrompt(systemPrompt string, metadata map[string]any) string {
+	parts := make([]string, 0, 2)
+	if strings.TrimSpace(systemPrompt) != "" {
+		parts = append(parts, systemPrompt)
+	}
+	if addendum := extractSystemAddendum(metadata); addendum != "" {
+		parts = append(parts, addendum)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func describeError(body []byte) string {
+	var parsed errorResponse
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		return fmt.Sprintf("%s: %s", parsed.Error.Type, parsed.Error.Message)
+	}
+	return strings.TrimSpace(string(body))
+}
+
+func pickModel(defaultModel string, metadata map[string]any) string {
+	if metadata == nil {
+		return defaultModel
+	}
+	if model, ok := metadata["model"].(string); ok && strings.TrimSpace(model) != "" {
+		return strings.TrimSpace(model)
+	}
+	return defaultModel
+}
+
+func mergeMetadata(items ...map[string]any) map[string]any {
+	merged := make(map[string]any)
+	for _, item := range items {
+		for k, v := range item {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func sanitizeMetadata(meta map[string]any) map[string]any {
+	if meta == nil {
+		return map[string]any{}
+	}
+	out := make(map[string]any, len(meta))
+	for k, v := range meta {
+		if k == "system" {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func extractSystemAddendum(metadata map[string]any) string {
+	if metadata == nil {
+		return ""
+	}
+	switch v := metadata["system_addendum"].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, "\n")
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return ""
+	}
+}
+
+func floatPointer(value any) *float64 {
+	switch v := value.(type) {
+	case float32:
+		f := float64(v)
+		return &f
+	case float64:
+		return &v
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return &f
+		}
+	case int:
+		f := float64(v)
+		return &f
+	case int64:
+		f := float64(v)
+		return &f
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
+func intPointer(value any) *int {
+	switch v := value.(type) {
+	case int:
+		return &v
+	case int32:
+		i := int(v)
+		return &i
+	case int64:
+		i := int(v)
+		return &i
+	case float64:
+		i := int(v)
+		return &i
+	case json.Number:
+		if i64, err := v.Int64(); err == nil {
+			i := int(i64)
+			return &i
+		}
+	case string:
+		if i64, err := strconv.ParseInt(v, 10, 32); err == nil {
+			i := int(i64)
+			return &i
+		}
+	}
+	return nil
+}
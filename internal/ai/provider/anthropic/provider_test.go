@@ -0,0 +1,281 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
+)
+
+type recordingServer struct {
+	mu        sync.Mutex
+	requests  []messagesRequest
+	headers   []http.Header
+	responses []func(http.ResponseWriter)
+}
+
+func (s *recordingServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		var payload messagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		s.mu.Lock()
+		idx := len(s.requests)
+		s.requests = append(s.requests, payload)
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mu.Unlock()
+
+		if idx >= len(s.responses) {
+			t.Errorf("unexpected request %d", idx+1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.responses[idx](w)
+	}
+}
+
+func jsonResponse(status int, body any) func(http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func textReply(text string) func(http.ResponseWriter) {
+	return jsonResponse(http.StatusOK, messagesResponse{
+		ID:         "msg_1",
+		Role:       roleAssistant,
+		Model:      defaultModel,
+		Content:    []contentBlock{{Type: blockText, Text: text}},
+		StopReason: "end_turn",
+		Usage:      usage{InputTokens: 10, OutputTokens: 5},
+	})
+}
+
+func newTestProvider(t *testing.T, srv *recordingServer, cfg Config, init clientpkg.ProviderInit) clientpkg.Provider {
+	t.Helper()
+	ts := httptest.NewServer(srv.handler(t))
+	t.Cleanup(ts.Close)
+
+	cfg.BaseURL = ts.URL + "/v1"
+	if init.Credential.Secret == "" {
+		init.Credential.Secret = "sk-ant-test"
+	}
+	provider, err := Factory(cfg)(init)
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	return provider
+}
+
+func TestFactoryRequiresAPIKey(t *testing.T) {
+	if _, err := Factory(Config{})(clientpkg.ProviderInit{}); !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("expected ErrMissingAPIKey, got %v", err)
+	}
+}
+
+func TestCompletionSendsSystemPromptAndHeaders(t *testing.T) {
+	srv := &recordingServer{responses: []func(http.ResponseWriter){textReply("hello there")}}
+	provider := newTestProvider(t, srv, Config{SystemPrompt: "base prompt"}, clientpkg.ProviderInit{})
+
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{
+		Prompt: "hi",
+		Metadata: map[string]any{
+			"system_addendum": "be brief",
+			"model":           "claude-3-5-haiku-latest",
+			"temperature":     0.2,
+			"max_tokens":      256,
+			"system":          "ignored",
+		},
+	})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if resp.Text != "hello there" {
+		t.Fatalf("unexpected text %q", resp.Text)
+	}
+
+	if len(srv.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(srv.requests))
+	}
+	req := srv.requests[0]
+	if req.System != "base prompt\nbe brief" {
+		t.Fatalf("unexpected system prompt %q", req.System)
+	}
+	if req.Model != "claude-3-5-haiku-latest" {
+		t.Fatalf("unexpected model %q", req.Model)
+	}
+	if req.MaxTokens != 256 {
+		t.Fatalf("unexpected max tokens %d", req.MaxTokens)
+	}
+	if req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Fatalf("unexpected temperature %v", req.Temperature)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != roleUser || req.Messages[0].Content[0].Text != "hi" {
+		t.Fatalf("unexpected messages %+v", req.Messages)
+	}
+
+	headers := srv.headers[0]
+	if got := headers.Get("x-api-key"); got != "sk-ant-test" {
+		t.Fatalf("unexpected api key header %q", got)
+	}
+	if got := headers.Get("anthropic-version"); got != apiVersion {
+		t.Fatalf("unexpected version header %q", got)
+	}
+}
+
+func TestConversationKeepsHistory(t *testing.T) {
+	srv := &recordingServer{responses: []func(http.ResponseWriter){textReply("first"), textReply("second")}}
+	provider := newTestProvider(t, srv, Config{}, clientpkg.ProviderInit{})
+
+	ctx := context.Background()
+	convo := provider.Conversation(ctx)
+
+	if _, err := convo.Send(ctx, clientpkg.ConversationMessage{Role: "system", Content: "stay on topic"}); err != nil {
+		t.Fatalf("system send: %v", err)
+	}
+	reply, err := convo.Send(ctx, clientpkg.ConversationMessage{Role: "user", Content: "one"})
+	if err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	if reply.Message.Content != "first" || reply.Message.Role != roleAssistant {
+		t.Fatalf("unexpected first reply %+v", reply.Message)
+	}
+	if _, err := convo.Send(ctx, clientpkg.ConversationMessage{Role: "user", Content: "two"}); err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+
+	if len(srv.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(srv.requests))
+	}
+	second := srv.requests[1]
+	if second.System != "stay on topic" {
+		t.Fatalf("unexpected system prompt %q", second.System)
+	}
+	roles := make([]string, 0, len(second.Messages))
+	for _, m := range second.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "user,assistant,user" {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+	if second.Messages[1].Content[0].Text != "first" {
+		t.Fatalf("expected assistant history to be replayed, got %+v", second.Messages[1])
+	}
+}
+
+type echoTool struct{}
+
+func (echoTool) Name() string    { return "echo" }
+func (echoTool) Summary() string { return "Echo the input" }
+func (echoTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"value": map[string]any{"type": "string"}},
+	}
+}
+func (echoTool) NewHandler() tool.Handler { return echoHandler{} }
+
+type echoHandler struct{}
+
+func (echoHandler) Invoke(_ context.Context, input map[string]any) (tool.Result, error) {
+	return tool.Result{Output: map[string]any{"echo": input["value"]}}, nil
+}
+
+func TestCompletionExecutesToolUse(t *testing.T) {
+	registry := tool.NewRegistry()
+	registry.Register(echoTool{})
+	executor := tool.NewExecutor(registry, nil)
+
+	srv := &recordingServer{responses: []func(http.ResponseWriter){
+		jsonResponse(http.StatusOK, messagesResponse{
+			Role: roleAssistant,
+			Content: []contentBlock{
+				{Type: blockText, Text: "Let me check."},
+				{Type: blockToolUse, ID: "toolu_1", Name: "echo", Input: json.RawMessage(`{"value":"ping"}`)},
+			},
+			StopReason: stopReasonToolUse,
+		}),
+		textReply("done"),
+	}}
+	provider := newTestProvider(t, srv, Config{}, clientpkg.ProviderInit{Executor: executor})
+
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "echo ping"})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if resp.Text != "done" {
+		t.Fatalf("unexpected text %q", resp.Text)
+	}
+
+	first := srv.requests[0]
+	if len(first.Tools) != 1 || first.Tools[0].Name != "echo" {
+		t.Fatalf("expected echo tool definition, got %+v", first.Tools)
+	}
+
+	second := srv.requests[1]
+	if len(second.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(second.Messages))
+	}
+	result := second.Messages[2]
+	if result.Role != roleUser || len(result.Content) != 1 {
+		t.Fatalf("unexpected tool result message %+v", result)
+	}
+	block := result.Content[0]
+	if block.Type != blockToolResult || block.ToolUseID != "toolu_1" || block.IsError {
+		t.Fatalf("unexpected tool result block %+v", block)
+	}
+	if block.Content != `{"echo":"ping"}` {
+		t.Fatalf("unexpected tool result content %q", block.Content)
+	}
+}
+
+func TestCompletionToolLoopExhausted(t *testing.T) {
+	registry := tool.NewRegistry()
+	registry.Register(echoTool{})
+	executor := tool.NewExecutor(registry, nil)
+
+	toolUse := jsonResponse(http.StatusOK, messagesResponse{
+		Role:       roleAssistant,
+		Content:    []contentBlock{{Type: blockToolUse, ID: "toolu_1", Name: "echo", Input: json.RawMessage(`{}`)}},
+		StopReason: stopReasonToolUse,
+	})
+	srv := &recordingServer{responses: []func(http.ResponseWriter){toolUse, toolUse, toolUse}}
+	provider := newTestProvider(t, srv, Config{}, clientpkg.ProviderInit{Executor: executor})
+
+	_, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "loop"})
+	if !errors.Is(err, ErrToolLoopExhausted) {
+		t.Fatalf("expected ErrToolLoopExhausted, got %v", err)
+	}
+}
+
+func TestCompletionSurfacesAPIError(t *testing.T) {
+	srv := &recordingServer{responses: []func(http.ResponseWriter){
+		jsonResponse(http.StatusUnauthorized, map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": "authentication_error", "message": "invalid x-api-key"},
+		}),
+	}}
+	provider := newTestProvider(t, srv, Config{}, clientpkg.ProviderInit{})
+
+	_, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "hi"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package anthropic
+
+import (
+	"encoding/json"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
+)
+
+const (
+	defaultBaseURL    = "https://api.anthropic.com/v1"
+	defaultModel      = "claude-3-5-sonnet-latest"
+	defaultMaxTokens  = 1024
+	messagesPath      = "/messages"
+	modelsPath        = "/models?limit=1"
+	apiVersion        = "2023-06-01"
+	roleUser          = "user"
+	roleAssistant     = "assistant"
+	blockText         = "text"
+	blockToolUse      = "tool_use"
+	blockToolResult   = "tool_result"
+	stopReasonToolUse = "tool_use"
+)
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+type messagesRequest struct {
+	Model         string           `json:"model"`
+	MaxTokens     int              `json:"max_tokens"`
+	System        string           `json:"system,omitempty"`
+	Messages      []message        `json:"messages"`
+	Temperature   *float64         `json:"temperature,omitempty"`
+	TopP          *float64         `json:"top_p,omitempty"`
+	TopK          *int             `json:"top_k,omitempty"`
+	StopSequences []string         `json:"stop_sequences,omitempty"`
+	Tools         []toolDefinition `json:"tools,omitempty"`
+	ToolChoice    interface{}      `json:"tool_choice,omitempty"`
+}
+
+type messagesResponse struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Role         string         `json:"role"`
+	Model        string         `json:"model"`
+	Content      []contentBlock `json:"content"`
+	StopReason   string         `json:"stop_reason"`
+	StopSequence string         `json:"stop_sequence,omitempty"`
+	Usage        usage          `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u usage) add(other usage) usage {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	return u
+}
+
+// toClient converts usage to the portable form, preferring the model the API reported.
+func (u usage) toClient(reportedModel, requestedModel string) clientpkg.Usage {
+	model := reportedModel
+	if model == "" {
+		model = requestedModel
+	}
+	return clientpkg.Usage{Model: model, PromptTokens: u.InputTokens, CompletionTokens: u.OutputTokens}
+}
+
+type errorResponse struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type toolDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// Text concatenates the text blocks of the response in order.
+func (r messagesResponse) Text() string {
+	var out string
+	for _, block := range r.Content {
+		if block.Type == blockText {
+			out += block.Text
+		}
+	}
+	return out
+}
+
+// ToolUses returns the tool_use blocks the model asked the client to execute.
+func (r messagesResponse) ToolUses() []contentBlock {
+	var calls []contentBlock
+	for _, block := range r.Content {
+		if block.Type == blockToolUse && block.Name != "" {
+			calls = append(calls, block)
+		}
+	}
+	return calls
+}
+
+func convertToolDescriptors(exec *tool.Executor) []toolDefinition {
+	if exec == nil {
+		return nil
+	}
+	descriptors := exec.Descriptors()
+	if len(descriptors) == 0 {
+		return nil
+	}
+
+	tools := make([]toolDefinition, 0, len(descriptors))
+	for _, d := range descriptors {
+		schema := cloneSchema(d.InputSchema)
+		if schema == nil {
+			// Anthropic rejects tools without an input schema, so advertise an empty object.
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		tools = append(tools, toolDefinition{
+			Name:        d.Name,
+			Description: d.Summary,
+			InputSchema: schema,
+		})
+	}
+	return tools
+}
+
+func cloneSchema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,352 @@
+// Package cassette records provider HTTP exchanges to JSON files and replays them, so
+// provider test suites run offline against real response shapes. Credentials are
+// scrubbed from URLs, headers, and bodies before anything is written or matched.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder serves stored interactions or captures live ones.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the network.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to the real transport and stores each exchange.
+	ModeRecord
+)
+
+// EnvRecord names the environment variable that switches test cassettes to record mode.
+const EnvRecord = "AI_CASSETTE_RECORD"
+
+// Redacted replaces every scrubbed credential.
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned when replaying a request the cassette does not contain.
+var ErrNoInteraction = errors.New("cassette: no recorded interaction matches request")
+
+// sensitiveHeaders carry credentials for the supported providers.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Api-Key",
+	"Cookie",
+	"Set-Cookie",
+	"Openai-Organization",
+	"Openai-Project",
+}
+
+// sensitiveParams carry credentials in query strings, such as Gemini's ?key=.
+var sensitiveParams = []string{"key", "api_key", "apikey", "access_token"}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the scrubbed form of an outgoing request.
+type Request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response is the scrubbed form of a received response.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+type file struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// ModeFromEnv returns ModeRecord when EnvRecord is set to a non-empty value.
+func ModeFromEnv() Mode {
+	if os.Getenv(EnvRecord) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Recorder is an http.RoundTripper backed by a cassette file. In replay mode each request
+// is matched by method, scrubbed URL, and JSON body against the first unused interaction,
+// so repeated identical requests replay their responses in order.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	secrets      []string
+	interactions []Interaction
+	used         []bool
+}
+
+// New opens the cassette at path. Replay mode requires the file to exist; record mode
+// starts empty and sends requests through transport, or http.DefaultTransport when nil.
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, transport: transport}
+	if mode == ModeRecord {
+		if r.transport == nil {
+			r.transport = http.DefaultTransport
+		}
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("cassette: parse %s: %w", path, err)
+	}
+	r.interactions = stored.Interactions
+	r.used = make([]bool, len(stored.Interactions))
+	return r, nil
+}
+
+// Mode reports whether the recorder is replaying or recording.
+func (r *Recorder) Mode() Mode { return r.mode }
+
+// Redact registers credential values to scrub wherever they appear, in addition to the
+// well-known credential headers and query parameters.
+func (r *Recorder) Redact(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+}
+
+// Client returns an http.Client that uses the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Remaining returns the number of interactions that have not been replayed.
+func (r *Recorder) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remaining := 0
+	for _, used := range r.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	r.mu.Lock()
+	recorded := Request{
+		Method:  req.Method,
+		URL:     r.scrubURL(req.URL),
+		Headers: r.scrubHeaders(req.Header),
+		Body:    encodeBody([]byte(r.scrubText(string(body)))),
+	}
+	r.mu.Unlock()
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, body, recorded)
+}
+
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	want := normalizeBody(recorded.Body)
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != recorded.Method || interaction.Request.URL != recorded.URL {
+			continue
+		}
+		if normalizeBody(interaction.Request.Body) != want {
+			continue
+		}
+		r.used[i] = true
+		return interaction.Response.httpResponse(req), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s %s", ErrNoInteraction, recorded.Method, recorded.URL, want)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, recorded Request) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	outgoing.ContentLength = int64(len(body))
+
+	resp, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: r.scrubHeaders(resp.Header),
+			Body:    encodeBody([]byte(r.scrubText(string(data)))),
+		},
+	})
+	r.used = append(r.used, true)
+	return resp, nil
+}
+
+// Save writes recorded interactions to the cassette file. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(file{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func (r *Recorder) scrubURL(u *url.URL) string {
+	scrubbed := *u
+	query := scrubbed.Query()
+	for _, param := range sensitiveParams {
+		if query.Has(param) {
+			query.Set(param, Redacted)
+		}
+	}
+	scrubbed.RawQuery = query.Encode()
+	// Registered secrets are replaced in the full URL to catch keys embedded in the path.
+	return r.scrubText(scrubbed.String())
+}
+
+func (r *Recorder) scrubHeaders(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(header))
+	for name := range header {
+		value := header.Get(name)
+		if isSensitiveHeader(name) {
+			value = Redacted
+		}
+		out[http.CanonicalHeaderKey(name)] = r.scrubText(value)
+	}
+	return out
+}
+
+func (r *Recorder) scrubText(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+func isSensitiveHeader(name string) bool {
+	for _, sensitive := range sensitiveHeaders {
+		if strings.EqualFold(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func (resp Response) httpResponse(req *http.Request) *http.Response {
+	header := make(http.Header, len(resp.Headers))
+	for name, value := range resp.Headers {
+		header.Set(name, value)
+	}
+	body := decodeBody(resp.Body)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// encodeBody stores JSON bodies as-is so cassettes stay readable and other bodies as JSON
+// strings.
+func encodeBody(data []byte) json.RawMessage {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if json.Valid(data) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err == nil {
+			return compact.Bytes()
+		}
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
+
+func decodeBody(raw json.RawMessage) []byte {
+	if len(raw) > 0 && raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			return []byte(text)
+		}
+	}
+	return raw
+}
+
+// normalizeBody re-encodes JSON so key order and whitespace do not affect matching.
+func normalizeBody(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return string(raw)
+	}
+	return string(data)
+}
@@ -0,0 +1,129 @@
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordScrubsCredentialsAndReplays(t *testing.T) {
+	const secret = "sk-live-1234567890"
+	var calls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		_, _ = io.WriteString(w, `{"echo": "`+secret+`", "n": `+string(rune('0'+calls))+`}`)
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := New(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("New record: %v", err)
+	}
+	rec.Redact(secret)
+
+	send := func(client *http.Client) (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodPost, upstream.URL+"/v1/generate?key="+secret+"&alt=json", strings.NewReader(`{"b": 1, "a": "`+secret+`"}`))
+		req.Header.Set("Authorization", "Bearer "+secret)
+		req.Header.Set("X-Goog-Api-Key", secret)
+		return client.Do(req)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := send(rec.Client())
+		if err != nil {
+			t.Fatalf("record request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), secret) {
+			t.Fatalf("recording must pass the live body through, got %s", body)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) || strings.Contains(string(data), "session=abc") {
+		t.Fatalf("cassette leaks credentials:\n%s", data)
+	}
+	if !strings.Contains(string(data), "key=REDACTED") {
+		t.Fatalf("expected scrubbed query key:\n%s", data)
+	}
+
+	replay, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("New replay: %v", err)
+	}
+	replay.Redact(secret)
+	for want := 1; want <= 2; want++ {
+		resp, err := send(replay.Client())
+		if err != nil {
+			t.Fatalf("replay request: %v", err)
+		}
+		var body struct {
+			Echo string `json:"echo"`
+			N    int    `json:"n"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode replayed body: %v", err)
+		}
+		resp.Body.Close()
+		if body.N != want || body.Echo != Redacted {
+			t.Fatalf("replayed body %+v, want n=%d with redacted echo", body, want)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("replay reached the network: %d upstream calls", calls)
+	}
+	if replay.Remaining() != 0 {
+		t.Fatalf("expected every interaction to be replayed, %d left", replay.Remaining())
+	}
+
+	_, err = send(replay.Client())
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("expected ErrNoInteraction once the cassette is exhausted, got %v", err)
+	}
+}
+
+func TestReplayMatchesBodyIgnoringKeyOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := `{"interactions": [
+		{"request": {"method": "POST", "url": "https://api.example.com/v1/chat", "body": {"model": "m", "messages": [1, 2]}},
+		 "response": {"status": 429, "headers": {"Retry-After": "3"}, "body": "slow down"}}
+	]}`
+	if err := os.WriteFile(path, []byte(cassette), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	resp, err := rec.Client().Post("https://api.example.com/v1/chat", "application/json", strings.NewReader(`{"messages":[1,2],"model":"m"}`))
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "3" || string(body) != "slow down" {
+		t.Fatalf("unexpected response %d %v %q", resp.StatusCode, resp.Header, body)
+	}
+
+	rec, _ = New(path, ModeReplay, nil)
+	_, err = rec.Client().Post("https://api.example.com/v1/chat", "application/json", strings.NewReader(`{"messages":[1],"model":"m"}`))
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("expected ErrNoInteraction for a different body, got %v", err)
+	}
+}
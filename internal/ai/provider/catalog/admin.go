@@ -0,0 +1,210 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+var (
+	// ErrInvalidEntry is returned when a catalog entry fails validation.
+	ErrInvalidEntry = errors.New("catalog: invalid entry")
+	// ErrNotFound is returned when no catalog entry has the requested ID.
+	ErrNotFound = errors.New("catalog: entry not found")
+)
+
+var entryIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+var fieldTypes = map[string]bool{
+	"text": true, "password": true, "url": true, "number": true, "select": true, "textarea": true,
+}
+
+// AdminStore exposes the catalog queries used by platform operators.
+type AdminStore interface {
+	ListAllAIProviderCatalogEntries(ctx context.Context) ([]database.AiProviderCatalog, error)
+	GetAIProviderCatalogEntry(ctx context.Context, id string) (database.AiProviderCatalog, error)
+	UpsertAIProviderCatalogEntry(ctx context.Context, arg database.UpsertAIProviderCatalogEntryParams) (database.AiProviderCatalog, error)
+	DeleteAIProviderCatalogEntry(ctx context.Context, id string) (int64, error)
+}
+
+// AdminEntry is a catalog entry as platform operators see it, including disabled entries.
+type AdminEntry struct {
+	Entry
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Admin edits the provider catalog. Every write invalidates the Loader so this instance
+// serves the change immediately; other instances pick it up when their cache expires.
+type Admin struct {
+	store  AdminStore
+	loader *Loader
+}
+
+// NewAdmin constructs an Admin. loader may be nil when no Loader caches the catalog.
+func NewAdmin(store AdminStore, loader *Loader) *Admin {
+	return &Admin{store: store, loader: loader}
+}
+
+// List returns every catalog entry, enabled or not, ordered by ID.
+func (a *Admin) List(ctx context.Context) ([]AdminEntry, error) {
+	rows, err := a.store.ListAllAIProviderCatalogEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AdminEntry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := mapAdminRow(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// Get returns a single catalog entry.
+func (a *Admin) Get(ctx context.Context, id string) (AdminEntry, error) {
+	row, err := a.store.GetAIProviderCatalogEntry(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AdminEntry{}, ErrNotFound
+	}
+	if err != nil {
+		return AdminEntry{}, err
+	}
+	return mapAdminRow(row)
+}
+
+// Save validates entry and creates or replaces the catalog row with its ID.
+func (a *Admin) Save(ctx context.Context, entry AdminEntry) (AdminEntry, error) {
+	entry, err := normalizeEntry(entry)
+	if err != nil {
+		return AdminEntry{}, err
+	}
+
+	fields, err := json.Marshal(entry.Fields)
+	if err != nil {
+		return AdminEntry{}, err
+	}
+	windows := entry.ContextWindows
+	if windows == nil {
+		windows = map[string]int{}
+	}
+	windowsJSON, err := json.Marshal(windows)
+	if err != nil {
+		return AdminEntry{}, err
+	}
+
+	row, err := a.store.UpsertAIProviderCatalogEntry(ctx, database.UpsertAIProviderCatalogEntryParams{
+		ID:               entry.ID,
+		Label:            entry.Label,
+		IconUrl:          nullString(entry.IconURL),
+		Description:      nullString(entry.Description),
+		DocumentationUrl: nullString(entry.DocumentationURL),
+		Capabilities:     entry.Capabilities,
+		Models:           entry.Models,
+		Fields:           fields,
+		Enabled:          entry.Enabled,
+		ContextWindows:   windowsJSON,
+	})
+	if err != nil {
+		return AdminEntry{}, err
+	}
+	a.loader.Invalidate()
+	return mapAdminRow(row)
+}
+
+// Delete removes the catalog entry. Stored credentials for the provider are kept, but the
+// provider disappears from settings and chat until the entry is recreated.
+func (a *Admin) Delete(ctx context.Context, id string) error {
+	affected, err := a.store.DeleteAIProviderCatalogEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	a.loader.Invalidate()
+	return nil
+}
+
+func mapAdminRow(row database.AiProviderCatalog) (AdminEntry, error) {
+	entry, err := mapRow(row)
+	if err != nil {
+		return AdminEntry{}, err
+	}
+	return AdminEntry{Entry: entry, Enabled: row.Enabled, UpdatedAt: row.UpdatedAt}, nil
+}
+
+func normalizeEntry(entry AdminEntry) (AdminEntry, error) {
+	entry.ID = strings.TrimSpace(entry.ID)
+	entry.Label = strings.TrimSpace(entry.Label)
+	entry.IconURL = strings.TrimSpace(entry.IconURL)
+	entry.Description = strings.TrimSpace(entry.Description)
+	entry.DocumentationURL = strings.TrimSpace(entry.DocumentationURL)
+
+	if !entryIDPattern.MatchString(entry.ID) {
+		return AdminEntry{}, fmt.Errorf("%w: id must be lowercase letters, digits, '-' or '_'", ErrInvalidEntry)
+	}
+	if entry.Label == "" {
+		return AdminEntry{}, fmt.Errorf("%w: label is required", ErrInvalidEntry)
+	}
+	entry.Capabilities = uniqueStrings(entry.Capabilities)
+	entry.Models = uniqueStrings(entry.Models)
+
+	seen := make(map[string]bool, len(entry.Fields))
+	fields := make([]Field, 0, len(entry.Fields))
+	for _, field := range entry.Fields {
+		field.ID = strings.TrimSpace(field.ID)
+		field.Label = strings.TrimSpace(field.Label)
+		field.Type = strings.TrimSpace(field.Type)
+		if field.Type == "" {
+			field.Type = "text"
+		}
+		switch {
+		case field.ID == "" || field.Label == "":
+			return AdminEntry{}, fmt.Errorf("%w: fields need an id and label", ErrInvalidEntry)
+		case seen[field.ID]:
+			return AdminEntry{}, fmt.Errorf("%w: duplicate field %q", ErrInvalidEntry, field.ID)
+		case !fieldTypes[field.Type]:
+			return AdminEntry{}, fmt.Errorf("%w: field %q has unsupported type %q", ErrInvalidEntry, field.ID, field.Type)
+		}
+		seen[field.ID] = true
+		field.Options = uniqueStrings(field.Options)
+		fields = append(fields, field)
+	}
+	entry.Fields = fields
+
+	for model, window := range entry.ContextWindows {
+		if strings.TrimSpace(model) == "" || window <= 0 {
+			return AdminEntry{}, fmt.Errorf("%w: context windows need a model and a positive size", ErrInvalidEntry)
+		}
+	}
+	return entry, nil
+}
+
+// uniqueStrings trims values and drops blanks and repeats, keeping the first occurrence.
+func uniqueStrings(values []string) []string {
+	out := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		out = append(out, value)
+	}
+	return out
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
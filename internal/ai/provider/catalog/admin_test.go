@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+type memoryStore struct {
+	rows  map[string]database.AiProviderCatalog
+	lists int
+}
+
+func (s *memoryStore) ListAIProviderCatalogEntries(ctx context.Context) ([]database.AiProviderCatalog, error) {
+	s.lists++
+	all, _ := s.ListAllAIProviderCatalogEntries(ctx)
+	enabled := all[:0]
+	for _, row := range all {
+		if row.Enabled {
+			enabled = append(enabled, row)
+		}
+	}
+	return enabled, nil
+}
+
+func (s *memoryStore) ListAllAIProviderCatalogEntries(context.Context) ([]database.AiProviderCatalog, error) {
+	rows := make([]database.AiProviderCatalog, 0, len(s.rows))
+	for _, row := range s.rows {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	return rows, nil
+}
+
+func (s *memoryStore) GetAIProviderCatalogEntry(_ context.Context, id string) (database.AiProviderCatalog, error) {
+	row, ok := s.rows[id]
+	if !ok {
+		return database.AiProviderCatalog{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (s *memoryStore) UpsertAIProviderCatalogEntry(_ context.Context, arg database.UpsertAIProviderCatalogEntryParams) (database.AiProviderCatalog, error) {
+	row := database.AiProviderCatalog{
+		ID:               arg.ID,
+		Label:            arg.Label,
+		IconUrl:          arg.IconUrl,
+		Description:      arg.Description,
+		DocumentationUrl: arg.DocumentationUrl,
+		Capabilities:     arg.Capabilities,
+		Models:           arg.Models,
+		Fields:           arg.Fields,
+		Enabled:          arg.Enabled,
+		UpdatedAt:        time.Now(),
+		ContextWindows:   arg.ContextWindows,
+	}
+	s.rows[arg.ID] = row
+	return row, nil
+}
+
+func (s *memoryStore) DeleteAIProviderCatalogEntry(_ context.Context, id string) (int64, error) {
+	if _, ok := s.rows[id]; !ok {
+		return 0, nil
+	}
+	delete(s.rows, id)
+	return 1, nil
+}
+
+func TestAdminSaveNormalizesAndInvalidatesLoader(t *testing.T) {
+	store := &memoryStore{rows: map[string]database.AiProviderCatalog{}}
+	loader := NewLoader(store, time.Hour)
+	admin := NewAdmin(store, loader)
+	ctx := context.Background()
+
+	if _, err := admin.Save(ctx, AdminEntry{Entry: Entry{ID: "mistral", Label: "Mistral"}, Enabled: true}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if entries := loader.Entries(ctx); len(entries) != 1 || entries[0].ID != "mistral" {
+		t.Fatalf("expected mistral entry, got %+v", entries)
+	}
+
+	saved, err := admin.Save(ctx, AdminEntry{
+		Entry: Entry{
+			ID:     "mistral",
+			Label:  " Mistral AI ",
+			Models: []string{"mistral-large", " ", "mistral-large", "mistral-small"},
+			Fields: []Field{{ID: "apiKey", Label: "API Key", Type: "password"}},
+		},
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if saved.Label != "Mistral AI" || len(saved.Models) != 2 {
+		t.Fatalf("expected trimmed label and de-duplicated models, got %+v", saved)
+	}
+	entries := loader.Entries(ctx)
+	if store.lists != 2 || len(entries) != 1 || entries[0].Label != "Mistral AI" {
+		t.Fatalf("expected loader to reload after save, lists=%d entries=%+v", store.lists, entries)
+	}
+
+	if err := admin.Delete(ctx, "mistral"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := admin.Delete(ctx, "mistral"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound on second delete, got %v", err)
+	}
+	if _, err := admin.Get(ctx, "mistral"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound from get, got %v", err)
+	}
+}
+
+func TestAdminSaveRejectsInvalidEntries(t *testing.T) {
+	admin := NewAdmin(&memoryStore{rows: map[string]database.AiProviderCatalog{}}, nil)
+	cases := map[string]AdminEntry{
+		"bad id":        {Entry: Entry{ID: "Open AI", Label: "OpenAI"}},
+		"missing label": {Entry: Entry{ID: "openai"}},
+		"dup field": {Entry: Entry{ID: "openai", Label: "OpenAI", Fields: []Field{
+			{ID: "apiKey", Label: "Key"}, {ID: "apiKey", Label: "Key again"},
+		}}},
+		"field type":     {Entry: Entry{ID: "openai", Label: "OpenAI", Fields: []Field{{ID: "x", Label: "X", Type: "file"}}}},
+		"context window": {Entry: Entry{ID: "openai", Label: "OpenAI", ContextWindows: map[string]int{"gpt": 0}}},
+	}
+	for name, entry := range cases {
+		if _, err := admin.Save(context.Background(), entry); !errors.Is(err, ErrInvalidEntry) {
+			t.Errorf("%s: expected ErrInvalidEntry, got %v", name, err)
+		}
+	}
+}
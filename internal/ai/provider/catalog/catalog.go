@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,6 +34,38 @@ type Entry struct {
 	Capabilities     []string `json:"capabilities,omitempty"`
 	Models           []string `json:"models,omitempty"`
 	Fields           []Field  `json:"fields,omitempty"`
+	// ContextWindows maps a model name or prefix to its context window in tokens.
+	ContextWindows map[string]int `json:"contextWindows,omitempty"`
+}
+
+// DefaultContextWindow is assumed when neither the model nor its provider declares a window.
+const DefaultContextWindow = 8192
+
+// ContextWindow returns the context window for model. The longest matching key wins so
+// dated snapshots ("gpt-4o-2024-08-06") inherit their family's window; an unknown or empty
+// model gets the smallest window the provider declares, erring towards sending less history.
+func (e Entry) ContextWindow(model string) int {
+	model = strings.TrimPrefix(strings.TrimSpace(model), "models/")
+	best, bestLen, smallest := 0, -1, 0
+	for key, window := range e.ContextWindows {
+		if window <= 0 {
+			continue
+		}
+		if smallest == 0 || window < smallest {
+			smallest = window
+		}
+		if model != "" && strings.HasPrefix(model, key) && len(key) > bestLen {
+			best, bestLen = window, len(key)
+		}
+	}
+	switch {
+	case best > 0:
+		return best
+	case smallest > 0:
+		return smallest
+	default:
+		return DefaultContextWindow
+	}
 }
 
 var defaultEntries = []Entry{
@@ -48,6 +81,8 @@ var defaultEntries = []Entry{
 			{ID: "apiKey", Label: "API Key", Type: "password", Required: true, Sensitive: true, Placeholder: "sk-..."},
 			{ID: "baseUrl", Label: "Base URL", Type: "url", Placeholder: "https://api.openai.com/v1"},
 			{ID: "model", Label: "Default Model", Type: "text", Placeholder: "gpt-4o-mini"},
+			{ID: "organization", Label: "Organization ID", Type: "text", Placeholder: "org-...", Description: "Optional; sent as the OpenAI-Organization header."},
+			{ID: "project", Label: "Project ID", Type: "text", Placeholder: "proj_...", Description: "Optional; sent as the OpenAI-Project header."},
 		},
 	},
 }
@@ -122,6 +157,17 @@ func (l *Loader) Entries(ctx context.Context) []Entry {
 	return copyEntries(entries)
 }
 
+// Invalidate drops the cached entries so the next Entries call reloads from the store.
+func (l *Loader) Invalidate() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.entries = nil
+	l.expires = time.Time{}
+	l.mu.Unlock()
+}
+
 func loadEntries(ctx context.Context, store Store) ([]Entry, error) {
 	rows, err := store.ListAIProviderCatalogEntries(ctx)
 	if err != nil {
@@ -147,6 +193,12 @@ func mapRow(row database.AiProviderCatalog) (Entry, error) {
 			return Entry{}, err
 		}
 	}
+	var windows map[string]int
+	if len(row.ContextWindows) > 0 {
+		if err := json.Unmarshal(row.ContextWindows, &windows); err != nil {
+			return Entry{}, err
+		}
+	}
 
 	entry := Entry{
 		ID:               row.ID,
@@ -157,6 +209,7 @@ func mapRow(row database.AiProviderCatalog) (Entry, error) {
 		Capabilities:     append([]string(nil), row.Capabilities...),
 		Models:           append([]string(nil), row.Models...),
 		Fields:           fields,
+		ContextWindows:   windows,
 	}
 	return entry, nil
 }
@@ -174,6 +227,12 @@ func copyEntries(src []Entry) []Entry {
 			fieldsCopy[j] = fieldCopy
 		}
 		copyEntry.Fields = fieldsCopy
+		if entry.ContextWindows != nil {
+			copyEntry.ContextWindows = make(map[string]int, len(entry.ContextWindows))
+			for model, window := range entry.ContextWindows {
+				copyEntry.ContextWindows[model] = window
+			}
+		}
 		out[i] = copyEntry
 	}
 	return out
@@ -0,0 +1,22 @@
+package catalog
+
+import "testing"
+
+func TestContextWindowMatchesLongestPrefix(t *testing.T) {
+	entry := Entry{ContextWindows: map[string]int{"gpt-4o": 128000, "gpt-3.5-turbo": 16385}}
+
+	cases := map[string]int{
+		"gpt-4o-2024-08-06": 128000,
+		"gpt-3.5-turbo":     16385,
+		"unknown":           16385,
+		"":                  16385,
+	}
+	for model, want := range cases {
+		if got := entry.ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+	if got := (Entry{}).ContextWindow("gpt-4o"); got != DefaultContextWindow {
+		t.Errorf("expected default window for entry without windows, got %d", got)
+	}
+}
@@ -0,0 +1,112 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+const roleModel = "model"
+
+// Chat replays a role-tagged transcript through generateContent. System messages and any
+// system addendum become the system instruction. This provider does not declare tools, so tool calls and results
+// recorded by other providers are replayed as text to keep the transcript intact.
+func (p *Provider) Chat(ctx context.Context, req clientpkg.ChatRequest) (clientpkg.ConversationReply, error) {
+	metadata := mergeMetadata(p.metadata, req.Metadata)
+	system, contents := buildChatContents(req.Messages)
+	if len(contents) == 0 {
+		return clientpkg.ConversationReply{}, errors.New("gemini: conversation has no messages")
+	}
+
+	payload := generateContentRequest{
+		Model:            pickModel(p.model, metadata),
+		Contents:         contents,
+		GenerationConfig: generationConfig(metadata),
+	}
+	payload.SystemInstruction = systemInstruction(system, metadata)
+
+	resp, err := p.performGenerateContent(ctx, payload)
+	if err != nil {
+		return clientpkg.ConversationReply{}, err
+	}
+
+	text := resp.FirstText()
+	if text == "" {
+		return clientpkg.ConversationReply{}, errors.New("gemini: empty response")
+	}
+
+	return clientpkg.ConversationReply{
+		Message: clientpkg.ConversationMessage{
+			Role:     roleModel,
+			Content:  text,
+			Metadata: map[string]any{"usage": resp.UsageMetadata},
+		},
+		Usage: resp.Usage(payload.Model),
+		Raw:   resp,
+	}, nil
+}
+
+// buildChatContents maps a portable transcript onto Gemini's user/model turns, merging
+// consecutive turns from the same role.
+func buildChatContents(messages []clientpkg.ConversationMessage) (string, []content) {
+	var system []string
+	out := make([]content, 0, len(messages))
+	appendText := func(role, text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Parts = append(out[n-1].Parts, part{Text: text})
+			return
+		}
+		out = append(out, content{Role: role, Parts: []part{{Text: text}}})
+	}
+
+	for _, msg := range messages {
+		switch strings.ToLower(msg.Role) {
+		case clientpkg.RoleSystem:
+			if text := strings.TrimSpace(msg.Content); text != "" {
+				system = append(system, text)
+			}
+		case clientpkg.RoleAssistant, roleModel:
+			appendText(roleModel, msg.Content)
+			for _, call := range msg.ToolCalls {
+				appendText(roleModel, fmt.Sprintf("[called tool %s with %s]", call.Name, call.Arguments))
+			}
+		case clientpkg.RoleTool:
+			name := msg.ToolName
+			if name == "" {
+				name = "tool"
+			}
+			appendText(clientpkg.RoleUser, fmt.Sprintf("[%s result] %s", name, msg.Content))
+		default:
+			appendText(clientpkg.RoleUser, msg.Content)
+		}
+	}
+	return strings.Join(system, "\n"), out
+}
+
+// conversationHandler keeps the transcript for the stateful Conversation API and
+// replays it through Chat on every turn.
+type conversationHandler struct {
+	provider *Provider
+	messages []clientpkg.ConversationMessage
+}
+
+func (h *conversationHandler) Send(ctx context.Context, msg clientpkg.ConversationMessage) (clientpkg.ConversationReply, error) {
+	if msg.Role == "" {
+		msg.Role = clientpkg.RoleUser
+	}
+	h.messages = append(h.messages, msg)
+
+	reply, err := h.provider.Chat(ctx, clientpkg.ChatRequest{Messages: h.messages, Metadata: msg.Metadata})
+	if err != nil {
+		return clientpkg.ConversationReply{}, err
+	}
+
+	h.messages = append(h.messages, reply.Message)
+	return reply, nil
+}
@@ -41,10 +41,12 @@ type Provider struct {
 
 type Option func(*Provider)
 
-// Factory constructs a Gemini provider compatible with the AI client.
+// Factory constructs a Gemini provider compatible with the AI client. A model or base URL
+// saved with the credential takes precedence over cfg.
 func Factory(cfg Config) clientpkg.ProviderFactory {
 	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
-		if strings.TrimSpace(init.APIKey) == "" {
+		credential := init.Credential
+		if strings.TrimSpace(credential.Secret) == "" {
 			return nil, ErrMissingAPIKey
 		}
 
@@ -58,24 +60,30 @@ func Factory(cfg Config) clientpkg.ProviderFactory {
 			logger = clientpkg.NewNoopLogger()
 		}
 
-		model := cfg.Model
+		model := credential.Model()
+		if model == "" {
+			model = cfg.Model
+		}
 		if model == "" {
 			model = "gemini-pro"
 		}
 
-		baseURL := cfg.BaseURL
+		baseURL := credential.BaseURL()
+		if baseURL == "" {
+			baseURL = cfg.BaseURL
+		}
 		if baseURL == "" {
 			baseURL = defaultBaseURL
 		}
 
-		metadata := cloneMetadata(init.Metadata)
+		metadata := cloneMetadata(credential.Metadata)
 
 		return &Provider{
 			httpClient: httpClient,
 			executor:   init.Executor,
 			logger:     logger,
 			config:     cfg,
-			apiKey:     init.APIKey,
+			apiKey:     credential.Secret,
 			model:      model,
 			baseURL:    baseURL,
 			metadata:   metadata,
@@ -85,6 +93,9 @@ func Factory(cfg Config) clientpkg.ProviderFactory {
 
 func (p *Provider) Name() string { return "gemini" }
 
+// DefaultModel reports the model used when a request does not name one.
+func (p *Provider) DefaultModel() string { return p.model }
+
 func (p *Provider) Completion(ctx context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error) {
 	// Use generateContent endpoint.
 	prompt := strings.TrimSpace(req.Prompt)
@@ -92,15 +103,18 @@ func (p *Provider) Completion(ctx context.Context, req clientpkg.CompletionReque
 		return clientpkg.CompletionResponse{}, errors.New("gemini: prompt is required")
 	}
 
+	metadata := mergeMetadata(p.metadata, req.Metadata)
 	payload := generateContentRequest{
-		Model: pickModel(p.model, mergeMetadata(p.metadata, req.Metadata)),
+		Model: pickModel(p.model, metadata),
 		Contents: []content{
 			{
 				Role:  "user",
 				Parts: []part{{Text: prompt}},
 			},
 		},
+		GenerationConfig: generationConfig(metadata),
 	}
+	payload.SystemInstruction = systemInstruction("", metadata)
 
 	resp, err := p.performGenerateContent(ctx, payload)
 	if err != nil {
@@ -112,11 +126,34 @@ func (p *Provider) Completion(ctx context.Context, req clientpkg.CompletionReque
 		return clientpkg.CompletionResponse{}, errors.New("gemini: empty response")
 	}
 
-	return clientpkg.CompletionResponse{Text: text, Raw: resp}, nil
+	return clientpkg.CompletionResponse{Text: text, Usage: resp.Usage(payload.Model), Raw: resp}, nil
+}
+
+// generationConfig maps an OpenAI-style response_format onto Gemini's JSON mode and passes
+// through a temperature. The schema itself is not forwarded because Gemini accepts only an
+// OpenAPI subset of it; callers describe the shape in the prompt as well.
+func generationConfig(metadata map[string]any) any {
+	config := map[string]any{}
+	if format, ok := metadata["response_format"].(map[string]any); ok {
+		switch format["type"] {
+		case "json_object", "json_schema":
+			config["responseMimeType"] = "application/json"
+		}
+	}
+	switch temperature := metadata["temperature"].(type) {
+	case float64:
+		config["temperature"] = temperature
+	case int:
+		config["temperature"] = float64(temperature)
+	}
+	if len(config) == 0 {
+		return nil
+	}
+	return config
 }
 
 func (p *Provider) Conversation(ctx context.Context) clientpkg.ConversationHandler {
-	return &conversationHandler{provider: p, messages: make([]content, 0)}
+	return &conversationHandler{provider: p}
 }
 
 func (p *Provider) Documents(ctx context.Context) clientpkg.DocumentHandler {
@@ -124,10 +161,11 @@ func (p *Provider) Documents(ctx context.Context) clientpkg.DocumentHandler {
 }
 
 type generateContentRequest struct {
-	Model            string    `json:"model"`
-	Contents         []content `json:"contents"`
-	SafetySettings   any       `json:"safetySettings,omitempty"`
-	GenerationConfig any       `json:"generationConfig,omitempty"`
+	Model             string    `json:"model"`
+	SystemInstruction *content  `json:"systemInstruction,omitempty"`
+	Contents          []content `json:"contents"`
+	SafetySettings    any       `json:"safetySettings,omitempty"`
+	GenerationConfig  any       `json:"generationConfig,omitempty"`
 }
 
 type content struct {
@@ -140,7 +178,28 @@ type part struct {
 }
 
 type generateContentResponse struct {
-	Candidates []candidate `json:"candidates"`
+	Candidates    []candidate   `json:"candidates"`
+	UsageMetadata usageMetadata `json:"usageMetadata"`
+	ModelVersion  string        `json:"modelVersion,omitempty"`
+}
+
+type usageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// Usage converts the response's usage metadata to the portable form.
+func (r generateContentResponse) Usage(requestedModel string) clientpkg.Usage {
+	model := r.ModelVersion
+	if model == "" {
+		model = requestedModel
+	}
+	return clientpkg.Usage{
+		Model:            model,
+		PromptTokens:     r.UsageMetadata.PromptTokenCount,
+		CompletionTokens: r.UsageMetadata.CandidatesTokenCount,
+	}
 }
 
 type candidate struct {
@@ -181,7 +240,7 @@ func (p *Provider) performGenerateContent(ctx context.Context, payload generateC
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		data, _ := io.ReadAll(resp.Body)
-		return generateContentResponse{}, fmt.Errorf("gemini: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		return generateContentResponse{}, clientpkg.NewStatusError("gemini", resp, data)
 	}
 
 	var out generateContentResponse
@@ -189,14 +248,69 @@ func (p *Provider) performGenerateContent(ctx context.Context, payload generateC
 		return generateContentResponse{}, err
 	}
 
-	p.logger.Info(ctx, "gemini: generate content", map[string]any{
-		"model":   payload.Model,
-		"latency": time.Since(start).String(),
-	})
+	p.logger.Info(ctx, "gemini: generate content", "model", payload.Model, "latency", time.Since(start).String())
 
 	return out, nil
 }
 
+// Ping confirms the API accepts the key by listing a single model (models.list). The key
+// travels in a header so it stays out of error messages that quote the URL.
+func (p *Provider) Ping(ctx context.Context) error {
+	endpoint := strings.TrimRight(p.baseURL, "/") + "/models?pageSize=1"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return clientpkg.NewStatusError("gemini", resp, data)
+	}
+	return nil
+}
+
+// systemInstruction joins the transcript's system text with any system_addendum from the
+// request metadata, returning nil when there is neither.
+func systemInstruction(system string, metadata map[string]any) *content {
+	parts := make([]string, 0, 2)
+	if strings.TrimSpace(system) != "" {
+		parts = append(parts, system)
+	}
+	if addendum := extractSystemAddendum(metadata); strings.TrimSpace(addendum) != "" {
+		parts = append(parts, addendum)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return &content{Parts: []part{{Text: strings.Join(parts, "\n")}}}
+}
+
+func extractSystemAddendum(metadata map[string]any) string {
+	switch v := metadata["system_addendum"].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, "\n")
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return ""
+	}
+}
+
 func pickModel(defaultModel string, metadata map[string]any) string {
 	if metadata == nil {
 		return defaultModel
@@ -228,37 +342,3 @@ func cloneMetadata(meta map[string]any) map[string]any {
 	}
 	return out
 }
-
-// conversationHandler maintains conversational history for Gemini.
-type conversationHandler struct {
-	provider *Provider
-	messages []content
-}
-
-func (h *conversationHandler) Send(ctx context.Context, msg clientpkg.ConversationMessage) (clientpkg.ConversationReply, error) {
-	userParts := []part{{Text: msg.Content}}
-	h.messages = append(h.messages, content{Role: "user", Parts: userParts})
-
-	payload := generateContentRequest{
-		Model:    pickModel(h.provider.model, mergeMetadata(h.provider.metadata, msg.Metadata)),
-		Contents: append([]content{}, h.messages...),
-	}
-
-	resp, err := h.provider.performGenerateContent(ctx, payload)
-	if err != nil {
-		return clientpkg.ConversationReply{}, err
-	}
-
-	text := resp.FirstText()
-	if text == "" {
-		return clientpkg.ConversationReply{}, errors.New("gemini: empty response")
-	}
-
-	replyContent := content{Role: "model", Parts: []part{{Text: text}}}
-	h.messages = append(h.messages, replyContent)
-
-	return clientpkg.ConversationReply{
-		Message: clientpkg.ConversationMessage{Role: "model", Content: text},
-		Raw:     resp,
-	}, nil
-}
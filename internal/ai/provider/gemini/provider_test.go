@@ -0,0 +1,260 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/provider/cassette"
+)
+
+const testAPIKey = "gemini-test-key"
+
+// newCassetteProvider serves the provider's HTTP traffic from testdata/<name>.json. Set
+// AI_CASSETTE_RECORD=1 and GEMINI_API_KEY to re-record a cassette against the live API.
+func newCassetteProvider(t *testing.T, name string, cfg Config, init clientpkg.ProviderInit) *Provider {
+	t.Helper()
+	mode := cassette.ModeFromEnv()
+	apiKey := testAPIKey
+	if mode == cassette.ModeRecord {
+		if apiKey = os.Getenv("GEMINI_API_KEY"); apiKey == "" {
+			t.Skip("GEMINI_API_KEY is required to record cassettes")
+		}
+	}
+
+	rec, err := cassette.New(filepath.Join("testdata", name+".json"), mode, nil)
+	if err != nil {
+		t.Fatalf("open cassette: %v", err)
+	}
+	rec.Redact(apiKey)
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("save cassette: %v", err)
+		}
+		if remaining := rec.Remaining(); remaining > 0 && !t.Failed() {
+			t.Errorf("%d cassette interactions were not replayed", remaining)
+		}
+	})
+
+	init.Credential.Secret = apiKey
+	init.HTTPClient = rec.Client()
+	provider, err := Factory(cfg)(init)
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	return provider.(*Provider)
+}
+
+func TestFactoryRequiresAPIKey(t *testing.T) {
+	if _, err := Factory(Config{})(clientpkg.ProviderInit{Credential: clientpkg.Credential{Secret: "  "}}); !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("expected ErrMissingAPIKey, got %v", err)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	provider := newCassetteProvider(t, "completion", Config{Model: "gemini-1.5-flash"}, clientpkg.ProviderInit{})
+
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "  What does ASC 606 cover?  "})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if !strings.Contains(resp.Text, "contracts with customers") {
+		t.Fatalf("unexpected text %q", resp.Text)
+	}
+	want := clientpkg.Usage{Model: "gemini-1.5-flash-002", PromptTokens: 8, CompletionTokens: 31}
+	if resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
+
+func TestCompletionRequestsJSONMode(t *testing.T) {
+	provider := newCassetteProvider(t, "completion_json", Config{}, clientpkg.ProviderInit{})
+
+	// The cassette only matches when the model override and responseMimeType are sent.
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{
+		Prompt: `List the five steps of ASC 606 as {"steps": [...]}.`,
+		Metadata: map[string]any{
+			"model":           "gemini-1.5-pro",
+			"response_format": map[string]any{"type": "json_schema", "json_schema": map[string]any{"name": "steps"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if !strings.HasPrefix(resp.Text, `{"steps"`) {
+		t.Fatalf("expected JSON reply, got %q", resp.Text)
+	}
+}
+
+func TestCompletionRequiresPrompt(t *testing.T) {
+	provider, err := Factory(Config{})(clientpkg.ProviderInit{Credential: clientpkg.Credential{Secret: testAPIKey}})
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	if _, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: " "}); err == nil {
+		t.Fatal("expected error for blank prompt")
+	}
+}
+
+func TestConversationCarriesHistory(t *testing.T) {
+	provider := newCassetteProvider(t, "conversation", Config{}, clientpkg.ProviderInit{})
+	ctx := context.Background()
+	convo := provider.Conversation(ctx)
+
+	if _, err := convo.Send(ctx, clientpkg.ConversationMessage{Content: "We sell annual SaaS subscriptions billed upfront."}); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	// The cassette only matches when the second request replays the first exchange as a
+	// model turn.
+	reply, err := convo.Send(ctx, clientpkg.ConversationMessage{Content: "When do we recognize the revenue?"})
+	if err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+	if reply.Message.Role != roleModel || !strings.Contains(reply.Message.Content, "ratably") {
+		t.Fatalf("unexpected reply %+v", reply.Message)
+	}
+}
+
+func TestChatFlattensToolTurns(t *testing.T) {
+	provider := newCassetteProvider(t, "chat_tool_transcript", Config{}, clientpkg.ProviderInit{})
+
+	// Gemini does not declare tools, so a transcript recorded with another provider's tool
+	// loop is sent as text: system messages become the system instruction, the tool call is
+	// narrated in the model turn, and the result is merged into the following user turn.
+	reply, err := provider.Chat(context.Background(), clientpkg.ChatRequest{Messages: []clientpkg.ConversationMessage{
+		{Role: clientpkg.RoleSystem, Content: "You are a revenue accountant."},
+		{Role: clientpkg.RoleSystem, Content: "Cite ASC paragraphs."},
+		{Role: clientpkg.RoleUser, Content: "Search the guidance for licenses."},
+		{Role: clientpkg.RoleAssistant, ToolCalls: []clientpkg.ToolCall{{ID: "call_1", Name: "guidance_search", Arguments: `{"query":"licenses"}`}}},
+		{Role: clientpkg.RoleTool, ToolCallID: "call_1", ToolName: "guidance_search", Content: `{"results":["ASC 606-10-55-54"]}`},
+		{Role: clientpkg.RoleUser, Content: "Summarize what you found."},
+	}})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if !strings.Contains(reply.Message.Content, "606-10-55-54") || len(reply.Turns) != 0 {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	cases := []struct {
+		cassette   string
+		status     int
+		temporary  bool
+		retryAfter time.Duration
+		body       string
+		emptyReply bool
+	}{
+		{cassette: "error_rate_limited", status: 429, temporary: true, retryAfter: 30 * time.Second, body: "RESOURCE_EXHAUSTED"},
+		{cassette: "error_invalid_key", status: 400, body: "API key not valid"},
+		{cassette: "error_unavailable", status: 503, temporary: true, body: "UNAVAILABLE"},
+		{cassette: "error_blocked", emptyReply: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.cassette, func(t *testing.T) {
+			provider := newCassetteProvider(t, tc.cassette, Config{}, clientpkg.ProviderInit{})
+			_, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "hi"})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tc.emptyReply {
+				if !strings.Contains(err.Error(), "empty response") {
+					t.Fatalf("expected empty response error, got %v", err)
+				}
+				return
+			}
+
+			var statusErr *clientpkg.StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("expected StatusError, got %v", err)
+			}
+			if statusErr.Provider != "gemini" || statusErr.StatusCode != tc.status || statusErr.Temporary() != tc.temporary {
+				t.Fatalf("unexpected status error %+v (temporary %v)", statusErr, statusErr.Temporary())
+			}
+			if statusErr.RetryAfter != tc.retryAfter {
+				t.Fatalf("retry after = %v, want %v", statusErr.RetryAfter, tc.retryAfter)
+			}
+			if !strings.Contains(statusErr.Body, tc.body) {
+				t.Fatalf("body %q does not mention %q", statusErr.Body, tc.body)
+			}
+			if strings.Contains(err.Error(), testAPIKey) {
+				t.Fatalf("error leaks the api key: %v", err)
+			}
+		})
+	}
+}
+
+func TestPingListsModelsAtCredentialBaseURL(t *testing.T) {
+	var gotPath, gotKey string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotKey = r.URL.Path, r.Header.Get("x-goog-api-key")
+		if r.URL.Query().Has("key") {
+			t.Errorf("api key must not be sent in the query string")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"models":[]}`))
+	}))
+	defer srv.Close()
+
+	provider, err := Factory(Config{BaseURL: "https://unused.example"})(clientpkg.ProviderInit{
+		Credential: clientpkg.Credential{Secret: testAPIKey, Metadata: map[string]any{"baseUrl": srv.URL + "/v1beta"}},
+	})
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	checker := provider.(clientpkg.StatusChecker)
+
+	if err := checker.Ping(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if gotPath != "/v1beta/models" || gotKey != testAPIKey {
+		t.Fatalf("unexpected request path=%q key=%q", gotPath, gotKey)
+	}
+
+	status = http.StatusForbidden
+	var statusErr *clientpkg.StatusError
+	if err := checker.Ping(context.Background()); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 StatusError, got %v", err)
+	}
+}
+
+func TestSystemAddendumJoinsSystemInstruction(t *testing.T) {
+	provider := newCassetteProvider(t, "system_addendum", Config{}, clientpkg.ProviderInit{})
+	ctx := context.Background()
+
+	// The cassette only matches when the system_addendum metadata is appended to the
+	// transcript's system text in systemInstruction, for Chat and Completion alike.
+	reply, err := provider.Chat(ctx, clientpkg.ChatRequest{
+		Messages: []clientpkg.ConversationMessage{
+			{Role: clientpkg.RoleSystem, Content: "You are a revenue accountant."},
+			{Role: clientpkg.RoleUser, Content: "When is a promised service distinct?"},
+		},
+		Metadata: map[string]any{"system_addendum": "Answer only from the cited guidance: [ASC 606-10-25-19] A good or service is distinct if..."},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if !strings.Contains(reply.Message.Content, "606-10-25-19") {
+		t.Fatalf("unexpected reply %+v", reply.Message)
+	}
+
+	resp, err := provider.Completion(ctx, clientpkg.CompletionRequest{
+		Prompt:   "What is a performance obligation?",
+		Metadata: map[string]any{"system_addendum": []any{"Reply in one sentence."}},
+	})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if !strings.Contains(resp.Text, "performance obligation") {
+		t.Fatalf("unexpected text %q", resp.Text)
+	}
+}
@@ -0,0 +1,88 @@
+// Package local targets self-hosted model servers (Ollama, vLLM, LM Studio, ...) that expose
+// the OpenAI chat completions API, so companies can keep contract data on-prem.
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/provider/openai"
+)
+
+// ProviderID is the catalog identifier for the self-hosted provider.
+const ProviderID = "local"
+
+const (
+	defaultBaseURL   = "http://localhost:11434/v1"
+	discoveryTimeout = 5 * time.Second
+)
+
+var ErrNoModels = errors.New("ai: local server reported no models")
+
+// Config captures optional provider settings such as base URL or model name.
+type Config struct {
+	BaseURL      string
+	Model        string
+	SystemPrompt string
+	Logger       clientpkg.Logger
+}
+
+// Factory constructs a provider for an OpenAI-compatible server. An API key is optional;
+// when neither the credential nor cfg names a model the first one advertised by /v1/models
+// is used. A base URL saved with the credential takes precedence over cfg.BaseURL.
+func Factory(cfg Config) clientpkg.ProviderFactory {
+	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
+		baseURL := init.Credential.BaseURL()
+		if baseURL == "" {
+			baseURL = cfg.BaseURL
+		}
+		if baseURL == "" {
+			baseURL = defaultBaseURL
+		}
+
+		model := init.Credential.Model()
+		if model == "" {
+			model = cfg.Model
+		}
+		if model == "" {
+			discovered, err := discoverModel(init.HTTPClient, baseURL, init.Credential.Secret)
+			if err != nil {
+				return nil, err
+			}
+			model = discovered
+		}
+
+		return openai.CompatibleFactory(ProviderID, openai.Config{
+			BaseURL:      baseURL,
+			Model:        model,
+			SystemPrompt: cfg.SystemPrompt,
+			Logger:       cfg.Logger,
+		})(init)
+	}
+}
+
+// Models lists the models served at baseURL, defaulting to a local Ollama instance.
+func Models(ctx context.Context, httpClient *http.Client, baseURL, apiKey string) ([]string, error) {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return openai.ListModels(ctx, httpClient, baseURL, apiKey)
+}
+
+func discoverModel(httpClient *http.Client, baseURL, apiKey string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	models, err := Models(ctx, httpClient, baseURL, apiKey)
+	if err != nil {
+		return "", fmt.Errorf("ai: local model discovery failed: %w", err)
+	}
+	if len(models) == 0 {
+		return "", ErrNoModels
+	}
+	return models[0], nil
+}
@@ -0,0 +1,90 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+func newServer(t *testing.T, models []string) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		data := make([]map[string]any, 0, len(models))
+		for _, id := range models {
+			data = append(data, map[string]any{"id": id, "object": "model"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data})
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("expected no authorization header, got %q", auth)
+		}
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, payload)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]any{"role": "assistant", "content": "on-prem reply"},
+				"finish_reason": "stop",
+			}},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestFactoryWithoutAPIKeyDiscoversModel(t *testing.T) {
+	srv, requests := newServer(t, []string{"qwen2.5", "llama3.1"})
+
+	provider, err := Factory(Config{BaseURL: srv.URL + "/v1"})(clientpkg.ProviderInit{})
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	if provider.Name() != ProviderID {
+		t.Fatalf("unexpected provider name %q", provider.Name())
+	}
+
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if resp.Text != "on-prem reply" {
+		t.Fatalf("unexpected text %q", resp.Text)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("expected 1 chat request, got %d", len(*requests))
+	}
+	if model := (*requests)[0]["model"]; model != "llama3.1" {
+		t.Fatalf("expected first sorted model to be used, got %v", model)
+	}
+
+	lister, ok := provider.(clientpkg.ModelLister)
+	if !ok {
+		t.Fatal("expected provider to support model discovery")
+	}
+	models, err := lister.Models(context.Background())
+	if err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("unexpected models %v", models)
+	}
+}
+
+func TestFactoryFailsWhenNoModelsAvailable(t *testing.T) {
+	srv, _ := newServer(t, nil)
+
+	if _, err := Factory(Config{BaseURL: srv.URL + "/v1"})(clientpkg.ProviderInit{}); err != ErrNoModels {
+		t.Fatalf("expected ErrNoModels, got %v", err)
+	}
+}
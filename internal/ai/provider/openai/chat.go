@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"context"
+	"strings"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+)
+
+// Chat replays a role-tagged transcript, executing requested tools until the model answers.
+func (p *Provider) Chat(ctx context.Context, req clientpkg.ChatRequest) (clientpkg.ConversationReply, error) {
+	metadata := mergeMetadata(p.metadata, sanitizeMetadata(req.Metadata))
+	history := buildChatMessages(p.systemPrompt, metadata, req.Messages)
+	start := len(history)
+
+	resp, assistant, err := p.exchange(ctx, &history, metadata)
+	if err != nil {
+		return clientpkg.ConversationReply{}, err
+	}
+
+	// exchange appends every generated message; the last one is the final answer.
+	generated := history[start : len(history)-1]
+	toolNames := make(map[string]string)
+	turns := make([]clientpkg.ConversationMessage, 0, len(generated))
+	for _, msg := range generated {
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+		turns = append(turns, fromChatMessage(msg, toolNames))
+	}
+
+	message := fromChatMessage(assistant, toolNames)
+	message.Metadata = map[string]any{"finish_reason": resp.Choices[0].FinishReason, "usage": resp.Usage}
+
+	return clientpkg.ConversationReply{
+		Message: message,
+		Turns:   turns,
+		Usage:   resp.Usage.toClient(resp.Model, pickModel(p.model, metadata)),
+		Raw:     resp,
+	}, nil
+}
+
+// buildChatMessages converts a portable transcript into chat completion messages,
+// prefixed by the configured system prompt and any system addendum.
+func buildChatMessages(systemPrompt string, metadata map[string]any, messages []clientpkg.ConversationMessage) []chatMessage {
+	out := make([]chatMessage, 0, len(messages)+2)
+	if systemPrompt != "" {
+		out = append(out, chatMessage{Role: clientpkg.RoleSystem, Content: systemPrompt})
+	}
+	if addendum := extractSystemAddendum(metadata); addendum != "" {
+		out = append(out, chatMessage{Role: clientpkg.RoleSystem, Content: addendum})
+	}
+	for _, msg := range messages {
+		out = append(out, toChatMessage(msg))
+	}
+	return out
+}
+
+func toChatMessage(msg clientpkg.ConversationMessage) chatMessage {
+	role := strings.ToLower(strings.TrimSpace(msg.Role))
+	switch role {
+	case "", clientpkg.RoleUser:
+		role = clientpkg.RoleUser
+	case "model":
+		role = clientpkg.RoleAssistant
+	}
+
+	out := chatMessage{Role: role, Content: msg.Content}
+	switch role {
+	case clientpkg.RoleAssistant:
+		for _, call := range msg.ToolCalls {
+			arguments := call.Arguments
+			if arguments == "" {
+				arguments = "{}"
+			}
+			out.ToolCalls = append(out.ToolCalls, toolCall{
+				ID:       call.ID,
+				Type:     "function",
+				Function: toolFunction{Name: call.Name, Arguments: arguments},
+			})
+		}
+	case clientpkg.RoleTool:
+		out.ToolCallID = msg.ToolCallID
+	}
+	return out
+}
+
+func fromChatMessage(msg chatMessage, toolNames map[string]string) clientpkg.ConversationMessage {
+	role := msg.Role
+	if role == "" {
+		role = clientpkg.RoleAssistant
+	}
+	out := clientpkg.ConversationMessage{Role: role, Content: msg.Content}
+	for _, call := range msg.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, clientpkg.ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	if role == clientpkg.RoleTool {
+		out.ToolCallID = msg.ToolCallID
+		out.ToolName = toolNames[msg.ToolCallID]
+	}
+	return out
+}
+
+// conversationHandler keeps the transcript for the stateful Conversation API and
+// replays it through Chat on every turn.
+type conversationHandler struct {
+	provider *Provider
+	messages []clientpkg.ConversationMessage
+}
+
+func (h *conversationHandler) Send(ctx context.Context, msg clientpkg.ConversationMessage) (clientpkg.ConversationReply, error) {
+	if msg.Role == "" {
+		msg.Role = clientpkg.RoleUser
+	}
+	h.messages = append(h.messages, msg)
+
+	reply, err := h.provider.Chat(ctx, clientpkg.ChatRequest{Messages: h.messages, Metadata: msg.Metadata})
+	if err != nil {
+		return clientpkg.ConversationReply{}, err
+	}
+
+	h.messages = append(h.messages, reply.Turns...)
+	h.messages = append(h.messages, reply.Message)
+	return reply, nil
+}
@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type modelListResponse struct {
+	Object string `json:"object"`
+	Data   []struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by,omitempty"`
+	} `json:"data"`
+}
+
+// Models lists the model identifiers advertised by the provider's /models endpoint.
+func (p *Provider) Models(ctx context.Context) ([]string, error) {
+	return listModels(ctx, p.httpClient, p.baseURL, p.authorize)
+}
+
+// Ping confirms the API accepts the key by listing models.
+func (p *Provider) Ping(ctx context.Context) error {
+	_, err := listModels(ctx, p.httpClient, p.baseURL, p.authorize)
+	return err
+}
+
+// ListModels queries an OpenAI-compatible /models endpoint and returns the model IDs in
+// sorted order. The API key is optional so the helper also works against local servers.
+func ListModels(ctx context.Context, httpClient *http.Client, baseURL, apiKey string) ([]string, error) {
+	return listModels(ctx, httpClient, baseURL, func(req *http.Request) {
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+	})
+}
+
+func listModels(ctx context.Context, httpClient *http.Client, baseURL string, authorize func(*http.Request)) ([]string, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	endpoint := strings.TrimRight(baseURL, "/") + modelsPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	authorize(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("models: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var out modelListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(out.Data))
+	for _, item := range out.Data {
+		if id := strings.TrimSpace(item.ID); id != "" {
+			models = append(models, id)
+		}
+	}
+	sort.Strings(models)
+	return models, nil
+}
@@ -38,58 +38,88 @@ type Provider struct {
 	logger       clientpkg.Logger
 	config       Config
 	apiKey       string
+	organization string
+	project      string
 	model        string
 	baseURL      string
 	metadata     map[string]any
 	systemPrompt string
 }
 
-// Factory constructs an OpenAI provider compatible with the AI client.
+// Factory constructs an OpenAI provider compatible with the AI client. A model, base URL,
+// organization or project saved with the credential takes precedence over cfg, which is
+// how companies point at Azure-style deployments.
 func Factory(cfg Config) clientpkg.ProviderFactory {
 	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
-		if init.APIKey == "" {
+		if init.Credential.Secret == "" {
 			return nil, ErrMissingAPIKey
 		}
+		return newProvider("openai", cfg, init), nil
+	}
+}
 
-		httpClient := init.HTTPClient
-		if httpClient == nil {
-			httpClient = http.DefaultClient
+// CompatibleFactory constructs a provider for servers exposing the OpenAI chat completions
+// API, such as Ollama or vLLM. The API key is optional and only sent when present.
+func CompatibleFactory(name string, cfg Config) clientpkg.ProviderFactory {
+	return func(init clientpkg.ProviderInit) (clientpkg.Provider, error) {
+		if cfg.BaseURL == "" && init.Credential.BaseURL() == "" {
+			return nil, fmt.Errorf("ai: %s base url not configured", name)
 		}
+		return newProvider(name, cfg, init), nil
+	}
+}
 
-		logger := cfg.Logger
-		if logger == nil {
-			logger = clientpkg.NewNoopLogger()
-		}
+func newProvider(name string, cfg Config, init clientpkg.ProviderInit) *Provider {
+	httpClient := init.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
 
-		model := cfg.Model
-		if model == "" {
-			model = "gpt-4o-mini"
-		}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = clientpkg.NewNoopLogger()
+	}
 
-		baseURL := cfg.BaseURL
-		if baseURL == "" {
-			baseURL = defaultBaseURL
-		}
+	credential := init.Credential
+	model := credential.Model()
+	if model == "" {
+		model = cfg.Model
+	}
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
 
-		metadata := sanitizeMetadata(cloneSchema(init.Metadata))
-
-		return &Provider{
-			name:         "openai",
-			httpClient:   httpClient,
-			executor:     init.Executor,
-			logger:       logger,
-			config:       cfg,
-			apiKey:       init.APIKey,
-			model:        model,
-			baseURL:      baseURL,
-			metadata:     metadata,
-			systemPrompt: cfg.SystemPrompt,
-		}, nil
+	baseURL := credential.BaseURL()
+	if baseURL == "" {
+		baseURL = cfg.BaseURL
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	metadata := sanitizeMetadata(cloneSchema(credential.Metadata))
+
+	return &Provider{
+		name:         name,
+		httpClient:   httpClient,
+		executor:     init.Executor,
+		logger:       logger,
+		config:       cfg,
+		apiKey:       credential.Secret,
+		organization: credential.Organization(),
+		project:      credential.Project(),
+		model:        model,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		metadata:     metadata,
+		systemPrompt: cfg.SystemPrompt,
 	}
 }
 
 func (p *Provider) Name() string { return p.name }
 
+// DefaultModel reports the model used when a request does not name one.
+func (p *Provider) DefaultModel() string { return p.model }
+
 func (p *Provider) Completion(ctx context.Context, req clientpkg.CompletionRequest) (clientpkg.CompletionResponse, error) {
 	metadata := mergeMetadata(p.metadata, sanitizeMetadata(req.Metadata))
 	messages := buildCompletionMessages(p.systemPrompt, metadata, req.Prompt)
@@ -113,19 +143,34 @@ func (p *Provider) Completion(ctx context.Context, req clientpkg.CompletionReque
 	}
 
 	return clientpkg.CompletionResponse{
-		Text: choice.Message.Content,
-		Raw:  resp,
+		Text:  choice.Message.Content,
+		Usage: resp.Usage.toClient(resp.Model, chatReq.Model),
+		Raw:   resp,
 	}, nil
 }
 
 func (p *Provider) Conversation(context.Context) clientpkg.ConversationHandler {
-	return &conversationHandler{provider: p, messages: make([]chatMessage, 0)}
+	return &conversationHandler{provider: p}
 }
 
 func (p *Provider) Documents(context.Context) clientpkg.DocumentHandler {
 	return nil
 }
 
+// authorize sets the API key and, when the credential names them, the organization and
+// project headers.
+func (p *Provider) authorize(req *http.Request) {
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	if p.organization != "" {
+		req.Header.Set("OpenAI-Organization", p.organization)
+	}
+	if p.project != "" {
+		req.Header.Set("OpenAI-Project", p.project)
+	}
+}
+
 func (p *Provider) performChat(ctx context.Context, payload chatCompletionRequest) (chatCompletionResponse, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -139,7 +184,7 @@ func (p *Provider) performChat(ctx context.Context, payload chatCompletionReques
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	p.authorize(req)
 
 	started := time.Now()
 	resp, err := p.httpClient.Do(req)
@@ -150,7 +195,7 @@ func (p *Provider) performChat(ctx context.Context, payload chatCompletionReques
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		data, _ := io.ReadAll(resp.Body)
-		return chatCompletionResponse{}, fmt.Errorf("openai: unexpected status %d: %s", resp.StatusCode, string(data))
+		return chatCompletionResponse{}, clientpkg.NewStatusError(p.name, resp, data)
 	}
 
 	var out chatCompletionResponse
@@ -158,10 +203,7 @@ func (p *Provider) performChat(ctx context.Context, payload chatCompletionReques
 		return chatCompletionResponse{}, err
 	}
 
-	p.logger.Info(ctx, "openai: chat completion", map[string]any{
-		"model":   payload.Model,
-		"latency": time.Since(started).String(),
-	})
+	p.logger.Info(ctx, "openai: chat completion", "model", payload.Model, "latency", time.Since(started).String())
 
 	return out, nil
 }
@@ -340,49 +382,11 @@ func intPointer(value any) *int {
 	return nil
 }
 
-type conversationHandler struct {
-	provider *Provider
-	messages []chatMessage
-}
-
-func (h *conversationHandler) Send(ctx context.Context, msg clientpkg.ConversationMessage) (clientpkg.ConversationReply, error) {
-	metadata := mergeMetadata(h.provider.metadata, sanitizeMetadata(msg.Metadata))
-
-	if len(h.messages) == 0 {
-		if sp := h.provider.systemPrompt; sp != "" {
-			h.messages = append(h.messages, chatMessage{Role: "system", Content: sp})
-		}
-		if addendum := extractSystemAddendum(metadata); addendum != "" {
-			h.messages = append(h.messages, chatMessage{Role: "system", Content: addendum})
-		}
-	}
-
-	role := msg.Role
-	if role == "" {
-		role = "user"
-	}
-	h.messages = append(h.messages, chatMessage{Role: role, Content: msg.Content})
-
-	resp, assistant, err := h.provider.exchange(ctx, &h.messages, metadata)
-	if err != nil {
-		return clientpkg.ConversationReply{}, err
-	}
-
-	reply := clientpkg.ConversationReply{
-		Message: clientpkg.ConversationMessage{
-			Role:     assistant.Role,
-			Content:  assistant.Content,
-			Metadata: map[string]any{"finish_reason": resp.Choices[0].FinishReason, "usage": resp.Usage},
-		},
-		Raw: resp,
-	}
-	return reply, nil
-}
-
 func (p *Provider) exchange(ctx context.Context, history *[]chatMessage, metadata map[string]any) (chatCompletionResponse, chatMessage, error) {
 	const maxToolIterations = 3
 	var lastResp chatCompletionResponse
 	var assistant chatMessage
+	var total usage
 
 	for i := 0; i < maxToolIterations; i++ {
 		chatReq := chatCompletionRequest{
@@ -397,6 +401,8 @@ func (p *Provider) exchange(ctx context.Context, history *[]chatMessage, metadat
 		if err != nil {
 			return chatCompletionResponse{}, chatMessage{}, err
 		}
+		total = total.add(resp.Usage)
+		resp.Usage = total
 		lastResp = resp
 
 		choice, err := firstChoice(resp)
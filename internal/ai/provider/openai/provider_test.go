@@ -0,0 +1,288 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/provider/cassette"
+	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
+)
+
+const testAPIKey = "sk-test"
+
+// newCassetteProvider serves the provider's HTTP traffic from testdata/<name>.json. Set
+// AI_CASSETTE_RECORD=1 and OPENAI_API_KEY to re-record a cassette against the live API.
+func newCassetteProvider(t *testing.T, name string, cfg Config, init clientpkg.ProviderInit) *Provider {
+	t.Helper()
+	mode := cassette.ModeFromEnv()
+	apiKey := testAPIKey
+	if mode == cassette.ModeRecord {
+		if apiKey = os.Getenv("OPENAI_API_KEY"); apiKey == "" {
+			t.Skip("OPENAI_API_KEY is required to record cassettes")
+		}
+	}
+
+	rec, err := cassette.New(filepath.Join("testdata", name+".json"), mode, nil)
+	if err != nil {
+		t.Fatalf("open cassette: %v", err)
+	}
+	rec.Redact(apiKey)
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("save cassette: %v", err)
+		}
+		if remaining := rec.Remaining(); remaining > 0 && !t.Failed() {
+			t.Errorf("%d cassette interactions were not replayed", remaining)
+		}
+	})
+
+	init.Credential.Secret = apiKey
+	init.HTTPClient = rec.Client()
+	provider, err := Factory(cfg)(init)
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	return provider.(*Provider)
+}
+
+type echoTool struct{}
+
+func (echoTool) Name() string    { return "echo" }
+func (echoTool) Summary() string { return "Echo the input" }
+func (echoTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"value": map[string]any{"type": "string"}},
+	}
+}
+func (echoTool) NewHandler() tool.Handler { return echoHandler{} }
+
+type echoHandler struct{}
+
+func (echoHandler) Invoke(_ context.Context, input map[string]any) (tool.Result, error) {
+	return tool.Result{Output: map[string]any{"echo": input["value"]}}, nil
+}
+
+func echoExecutor() *tool.Executor {
+	registry := tool.NewRegistry()
+	registry.Register(echoTool{})
+	return tool.NewExecutor(registry, nil)
+}
+
+func TestFactoryRequiresAPIKey(t *testing.T) {
+	if _, err := Factory(Config{})(clientpkg.ProviderInit{}); !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("expected ErrMissingAPIKey, got %v", err)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	provider := newCassetteProvider(t, "completion", Config{SystemPrompt: "You are a revenue accountant."}, clientpkg.ProviderInit{})
+
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{
+		Prompt: "What does ASC 606 cover?",
+		Metadata: map[string]any{
+			"system_addendum": "Answer in one sentence.",
+			"temperature":     0.2,
+			"max_tokens":      64,
+			"system":          "ignored",
+		},
+	})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if !strings.Contains(resp.Text, "contracts with customers") {
+		t.Fatalf("unexpected text %q", resp.Text)
+	}
+	want := clientpkg.Usage{Model: "gpt-4o-mini-2024-07-18", PromptTokens: 34, CompletionTokens: 27}
+	if resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
+
+func TestCompletionHonorsModelAndResponseFormat(t *testing.T) {
+	provider := newCassetteProvider(t, "completion_json", Config{}, clientpkg.ProviderInit{
+		Credential: clientpkg.Credential{Metadata: map[string]any{"model": "gpt-4o"}},
+	})
+
+	resp, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{
+		Prompt:   `List the five steps of ASC 606 as {"steps": [...]}.`,
+		Metadata: map[string]any{"response_format": map[string]any{"type": "json_object"}},
+	})
+	if err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+	if !strings.HasPrefix(resp.Text, `{"steps"`) {
+		t.Fatalf("expected JSON reply, got %q", resp.Text)
+	}
+	if resp.Usage.Model != "gpt-4o-2024-08-06" {
+		t.Fatalf("unexpected model %q", resp.Usage.Model)
+	}
+}
+
+func TestConversationCarriesHistory(t *testing.T) {
+	provider := newCassetteProvider(t, "conversation", Config{}, clientpkg.ProviderInit{})
+	ctx := context.Background()
+	convo := provider.Conversation(ctx)
+
+	first, err := convo.Send(ctx, clientpkg.ConversationMessage{Content: "We sell annual SaaS subscriptions billed upfront."})
+	if err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	if first.Message.Role != clientpkg.RoleAssistant {
+		t.Fatalf("unexpected role %q", first.Message.Role)
+	}
+
+	// The cassette only matches when the second request replays the first exchange.
+	second, err := convo.Send(ctx, clientpkg.ConversationMessage{Content: "When do we recognize the revenue?"})
+	if err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+	if !strings.Contains(second.Message.Content, "ratably") {
+		t.Fatalf("unexpected reply %q", second.Message.Content)
+	}
+	if second.Message.Metadata["finish_reason"] != "stop" {
+		t.Fatalf("unexpected metadata %+v", second.Message.Metadata)
+	}
+}
+
+func TestChatExecutesToolCalls(t *testing.T) {
+	provider := newCassetteProvider(t, "chat_tools", Config{}, clientpkg.ProviderInit{Executor: echoExecutor()})
+
+	reply, err := provider.Chat(context.Background(), clientpkg.ChatRequest{
+		Messages: []clientpkg.ConversationMessage{{Role: clientpkg.RoleUser, Content: "Use the echo tool with value ping."}},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if reply.Message.Content != "The echo tool returned: ping." {
+		t.Fatalf("unexpected reply %q", reply.Message.Content)
+	}
+
+	wantTurns := []clientpkg.ConversationMessage{
+		{Role: clientpkg.RoleAssistant, ToolCalls: []clientpkg.ToolCall{{ID: "call_echo_1", Name: "echo", Arguments: `{"value":"ping"}`}}},
+		{Role: clientpkg.RoleTool, Content: `{"echo":"ping"}`, ToolCallID: "call_echo_1", ToolName: "echo"},
+	}
+	if !reflect.DeepEqual(reply.Turns, wantTurns) {
+		t.Fatalf("turns = %+v, want %+v", reply.Turns, wantTurns)
+	}
+
+	// Usage covers both round trips.
+	want := clientpkg.Usage{Model: "gpt-4o-mini-2024-07-18", PromptTokens: 58 + 83, CompletionTokens: 15 + 9}
+	if reply.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", reply.Usage, want)
+	}
+}
+
+func TestChatToolLoopExhausted(t *testing.T) {
+	provider := newCassetteProvider(t, "chat_tool_loop", Config{}, clientpkg.ProviderInit{Executor: echoExecutor()})
+
+	_, err := provider.Chat(context.Background(), clientpkg.ChatRequest{
+		Messages: []clientpkg.ConversationMessage{{Role: clientpkg.RoleUser, Content: "Keep calling echo."}},
+	})
+	if !errors.Is(err, ErrToolLoopExhausted) {
+		t.Fatalf("expected ErrToolLoopExhausted, got %v", err)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	cases := []struct {
+		cassette   string
+		status     int
+		temporary  bool
+		retryAfter time.Duration
+		body       string
+		sentinel   error
+	}{
+		{cassette: "error_rate_limited", status: 429, temporary: true, retryAfter: 20 * time.Second, body: "Rate limit reached"},
+		{cassette: "error_unauthorized", status: 401, body: "Incorrect API key provided"},
+		{cassette: "error_server", status: 500, temporary: true, body: "The server had an error"},
+		{cassette: "error_empty_choices", sentinel: ErrEmptyResponse},
+	}
+	for _, tc := range cases {
+		t.Run(tc.cassette, func(t *testing.T) {
+			provider := newCassetteProvider(t, tc.cassette, Config{}, clientpkg.ProviderInit{})
+			_, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "hi"})
+			if tc.sentinel != nil {
+				if !errors.Is(err, tc.sentinel) {
+					t.Fatalf("expected %v, got %v", tc.sentinel, err)
+				}
+				return
+			}
+
+			var statusErr *clientpkg.StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("expected StatusError, got %v", err)
+			}
+			if statusErr.Provider != "openai" || statusErr.StatusCode != tc.status || statusErr.Temporary() != tc.temporary {
+				t.Fatalf("unexpected status error %+v (temporary %v)", statusErr, statusErr.Temporary())
+			}
+			if statusErr.RetryAfter != tc.retryAfter {
+				t.Fatalf("retry after = %v, want %v", statusErr.RetryAfter, tc.retryAfter)
+			}
+			if !strings.Contains(statusErr.Body, tc.body) {
+				t.Fatalf("body %q does not mention %q", statusErr.Body, tc.body)
+			}
+		})
+	}
+}
+
+func TestModels(t *testing.T) {
+	provider := newCassetteProvider(t, "models", Config{}, clientpkg.ProviderInit{})
+
+	models, err := provider.Models(context.Background())
+	if err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	want := []string{"gpt-4o", "gpt-4o-mini", "o3-mini"}
+	if !reflect.DeepEqual(models, want) {
+		t.Fatalf("models = %v, want %v", models, want)
+	}
+}
+
+func TestFactoryAppliesCredentialOverrides(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"acme-gpt-4o","choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	provider, err := Factory(Config{BaseURL: "https://unused.example/v1", Model: "gpt-4o-mini"})(clientpkg.ProviderInit{
+		Credential: clientpkg.Credential{Secret: testAPIKey, Metadata: map[string]any{
+			"base_url":     srv.URL + "/openai/deployments/acme",
+			"model":        "acme-gpt-4o",
+			"organization": "org-acme",
+			"project":      "proj-revenue",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	if _, err := provider.Completion(context.Background(), clientpkg.CompletionRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("completion: %v", err)
+	}
+
+	if got.URL.Path != "/openai/deployments/acme"+chatCompletionsPath {
+		t.Fatalf("path = %q, want the credential base URL", got.URL.Path)
+	}
+	if got.Header.Get("OpenAI-Organization") != "org-acme" || got.Header.Get("OpenAI-Project") != "proj-revenue" {
+		t.Fatalf("organization/project headers missing: %v", got.Header)
+	}
+	if !strings.Contains(body, `"model":"acme-gpt-4o"`) {
+		t.Fatalf("request did not use the credential model: %s", body)
+	}
+}
@@ -3,12 +3,14 @@ package openai
 import (
 	"encoding/json"
 
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
 	"github.com/JonMunkholm/RevProject1/internal/ai/tool"
 )
 
 const (
 	defaultBaseURL      = "https://api.openai.com/v1"
 	chatCompletionsPath = "/chat/completions"
+	modelsPath          = "/models"
 )
 
 type chatMessage struct {
@@ -66,6 +68,22 @@ type usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+func (u usage) add(other usage) usage {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	return u
+}
+
+// toClient converts usage to the portable form, preferring the model the provider reported.
+func (u usage) toClient(reportedModel, requestedModel string) clientpkg.Usage {
+	model := reportedModel
+	if model == "" {
+		model = requestedModel
+	}
+	return clientpkg.Usage{Model: model, PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
 type toolDefinition struct {
 	Type     string             `json:"type"`
 	Function functionDefinition `json:"function"`
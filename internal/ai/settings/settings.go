@@ -0,0 +1,386 @@
+// Package settings stores company-wide AI policy such as the provider fallback chain,
+// monthly spend budget, credential rotation policy and model allowlist.
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+// Store exposes the subset of database.Queries needed for company AI settings.
+type Store interface {
+	GetAICompanySettings(ctx context.Context, companyID uuid.UUID) (database.AiCompanySetting, error)
+	UpsertAICompanyFallbackProviders(ctx context.Context, arg database.UpsertAICompanyFallbackProvidersParams) (database.AiCompanySetting, error)
+	UpsertAICompanyBudget(ctx context.Context, arg database.UpsertAICompanyBudgetParams) (database.AiCompanySetting, error)
+	UpsertAICompanyCredentialPolicy(ctx context.Context, arg database.UpsertAICompanyCredentialPolicyParams) (database.AiCompanySetting, error)
+	UpsertAICompanyModelAllowlist(ctx context.Context, arg database.UpsertAICompanyModelAllowlistParams) (database.AiCompanySetting, error)
+}
+
+// DefaultBudgetWarningPercent is the share of the monthly budget at which users are warned.
+const DefaultBudgetWarningPercent = 80
+
+// ErrInvalidBudget is returned when a budget limit or warning threshold is out of range.
+var ErrInvalidBudget = errors.New("settings: invalid budget")
+
+// Budget caps a company's monthly AI spend. A zero MonthlyLimitMicros means no limit.
+type Budget struct {
+	MonthlyLimitMicros int64
+	WarningPercent     int
+}
+
+// DefaultCredentialWarningDays is how far ahead of an expiry or rotation date credentials
+// are flagged.
+const DefaultCredentialWarningDays = 14
+
+// ErrInvalidCredentialPolicy is returned when rotation or warning days are out of range.
+var ErrInvalidCredentialPolicy = errors.New("settings: invalid credential policy")
+
+// CredentialPolicy decides when stored provider credentials are due for rotation. A zero
+// RotationDays means credentials never go stale.
+type CredentialPolicy struct {
+	RotationDays int
+	WarningDays  int
+}
+
+// ErrInvalidAllowlist is returned when a model allowlist would block every provider.
+var ErrInvalidAllowlist = errors.New("settings: invalid model allowlist")
+
+// Allowlist restricts which providers and models a company may use. A nil Providers map
+// leaves the company unrestricted; an empty model list allows every model of that provider.
+type Allowlist struct {
+	Providers map[string][]string `json:"providers"`
+}
+
+// Limited reports whether the budget enforces a spend cap.
+func (b Budget) Limited() bool {
+	return b.MonthlyLimitMicros > 0
+}
+
+// Service reads and writes company AI settings.
+type Service struct {
+	store Store
+}
+
+// New constructs a settings Service backed by store.
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// FallbackProviders returns the ordered providers to try when a company's primary provider fails.
+// Companies without saved settings have an empty chain.
+func (s *Service) FallbackProviders(ctx context.Context, companyID uuid.UUID) ([]string, error) {
+	if s == nil || s.store == nil {
+		return nil, nil
+	}
+	row, err := s.store.GetAICompanySettings(ctx, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), row.FallbackProviders...), nil
+}
+
+// SetFallbackProviders replaces the company's fallback chain, dropping blanks and duplicates.
+func (s *Service) SetFallbackProviders(ctx context.Context, companyID uuid.UUID, providers []string) ([]string, error) {
+	if s == nil || s.store == nil {
+		return nil, errors.New("settings: store not configured")
+	}
+	row, err := s.store.UpsertAICompanyFallbackProviders(ctx, database.UpsertAICompanyFallbackProvidersParams{
+		CompanyID:         companyID,
+		FallbackProviders: NormalizeProviders(providers),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), row.FallbackProviders...), nil
+}
+
+// NormalizeProviders trims provider IDs and removes blanks and duplicates, preserving order.
+func NormalizeProviders(providers []string) []string {
+	out := make([]string, 0, len(providers))
+	seen := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		id := strings.TrimSpace(provider)
+		if id == "" {
+			continue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+// Budget returns the company's monthly AI budget. Companies without saved settings are unlimited.
+func (s *Service) Budget(ctx context.Context, companyID uuid.UUID) (Budget, error) {
+	if s == nil || s.store == nil {
+		return Budget{WarningPercent: DefaultBudgetWarningPercent}, nil
+	}
+	row, err := s.store.GetAICompanySettings(ctx, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Budget{WarningPercent: DefaultBudgetWarningPercent}, nil
+	}
+	if err != nil {
+		return Budget{}, err
+	}
+	return budgetFromRow(row), nil
+}
+
+// SetBudget replaces the company's monthly budget. A zero limit removes the cap; a zero
+// warning percent falls back to DefaultBudgetWarningPercent.
+func (s *Service) SetBudget(ctx context.Context, companyID uuid.UUID, budget Budget) (Budget, error) {
+	if s == nil || s.store == nil {
+		return Budget{}, errors.New("settings: store not configured")
+	}
+	if budget.WarningPercent == 0 {
+		budget.WarningPercent = DefaultBudgetWarningPercent
+	}
+	if budget.MonthlyLimitMicros < 0 || budget.WarningPercent < 1 || budget.WarningPercent > 100 {
+		return Budget{}, ErrInvalidBudget
+	}
+
+	row, err := s.store.UpsertAICompanyBudget(ctx, database.UpsertAICompanyBudgetParams{
+		CompanyID:              companyID,
+		MonthlyBudgetUsdMicros: sql.NullInt64{Int64: budget.MonthlyLimitMicros, Valid: budget.Limited()},
+		BudgetWarningPercent:   int32(budget.WarningPercent),
+	})
+	if err != nil {
+		return Budget{}, err
+	}
+	return budgetFromRow(row), nil
+}
+
+func budgetFromRow(row database.AiCompanySetting) Budget {
+	budget := Budget{WarningPercent: int(row.BudgetWarningPercent)}
+	if row.MonthlyBudgetUsdMicros.Valid {
+		budget.MonthlyLimitMicros = row.MonthlyBudgetUsdMicros.Int64
+	}
+	if budget.WarningPercent == 0 {
+		budget.WarningPercent = DefaultBudgetWarningPercent
+	}
+	return budget
+}
+
+// CredentialPolicy returns the company's credential rotation policy. Companies without
+// saved settings have no rotation requirement.
+func (s *Service) CredentialPolicy(ctx context.Context, companyID uuid.UUID) (CredentialPolicy, error) {
+	if s == nil || s.store == nil {
+		return CredentialPolicy{WarningDays: DefaultCredentialWarningDays}, nil
+	}
+	row, err := s.store.GetAICompanySettings(ctx, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CredentialPolicy{WarningDays: DefaultCredentialWarningDays}, nil
+	}
+	if err != nil {
+		return CredentialPolicy{}, err
+	}
+	return credentialPolicyFromRow(row), nil
+}
+
+// SetCredentialPolicy replaces the company's rotation policy. A zero RotationDays removes
+// the requirement; a zero WarningDays falls back to DefaultCredentialWarningDays.
+func (s *Service) SetCredentialPolicy(ctx context.Context, companyID uuid.UUID, policy CredentialPolicy) (CredentialPolicy, error) {
+	if s == nil || s.store == nil {
+		return CredentialPolicy{}, errors.New("settings: store not configured")
+	}
+	if policy.WarningDays == 0 {
+		policy.WarningDays = DefaultCredentialWarningDays
+	}
+	if policy.RotationDays < 0 || policy.RotationDays > 3650 || policy.WarningDays < 1 || policy.WarningDays > 365 {
+		return CredentialPolicy{}, ErrInvalidCredentialPolicy
+	}
+
+	row, err := s.store.UpsertAICompanyCredentialPolicy(ctx, database.UpsertAICompanyCredentialPolicyParams{
+		CompanyID:              companyID,
+		CredentialRotationDays: sql.NullInt32{Int32: int32(policy.RotationDays), Valid: policy.RotationDays > 0},
+		CredentialWarningDays:  int32(policy.WarningDays),
+	})
+	if err != nil {
+		return CredentialPolicy{}, err
+	}
+	return credentialPolicyFromRow(row), nil
+}
+
+func credentialPolicyFromRow(row database.AiCompanySetting) CredentialPolicy {
+	policy := CredentialPolicy{WarningDays: int(row.CredentialWarningDays)}
+	if row.CredentialRotationDays.Valid {
+		policy.RotationDays = int(row.CredentialRotationDays.Int32)
+	}
+	if policy.WarningDays == 0 {
+		policy.WarningDays = DefaultCredentialWarningDays
+	}
+	return policy
+}
+
+// Restricted reports whether the allowlist limits the company to specific providers.
+func (a Allowlist) Restricted() bool {
+	return a.Providers != nil
+}
+
+// AllowedModels reports whether provider may be used and, when it may, which models are
+// allowed. A nil model slice with ok true means any model.
+func (a Allowlist) AllowedModels(provider string) (models []string, ok bool) {
+	if !a.Restricted() {
+		return nil, true
+	}
+	models, ok = a.Providers[provider]
+	if !ok {
+		return nil, false
+	}
+	if len(models) == 0 {
+		return nil, true
+	}
+	return append([]string(nil), models...), true
+}
+
+// AllowsModel reports whether model may be used with provider.
+func (a Allowlist) AllowsModel(provider, model string) bool {
+	models, ok := a.AllowedModels(provider)
+	if !ok {
+		return false
+	}
+	if models == nil {
+		return true
+	}
+	for _, allowed := range models {
+		if allowed == model {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderIDs returns the allowed provider IDs in sorted order.
+func (a Allowlist) ProviderIDs() []string {
+	ids := make([]string, 0, len(a.Providers))
+	for id := range a.Providers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Allowlist returns the company's provider and model allowlist. Companies without one
+// are unrestricted.
+func (s *Service) Allowlist(ctx context.Context, companyID uuid.UUID) (Allowlist, error) {
+	if s == nil || s.store == nil {
+		return Allowlist{}, nil
+	}
+	row, err := s.store.GetAICompanySettings(ctx, companyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Allowlist{}, nil
+	}
+	if err != nil {
+		return Allowlist{}, err
+	}
+	return allowlistFromRow(row)
+}
+
+// SetAllowlist replaces the company's allowlist. An allowlist with a nil Providers map
+// removes the restriction; one naming no providers is rejected because it would block all AI use.
+func (s *Service) SetAllowlist(ctx context.Context, companyID uuid.UUID, allowlist Allowlist) (Allowlist, error) {
+	if s == nil || s.store == nil {
+		return Allowlist{}, errors.New("settings: store not configured")
+	}
+
+	var raw pqtype.NullRawMessage
+	if allowlist.Restricted() {
+		normalized := make(map[string][]string, len(allowlist.Providers))
+		for provider, models := range allowlist.Providers {
+			id := strings.TrimSpace(provider)
+			if id == "" {
+				continue
+			}
+			normalized[id] = NormalizeProviders(append(normalized[id], models...))
+		}
+		if len(normalized) == 0 {
+			return Allowlist{}, ErrInvalidAllowlist
+		}
+		allowlist.Providers = normalized
+		payload, err := json.Marshal(allowlist)
+		if err != nil {
+			return Allowlist{}, err
+		}
+		raw = pqtype.NullRawMessage{RawMessage: payload, Valid: true}
+	}
+
+	row, err := s.store.UpsertAICompanyModelAllowlist(ctx, database.UpsertAICompanyModelAllowlistParams{
+		CompanyID:      companyID,
+		ModelAllowlist: raw,
+	})
+	if err != nil {
+		return Allowlist{}, err
+	}
+	return allowlistFromRow(row)
+}
+
+// AllowedModels reports whether the company may use providerID and which of its models are
+// allowed, so the Service can act as the AI client's model policy.
+func (s *Service) AllowedModels(ctx context.Context, companyID uuid.UUID, providerID string) ([]string, bool, error) {
+	allowlist, err := s.Allowlist(ctx, companyID)
+	if err != nil {
+		return nil, false, err
+	}
+	models, ok := allowlist.AllowedModels(providerID)
+	return models, ok, nil
+}
+
+func allowlistFromRow(row database.AiCompanySetting) (Allowlist, error) {
+	if !row.ModelAllowlist.Valid || len(row.ModelAllowlist.RawMessage) == 0 {
+		return Allowlist{}, nil
+	}
+	var allowlist Allowlist
+	if err := json.Unmarshal(row.ModelAllowlist.RawMessage, &allowlist); err != nil {
+		return Allowlist{}, err
+	}
+	return allowlist, nil
+}
+
+// ParseAllowlist reads the settings-form representation of an allowlist: one provider per
+// line, optionally followed by a colon and comma-separated models. Blank text means no
+// restriction.
+func ParseAllowlist(text string) (Allowlist, error) {
+	allowlist := Allowlist{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		provider, models, _ := strings.Cut(line, ":")
+		provider = strings.TrimSpace(provider)
+		if provider == "" {
+			return Allowlist{}, ErrInvalidAllowlist
+		}
+		if allowlist.Providers == nil {
+			allowlist.Providers = map[string][]string{}
+		}
+		allowlist.Providers[provider] = append(allowlist.Providers[provider], strings.Split(models, ",")...)
+	}
+	return allowlist, nil
+}
+
+// String formats the allowlist the way ParseAllowlist reads it.
+func (a Allowlist) String() string {
+	lines := make([]string, 0, len(a.Providers))
+	for _, provider := range a.ProviderIDs() {
+		if models := NormalizeProviders(a.Providers[provider]); len(models) > 0 {
+			lines = append(lines, provider+": "+strings.Join(models, ", "))
+			continue
+		}
+		lines = append(lines, provider)
+	}
+	return strings.Join(lines, "\n")
+}
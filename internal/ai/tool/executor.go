@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 )
 
 // Executor wraps a registry and provides helpers for providers to surface and execute tools.
 type Executor struct {
 	registry *Registry
 	logger   Logger
+	metrics  Metrics
 }
 
 // Logger instruments tool operations.
@@ -26,7 +28,32 @@ func NewExecutor(registry *Registry, logger Logger) *Executor {
 	if logger == nil {
 		logger = noopLogger{}
 	}
-	return &Executor{registry: registry, logger: logger}
+	return &Executor{registry: registry, logger: logger, metrics: noopMetrics{}}
+}
+
+// Metrics counts tool invocations by tool name and outcome.
+type Metrics interface {
+	ToolInvocation(name, status string, duration time.Duration)
+}
+
+// Invocation statuses, as reported to Metrics.
+const (
+	StatusOK           = "ok"
+	StatusError        = "error"
+	StatusUnregistered = "unregistered"
+	StatusNoHandler    = "no_handler"
+)
+
+// WithMetrics returns a copy of e that reports every invocation to metrics. Unknown tool
+// names requested by a model are reported as "unregistered" so they cannot grow the
+// label set.
+func (e *Executor) WithMetrics(metrics Metrics) *Executor {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	clone := *e
+	clone.metrics = metrics
+	return &clone
 }
 
 // Descriptors exposes tool metadata in a provider-agnostic format ready to be embedded into LLM payloads.
@@ -40,10 +67,12 @@ func (e *Executor) Execute(ctx context.Context, invocation Invocation) (Result,
 		return Result{}, errors.New("ai: missing tool name")
 	}
 
+	started := time.Now()
 	tool, ok := e.registry.Get(invocation.Name)
 	if !ok {
 		err := fmt.Errorf("ai: tool %q not registered", invocation.Name)
 		e.logger.Error(ctx, "ai: tool lookup failed", err, "tool", invocation.Name)
+		e.metrics.ToolInvocation(StatusUnregistered, StatusUnregistered, time.Since(started))
 		return Result{}, err
 	}
 
@@ -51,16 +80,19 @@ func (e *Executor) Execute(ctx context.Context, invocation Invocation) (Result,
 	if handler == nil {
 		err := fmt.Errorf("ai: tool %q does not provide a handler", invocation.Name)
 		e.logger.Error(ctx, "ai: tool handler missing", err, "tool", invocation.Name)
+		e.metrics.ToolInvocation(invocation.Name, StatusNoHandler, time.Since(started))
 		return Result{}, err
 	}
 
 	result, err := handler.Invoke(ctx, invocation.Input)
 	if err != nil {
 		e.logger.Error(ctx, "ai: tool invocation failed", err, "tool", invocation.Name)
+		e.metrics.ToolInvocation(invocation.Name, StatusError, time.Since(started))
 		return Result{}, err
 	}
 
 	e.logger.Info(ctx, "ai: tool invocation successful", "tool", invocation.Name)
+	e.metrics.ToolInvocation(invocation.Name, StatusOK, time.Since(started))
 	return result, nil
 }
 
@@ -68,3 +100,7 @@ type noopLogger struct{}
 
 func (noopLogger) Info(context.Context, string, ...any)         {}
 func (noopLogger) Error(context.Context, string, error, ...any) {}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ToolInvocation(string, string, time.Duration) {}
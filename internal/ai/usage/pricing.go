@@ -0,0 +1,84 @@
+package usage
+
+import (
+	"math"
+	"strings"
+)
+
+// Price is the list price of a model in USD per million tokens.
+type Price struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+type modelPrice struct {
+	prefix string
+	price  Price
+}
+
+// priceTable maps provider IDs to model prices. Models are matched by longest prefix so
+// dated snapshots (gpt-4o-2024-08-06, gemini-1.5-flash-002) share their family's price.
+// The empty prefix is the provider's fallback for models not listed here.
+var priceTable = map[string][]modelPrice{
+	"openai": {
+		{"", Price{2.50, 10.00}},
+		{"gpt-3.5-turbo", Price{0.50, 1.50}},
+		{"gpt-4o", Price{2.50, 10.00}},
+		{"gpt-4o-mini", Price{0.15, 0.60}},
+		{"gpt-4.1", Price{2.00, 8.00}},
+		{"gpt-4.1-mini", Price{0.40, 1.60}},
+		{"gpt-4.1-nano", Price{0.10, 0.40}},
+		{"o3-mini", Price{1.10, 4.40}},
+		{"o4-mini", Price{1.10, 4.40}},
+	},
+	"gemini": {
+		{"", Price{1.25, 5.00}},
+		{"gemini-1.5-flash", Price{0.075, 0.30}},
+		{"gemini-1.5-pro", Price{1.25, 5.00}},
+		{"gemini-2.0-flash", Price{0.10, 0.40}},
+		{"gemini-2.5-flash", Price{0.30, 2.50}},
+		{"gemini-2.5-pro", Price{1.25, 10.00}},
+	},
+	"anthropic": {
+		{"", Price{3.00, 15.00}},
+		{"claude-3-haiku", Price{0.25, 1.25}},
+		{"claude-3-5-haiku", Price{0.80, 4.00}},
+		{"claude-3-5-sonnet", Price{3.00, 15.00}},
+		{"claude-3-7-sonnet", Price{3.00, 15.00}},
+		{"claude-sonnet-4", Price{3.00, 15.00}},
+		{"claude-3-opus", Price{15.00, 75.00}},
+		{"claude-opus-4", Price{15.00, 75.00}},
+	},
+	// Self-hosted models carry no per-token charge.
+	"local": {
+		{"", Price{}},
+	},
+}
+
+// PriceFor returns the list price for a provider's model. Unknown providers are free so
+// usage is still recorded without inventing a cost.
+func PriceFor(providerID, model string) (Price, bool) {
+	entries, ok := priceTable[providerID]
+	if !ok {
+		return Price{}, false
+	}
+	model = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(model)), "models/")
+
+	best := -1
+	var price Price
+	for _, entry := range entries {
+		if strings.HasPrefix(model, entry.prefix) && len(entry.prefix) > best {
+			best = len(entry.prefix)
+			price = entry.price
+		}
+	}
+	return price, best >= 0
+}
+
+// CostMicros returns the cost of a call in millionths of a US dollar. A price per million
+// tokens is numerically the micro-dollar cost of a single token.
+func CostMicros(providerID, model string, promptTokens, completionTokens int) int64 {
+	price, _ := PriceFor(providerID, model)
+	cost := float64(promptTokens)*price.PromptPerMillion + float64(completionTokens)*price.CompletionPerMillion
+	return int64(math.Round(cost))
+}
@@ -0,0 +1,199 @@
+// Package usage records AI token consumption in the usage ledger and enforces per-company
+// monthly budgets.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+// Budget statuses reported by Summary.
+const (
+	StatusUnlimited = "unlimited"
+	StatusOK        = "ok"
+	StatusWarning   = "warning"
+	StatusExceeded  = "exceeded"
+)
+
+// Store exposes the subset of database.Queries needed for the usage ledger.
+type Store interface {
+	InsertAIUsageEntry(ctx context.Context, arg database.InsertAIUsageEntryParams) (database.AiUsageLedger, error)
+	SumAIUsageCostSince(ctx context.Context, arg database.SumAIUsageCostSinceParams) (int64, error)
+	ListAIUsageByModelSince(ctx context.Context, arg database.ListAIUsageByModelSinceParams) ([]database.ListAIUsageByModelSinceRow, error)
+}
+
+// BudgetSource supplies a company's monthly budget.
+type BudgetSource interface {
+	Budget(ctx context.Context, companyID uuid.UUID) (settings.Budget, error)
+}
+
+// ModelUsage aggregates a month's usage for one provider model.
+type ModelUsage struct {
+	ProviderID       string `json:"providerId"`
+	Model            string `json:"model"`
+	Requests         int64  `json:"requests"`
+	PromptTokens     int64  `json:"promptTokens"`
+	CompletionTokens int64  `json:"completionTokens"`
+	CostMicros       int64  `json:"costMicros"`
+}
+
+// Summary describes a company's spend for the current month against its budget.
+type Summary struct {
+	PeriodStart    time.Time    `json:"periodStart"`
+	SpentMicros    int64        `json:"spentMicros"`
+	LimitMicros    int64        `json:"limitMicros"`
+	WarningPercent int          `json:"warningPercent"`
+	Status         string       `json:"status"`
+	Models         []ModelUsage `json:"models"`
+}
+
+// PercentUsed returns spend as a whole percentage of the limit, or 0 when unlimited.
+func (s Summary) PercentUsed() int {
+	if s.LimitMicros <= 0 {
+		return 0
+	}
+	return int(s.SpentMicros * 100 / s.LimitMicros)
+}
+
+// Service implements client.Accountant against the usage ledger.
+type Service struct {
+	store   Store
+	budgets BudgetSource
+	now     func() time.Time
+}
+
+var _ clientpkg.Accountant = (*Service)(nil)
+
+// New constructs a usage Service.
+func New(store Store, budgets BudgetSource) *Service {
+	return &Service{store: store, budgets: budgets, now: time.Now}
+}
+
+// RecordUsage prices the call and appends it to the ledger.
+func (s *Service) RecordUsage(ctx context.Context, record clientpkg.UsageRecord) error {
+	if s == nil || s.store == nil {
+		return nil
+	}
+	_, err := s.store.InsertAIUsageEntry(ctx, database.InsertAIUsageEntryParams{
+		CompanyID:        record.CompanyID,
+		UserID:           uuid.NullUUID{UUID: record.UserID, Valid: record.UserID != uuid.Nil},
+		ProviderID:       record.Provider,
+		Model:            record.Usage.Model,
+		Feature:          record.Feature,
+		PromptTokens:     int32(record.Usage.PromptTokens),
+		CompletionTokens: int32(record.Usage.CompletionTokens),
+		CostUsdMicros:    CostMicros(record.Provider, record.Usage.Model, record.Usage.PromptTokens, record.Usage.CompletionTokens),
+	})
+	return err
+}
+
+// CheckBudget compares the month's spend with the company's budget. Spend at or above the
+// warning threshold produces a warning; spend at or above the limit blocks further calls.
+func (s *Service) CheckBudget(ctx context.Context, companyID uuid.UUID) (clientpkg.BudgetDecision, error) {
+	if s == nil || s.store == nil || s.budgets == nil {
+		return clientpkg.BudgetDecision{Allowed: true}, nil
+	}
+	budget, err := s.budgets.Budget(ctx, companyID)
+	if err != nil {
+		return clientpkg.BudgetDecision{}, err
+	}
+	if !budget.Limited() {
+		return clientpkg.BudgetDecision{Allowed: true}, nil
+	}
+
+	spent, err := s.store.SumAIUsageCostSince(ctx, database.SumAIUsageCostSinceParams{
+		CompanyID: companyID,
+		CreatedAt: monthStart(s.now()),
+	})
+	if err != nil {
+		return clientpkg.BudgetDecision{}, err
+	}
+
+	switch budgetStatus(spent, budget) {
+	case StatusExceeded:
+		return clientpkg.BudgetDecision{
+			Allowed: false,
+			Warning: fmt.Sprintf("AI spend of %s has reached the %s monthly budget", FormatUSD(spent), FormatUSD(budget.MonthlyLimitMicros)),
+		}, nil
+	case StatusWarning:
+		return clientpkg.BudgetDecision{
+			Allowed: true,
+			Warning: fmt.Sprintf("AI spend is at %d%% of the %s monthly budget", spent*100/budget.MonthlyLimitMicros, FormatUSD(budget.MonthlyLimitMicros)),
+		}, nil
+	default:
+		return clientpkg.BudgetDecision{Allowed: true}, nil
+	}
+}
+
+// Summary reports the company's spend for the current calendar month (UTC).
+func (s *Service) Summary(ctx context.Context, companyID uuid.UUID) (Summary, error) {
+	start := monthStart(s.now())
+	summary := Summary{PeriodStart: start, WarningPercent: settings.DefaultBudgetWarningPercent, Status: StatusUnlimited}
+	if s == nil || s.store == nil {
+		return summary, nil
+	}
+
+	var budget settings.Budget
+	if s.budgets != nil {
+		var err error
+		budget, err = s.budgets.Budget(ctx, companyID)
+		if err != nil {
+			return Summary{}, err
+		}
+		summary.WarningPercent = budget.WarningPercent
+		summary.LimitMicros = budget.MonthlyLimitMicros
+	}
+
+	rows, err := s.store.ListAIUsageByModelSince(ctx, database.ListAIUsageByModelSinceParams{
+		CompanyID: companyID,
+		CreatedAt: start,
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	summary.Models = make([]ModelUsage, 0, len(rows))
+	for _, row := range rows {
+		summary.SpentMicros += row.CostUsdMicros
+		summary.Models = append(summary.Models, ModelUsage{
+			ProviderID:       row.ProviderID,
+			Model:            row.Model,
+			Requests:         row.Requests,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			CostMicros:       row.CostUsdMicros,
+		})
+	}
+
+	summary.Status = budgetStatus(summary.SpentMicros, budget)
+	return summary, nil
+}
+
+func budgetStatus(spent int64, budget settings.Budget) string {
+	switch {
+	case !budget.Limited():
+		return StatusUnlimited
+	case spent >= budget.MonthlyLimitMicros:
+		return StatusExceeded
+	case spent*100 >= budget.MonthlyLimitMicros*int64(budget.WarningPercent):
+		return StatusWarning
+	default:
+		return StatusOK
+	}
+}
+
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// FormatUSD renders micro-dollars as a dollar amount with cents.
+func FormatUSD(micros int64) string {
+	return fmt.Sprintf("$%.2f", float64(micros)/1e6)
+}
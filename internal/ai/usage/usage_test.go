@@ -0,0 +1,107 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	clientpkg "github.com/JonMunkholm/RevProject1/internal/ai/client"
+	"github.com/JonMunkholm/RevProject1/internal/ai/settings"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+type fakeStore struct {
+	entries []database.InsertAIUsageEntryParams
+	spent   int64
+	since   time.Time
+}
+
+func (f *fakeStore) InsertAIUsageEntry(_ context.Context, arg database.InsertAIUsageEntryParams) (database.AiUsageLedger, error) {
+	f.entries = append(f.entries, arg)
+	return database.AiUsageLedger{}, nil
+}
+
+func (f *fakeStore) SumAIUsageCostSince(_ context.Context, arg database.SumAIUsageCostSinceParams) (int64, error) {
+	f.since = arg.CreatedAt
+	return f.spent, nil
+}
+
+func (f *fakeStore) ListAIUsageByModelSince(context.Context, database.ListAIUsageByModelSinceParams) ([]database.ListAIUsageByModelSinceRow, error) {
+	return nil, nil
+}
+
+type fixedBudget settings.Budget
+
+func (b fixedBudget) Budget(context.Context, uuid.UUID) (settings.Budget, error) {
+	return settings.Budget(b), nil
+}
+
+func TestCostMicrosUsesLongestPrefix(t *testing.T) {
+	// gpt-4o-mini must not be priced as gpt-4o.
+	if got := CostMicros("openai", "gpt-4o-mini-2024-07-18", 1_000_000, 1_000_000); got != 750_000 {
+		t.Fatalf("gpt-4o-mini cost = %d, want 750000", got)
+	}
+	if got := CostMicros("gemini", "models/gemini-1.5-flash-002", 1000, 0); got != 75 {
+		t.Fatalf("gemini flash cost = %d, want 75", got)
+	}
+	if got := CostMicros("local", "llama3", 1_000_000, 1_000_000); got != 0 {
+		t.Fatalf("local cost = %d, want 0", got)
+	}
+	if got := CostMicros("unknown", "model", 1000, 1000); got != 0 {
+		t.Fatalf("unknown provider cost = %d, want 0", got)
+	}
+}
+
+func TestCheckBudgetWarnsThenStops(t *testing.T) {
+	store := &fakeStore{}
+	svc := New(store, fixedBudget{MonthlyLimitMicros: 10_000_000, WarningPercent: 80})
+	svc.now = func() time.Time { return time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		spent   int64
+		allowed bool
+		warning bool
+	}{
+		{spent: 7_999_999, allowed: true},
+		{spent: 8_000_000, allowed: true, warning: true},
+		{spent: 10_000_000, allowed: false, warning: true},
+	}
+	for _, tc := range cases {
+		store.spent = tc.spent
+		decision, err := svc.CheckBudget(context.Background(), uuid.New())
+		if err != nil {
+			t.Fatalf("spent %d: %v", tc.spent, err)
+		}
+		if decision.Allowed != tc.allowed || (decision.Warning != "") != tc.warning {
+			t.Fatalf("spent %d: got %+v", tc.spent, decision)
+		}
+	}
+
+	if want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !store.since.Equal(want) {
+		t.Fatalf("expected spend since %v, got %v", want, store.since)
+	}
+}
+
+func TestRecordUsagePricesEntry(t *testing.T) {
+	store := &fakeStore{}
+	svc := New(store, fixedBudget{})
+
+	err := svc.RecordUsage(context.Background(), clientpkg.UsageRecord{
+		CompanyID: uuid.New(),
+		Provider:  "anthropic",
+		Feature:   "chat",
+		Usage:     clientpkg.Usage{Model: "claude-3-5-haiku-latest", PromptTokens: 1000, CompletionTokens: 500},
+	})
+	if err != nil {
+		t.Fatalf("record usage: %v", err)
+	}
+	if len(store.entries) != 1 {
+		t.Fatalf("expected one ledger entry, got %d", len(store.entries))
+	}
+	entry := store.entries[0]
+	if entry.CostUsdMicros != 2800 || entry.UserID.Valid {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+}
@@ -5,30 +5,41 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/JonMunkholm/RevProject1/internal/ai"
 	docsvr "github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/ai/logging"
 	catalogProvider "github.com/JonMunkholm/RevProject1/internal/ai/provider/catalog"
 	geminiProvider "github.com/JonMunkholm/RevProject1/internal/ai/provider/gemini"
 	openaiProvider "github.com/JonMunkholm/RevProject1/internal/ai/provider/openai"
 	"github.com/JonMunkholm/RevProject1/internal/database"
 	"github.com/JonMunkholm/RevProject1/internal/handler"
+	"github.com/JonMunkholm/RevProject1/internal/retrieval"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
 	defaultAIProvider = "openai"
 	catalogCacheTTL   = 5 * time.Minute
+	defaultUploadDir  = "data/uploads"
 )
 
 type App struct {
 	router            http.Handler
+	sqlDB             *sql.DB
 	db                *database.Queries
 	jwtSecret         string
 	port              string
+	metricsAddr       string
+	metrics           *prometheus.Registry
 	credentialStore   ai.CredentialStore
 	credentialCipher  ai.CredentialCipher
 	credentialEvents  *ai.CredentialEventStore
@@ -42,58 +53,100 @@ type App struct {
 	aiClient          *ai.Client
 	aiAPIKey          string
 	providerCatalog   *catalogProvider.Loader
+	aiSettings        *ai.CompanySettingsService
+	aiUsage           *ai.UsageService
+	retrieval         *retrieval.Service
+	grounding         *ai.GroundingService
+	promptTemplates   *ai.PromptTemplateService
+	userPreferences   *ai.UserPreferenceService
 	aiHandler         *handler.AI
+	credentialHealth  *ai.CredentialHealthChecker
+	platformOperators []uuid.UUID
 }
 
 // Define app struct and load routes
 func New() *App {
+	sqlDB := dbConnect()
 	app := &App{
-		db:        dbConnect(),
+		sqlDB:     sqlDB,
+		db:        database.New(sqlDB),
 		jwtSecret: setValEnv("JWT_SECRET"),
 		port:      setValEnv("PORT"),
+		metrics:   newMetricsRegistry(sqlDB),
 	}
+	app.metricsAddr = metricsAddr(os.Getenv("METRICS_ADDR"))
+	app.platformOperators = parsePlatformOperators(os.Getenv("PLATFORM_OPERATOR_IDS"))
 
 	app.initAI()
 
 	app.loadRoutes()
+	app.credentialHealth = app.newCredentialHealthChecker()
 
 	return app
 }
 
 func (a *App) initAI() {
-	key := setValEnv("AI_CREDENTIAL_KEY")
-	cipher, err := ai.NewAESCipherFromBase64(key)
+	cipher, err := ai.NewCredentialCipherFromEnv(os.Getenv)
 	if err != nil {
-		log.Fatalf("invalid AI_CREDENTIAL_KEY: %v", err)
+		log.Fatalf("invalid AI credential cipher: %v", err)
 	}
 
 	a.aiSystemPrompt = os.Getenv("AI_SYSTEM_PROMPT")
 
 	store := ai.NewCredentialSQLStore(a.db)
 	credentialEvents := ai.NewCredentialEventSQLStore(a.db)
-	credentialMetrics := ai.NewCredentialMetrics(nil)
-	logger := ai.NewNoopCredentialLogger()
+	var credentialMetricsOpts []ai.CredentialMetricsOption
+	if envBool("AI_METRICS_COMPANY_LABELS") {
+		credentialMetricsOpts = append(credentialMetricsOpts, ai.CredentialMetricsWithCompanyLabels())
+	}
+	credentialMetrics := ai.NewCredentialMetrics(a.metrics, credentialMetricsOpts...)
+	aiLogger := newAILogger()
+	logger := ai.NewSlogCredentialLogger(aiLogger)
 	a.credentialStore = store
 	a.credentialCipher = cipher
 	a.aiResolver = ai.NewDBCredentialResolver(store, cipher, logger)
 	a.credentialEvents = credentialEvents
 	a.credentialMetrics = credentialMetrics
 
-	clientLogger := ai.NewNoopLogger()
+	a.aiSettings = ai.NewCompanySettingsService(a.db)
+	a.aiUsage = ai.NewUsageService(a.db, a.aiSettings)
+	a.promptTemplates = ai.NewPromptTemplateService(a.db, a.sqlDB)
+	a.userPreferences = ai.NewUserPreferenceService(a.db)
+
+	clientLogger := ai.NewSlogLogger(aiLogger)
 	convStore := ai.NewConversationSQLStore(a.db)
 	a.convService = ai.NewConversationService(convStore, clientLogger)
 
 	docStore := ai.NewDocumentSQLStore(a.db)
 	a.docService = ai.NewDocumentService(docStore, clientLogger)
+	uploadDir := os.Getenv("AI_UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = defaultUploadDir
+	}
+	if blobs, err := ai.NewLocalBlobStore(uploadDir); err != nil {
+		log.Printf("AI: document uploads disabled: %v", err)
+	} else {
+		a.docService.SetFileStorage(ai.NewDocumentFileSQLStore(a.db), blobs)
+	}
 
 	a.toolAuditStore = ai.NewToolAuditSQLStore(a.db)
-	a.docWorker = docsvr.NewWorker(a.docService, nil, clientLogger, 0)
+	a.docWorker = docsvr.NewWorker(a.docService, nil, clientLogger, docsvr.WorkerConfig{
+		Concurrency: envInt("AI_DOCUMENT_WORKERS", 0),
+		Metrics:     ai.NewDocumentJobMetrics(a.metrics),
+	})
 
 	a.aiAPIKey = os.Getenv("OPENAI_API_KEY")
 	if a.aiAPIKey == "" {
 		log.Println("AI: OPENAI_API_KEY not set; expecting per-tenant credentials")
 	}
 
+	var tools []ai.Tool
+	a.retrieval = a.newRetrievalService()
+	if a.retrieval != nil {
+		a.grounding = ai.NewGroundingService(a.retrieval)
+		tools = append(tools, ai.NewGuidanceSearchTool(a.retrieval))
+	}
+
 	openAIConfig := openaiProvider.Config{
 		BaseURL:      os.Getenv("OPENAI_API_BASE"),
 		Model:        os.Getenv("OPENAI_MODEL"),
@@ -107,14 +160,38 @@ func (a *App) initAI() {
 		Logger:  clientLogger,
 	}
 
+	anthropicConfig := ai.AnthropicConfig{
+		BaseURL:      os.Getenv("ANTHROPIC_API_BASE"),
+		Model:        os.Getenv("ANTHROPIC_MODEL"),
+		SystemPrompt: a.aiSystemPrompt,
+		Logger:       clientLogger,
+	}
+
+	localConfig := ai.LocalConfig{
+		BaseURL:      os.Getenv("LOCAL_AI_BASE_URL"),
+		Model:        os.Getenv("LOCAL_AI_MODEL"),
+		SystemPrompt: a.aiSystemPrompt,
+		Logger:       clientLogger,
+	}
+
 	clientConfig := ai.Config{
 		Providers: map[string]ai.ProviderFactory{
-			defaultAIProvider: ai.NewOpenAIProviderFactory(openAIConfig),
-			"gemini":          ai.NewGeminiProviderFactory(geminiConfig),
+			defaultAIProvider:  ai.NewOpenAIProviderFactory(openAIConfig),
+			"gemini":           ai.NewGeminiProviderFactory(geminiConfig),
+			"anthropic":        ai.NewAnthropicProviderFactory(anthropicConfig),
+			ai.LocalProviderID: ai.NewLocalProviderFactory(localConfig),
 		},
 		DefaultProvider: defaultAIProvider,
 		Logger:          clientLogger,
 		Credentials:     a.aiResolver,
+		Metrics:         ai.NewProviderMetrics(a.metrics),
+		Accountant:      a.aiUsage,
+		ModelPolicy:     a.aiSettings,
+		Tools:           tools,
+		Cache: ai.ProviderCacheConfig{
+			MaxEntries: envInt("AI_PROVIDER_CACHE_SIZE", 0),
+			TTL:        envDuration("AI_PROVIDER_CACHE_TTL", 0),
+		},
 	}
 
 	client, err := ai.NewClient(clientConfig)
@@ -125,12 +202,36 @@ func (a *App) initAI() {
 
 	if a.docWorker != nil {
 		processor := docsvr.NewAIProcessor(a.aiClient, a.aiResolver, a.aiAPIKey, defaultAIProvider)
+		processor.SetFallbackSource(a.aiSettings)
+		processor.SetFileSource(a.docService)
+		processor.SetDraftWriter(&handler.ContractDrafts{DB: a.db, Conn: a.sqlDB})
+		processor.SetPromptSource(a.promptTemplates)
 		a.docWorker.SetProcessor(processor)
 	}
 
 	a.providerCatalog = catalogProvider.NewLoader(a.db, catalogCacheTTL)
 }
 
+// newRetrievalService enables ASC guidance grounding when an OpenAI key is available for
+// query embeddings; the corpus must already be ingested into asc_paragraphs.
+func (a *App) newRetrievalService() *retrieval.Service {
+	if a.aiAPIKey == "" {
+		log.Println("AI: guidance retrieval disabled; OPENAI_API_KEY is required for query embeddings")
+		return nil
+	}
+	svc, err := retrieval.NewService(retrieval.Config{
+		DB:        a.sqlDB,
+		OpenAIKey: a.aiAPIKey,
+		OpenAIURL: os.Getenv("OPENAI_API_BASE"),
+		ProjectID: os.Getenv("OPENAI_PROJECT_ID"),
+	})
+	if err != nil {
+		log.Printf("AI: guidance retrieval disabled: %v", err)
+		return nil
+	}
+	return svc
+}
+
 func (a *App) newAIHandler() *handler.AI {
 	catalogEntries := ai.ProviderCatalog()
 	if a.providerCatalog != nil {
@@ -152,20 +253,64 @@ func (a *App) newAIHandler() *handler.AI {
 		CredentialMetrics: a.credentialMetrics,
 		ProviderCatalog:   catalogEntries,
 		CatalogLoader:     a.providerCatalog,
+		CatalogAdmin:      catalogProvider.NewAdmin(a.db, a.providerCatalog),
+		Settings:          a.aiSettings,
+		Usage:             a.aiUsage,
+		Grounding:         a.grounding,
+		Prompts:           a.promptTemplates,
+		Preferences:       a.userPreferences,
+	}
+}
+
+// newCredentialHealthChecker pings stored default credentials in the background.
+// AI_CREDENTIAL_HEALTH_INTERVAL sets how often each one is checked (default 6h);
+// "off" or "0" disables the checks.
+func (a *App) newCredentialHealthChecker() *ai.CredentialHealthChecker {
+	if a.aiHandler == nil || a.credentialCipher == nil {
+		return nil
+	}
+	var interval time.Duration
+	switch raw := strings.TrimSpace(os.Getenv("AI_CREDENTIAL_HEALTH_INTERVAL")); raw {
+	case "":
+	case "off", "0":
+		log.Println("AI: credential health checks disabled")
+		return nil
+	default:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			log.Printf("AI_CREDENTIAL_HEALTH_INTERVAL must be a positive duration; using default")
+		} else {
+			interval = parsed
+		}
 	}
+	return ai.NewCredentialHealthChecker(a.db, a.credentialCipher, a.aiHandler.CheckCredential, ai.CredentialHealthConfig{
+		Interval: interval,
+		Policies: a.aiSettings,
+		Events:   a.credentialEvents,
+		Metrics:  a.credentialMetrics,
+	})
 }
 
-// Start server on port, with graceful shutdown
+// Start server on port, with graceful shutdown. Metrics are served on a separate admin
+// listener unless METRICS_ADDR is "off".
 func (a *App) Start(ctx context.Context) error {
 	server := &http.Server{
 		Addr:    a.port,
 		Handler: a.router,
 	}
+	var admin *http.Server
+	if a.metricsAddr != "" {
+		admin = metricsServer(a.metricsAddr, a.metrics)
+	}
 
 	if a.docWorker != nil && a.aiClient != nil {
 		a.docWorker.Start(ctx)
 		defer a.docWorker.Stop()
 	}
+	if a.credentialHealth != nil {
+		a.credentialHealth.Start(ctx)
+		defer a.credentialHealth.Stop()
+	}
 
 	// Run server in a goroutine so we can listen for context cancellation
 	errCh := make(chan error, 1)
@@ -176,6 +321,14 @@ func (a *App) Start(ctx context.Context) error {
 		}
 		close(errCh)
 	}()
+	if admin != nil {
+		go func() {
+			if err := admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("metrics listener stopped: %v", err)
+			}
+		}()
+		log.Printf("Serving metrics on %s/metrics", a.metricsAddr)
+	}
 
 	fmt.Println("Starting server")
 	select {
@@ -185,13 +338,19 @@ func (a *App) Start(ctx context.Context) error {
 		timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancel()
 
+		if admin != nil {
+			defer admin.Shutdown(timeout)
+		}
 		return server.Shutdown(timeout)
 	case err := <-errCh:
+		if admin != nil {
+			admin.Close()
+		}
 		return err
 	}
 }
 
-func dbConnect() *database.Queries {
+func dbConnect() *sql.DB {
 	dbURL := os.Getenv("DB_URL")
 	if dbURL == "" {
 		log.Fatal("DB_URL must be set")
@@ -207,7 +366,96 @@ func dbConnect() *database.Queries {
 		log.Fatal("Failed to ping DB:", err)
 	}
 
-	return database.New(db)
+	return db
+}
+
+// newAILogger builds the structured logger for the AI stack. AI_LOG_LEVEL sets the minimum
+// level (default info), AI_LOG_CONTENT_LEVEL the least severe level whose records keep
+// prompt content (default debug), and AI_LOG_FORMAT=json switches to JSON output.
+func newAILogger() *slog.Logger {
+	cfg := ai.LogConfig{
+		Level: logging.ParseLevel(os.Getenv("AI_LOG_LEVEL"), slog.LevelInfo),
+		JSON:  strings.EqualFold(strings.TrimSpace(os.Getenv("AI_LOG_FORMAT")), "json"),
+	}
+	if raw := os.Getenv("AI_LOG_CONTENT_LEVEL"); raw != "" {
+		cfg.ContentLevel = logging.ParseLevel(raw, slog.LevelDebug)
+	}
+	return ai.NewStructuredLogger(cfg)
+}
+
+// metricsAddr reads the admin listener address: empty uses the default, "off" disables it.
+func metricsAddr(raw string) string {
+	switch raw = strings.TrimSpace(raw); raw {
+	case "":
+		return defaultMetricsAddr
+	case "off":
+		log.Println("metrics listener disabled")
+		return ""
+	default:
+		return raw
+	}
+}
+
+// envBool reports whether an optional flag is set to a true value such as "1" or "true".
+func envBool(key string) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("%s must be a boolean; ignoring", key)
+		return false
+	}
+	return value
+}
+
+// envInt reads an optional integer setting, returning fallback when unset or invalid.
+func envInt(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("%s must be an integer; using default", key)
+		return fallback
+	}
+	return value
+}
+
+// envDuration reads an optional duration setting such as "15m", returning fallback when
+// unset or invalid.
+func envDuration(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		log.Printf("%s must be a positive duration; using default", key)
+		return fallback
+	}
+	return value
+}
+
+// parsePlatformOperators reads the comma-separated user IDs allowed to administer the
+// provider catalog. Invalid IDs are logged and skipped.
+func parsePlatformOperators(raw string) []uuid.UUID {
+	var operators []uuid.UUID
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := uuid.Parse(part)
+		if err != nil {
+			log.Printf("PLATFORM_OPERATOR_IDS: ignoring invalid user id %q", part)
+			continue
+		}
+		operators = append(operators, id)
+	}
+	return operators
 }
 
 func setValEnv(req string) string {
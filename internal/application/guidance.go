@@ -0,0 +1,51 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/app/pages"
+	"github.com/JonMunkholm/RevProject1/internal/retrieval"
+)
+
+// guidancePage shows a single ASC paragraph; chat citations link here.
+func (a *App) guidancePage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.retrieval == nil {
+			a.renderGuidanceError(w, r, http.StatusNotFound, "Guidance search is not configured.")
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "paragraphID"))
+		if err != nil {
+			a.renderGuidanceError(w, r, http.StatusNotFound, "Paragraph not found.")
+			return
+		}
+
+		paragraph, err := a.retrieval.Paragraph(r.Context(), id)
+		if err != nil {
+			status, message := http.StatusInternalServerError, "Failed to load paragraph."
+			if errors.Is(err, retrieval.ErrParagraphNotFound) {
+				status, message = http.StatusNotFound, "Paragraph not found."
+			}
+			a.renderGuidanceError(w, r, status, message)
+			return
+		}
+
+		a.render(w, r, pages.GuidanceParagraphPage(pages.GuidanceParagraphProps{
+			Reference:  paragraph.ASCReference,
+			Content:    paragraph.Content,
+			Guidance:   paragraph.Guidance,
+			SourceType: paragraph.SourceType,
+		}))
+	}
+}
+
+func (a *App) renderGuidanceError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	a.render(w, r, pages.GuidanceParagraphPage(pages.GuidanceParagraphProps{ErrorMessage: message}))
+}
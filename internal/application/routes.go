@@ -15,6 +15,8 @@ import (
 func (a *App) loadRoutes() {
 	r := chi.NewRouter()
 
+	r.Use(middleware.RequestID)
+	r.Use(requestMetrics(a.metrics))
 	r.Use(middleware.Logger)
 
 	serveAppAssets(r)
@@ -42,6 +44,7 @@ func (a *App) loadRoutes() {
 			r.Get("/products", a.dashboardPage("products"))
 			r.Route("/settings", a.loadSettingsRoutes)
 			r.Route("/chat", a.loadChatRoutes)
+			r.Get("/guidance/{paragraphID}", a.guidancePage())
 		})
 
 		r.Route("/api", func(r chi.Router) {
@@ -126,6 +129,22 @@ func (a *App) loadDashboardRoutes(r chi.Router) {
 
 func (a *App) loadReviewRoutes(r chi.Router) {
 	bindDashboardSummary(a.db, r)
+
+	if a.aiHandler == nil {
+		a.aiHandler = a.newAIHandler()
+	}
+	aiHandler := a.aiHandler
+	reviewHandler := &handler.Review{DB: a.db, AI: aiHandler}
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireCompanyRole(auth.RoleViewer))
+		r.Get("/customers", reviewHandler.SearchCustomers)
+		r.Post("/analyze", reviewHandler.Analyze)
+		r.Get("/jobs/{jobID}/report", reviewHandler.Report)
+		r.Get("/jobs", aiHandler.ReviewJobs)
+		r.Get("/jobs/{jobID}", aiHandler.ReviewJobStatus)
+		r.Post("/jobs/{jobID}/cancel", aiHandler.ReviewCancelJob)
+		r.Delete("/jobs/{jobID}", aiHandler.ReviewDeleteJob)
+	})
 }
 
 func bindDashboardSummary(db *database.Queries, r chi.Router) {
@@ -167,20 +186,57 @@ func (a *App) loadAIRoutes(r chi.Router) {
 	r.Get("/conversations", aiHandler.ListConversations)
 	r.Get("/conversations/{sessionID}/messages", aiHandler.ListConversationMessages)
 	r.Post("/conversations/{sessionID}/messages", aiHandler.AppendConversationMessage)
+	r.Put("/conversations/{sessionID}/prompt-template", aiHandler.PinConversationPromptTemplate)
 
 	r.Post("/documents/jobs", aiHandler.CreateDocumentJob)
 	r.Get("/documents/jobs", aiHandler.ListDocumentJobs)
 	r.Get("/documents/jobs/{jobID}", aiHandler.GetDocumentJob)
+	r.Post("/documents/jobs/{jobID}/cancel", aiHandler.CancelDocumentJob)
+	r.Delete("/documents/jobs/{jobID}", aiHandler.DeleteDocumentJob)
+	r.Post("/documents/files", aiHandler.UploadDocumentFiles)
+	r.Get("/documents/files", aiHandler.ListDocumentFiles)
+	r.Delete("/documents/files/{fileID}", aiHandler.DeleteDocumentFile)
 	r.Get("/providers/catalog", aiHandler.ListProviderCatalog)
 	r.Get("/providers", aiHandler.ListProviderCredentials)
 	r.Get("/providers/{providerID}/credentials", aiHandler.ListProviderCredentials)
 	r.Post("/providers", aiHandler.UpsertProviderCredential)
 	r.Post("/providers/test", aiHandler.TestProviderCredential)
 	r.Get("/providers/{providerID}/status", aiHandler.ProviderStatus)
+	r.Get("/providers/{providerID}/models", aiHandler.ListProviderModels)
 	r.Get("/providers/{providerID}/events", aiHandler.ListProviderCredentialEvents)
 	r.Post("/providers/{providerID}/credential", aiHandler.UpsertProviderCredential)
 	r.Post("/providers/{providerID}/credential/test", aiHandler.TestProviderCredential)
 	r.Delete("/credentials/{credentialID}", aiHandler.DeleteProviderCredential)
+	r.Get("/settings/fallbacks", aiHandler.GetFallbackProviders)
+	r.Put("/settings/fallbacks", aiHandler.UpdateFallbackProviders)
+	r.Put("/settings/budget", aiHandler.UpdateAIBudget)
+	r.Post("/settings/budget", aiHandler.UpdateAIBudget)
+	r.Get("/settings/credential-policy", aiHandler.GetCredentialPolicy)
+	r.Put("/settings/credential-policy", aiHandler.UpdateCredentialPolicy)
+	r.Post("/settings/credential-policy", aiHandler.UpdateCredentialPolicy)
+	r.Get("/settings/model-allowlist", aiHandler.GetModelAllowlist)
+	r.Put("/settings/model-allowlist", aiHandler.UpdateModelAllowlist)
+	r.Post("/settings/model-allowlist", aiHandler.UpdateModelAllowlist)
+	r.Get("/preferences", aiHandler.GetAIPreferences)
+	r.Put("/preferences", aiHandler.UpdateAIPreferences)
+	r.Post("/preferences", aiHandler.UpdateAIPreferences)
+	r.Delete("/preferences", aiHandler.DeleteAIPreferences)
+	r.Get("/settings/prompts", aiHandler.ListPromptTemplates)
+	r.Post("/settings/prompts", aiHandler.CreatePromptTemplate)
+	r.Put("/settings/prompts/{templateID}", aiHandler.UpdatePromptTemplate)
+	r.Post("/settings/prompts/{templateID}", aiHandler.UpdatePromptTemplate)
+	r.Delete("/settings/prompts/{templateID}", aiHandler.DeletePromptTemplate)
+	r.Post("/settings/prompts/{templateID}/default", aiHandler.SetDefaultPromptTemplate)
+	r.Get("/settings/prompts/{templateID}/versions", aiHandler.ListPromptTemplateVersions)
+	r.Get("/usage", aiHandler.GetAIUsage)
+
+	r.Route("/admin/catalog", func(r chi.Router) {
+		r.Use(auth.RequirePlatformOperator(a.platformOperators))
+		r.Get("/", aiHandler.ListCatalogEntries)
+		r.Get("/{providerID}", aiHandler.GetCatalogEntry)
+		r.Put("/{providerID}", aiHandler.SaveCatalogEntry)
+		r.Delete("/{providerID}", aiHandler.DeleteCatalogEntry)
+	})
 }
 
 func (a *App) loadChatRoutes(r chi.Router) {
@@ -3,13 +3,18 @@ package application
 import (
 	"context"
 	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 
 	"github.com/JonMunkholm/RevProject1/app/pages"
 	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
+	"github.com/JonMunkholm/RevProject1/internal/ai/usage"
 	"github.com/JonMunkholm/RevProject1/internal/auth"
 	"github.com/JonMunkholm/RevProject1/internal/contextutil"
 )
@@ -20,6 +25,8 @@ func (a *App) loadSettingsRoutes(r chi.Router) {
 	r.Get("/", a.settingsGeneralPage())
 	r.Get("/users", a.settingsUsersPage())
 	r.Get("/ai", a.settingsAIPage())
+	r.Get("/usage", a.settingsUsagePage())
+	r.Get("/prompts", a.settingsPromptsPage())
 }
 
 func (a *App) settingsGeneralPage() http.HandlerFunc {
@@ -125,8 +132,74 @@ func (a *App) settingsAIPage() http.HandlerFunc {
 	}
 }
 
+func (a *App) settingsUsagePage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := auth.SessionFromContext(r.Context())
+		if !ok {
+			auth.RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+			return
+		}
+
+		ctx := r.Context()
+		tabs := a.availableSettingsTabs(ctx, session)
+		if !tabActive(tabs, "usage") {
+			if len(tabs) == 0 {
+				auth.RespondWithError(w, http.StatusForbidden, "no accessible settings", errors.New("insufficient role"))
+				return
+			}
+			http.Redirect(w, r, tabs[0].Path, http.StatusSeeOther)
+			return
+		}
+
+		props := a.buildUsageProps(ctx, session)
+
+		if isHTMXRequest(r) {
+			if err := pages.SettingsUsageContent(props).Render(ctx, w); err != nil {
+				http.Error(w, "Failed to render", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		component := pages.SettingsUsagePage(activateSettingsTabs(tabs, "usage"), props)
+		a.render(w, r, component)
+	}
+}
+
+func (a *App) settingsPromptsPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := auth.SessionFromContext(r.Context())
+		if !ok {
+			auth.RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+			return
+		}
+
+		ctx := r.Context()
+		tabs := a.availableSettingsTabs(ctx, session)
+		if !tabActive(tabs, "prompts") {
+			if len(tabs) == 0 {
+				auth.RespondWithError(w, http.StatusForbidden, "no accessible settings", errors.New("insufficient role"))
+				return
+			}
+			http.Redirect(w, r, tabs[0].Path, http.StatusSeeOther)
+			return
+		}
+
+		props := a.buildPromptsProps(ctx, session)
+
+		if isHTMXRequest(r) {
+			if err := pages.SettingsPromptsContent(props).Render(ctx, w); err != nil {
+				http.Error(w, "Failed to render", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		component := pages.SettingsPromptsPage(activateSettingsTabs(tabs, "prompts"), props)
+		a.render(w, r, component)
+	}
+}
+
 func (a *App) availableSettingsTabs(ctx context.Context, session auth.Session) []pages.SettingsTab {
-	tabs := make([]pages.SettingsTab, 0, 3)
+	tabs := make([]pages.SettingsTab, 0, 5)
 	if contextutil.CanViewCompanySettings(ctx) {
 		tabs = append(tabs, pages.SettingsTab{ID: "general", Label: "General", Path: "/app/settings"})
 	}
@@ -136,6 +209,12 @@ func (a *App) availableSettingsTabs(ctx context.Context, session auth.Session) [
 	if contextutil.CanViewProviderCredentials(ctx) {
 		tabs = append(tabs, pages.SettingsTab{ID: "ai", Label: "AI", Path: "/app/settings/ai"})
 	}
+	if a.aiUsage != nil && contextutil.CanViewCompanySettings(ctx) {
+		tabs = append(tabs, pages.SettingsTab{ID: "usage", Label: "Usage", Path: "/app/settings/usage"})
+	}
+	if a.promptTemplates != nil && contextutil.CanViewCompanySettings(ctx) {
+		tabs = append(tabs, pages.SettingsTab{ID: "prompts", Label: "Prompts", Path: "/app/settings/prompts"})
+	}
 	return tabs
 }
 
@@ -198,6 +277,37 @@ func (a *App) buildAIProps(ctx context.Context, session auth.Session, providerID
 		CanManagePersonal:  session.Capabilities.CanManagePersonalCredentials,
 		CanViewCredentials: session.Capabilities.CanViewProviderCredentials,
 		HasProviders:       len(providers) > 0,
+		WarningDays:        ai.DefaultCredentialWarningDays,
+	}
+
+	if props.CanManageCompany {
+		policy, err := a.aiSettings.CredentialPolicy(ctx, session.CompanyID)
+		if err != nil {
+			log.Printf("settings: load credential policy: %v", err)
+		} else {
+			props.RotationDays = policy.RotationDays
+			props.WarningDays = policy.WarningDays
+		}
+		allowlist, err := a.aiSettings.Allowlist(ctx, session.CompanyID)
+		if err != nil {
+			log.Printf("settings: load model allowlist: %v", err)
+		} else {
+			props.ModelAllowlist = allowlist.String()
+		}
+	}
+
+	pref, err := a.userPreferences.Get(ctx, session.CompanyID, session.UserID)
+	if err != nil {
+		log.Printf("settings: load ai preferences: %v", err)
+	}
+	props.PreferredProviderID = pref.ProviderID
+	props.PreferredModel = pref.Model
+	props.PreferredAddendum = pref.SystemAddendum
+	if pref.Temperature != nil {
+		props.PreferredTemperature = strconv.FormatFloat(*pref.Temperature, 'f', -1, 64)
+	}
+	if props.PreferredProviderID == "" {
+		props.PreferredProviderID = defaultAIProvider
 	}
 
 	if len(providers) == 0 {
@@ -226,6 +336,107 @@ func (a *App) buildAIProps(ctx context.Context, session auth.Session, providerID
 	return props
 }
 
+func (a *App) buildUsageProps(ctx context.Context, session auth.Session) pages.SettingsUsageProps {
+	props := pages.SettingsUsageProps{CanManage: session.Capabilities.CanManageCompanyCredentials}
+
+	summary, err := a.aiUsage.Summary(ctx, session.CompanyID)
+	if err != nil {
+		log.Printf("settings: load ai usage: %v", err)
+		props.ErrorMessage = "Usage data is temporarily unavailable."
+	}
+
+	period := summary.PeriodStart
+	if period.IsZero() {
+		period = time.Now().UTC()
+	}
+	props.Period = period.Format("January 2006")
+	props.Spent = usage.FormatUSD(summary.SpentMicros)
+	props.WarningPercent = summary.WarningPercent
+	if summary.LimitMicros > 0 {
+		props.Limit = usage.FormatUSD(summary.LimitMicros)
+		props.LimitInput = strconv.FormatFloat(float64(summary.LimitMicros)/1e6, 'f', 2, 64)
+		props.PercentUsed = summary.PercentUsed()
+	}
+
+	switch summary.Status {
+	case usage.StatusExceeded:
+		props.Status = pages.SettingsStatusBadge{Status: "error", Message: "Budget exhausted"}
+	case usage.StatusWarning:
+		props.Status = pages.SettingsStatusBadge{Status: "warning", Message: "Approaching budget"}
+	case usage.StatusOK:
+		props.Status = pages.SettingsStatusBadge{Status: "ok", Message: "Within budget"}
+	default:
+		props.Status = pages.SettingsStatusBadge{Status: "ok", Message: "No limit"}
+	}
+
+	props.Models = make([]pages.SettingsUsageModel, 0, len(summary.Models))
+	for _, row := range summary.Models {
+		model := row.Model
+		if model == "" {
+			model = "—"
+		}
+		props.Models = append(props.Models, pages.SettingsUsageModel{
+			Provider:         row.ProviderID,
+			Model:            model,
+			Requests:         row.Requests,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			Cost:             usage.FormatUSD(row.CostMicros),
+		})
+	}
+	return props
+}
+
+var promptKindLabels = map[string]string{
+	prompts.KindChat:            "Chat",
+	prompts.KindDocumentSummary: "Document analysis",
+}
+
+func (a *App) buildPromptsProps(ctx context.Context, session auth.Session) pages.SettingsPromptsProps {
+	props := pages.SettingsPromptsProps{CanManage: session.Capabilities.CanManageCompanyCredentials}
+	for _, kind := range prompts.Kinds {
+		props.Kinds = append(props.Kinds, pages.SettingsPromptKind{ID: kind, Label: promptKindLabels[kind]})
+	}
+
+	templates, err := a.promptTemplates.List(ctx, session.CompanyID)
+	if err != nil {
+		log.Printf("settings: load prompt templates: %v", err)
+		props.ErrorMessage = "Prompt templates are temporarily unavailable."
+		return props
+	}
+	for _, tmpl := range templates {
+		view := pages.SettingsPromptTemplate{
+			ID:          tmpl.ID.String(),
+			Name:        tmpl.Name,
+			Kind:        tmpl.Kind,
+			KindLabel:   promptKindLabels[tmpl.Kind],
+			Description: tmpl.Description,
+			IsDefault:   tmpl.IsDefault,
+			Latest:      settingsPromptVersion(tmpl.Latest),
+		}
+		if tmpl.Latest.Version > 1 {
+			versions, err := a.promptTemplates.Versions(ctx, session.CompanyID, tmpl.ID)
+			if err != nil {
+				log.Printf("settings: load prompt template %s versions: %v", tmpl.ID, err)
+			}
+			for _, version := range versions {
+				view.History = append(view.History, settingsPromptVersion(version))
+			}
+		}
+		props.Templates = append(props.Templates, view)
+	}
+	return props
+}
+
+func settingsPromptVersion(version ai.PromptTemplateVersion) pages.SettingsPromptVersion {
+	return pages.SettingsPromptVersion{
+		ID:        version.ID.String(),
+		Version:   version.Version,
+		Body:      version.Body,
+		CreatedAt: version.CreatedAt.Local().Format("Jan 2, 2006 15:04"),
+	}
+}
+
 func isHTMXRequest(r *http.Request) bool {
 	return strings.EqualFold(r.Header.Get("HX-Request"), "true")
 }
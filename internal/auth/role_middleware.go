@@ -5,6 +5,8 @@ import (
 	"errors"
 	"log"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
 var (
@@ -49,3 +51,31 @@ func RequireCompanyRole(min Role) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+var errNotPlatformOperator = errors.New("platform operator required")
+
+// RequirePlatformOperator limits a route to the platform operators listed in operators.
+// Operators run the deployment itself (for example the shared provider catalog), so the
+// check ignores company roles entirely.
+func RequirePlatformOperator(operators []uuid.UUID) func(http.Handler) http.Handler {
+	allowed := make(map[uuid.UUID]struct{}, len(operators))
+	for _, id := range operators {
+		allowed[id] = struct{}{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, ok := SessionFromContext(r.Context())
+			if !ok {
+				log.Printf("auth: missing session for path=%s", r.URL.Path)
+				RespondWithError(w, http.StatusUnauthorized, "authentication required", errSessionMissing)
+				return
+			}
+			if _, ok := allowed[session.UserID]; !ok {
+				log.Printf("auth: platform operator denied user=%s path=%s", session.UserID, r.URL.Path)
+				RespondWithError(w, http.StatusForbidden, "insufficient permissions", errNotPlatformOperator)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -14,6 +14,71 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+const claimAIProviderCredentialsForHealthCheck = `-- name: ClaimAIProviderCredentialsForHealthCheck :many
+UPDATE ai_provider_credentials
+SET health_checked_at = now()
+WHERE id IN (
+    SELECT id
+    FROM ai_provider_credentials
+    WHERE is_default
+      AND (health_checked_at IS NULL OR health_checked_at < $1)
+    ORDER BY health_checked_at NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
+`
+
+type ClaimAIProviderCredentialsForHealthCheckParams struct {
+	CheckedBefore time.Time
+	Limit         int32
+}
+
+// Marks up to limit default credentials not checked since checked_before as checked now
+// and returns them. SKIP LOCKED lets several app instances share the work.
+func (q *Queries) ClaimAIProviderCredentialsForHealthCheck(ctx context.Context, arg ClaimAIProviderCredentialsForHealthCheckParams) ([]AiProviderCredential, error) {
+	rows, err := q.db.QueryContext(ctx, claimAIProviderCredentialsForHealthCheck, arg.CheckedBefore, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AiProviderCredential
+	for rows.Next() {
+		var i AiProviderCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompanyID,
+			&i.UserID,
+			&i.ProviderID,
+			&i.CredentialCipher,
+			&i.CredentialHash,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastUsedAt,
+			&i.RotatedAt,
+			&i.Label,
+			&i.IsDefault,
+			&i.LastTestedAt,
+			&i.Fingerprint,
+			&i.ExpiresAt,
+			&i.HealthCheckedAt,
+			&i.HealthStatus,
+			&i.HealthError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const clearDefaultAIProviderCredentials = `-- name: ClearDefaultAIProviderCredentials :exec
 UPDATE ai_provider_credentials
 SET is_default = false,
@@ -82,7 +147,7 @@ func (q *Queries) DeleteAIUserPreference(ctx context.Context, arg DeleteAIUserPr
 }
 
 const getAIProviderCredential = `-- name: GetAIProviderCredential :one
-SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint
+SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
 FROM ai_provider_credentials
 WHERE id = $1
 `
@@ -106,6 +171,10 @@ func (q *Queries) GetAIProviderCredential(ctx context.Context, id uuid.UUID) (Ai
 		&i.IsDefault,
 		&i.LastTestedAt,
 		&i.Fingerprint,
+		&i.ExpiresAt,
+		&i.HealthCheckedAt,
+		&i.HealthStatus,
+		&i.HealthError,
 	)
 	return i, err
 }
@@ -146,7 +215,9 @@ INSERT INTO ai_provider_credentials (
     metadata,
     label,
     is_default,
-    last_tested_at
+    last_tested_at,
+    id,
+    expires_at
 )
 VALUES (
     $1,
@@ -157,9 +228,11 @@ VALUES (
     COALESCE($6, '{}'::jsonb),
     $7,
     COALESCE($8, false),
-    $9
+    $9,
+    COALESCE($10::uuid, gen_random_uuid()),
+    $11
 )
-RETURNING id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint
+RETURNING id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
 `
 
 type InsertAIProviderCredentialParams struct {
@@ -172,6 +245,8 @@ type InsertAIProviderCredentialParams struct {
 	Label            sql.NullString
 	IsDefault        interface{}
 	LastTestedAt     sql.NullTime
+	ID               uuid.NullUUID
+	ExpiresAt        sql.NullTime
 }
 
 func (q *Queries) InsertAIProviderCredential(ctx context.Context, arg InsertAIProviderCredentialParams) (AiProviderCredential, error) {
@@ -185,6 +260,8 @@ func (q *Queries) InsertAIProviderCredential(ctx context.Context, arg InsertAIPr
 		arg.Label,
 		arg.IsDefault,
 		arg.LastTestedAt,
+		arg.ID,
+		arg.ExpiresAt,
 	)
 	var i AiProviderCredential
 	err := row.Scan(
@@ -203,6 +280,10 @@ func (q *Queries) InsertAIProviderCredential(ctx context.Context, arg InsertAIPr
 		&i.IsDefault,
 		&i.LastTestedAt,
 		&i.Fingerprint,
+		&i.ExpiresAt,
+		&i.HealthCheckedAt,
+		&i.HealthStatus,
+		&i.HealthError,
 	)
 	return i, err
 }
@@ -361,8 +442,64 @@ func (q *Queries) ListAIProviderCredentialEvents(ctx context.Context, arg ListAI
 	return items, nil
 }
 
+const listAIProviderCredentialsAfter = `-- name: ListAIProviderCredentialsAfter :many
+SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
+FROM ai_provider_credentials
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAIProviderCredentialsAfterParams struct {
+	After uuid.UUID
+	Limit int32
+}
+
+func (q *Queries) ListAIProviderCredentialsAfter(ctx context.Context, arg ListAIProviderCredentialsAfterParams) ([]AiProviderCredential, error) {
+	rows, err := q.db.QueryContext(ctx, listAIProviderCredentialsAfter, arg.After, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AiProviderCredential
+	for rows.Next() {
+		var i AiProviderCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.CompanyID,
+			&i.UserID,
+			&i.ProviderID,
+			&i.CredentialCipher,
+			&i.CredentialHash,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastUsedAt,
+			&i.RotatedAt,
+			&i.Label,
+			&i.IsDefault,
+			&i.LastTestedAt,
+			&i.Fingerprint,
+			&i.ExpiresAt,
+			&i.HealthCheckedAt,
+			&i.HealthStatus,
+			&i.HealthError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAIProviderCredentialsByCompany = `-- name: ListAIProviderCredentialsByCompany :many
-SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint
+SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
 FROM ai_provider_credentials
 WHERE company_id = $1
 ORDER BY provider_id, user_id, is_default DESC, updated_at DESC
@@ -400,6 +537,10 @@ func (q *Queries) ListAIProviderCredentialsByCompany(ctx context.Context, arg Li
 			&i.IsDefault,
 			&i.LastTestedAt,
 			&i.Fingerprint,
+			&i.ExpiresAt,
+			&i.HealthCheckedAt,
+			&i.HealthStatus,
+			&i.HealthError,
 		); err != nil {
 			return nil, err
 		}
@@ -415,7 +556,7 @@ func (q *Queries) ListAIProviderCredentialsByCompany(ctx context.Context, arg Li
 }
 
 const listAIProviderCredentialsByScope = `-- name: ListAIProviderCredentialsByScope :many
-SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint
+SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
 FROM ai_provider_credentials
 WHERE company_id = $1
   AND provider_id = $2
@@ -457,6 +598,10 @@ func (q *Queries) ListAIProviderCredentialsByScope(ctx context.Context, arg List
 			&i.IsDefault,
 			&i.LastTestedAt,
 			&i.Fingerprint,
+			&i.ExpiresAt,
+			&i.HealthCheckedAt,
+			&i.HealthStatus,
+			&i.HealthError,
 		); err != nil {
 			return nil, err
 		}
@@ -472,7 +617,7 @@ func (q *Queries) ListAIProviderCredentialsByScope(ctx context.Context, arg List
 }
 
 const listAIProviderCredentialsForResolver = `-- name: ListAIProviderCredentialsForResolver :many
-SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint
+SELECT id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
 FROM ai_provider_credentials
 WHERE company_id = $1
   AND provider_id = $2
@@ -517,6 +662,10 @@ func (q *Queries) ListAIProviderCredentialsForResolver(ctx context.Context, arg
 			&i.IsDefault,
 			&i.LastTestedAt,
 			&i.Fingerprint,
+			&i.ExpiresAt,
+			&i.HealthCheckedAt,
+			&i.HealthStatus,
+			&i.HealthError,
 		); err != nil {
 			return nil, err
 		}
@@ -625,6 +774,49 @@ func (q *Queries) ListAIToolInvocationsByUser(ctx context.Context, arg ListAIToo
 	return items, nil
 }
 
+const recordAIProviderCredentialHealth = `-- name: RecordAIProviderCredentialHealth :exec
+UPDATE ai_provider_credentials
+SET health_status  = $1,
+    health_error   = $2,
+    last_tested_at = now()
+WHERE id = $3
+`
+
+type RecordAIProviderCredentialHealthParams struct {
+	HealthStatus sql.NullString
+	HealthError  sql.NullString
+	ID           uuid.UUID
+}
+
+// Stores a health check outcome without touching updated_at, which orders resolution.
+func (q *Queries) RecordAIProviderCredentialHealth(ctx context.Context, arg RecordAIProviderCredentialHealthParams) error {
+	_, err := q.db.ExecContext(ctx, recordAIProviderCredentialHealth, arg.HealthStatus, arg.HealthError, arg.ID)
+	return err
+}
+
+const replaceAIProviderCredentialCipher = `-- name: ReplaceAIProviderCredentialCipher :execrows
+UPDATE ai_provider_credentials
+SET credential_cipher = $1
+WHERE id = $2
+  AND credential_cipher = $3
+`
+
+type ReplaceAIProviderCredentialCipherParams struct {
+	NewCipher []byte
+	ID        uuid.UUID
+	OldCipher []byte
+}
+
+// Re-encryption keeps the secret, so updated_at (which orders credential resolution) and
+// rotated_at are left alone. The old ciphertext guards against concurrent edits.
+func (q *Queries) ReplaceAIProviderCredentialCipher(ctx context.Context, arg ReplaceAIProviderCredentialCipherParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, replaceAIProviderCredentialCipher, arg.NewCipher, arg.ID, arg.OldCipher)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const touchAIProviderCredential = `-- name: TouchAIProviderCredential :exec
 UPDATE ai_provider_credentials
 SET last_used_at = now(),
@@ -666,14 +858,20 @@ SET
     label             = COALESCE($4, label),
     is_default        = COALESCE($5, is_default),
     last_tested_at    = COALESCE($6, last_tested_at),
+    expires_at        = $7,
     updated_at        = now(),
     rotated_at        = CASE
         WHEN $1 IS NOT NULL
              AND $1 IS DISTINCT FROM credential_cipher THEN now()
         ELSE rotated_at
+    END,
+    health_status     = CASE
+        WHEN $1 IS NOT NULL
+             AND $1 IS DISTINCT FROM credential_cipher THEN NULL
+        ELSE health_status
     END
-WHERE id = $7
-RETURNING id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint
+WHERE id = $8
+RETURNING id, company_id, user_id, provider_id, credential_cipher, credential_hash, metadata, created_at, updated_at, last_used_at, rotated_at, label, is_default, last_tested_at, fingerprint, expires_at, health_checked_at, health_status, health_error
 `
 
 type UpdateAIProviderCredentialParams struct {
@@ -683,6 +881,7 @@ type UpdateAIProviderCredentialParams struct {
 	Label            sql.NullString
 	IsDefault        sql.NullBool
 	LastTestedAt     sql.NullTime
+	ExpiresAt        sql.NullTime
 	ID               uuid.UUID
 }
 
@@ -694,6 +893,7 @@ func (q *Queries) UpdateAIProviderCredential(ctx context.Context, arg UpdateAIPr
 		arg.Label,
 		arg.IsDefault,
 		arg.LastTestedAt,
+		arg.ExpiresAt,
 		arg.ID,
 	)
 	var i AiProviderCredential
@@ -713,6 +913,10 @@ func (q *Queries) UpdateAIProviderCredential(ctx context.Context, arg UpdateAIPr
 		&i.IsDefault,
 		&i.LastTestedAt,
 		&i.Fingerprint,
+		&i.ExpiresAt,
+		&i.HealthCheckedAt,
+		&i.HealthStatus,
+		&i.HealthError,
 	)
 	return i, err
 }
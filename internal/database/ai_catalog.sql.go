@@ -7,10 +7,63 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 
 	"github.com/lib/pq"
 )
 
+const deleteAIProviderCatalogEntry = `-- name: DeleteAIProviderCatalogEntry :execrows
+DELETE FROM ai_provider_catalog
+WHERE id = $1
+`
+
+func (q *Queries) DeleteAIProviderCatalogEntry(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAIProviderCatalogEntry, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAIProviderCatalogEntry = `-- name: GetAIProviderCatalogEntry :one
+SELECT
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    created_at,
+    updated_at,
+    context_windows
+FROM ai_provider_catalog
+WHERE id = $1
+`
+
+func (q *Queries) GetAIProviderCatalogEntry(ctx context.Context, id string) (AiProviderCatalog, error) {
+	row := q.db.QueryRowContext(ctx, getAIProviderCatalogEntry, id)
+	var i AiProviderCatalog
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.IconUrl,
+		&i.Description,
+		&i.DocumentationUrl,
+		pq.Array(&i.Capabilities),
+		pq.Array(&i.Models),
+		&i.Fields,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ContextWindows,
+	)
+	return i, err
+}
+
 const listAIProviderCatalogEntries = `-- name: ListAIProviderCatalogEntries :many
 SELECT
     id,
@@ -23,7 +76,8 @@ SELECT
     fields,
     enabled,
     created_at,
-    updated_at
+    updated_at,
+    context_windows
 FROM ai_provider_catalog
 WHERE enabled = TRUE
 ORDER BY id
@@ -50,6 +104,62 @@ func (q *Queries) ListAIProviderCatalogEntries(ctx context.Context) ([]AiProvide
 			&i.Enabled,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ContextWindows,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllAIProviderCatalogEntries = `-- name: ListAllAIProviderCatalogEntries :many
+SELECT
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    created_at,
+    updated_at,
+    context_windows
+FROM ai_provider_catalog
+ORDER BY id
+`
+
+// Includes disabled entries for catalog administration.
+func (q *Queries) ListAllAIProviderCatalogEntries(ctx context.Context) ([]AiProviderCatalog, error) {
+	rows, err := q.db.QueryContext(ctx, listAllAIProviderCatalogEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AiProviderCatalog
+	for rows.Next() {
+		var i AiProviderCatalog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Label,
+			&i.IconUrl,
+			&i.Description,
+			&i.DocumentationUrl,
+			pq.Array(&i.Capabilities),
+			pq.Array(&i.Models),
+			&i.Fields,
+			&i.Enabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ContextWindows,
 		); err != nil {
 			return nil, err
 		}
@@ -63,3 +173,87 @@ func (q *Queries) ListAIProviderCatalogEntries(ctx context.Context) ([]AiProvide
 	}
 	return items, nil
 }
+
+const upsertAIProviderCatalogEntry = `-- name: UpsertAIProviderCatalogEntry :one
+INSERT INTO ai_provider_catalog (
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    context_windows
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (id) DO UPDATE SET
+    label = EXCLUDED.label,
+    icon_url = EXCLUDED.icon_url,
+    description = EXCLUDED.description,
+    documentation_url = EXCLUDED.documentation_url,
+    capabilities = EXCLUDED.capabilities,
+    models = EXCLUDED.models,
+    fields = EXCLUDED.fields,
+    enabled = EXCLUDED.enabled,
+    context_windows = EXCLUDED.context_windows
+RETURNING
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    created_at,
+    updated_at,
+    context_windows
+`
+
+type UpsertAIProviderCatalogEntryParams struct {
+	ID               string
+	Label            string
+	IconUrl          sql.NullString
+	Description      sql.NullString
+	DocumentationUrl sql.NullString
+	Capabilities     []string
+	Models           []string
+	Fields           json.RawMessage
+	Enabled          bool
+	ContextWindows   json.RawMessage
+}
+
+func (q *Queries) UpsertAIProviderCatalogEntry(ctx context.Context, arg UpsertAIProviderCatalogEntryParams) (AiProviderCatalog, error) {
+	row := q.db.QueryRowContext(ctx, upsertAIProviderCatalogEntry,
+		arg.ID,
+		arg.Label,
+		arg.IconUrl,
+		arg.Description,
+		arg.DocumentationUrl,
+		pq.Array(arg.Capabilities),
+		pq.Array(arg.Models),
+		arg.Fields,
+		arg.Enabled,
+		arg.ContextWindows,
+	)
+	var i AiProviderCatalog
+	err := row.Scan(
+		&i.ID,
+		&i.Label,
+		&i.IconUrl,
+		&i.Description,
+		&i.DocumentationUrl,
+		pq.Array(&i.Capabilities),
+		pq.Array(&i.Models),
+		&i.Fields,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ContextWindows,
+	)
+	return i, err
+}
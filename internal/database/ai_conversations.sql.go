@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/google/uuid"
 )
@@ -237,6 +238,25 @@ func (q *Queries) ListAIConversationSessionsByCompany(ctx context.Context, arg L
 	return items, nil
 }
 
+const mergeAIConversationSessionMetadata = `-- name: MergeAIConversationSessionMetadata :exec
+UPDATE ai_conversation_sessions
+SET metadata = COALESCE(metadata, '{}'::jsonb) || $1::jsonb,
+    updated_at = now()
+WHERE id = $2
+  AND company_id = $3
+`
+
+type MergeAIConversationSessionMetadataParams struct {
+	Patch     json.RawMessage
+	ID        uuid.UUID
+	CompanyID uuid.UUID
+}
+
+func (q *Queries) MergeAIConversationSessionMetadata(ctx context.Context, arg MergeAIConversationSessionMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, mergeAIConversationSessionMetadata, arg.Patch, arg.ID, arg.CompanyID)
+	return err
+}
+
 const updateAIConversationSessionTitle = `-- name: UpdateAIConversationSessionTitle :exec
 UPDATE ai_conversation_sessions
 SET title = $3,
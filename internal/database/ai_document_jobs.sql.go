@@ -14,36 +14,78 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
-const deleteAIDocumentJob = `-- name: DeleteAIDocumentJob :exec
-DELETE FROM ai_document_jobs
+const cancelAIDocumentJob = `-- name: CancelAIDocumentJob :one
+UPDATE ai_document_jobs
+SET status              = CASE WHEN status = 'queued' THEN 'cancelled' ELSE status END,
+    cancel_requested_at = now(),
+    completed_at        = CASE WHEN status = 'queued' THEN now() ELSE completed_at END,
+    updated_at          = now()
 WHERE id = $1
   AND company_id = $2
+  AND status IN ('queued', 'processing')
+RETURNING id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at, attempts, max_attempts, run_after, locked_by, lease_expires_at, progress_stage, progress_detail, cancel_requested_at
 `
 
-type DeleteAIDocumentJobParams struct {
+type CancelAIDocumentJobParams struct {
 	ID        uuid.UUID
 	CompanyID uuid.UUID
 }
 
-func (q *Queries) DeleteAIDocumentJob(ctx context.Context, arg DeleteAIDocumentJobParams) error {
-	_, err := q.db.ExecContext(ctx, deleteAIDocumentJob, arg.ID, arg.CompanyID)
-	return err
+func (q *Queries) CancelAIDocumentJob(ctx context.Context, arg CancelAIDocumentJobParams) (AiDocumentJob, error) {
+	row := q.db.QueryRowContext(ctx, cancelAIDocumentJob, arg.ID, arg.CompanyID)
+	var i AiDocumentJob
+	err := row.Scan(
+		&i.ID,
+		&i.CompanyID,
+		&i.UserID,
+		&i.ProviderID,
+		&i.Status,
+		&i.Request,
+		&i.Response,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAfter,
+		&i.LockedBy,
+		&i.LeaseExpiresAt,
+		&i.ProgressStage,
+		&i.ProgressDetail,
+		&i.CancelRequestedAt,
+	)
+	return i, err
 }
 
-const getAIDocumentJob = `-- name: GetAIDocumentJob :one
-SELECT id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at
-FROM ai_document_jobs
-WHERE id = $1
-  AND company_id = $2
+const claimAIDocumentJob = `-- name: ClaimAIDocumentJob :one
+UPDATE ai_document_jobs
+SET status           = 'processing',
+    attempts         = attempts + 1,
+    locked_by        = $1::text,
+    lease_expires_at = now() + $2::int * interval '1 second',
+    progress_stage   = '',
+    progress_detail  = '',
+    updated_at       = now()
+WHERE id = (
+    SELECT id
+    FROM ai_document_jobs
+    WHERE status = 'queued'
+      AND run_after <= now()
+    ORDER BY run_after ASC, created_at ASC
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at, attempts, max_attempts, run_after, locked_by, lease_expires_at, progress_stage, progress_detail, cancel_requested_at
 `
 
-type GetAIDocumentJobParams struct {
-	ID        uuid.UUID
-	CompanyID uuid.UUID
+type ClaimAIDocumentJobParams struct {
+	LockedBy     string
+	LeaseSeconds int32
 }
 
-func (q *Queries) GetAIDocumentJob(ctx context.Context, arg GetAIDocumentJobParams) (AiDocumentJob, error) {
-	row := q.db.QueryRowContext(ctx, getAIDocumentJob, arg.ID, arg.CompanyID)
+func (q *Queries) ClaimAIDocumentJob(ctx context.Context, arg ClaimAIDocumentJobParams) (AiDocumentJob, error) {
+	row := q.db.QueryRowContext(ctx, claimAIDocumentJob, arg.LockedBy, arg.LeaseSeconds)
 	var i AiDocumentJob
 	err := row.Scan(
 		&i.ID,
@@ -57,20 +99,166 @@ func (q *Queries) GetAIDocumentJob(ctx context.Context, arg GetAIDocumentJobPara
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAfter,
+		&i.LockedBy,
+		&i.LeaseExpiresAt,
+		&i.ProgressStage,
+		&i.ProgressDetail,
+		&i.CancelRequestedAt,
 	)
 	return i, err
 }
 
-const getNextQueuedAIDocumentJob = `-- name: GetNextQueuedAIDocumentJob :one
-SELECT id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at
+const completeAIDocumentJob = `-- name: CompleteAIDocumentJob :execrows
+UPDATE ai_document_jobs
+SET response         = $1,
+    status           = 'completed',
+    error_message    = NULL,
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = now()
+WHERE id = $2
+  AND status = 'processing'
+  AND locked_by = $3::text
+`
+
+type CompleteAIDocumentJobParams struct {
+	Response pqtype.NullRawMessage
+	ID       uuid.UUID
+	LockedBy string
+}
+
+func (q *Queries) CompleteAIDocumentJob(ctx context.Context, arg CompleteAIDocumentJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeAIDocumentJob, arg.Response, arg.ID, arg.LockedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAIDocumentJobsByStatus = `-- name: CountAIDocumentJobsByStatus :many
+SELECT status, count(*)::bigint AS jobs
 FROM ai_document_jobs
-WHERE status = 'queued'
-ORDER BY created_at ASC
-LIMIT 1
+GROUP BY status
+`
+
+type CountAIDocumentJobsByStatusRow struct {
+	Status string
+	Jobs   int64
+}
+
+func (q *Queries) CountAIDocumentJobsByStatus(ctx context.Context) ([]CountAIDocumentJobsByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countAIDocumentJobsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountAIDocumentJobsByStatusRow
+	for rows.Next() {
+		var i CountAIDocumentJobsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Jobs); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteAIDocumentJob = `-- name: DeleteAIDocumentJob :exec
+DELETE FROM ai_document_jobs
+WHERE id = $1
+  AND company_id = $2
 `
 
-func (q *Queries) GetNextQueuedAIDocumentJob(ctx context.Context) (AiDocumentJob, error) {
-	row := q.db.QueryRowContext(ctx, getNextQueuedAIDocumentJob)
+type DeleteAIDocumentJobParams struct {
+	ID        uuid.UUID
+	CompanyID uuid.UUID
+}
+
+func (q *Queries) DeleteAIDocumentJob(ctx context.Context, arg DeleteAIDocumentJobParams) error {
+	_, err := q.db.ExecContext(ctx, deleteAIDocumentJob, arg.ID, arg.CompanyID)
+	return err
+}
+
+const extendAIDocumentJobLease = `-- name: ExtendAIDocumentJobLease :one
+UPDATE ai_document_jobs
+SET lease_expires_at = now() + $1::int * interval '1 second',
+    updated_at       = now()
+WHERE id = $2
+  AND status = 'processing'
+  AND locked_by = $3::text
+RETURNING (cancel_requested_at IS NOT NULL)::boolean AS cancel_requested
+`
+
+type ExtendAIDocumentJobLeaseParams struct {
+	LeaseSeconds int32
+	ID           uuid.UUID
+	LockedBy     string
+}
+
+func (q *Queries) ExtendAIDocumentJobLease(ctx context.Context, arg ExtendAIDocumentJobLeaseParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, extendAIDocumentJobLease, arg.LeaseSeconds, arg.ID, arg.LockedBy)
+	var cancel_requested bool
+	err := row.Scan(&cancel_requested)
+	return cancel_requested, err
+}
+
+const failAIDocumentJob = `-- name: FailAIDocumentJob :execrows
+UPDATE ai_document_jobs
+SET status           = $1,
+    error_message    = $2::text,
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = now()
+WHERE id = $3
+  AND status = 'processing'
+  AND locked_by = $4::text
+`
+
+type FailAIDocumentJobParams struct {
+	Status       string
+	ErrorMessage string
+	ID           uuid.UUID
+	LockedBy     string
+}
+
+func (q *Queries) FailAIDocumentJob(ctx context.Context, arg FailAIDocumentJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, failAIDocumentJob,
+		arg.Status,
+		arg.ErrorMessage,
+		arg.ID,
+		arg.LockedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAIDocumentJob = `-- name: GetAIDocumentJob :one
+SELECT id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at, attempts, max_attempts, run_after, locked_by, lease_expires_at, progress_stage, progress_detail, cancel_requested_at
+FROM ai_document_jobs
+WHERE id = $1
+  AND company_id = $2
+`
+
+type GetAIDocumentJobParams struct {
+	ID        uuid.UUID
+	CompanyID uuid.UUID
+}
+
+func (q *Queries) GetAIDocumentJob(ctx context.Context, arg GetAIDocumentJobParams) (AiDocumentJob, error) {
+	row := q.db.QueryRowContext(ctx, getAIDocumentJob, arg.ID, arg.CompanyID)
 	var i AiDocumentJob
 	err := row.Scan(
 		&i.ID,
@@ -84,6 +272,14 @@ func (q *Queries) GetNextQueuedAIDocumentJob(ctx context.Context) (AiDocumentJob
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAfter,
+		&i.LockedBy,
+		&i.LeaseExpiresAt,
+		&i.ProgressStage,
+		&i.ProgressDetail,
+		&i.CancelRequestedAt,
 	)
 	return i, err
 }
@@ -102,7 +298,7 @@ INSERT INTO ai_document_jobs (
     COALESCE($4, 'queued'),
     $5
 )
-RETURNING id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at
+RETURNING id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at, attempts, max_attempts, run_after, locked_by, lease_expires_at, progress_stage, progress_detail, cancel_requested_at
 `
 
 type InsertAIDocumentJobParams struct {
@@ -134,12 +330,20 @@ func (q *Queries) InsertAIDocumentJob(ctx context.Context, arg InsertAIDocumentJ
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAfter,
+		&i.LockedBy,
+		&i.LeaseExpiresAt,
+		&i.ProgressStage,
+		&i.ProgressDetail,
+		&i.CancelRequestedAt,
 	)
 	return i, err
 }
 
 const listAIDocumentJobsByCompany = `-- name: ListAIDocumentJobsByCompany :many
-SELECT id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at
+SELECT id, company_id, user_id, provider_id, status, request, response, error_message, created_at, updated_at, completed_at, attempts, max_attempts, run_after, locked_by, lease_expires_at, progress_stage, progress_detail, cancel_requested_at
 FROM ai_document_jobs
 WHERE company_id = $1
 ORDER BY created_at DESC
@@ -173,6 +377,14 @@ func (q *Queries) ListAIDocumentJobsByCompany(ctx context.Context, arg ListAIDoc
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAfter,
+			&i.LockedBy,
+			&i.LeaseExpiresAt,
+			&i.ProgressStage,
+			&i.ProgressDetail,
+			&i.CancelRequestedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -187,12 +399,106 @@ func (q *Queries) ListAIDocumentJobsByCompany(ctx context.Context, arg ListAIDoc
 	return items, nil
 }
 
+const recoverExpiredAIDocumentJobs = `-- name: RecoverExpiredAIDocumentJobs :execrows
+UPDATE ai_document_jobs
+SET status           = CASE
+                           WHEN cancel_requested_at IS NOT NULL THEN 'cancelled'
+                           WHEN attempts >= max_attempts THEN 'dead_letter'
+                           ELSE 'queued'
+                       END,
+    error_message    = 'worker lease expired',
+    run_after        = now(),
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = CASE
+                           WHEN cancel_requested_at IS NOT NULL OR attempts >= max_attempts THEN now()
+                           ELSE completed_at
+                       END
+WHERE status = 'processing'
+  AND (lease_expires_at IS NULL OR lease_expires_at < now())
+`
+
+func (q *Queries) RecoverExpiredAIDocumentJobs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recoverExpiredAIDocumentJobs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryAIDocumentJob = `-- name: RetryAIDocumentJob :execrows
+UPDATE ai_document_jobs
+SET status           = CASE WHEN cancel_requested_at IS NULL THEN 'queued' ELSE 'cancelled' END,
+    error_message    = $1::text,
+    run_after        = now() + $2::int * interval '1 second',
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = CASE WHEN cancel_requested_at IS NULL THEN completed_at ELSE now() END
+WHERE id = $3
+  AND status = 'processing'
+  AND locked_by = $4::text
+`
+
+type RetryAIDocumentJobParams struct {
+	ErrorMessage string
+	DelaySeconds int32
+	ID           uuid.UUID
+	LockedBy     string
+}
+
+func (q *Queries) RetryAIDocumentJob(ctx context.Context, arg RetryAIDocumentJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retryAIDocumentJob,
+		arg.ErrorMessage,
+		arg.DelaySeconds,
+		arg.ID,
+		arg.LockedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateAIDocumentJobProgress = `-- name: UpdateAIDocumentJobProgress :execrows
+UPDATE ai_document_jobs
+SET progress_stage  = $1,
+    progress_detail = $2,
+    updated_at      = now()
+WHERE id = $3
+  AND status = 'processing'
+  AND locked_by = $4::text
+`
+
+type UpdateAIDocumentJobProgressParams struct {
+	ProgressStage  string
+	ProgressDetail string
+	ID             uuid.UUID
+	LockedBy       string
+}
+
+func (q *Queries) UpdateAIDocumentJobProgress(ctx context.Context, arg UpdateAIDocumentJobProgressParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAIDocumentJobProgress,
+		arg.ProgressStage,
+		arg.ProgressDetail,
+		arg.ID,
+		arg.LockedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAIDocumentJobResponse = `-- name: UpdateAIDocumentJobResponse :exec
 UPDATE ai_document_jobs
-SET response     = $3,
-    status       = 'completed',
-    updated_at   = now(),
-    completed_at = now()
+SET response         = $3,
+    status           = 'completed',
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = now()
 WHERE id = $1
   AND company_id = $2
 `
@@ -213,7 +519,7 @@ UPDATE ai_document_jobs
 SET status        = $3,
     error_message = $4,
     updated_at    = now(),
-    completed_at  = CASE WHEN $3 IN ('completed', 'failed') THEN now() ELSE completed_at END
+    completed_at  = CASE WHEN $3 IN ('completed', 'failed', 'dead_letter', 'cancelled') THEN now() ELSE completed_at END
 WHERE id = $1
   AND company_id = $2
 `
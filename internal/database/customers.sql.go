@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -219,6 +220,82 @@ func (q *Queries) ResetCustomers(ctx context.Context) error {
 	return err
 }
 
+const searchCustomers = `-- name: SearchCustomers :many
+SELECT cu.id, cu.customer_name, cu.created_at, cu.updated_at, cu.is_active, cu.company_id,
+    (
+        SELECT COUNT(*) FROM contracts co
+        WHERE co.Customer_ID = cu.ID
+        AND co.Company_ID = cu.Company_ID
+    ) AS contract_count
+FROM customers cu
+WHERE cu.Company_ID = $1
+AND (
+    cu.Customer_Name ILIKE $2::text
+    OR cu.ID::text LIKE $3::text
+    OR EXISTS (
+        SELECT 1 FROM contracts co
+        WHERE co.Customer_ID = cu.ID
+        AND co.Company_ID = cu.Company_ID
+        AND co.ID::text LIKE $3::text
+    )
+)
+ORDER BY cu.Is_Active DESC, cu.Customer_Name
+LIMIT $4
+`
+
+type SearchCustomersParams struct {
+	CompanyID   uuid.UUID
+	NamePattern string
+	IDPrefix    string
+	RowLimit    int32
+}
+
+type SearchCustomersRow struct {
+	ID            uuid.UUID
+	CustomerName  string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	IsActive      bool
+	CompanyID     uuid.UUID
+	ContractCount int64
+}
+
+func (q *Queries) SearchCustomers(ctx context.Context, arg SearchCustomersParams) ([]SearchCustomersRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCustomers,
+		arg.CompanyID,
+		arg.NamePattern,
+		arg.IDPrefix,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCustomersRow
+	for rows.Next() {
+		var i SearchCustomersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerName,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsActive,
+			&i.CompanyID,
+			&i.ContractCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setCustomerActiveStatus = `-- name: SetCustomerActiveStatus :exec
 UPDATE customers
 SET Is_Active = $1
@@ -13,6 +13,18 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type AiCompanySetting struct {
+	CompanyID              uuid.UUID
+	FallbackProviders      []string
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	MonthlyBudgetUsdMicros sql.NullInt64
+	BudgetWarningPercent   int32
+	CredentialRotationDays sql.NullInt32
+	CredentialWarningDays  int32
+	ModelAllowlist         pqtype.NullRawMessage
+}
+
 type AiConversationMessage struct {
 	ID        uuid.UUID
 	SessionID uuid.UUID
@@ -33,18 +45,68 @@ type AiConversationSession struct {
 	UpdatedAt  time.Time
 }
 
+type AiDocumentFile struct {
+	ID            uuid.UUID
+	CompanyID     uuid.UUID
+	UserID        uuid.UUID
+	Filename      string
+	ContentType   string
+	SizeBytes     int64
+	Sha256        string
+	StorageKey    string
+	ExtractedText string
+	ChunkCount    int32
+	CreatedAt     time.Time
+}
+
 type AiDocumentJob struct {
-	ID           uuid.UUID
-	CompanyID    uuid.UUID
-	UserID       uuid.UUID
-	ProviderID   string
-	Status       string
-	Request      json.RawMessage
-	Response     pqtype.NullRawMessage
-	ErrorMessage sql.NullString
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	CompletedAt  sql.NullTime
+	ID                uuid.UUID
+	CompanyID         uuid.UUID
+	UserID            uuid.UUID
+	ProviderID        string
+	Status            string
+	Request           json.RawMessage
+	Response          pqtype.NullRawMessage
+	ErrorMessage      sql.NullString
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	CompletedAt       sql.NullTime
+	Attempts          int32
+	MaxAttempts       int32
+	RunAfter          time.Time
+	LockedBy          sql.NullString
+	LeaseExpiresAt    sql.NullTime
+	ProgressStage     string
+	ProgressDetail    string
+	CancelRequestedAt sql.NullTime
+}
+
+type AiDocumentJobDraft struct {
+	JobID      uuid.UUID
+	CompanyID  uuid.UUID
+	ContractID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type AiPromptTemplate struct {
+	ID          uuid.UUID
+	CompanyID   uuid.UUID
+	Name        string
+	Kind        string
+	Description string
+	IsDefault   bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ArchivedAt  sql.NullTime
+}
+
+type AiPromptTemplateVersion struct {
+	ID         uuid.UUID
+	TemplateID uuid.UUID
+	Version    int32
+	Body       string
+	CreatedBy  uuid.NullUUID
+	CreatedAt  time.Time
 }
 
 type AiProviderCatalog struct {
@@ -59,6 +121,7 @@ type AiProviderCatalog struct {
 	Enabled          bool
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	ContextWindows   json.RawMessage
 }
 
 type AiProviderCredential struct {
@@ -77,6 +140,10 @@ type AiProviderCredential struct {
 	IsDefault        bool
 	LastTestedAt     sql.NullTime
 	Fingerprint      sql.NullString
+	ExpiresAt        sql.NullTime
+	HealthCheckedAt  sql.NullTime
+	HealthStatus     sql.NullString
+	HealthError      sql.NullString
 }
 
 type AiProviderCredentialEvent struct {
@@ -102,6 +169,19 @@ type AiToolInvocation struct {
 	CreatedAt    time.Time
 }
 
+type AiUsageLedger struct {
+	ID               uuid.UUID
+	CompanyID        uuid.UUID
+	UserID           uuid.NullUUID
+	ProviderID       string
+	Model            string
+	Feature          string
+	PromptTokens     int32
+	CompletionTokens int32
+	CostUsdMicros    int64
+	CreatedAt        time.Time
+}
+
 type AiUserPreference struct {
 	UserID     uuid.UUID
 	CompanyID  uuid.UUID
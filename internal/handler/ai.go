@@ -8,8 +8,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"sort"
@@ -21,6 +22,8 @@ import (
 	"github.com/JonMunkholm/RevProject1/internal/ai"
 	"github.com/JonMunkholm/RevProject1/internal/ai/conversation"
 	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/ai/grounding"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
 	catalog "github.com/JonMunkholm/RevProject1/internal/ai/provider/catalog"
 	"github.com/JonMunkholm/RevProject1/internal/auth"
 	"github.com/JonMunkholm/RevProject1/internal/database"
@@ -34,11 +37,13 @@ const (
 	defaultCredentialLimit      int32 = 20
 	defaultCredentialEventLimit int32 = 20
 	chatPreviewCharacterLimit         = 80
+	featureChat                       = "chat"
+	featureChatSummary                = "chat_summary"
 
 	metadataKeyCredentialSuffix = "key_suffix"
 )
 
-var errStatusNotImplemented = errors.New("ai: provider status check not implemented")
+var errStatusNotImplemented = ai.ErrCredentialCheckUnsupported
 
 type credentialEventStore interface {
 	Insert(ctx context.Context, params database.InsertAIProviderCredentialEventParams) error
@@ -59,6 +64,12 @@ type AI struct {
 	CredentialMetrics ai.CredentialMetrics
 	ProviderCatalog   []ai.ProviderCatalogEntry
 	CatalogLoader     *catalog.Loader
+	CatalogAdmin      *catalog.Admin
+	Settings          *ai.CompanySettingsService
+	Usage             *ai.UsageService
+	Grounding         *ai.GroundingService
+	Prompts           *ai.PromptTemplateService
+	Preferences       *ai.UserPreferenceService
 }
 
 type conversationResponse struct {
@@ -85,9 +96,21 @@ type documentJobResponse struct {
 	Request     map[string]any `json:"request"`
 	Response    map[string]any `json:"response,omitempty"`
 	Error       *string        `json:"error,omitempty"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	CompletedAt *time.Time     `json:"completedAt,omitempty"`
+	Attempts    int            `json:"attempts"`
+	MaxAttempts int            `json:"maxAttempts"`
+	// NextAttemptAt is set while a failed job waits to be retried.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	// Progress describes the step a running job is on.
+	Progress        *documentJobProgress `json:"progress,omitempty"`
+	CancelRequested bool                 `json:"cancelRequested"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
+	CompletedAt     *time.Time           `json:"completedAt,omitempty"`
+}
+
+type documentJobProgress struct {
+	Stage  string `json:"stage"`
+	Detail string `json:"detail,omitempty"`
 }
 
 type createConversationRequest struct {
@@ -103,10 +126,20 @@ type appendMessageRequest struct {
 }
 
 type createDocumentJobRequest struct {
-	Provider     string         `json:"provider,omitempty"`
-	Documents    []string       `json:"documents"`
-	Instructions string         `json:"instructions,omitempty"`
-	Metadata     map[string]any `json:"metadata,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	// Type selects the job kind: "summary" (default) or "contract_extraction".
+	Type      string   `json:"type,omitempty"`
+	Documents []string `json:"documents,omitempty"`
+	// Files lists IDs of previously uploaded files to analyse.
+	Files []string `json:"files,omitempty"`
+	// CustomerID attaches contract extraction drafts to an existing customer instead of
+	// matching the extracted name.
+	CustomerID   string `json:"customerId,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+	// PromptTemplateVersionID pins a summary job to a document prompt template version
+	// instead of the company default.
+	PromptTemplateVersionID string         `json:"promptTemplateVersionId,omitempty"`
+	Metadata                map[string]any `json:"metadata,omitempty"`
 }
 
 type upsertProviderCredentialRequest struct {
@@ -120,6 +153,9 @@ type upsertProviderCredentialRequest struct {
 	Label        string         `json:"label,omitempty"`
 	MakeDefault  bool           `json:"makeDefault,omitempty"`
 	CredentialID string         `json:"credentialId,omitempty"`
+	// ExpiresAt is a date (2006-01-02) or RFC 3339 timestamp. Omitted keeps the stored
+	// expiry on updates; an empty string clears it.
+	ExpiresAt *string `json:"expiresAt,omitempty"`
 }
 
 type listResponse[T any] struct {
@@ -140,6 +176,15 @@ type providerCredentialResponse struct {
 	LastUsedAt  *time.Time     `json:"lastUsedAt,omitempty"`
 	RotatedAt   *time.Time     `json:"rotatedAt,omitempty"`
 	KeySuffix   string         `json:"keySuffix,omitempty"`
+	ExpiresAt   *time.Time     `json:"expiresAt,omitempty"`
+	// LastTestedAt is the last manual or background status check.
+	LastTestedAt *time.Time `json:"lastTestedAt,omitempty"`
+	// Lifecycle is ok, rotation_due, stale, expiring or expired under the company's
+	// rotation policy; LifecycleDueAt is the deadline behind it.
+	Lifecycle      string     `json:"lifecycle"`
+	LifecycleDueAt *time.Time `json:"lifecycleDueAt,omitempty"`
+	HealthStatus   string     `json:"healthStatus,omitempty"`
+	HealthError    string     `json:"healthError,omitempty"`
 }
 
 type providerCredentialEventResponse struct {
@@ -324,8 +369,13 @@ func (h *AI) ChatAppendMessage(w http.ResponseWriter, r *http.Request) {
 	sessionRecord, messages, _, appendErr := h.appendConversationAndListMessages(ctx, sessionInfo, sessionID, role, content, req.Metadata)
 	if appendErr != nil {
 		props := pages.ChatTranscriptProps{ConversationID: sessionID.String(), ErrorMessage: "Failed to send message."}
-		if errors.Is(appendErr, sql.ErrNoRows) {
+		switch {
+		case errors.Is(appendErr, sql.ErrNoRows):
 			props.ErrorMessage = "Conversation not found. Start a new conversation."
+		case errors.Is(appendErr, ai.ErrBudgetExceeded):
+			props.ErrorMessage = "Your company has reached its monthly AI budget. Ask an administrator to raise it."
+		case errors.Is(appendErr, ai.ErrModelNotAllowed):
+			props.ErrorMessage = "That model is not on your company's allowlist. Pick another model."
 		}
 		providerID := sessionRecord.ProviderID
 		if blocked := h.chatCredentialReason(ctx, sessionInfo, providerID); blocked != "" {
@@ -342,7 +392,7 @@ func (h *AI) ChatAppendMessage(w http.ResponseWriter, r *http.Request) {
 	h.writeChatTranscript(w, r.Context(), props)
 }
 
-func credentialRecordToResponse(record ai.CredentialRecord) providerCredentialResponse {
+func credentialRecordToResponse(record ai.CredentialRecord, policy ai.CredentialPolicy) providerCredentialResponse {
 	var userID *string
 	if record.UserID.Valid {
 		id := record.UserID.UUID.String()
@@ -369,6 +419,8 @@ func credentialRecordToResponse(record ai.CredentialRecord) providerCredentialRe
 		keySuffix = suffix
 	}
 
+	assessment := ai.AssessCredential(record, policy, time.Now())
+
 	return providerCredentialResponse{
 		ID:          record.ID.String(),
 		ProviderID:  record.ProviderID,
@@ -382,11 +434,18 @@ func credentialRecordToResponse(record ai.CredentialRecord) providerCredentialRe
 		LastUsedAt:  record.LastUsedAt,
 		RotatedAt:   record.RotatedAt,
 		KeySuffix:   keySuffix,
+
+		ExpiresAt:      record.ExpiresAt,
+		LastTestedAt:   record.LastTestedAt,
+		Lifecycle:      string(assessment.Status),
+		LifecycleDueAt: assessment.Due,
+		HealthStatus:   record.HealthStatus,
+		HealthError:    record.HealthError,
 	}
 }
 
-func credentialRecordToPageView(record ai.CredentialRecord) pages.AICredentialView {
-	resp := credentialRecordToResponse(record)
+func credentialRecordToPageView(record ai.CredentialRecord, policy ai.CredentialPolicy) pages.AICredentialView {
+	resp := credentialRecordToResponse(record, policy)
 	view := pages.AICredentialView{
 		ID:          resp.ID,
 		Provider:    resp.ProviderID,
@@ -400,6 +459,13 @@ func credentialRecordToPageView(record ai.CredentialRecord) pages.AICredentialVi
 		LastUsedAt:  resp.LastUsedAt,
 		RotatedAt:   resp.RotatedAt,
 		IsDefault:   resp.IsDefault,
+
+		ExpiresAt:      resp.ExpiresAt,
+		LastTestedAt:   resp.LastTestedAt,
+		Lifecycle:      resp.Lifecycle,
+		LifecycleDueAt: resp.LifecycleDueAt,
+		HealthStatus:   resp.HealthStatus,
+		HealthError:    resp.HealthError,
 	}
 
 	scopeLabel := "Company"
@@ -473,6 +539,400 @@ func (h *AI) ListProviderCatalog(w http.ResponseWriter, r *http.Request) {
 	}{Items: entries})
 }
 
+// ListProviderModels returns the models a provider serves, discovering them from the
+// provider when supported and falling back to the catalog list otherwise.
+func (h *AI) ListProviderModels(w http.ResponseWriter, r *http.Request) {
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	providerID, entry, err := h.normalizeProvider(r.Context(), chi.URLParam(r, "providerID"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "unknown provider", err)
+		return
+	}
+
+	models := append([]string(nil), entry.Models...)
+	allowed, ok := h.allowedModels(r.Context(), session.CompanyID, providerID)
+	if !ok {
+		RespondWithError(w, http.StatusForbidden, "provider not allowed for company", ai.ErrProviderNotAllowed)
+		return
+	}
+	if allowed != nil {
+		models = allowed
+	}
+	source := "catalog"
+	if h.Client != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		opts := h.userOptions(ctx, session.CompanyID, session.UserID, providerID)
+		discovered, err := h.Client.Models(ctx, opts)
+		switch {
+		case err == nil:
+			models = discovered
+			source = "provider"
+		case errors.Is(err, ai.ErrCapabilityNotImplemented):
+		default:
+			if len(models) == 0 {
+				RespondWithError(w, http.StatusBadGateway, "model discovery failed", err)
+				return
+			}
+			log.Printf("ai: model discovery for %s failed: %v", providerID, err)
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]any{
+		"provider": providerID,
+		"source":   source,
+		"items":    models,
+	})
+}
+
+type fallbackProvidersRequest struct {
+	Providers []string `json:"providers"`
+}
+
+// GetFallbackProviders returns the company's ordered provider fallback chain.
+func (h *AI) GetFallbackProviders(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanViewCompanySettings {
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("view not permitted"))
+		return
+	}
+
+	providers, err := h.Settings.FallbackProviders(r.Context(), session.CompanyID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to load fallback providers", err)
+		return
+	}
+	if providers == nil {
+		providers = []string{}
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]any{"providers": providers})
+}
+
+// UpdateFallbackProviders replaces the company's provider fallback chain.
+func (h *AI) UpdateFallbackProviders(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanManageCompanyCredentials {
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("manage not permitted"))
+		return
+	}
+
+	var req fallbackProvidersRequest
+	if err := decodeJSON(r, &req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+
+	providers := make([]string, 0, len(req.Providers))
+	for _, candidate := range req.Providers {
+		providerID, _, err := h.normalizeProvider(r.Context(), candidate)
+		if err != nil || strings.TrimSpace(candidate) == "" {
+			RespondWithError(w, http.StatusBadRequest, "unknown provider", fmt.Errorf("unknown provider %q", candidate))
+			return
+		}
+		providers = append(providers, providerID)
+	}
+
+	saved, err := h.Settings.SetFallbackProviders(r.Context(), session.CompanyID, providers)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to save fallback providers", err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]any{"providers": saved})
+}
+
+type budgetRequest struct {
+	MonthlyLimitUSD float64 `json:"monthlyLimitUsd"`
+	WarningPercent  int     `json:"warningPercent"`
+}
+
+// GetAIUsage returns the company's AI spend for the current month against its budget.
+func (h *AI) GetAIUsage(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Usage == nil {
+		RespondWithError(w, http.StatusInternalServerError, "usage unavailable", errors.New("usage service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanViewCompanySettings {
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("view not permitted"))
+		return
+	}
+
+	summary, err := h.Usage.Summary(r.Context(), session.CompanyID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to load usage", err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, summary)
+}
+
+// UpdateAIBudget sets the company's monthly AI budget. A zero limit removes the cap.
+func (h *AI) UpdateAIBudget(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		if respondWithAINotice(w, r, "error", "Settings unavailable", errors.New("settings service not configured")) {
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		if respondWithAINotice(w, r, "error", "Authentication required", errors.New("session missing")) {
+			return
+		}
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanManageCompanyCredentials {
+		if respondWithAINotice(w, r, "error", "Only company administrators can change the AI budget", errors.New("manage not permitted")) {
+			return
+		}
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("manage not permitted"))
+		return
+	}
+
+	req, err := parseBudgetRequest(r)
+	if err == nil && (req.MonthlyLimitUSD < 0 || math.IsNaN(req.MonthlyLimitUSD) || math.IsInf(req.MonthlyLimitUSD, 0)) {
+		err = ai.ErrInvalidBudget
+	}
+	if err != nil {
+		if respondWithAINotice(w, r, "error", "Invalid budget", err) {
+			return
+		}
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+
+	saved, err := h.Settings.SetBudget(r.Context(), session.CompanyID, ai.CompanyBudget{
+		MonthlyLimitMicros: int64(math.Round(req.MonthlyLimitUSD * 1e6)),
+		WarningPercent:     req.WarningPercent,
+	})
+	if err != nil {
+		status, message := http.StatusInternalServerError, "failed to save budget"
+		if errors.Is(err, ai.ErrInvalidBudget) {
+			status, message = http.StatusBadRequest, "warning percent must be between 1 and 100"
+		}
+		if respondWithAINotice(w, r, "error", message, err) {
+			return
+		}
+		RespondWithError(w, status, message, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Budget saved", nil)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]any{
+		"monthlyLimitUsd": float64(saved.MonthlyLimitMicros) / 1e6,
+		"warningPercent":  saved.WarningPercent,
+	})
+}
+
+func parseBudgetRequest(r *http.Request) (budgetRequest, error) {
+	var req budgetRequest
+	contentType := strings.TrimSpace(r.Header.Get("Content-Type"))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+
+	if contentType == "application/json" {
+		err := decodeJSON(r, &req)
+		return req, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return budgetRequest{}, err
+	}
+	if raw := strings.TrimPrefix(formValue(r.PostForm, "monthlyLimitUsd"), "$"); raw != "" {
+		limit, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return budgetRequest{}, fmt.Errorf("invalid monthly limit: %w", err)
+		}
+		req.MonthlyLimitUSD = limit
+	}
+	if raw := formValue(r.PostForm, "warningPercent"); raw != "" {
+		percent, err := strconv.Atoi(raw)
+		if err != nil {
+			return budgetRequest{}, fmt.Errorf("invalid warning percent: %w", err)
+		}
+		req.WarningPercent = percent
+	}
+	return req, nil
+}
+
+type credentialPolicyRequest struct {
+	RotationDays int `json:"rotationDays"`
+	WarningDays  int `json:"warningDays"`
+}
+
+// credentialPolicy returns the company's rotation policy, falling back to the defaults
+// when settings are unavailable so credential listings still render.
+func (h *AI) credentialPolicy(ctx context.Context, companyID uuid.UUID) ai.CredentialPolicy {
+	var settings *ai.CompanySettingsService
+	if h != nil {
+		settings = h.Settings
+	}
+	policy, err := settings.CredentialPolicy(ctx, companyID)
+	if err != nil {
+		return ai.CredentialPolicy{WarningDays: ai.DefaultCredentialWarningDays}
+	}
+	return policy
+}
+
+// GetCredentialPolicy returns the company's credential rotation policy.
+func (h *AI) GetCredentialPolicy(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanViewCompanySettings {
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("view not permitted"))
+		return
+	}
+
+	policy, err := h.Settings.CredentialPolicy(r.Context(), session.CompanyID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to load credential policy", err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, credentialPolicyRequest{RotationDays: policy.RotationDays, WarningDays: policy.WarningDays})
+}
+
+// UpdateCredentialPolicy sets how often company credentials should be rotated and how
+// early reminders start. Zero rotation days turns rotation reminders off.
+func (h *AI) UpdateCredentialPolicy(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		if respondWithAINotice(w, r, "error", "Settings unavailable", errors.New("settings service not configured")) {
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		if respondWithAINotice(w, r, "error", "Authentication required", errors.New("session missing")) {
+			return
+		}
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanManageCompanyCredentials {
+		if respondWithAINotice(w, r, "error", "Only company administrators can change the rotation policy", errors.New("manage not permitted")) {
+			return
+		}
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("manage not permitted"))
+		return
+	}
+
+	req, err := parseCredentialPolicyRequest(r)
+	if err != nil {
+		if respondWithAINotice(w, r, "error", "Invalid rotation policy", err) {
+			return
+		}
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+
+	saved, err := h.Settings.SetCredentialPolicy(r.Context(), session.CompanyID, ai.CredentialPolicy{
+		RotationDays: req.RotationDays,
+		WarningDays:  req.WarningDays,
+	})
+	if err != nil {
+		status, message := http.StatusInternalServerError, "failed to save rotation policy"
+		if errors.Is(err, ai.ErrInvalidCredentialPolicy) {
+			status, message = http.StatusBadRequest, "rotation days must be between 0 and 3650 and warning days between 1 and 365"
+		}
+		if respondWithAINotice(w, r, "error", message, err) {
+			return
+		}
+		RespondWithError(w, status, message, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Rotation policy saved", nil)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, credentialPolicyRequest{RotationDays: saved.RotationDays, WarningDays: saved.WarningDays})
+}
+
+func parseCredentialPolicyRequest(r *http.Request) (credentialPolicyRequest, error) {
+	req := credentialPolicyRequest{WarningDays: ai.DefaultCredentialWarningDays}
+	contentType := strings.TrimSpace(r.Header.Get("Content-Type"))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+
+	if contentType == "application/json" {
+		err := decodeJSON(r, &req)
+		return req, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return credentialPolicyRequest{}, err
+	}
+	if raw := formValue(r.PostForm, "rotationDays"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil {
+			return credentialPolicyRequest{}, fmt.Errorf("invalid rotation days: %w", err)
+		}
+		req.RotationDays = days
+	}
+	if raw := formValue(r.PostForm, "warningDays"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil {
+			return credentialPolicyRequest{}, fmt.Errorf("invalid warning days: %w", err)
+		}
+		req.WarningDays = days
+	}
+	return req, nil
+}
+
 // ListProviderCredentials returns credential metadata for the current company.
 func (h *AI) ListProviderCredentials(w http.ResponseWriter, r *http.Request) {
 	if h == nil || h.CredentialStore == nil {
@@ -536,10 +996,11 @@ func (h *AI) ListProviderCredentials(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	policy := h.credentialPolicy(ctx, session.CompanyID)
 	if isHTMX(r) {
 		views := make([]pages.AICredentialView, 0, len(records))
 		for _, record := range records {
-			views = append(views, credentialRecordToPageView(record))
+			views = append(views, credentialRecordToPageView(record, policy))
 		}
 		if err := renderCredentialTable(r.Context(), w, views); err != nil {
 			RespondWithError(w, http.StatusInternalServerError, "failed to render credentials", err)
@@ -549,7 +1010,7 @@ func (h *AI) ListProviderCredentials(w http.ResponseWriter, r *http.Request) {
 
 	resp := listResponse[providerCredentialResponse]{NextOffset: nextOffset}
 	for _, record := range records {
-		resp.Items = append(resp.Items, credentialRecordToResponse(record))
+		resp.Items = append(resp.Items, credentialRecordToResponse(record, policy))
 	}
 
 	RespondWithJSON(w, http.StatusOK, resp)
@@ -667,7 +1128,8 @@ func (h *AI) ProviderStatus(w http.ResponseWriter, r *http.Request) {
 	var fromCredential bool
 	htmx := isHTMX(r)
 
-	if strings.TrimSpace(h.APIKey) != "" {
+	// The global key is an OpenAI key, so it only stands in for the default provider.
+	if strings.TrimSpace(h.APIKey) != "" && providerID == h.DefaultProvider {
 		apiKey = strings.TrimSpace(h.APIKey)
 		scopeLabel = "global"
 	} else {
@@ -676,7 +1138,7 @@ func (h *AI) ProviderStatus(w http.ResponseWriter, r *http.Request) {
 			handleProviderStatusError(w, r, http.StatusInternalServerError, "Failed to load credentials", err)
 			return
 		}
-		if !found {
+		if !found && h.providerRequiresAPIKey(entry) {
 			if h.CredentialMetrics != nil {
 				h.CredentialMetrics.CredentialMissing(session.CompanyID, providerID, scopeLabel)
 			}
@@ -690,26 +1152,28 @@ func (h *AI) ProviderStatus(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if h.CredentialCipher == nil {
-			handleProviderStatusError(w, r, http.StatusInternalServerError, "Credential cipher unavailable", errors.New("credential cipher not configured"))
-			return
-		}
+		if found {
+			if h.CredentialCipher == nil {
+				handleProviderStatusError(w, r, http.StatusInternalServerError, "Credential cipher unavailable", errors.New("credential cipher not configured"))
+				return
+			}
 
-		plaintext, err := h.CredentialCipher.Decrypt(ctx, record.CredentialCipher)
-		if err != nil {
-			handleProviderStatusError(w, r, http.StatusInternalServerError, "Failed to decrypt credential", err)
-			return
-		}
+			plaintext, err := h.CredentialCipher.Decrypt(ai.WithCredentialBinding(ctx, record.Binding()), record.CredentialCipher)
+			if err != nil {
+				handleProviderStatusError(w, r, http.StatusInternalServerError, "Failed to decrypt credential", err)
+				return
+			}
 
-		credentialRecord = record
-		metadata = record.Metadata
-		apiKey = strings.TrimSpace(string(plaintext))
-		scopeUser = userID
-		scopeLabel = scope
-		fromCredential = true
+			credentialRecord = record
+			metadata = record.Metadata
+			apiKey = strings.TrimSpace(string(plaintext))
+			scopeUser = userID
+			scopeLabel = scope
+			fromCredential = true
+		}
 	}
 
-	if apiKey == "" {
+	if apiKey == "" && h.providerRequiresAPIKey(entry) {
 		if h.CredentialMetrics != nil {
 			h.CredentialMetrics.CredentialMissing(session.CompanyID, providerID, scopeLabel)
 		}
@@ -724,7 +1188,7 @@ func (h *AI) ProviderStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	start := time.Now()
-	if err := h.pingProvider(ctx, providerID, entry, apiKey, metadata); err != nil {
+	if err := h.pingProvider(ctx, providerID, apiKey, metadata); err != nil {
 		if errors.Is(err, errStatusNotImplemented) {
 			meta := map[string]any{"status": "skipped", "scope": scopeLabel, "reason": err.Error()}
 			h.recordCredentialEvent(ctx, session.CompanyID, scopeUser, session.UserID, providerID, "status", meta)
@@ -872,6 +1336,7 @@ func (h *AI) DeleteProviderCredential(w http.ResponseWriter, r *http.Request) {
 		eventMeta["user_id"] = record.UserID.UUID.String()
 	}
 	h.recordCredentialEvent(ctx, session.CompanyID, record.UserID, session.UserID, record.ProviderID, "delete", eventMeta)
+	h.evictCredential(credentialID)
 
 	triggerCredentialRefresh(w)
 	if respondWithAINotice(w, r, "success", "Credential deleted", nil) {
@@ -914,7 +1379,7 @@ func (h *AI) TestProviderCredential(w http.ResponseWriter, r *http.Request) {
 	if providerCandidate == "" {
 		providerCandidate = req.Provider
 	}
-	providerID, _, err := h.normalizeProvider(r.Context(), providerCandidate)
+	providerID, entry, err := h.normalizeProvider(r.Context(), providerCandidate)
 	if err != nil {
 		if respondWithAINotice(w, r, "error", "Unknown provider", err) {
 			return
@@ -976,16 +1441,46 @@ func (h *AI) TestProviderCredential(w http.ResponseWriter, r *http.Request) {
 		h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusForbidden, msg, permErr)
 		return
 	}
-
-	if stored == nil {
-		if req.APIKey == "" {
-			h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusBadRequest, "apiKey is required", errors.New("missing api key"))
+
+	if stored == nil && h.providerRequiresAPIKey(entry) {
+		if req.APIKey == "" {
+			h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusBadRequest, "apiKey is required", errors.New("missing api key"))
+			return
+		}
+		if err := validateAPIKeyFormat(providerID, req.APIKey); err != nil {
+			h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusBadRequest, "invalid api key", err)
+			return
+		}
+	}
+
+	apiKey := req.APIKey
+	metadata := cloneMetadata(req.Metadata)
+	if req.Model != "" {
+		metadata["model"] = req.Model
+	}
+	if req.BaseURL != "" {
+		metadata["base_url"] = req.BaseURL
+	}
+	if stored != nil {
+		if h.CredentialCipher == nil {
+			h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusInternalServerError, "credential cipher unavailable", errors.New("credential cipher not configured"))
 			return
 		}
-		if err := validateAPIKeyFormat(providerID, req.APIKey); err != nil {
-			h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusBadRequest, "invalid api key", err)
+		plaintext, err := h.CredentialCipher.Decrypt(ai.WithCredentialBinding(r.Context(), stored.Binding()), stored.CredentialCipher)
+		if err != nil {
+			h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusInternalServerError, "failed to decrypt credential", err)
 			return
 		}
+		apiKey = strings.TrimSpace(string(plaintext))
+		metadata = stored.Metadata
+	}
+
+	pingCtx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	pingErr := h.pingProvider(pingCtx, providerID, apiKey, metadata)
+	cancel()
+	if pingErr != nil && !errors.Is(pingErr, errStatusNotImplemented) {
+		h.handleTestFailure(w, r, session, providerID, scopeUser, scopeLabel, http.StatusBadGateway, "provider rejected credential", pingErr)
+		return
 	}
 
 	meta := map[string]any{
@@ -993,6 +1488,12 @@ func (h *AI) TestProviderCredential(w http.ResponseWriter, r *http.Request) {
 		"scope":        scopeLabel,
 		"api_supplied": stored == nil,
 	}
+	notice := "Credential test succeeded"
+	if pingErr != nil {
+		meta["status"] = "skipped"
+		meta["reason"] = pingErr.Error()
+		notice = "Credential format looks valid; this provider has no live check"
+	}
 	if stored != nil {
 		meta["credential_id"] = stored.ID.String()
 		meta["fingerprint"] = stored.Fingerprint
@@ -1002,9 +1503,8 @@ func (h *AI) TestProviderCredential(w http.ResponseWriter, r *http.Request) {
 	}
 	h.recordCredentialEvent(r.Context(), session.CompanyID, scopeUser, session.UserID, providerID, "test", meta)
 
-	// TODO: implement provider ping once provider clients support it.
 	triggerCredentialRefresh(w)
-	if respondWithAINotice(w, r, "success", "Credential test succeeded", nil) {
+	if respondWithAINotice(w, r, "success", notice, nil) {
 		return
 	}
 
@@ -1056,7 +1556,7 @@ func (h *AI) CreateConversation(w http.ResponseWriter, r *http.Request) {
 
 	providerID := req.Provider
 	if providerID == "" {
-		providerID = h.DefaultProvider
+		providerID = h.preferredProvider(r.Context(), session.CompanyID, session.UserID)
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
@@ -1201,6 +1701,14 @@ func (h *AI) AppendConversationMessage(w http.ResponseWriter, r *http.Request) {
 			RespondWithError(w, http.StatusNotFound, "conversation not found", err)
 			return
 		}
+		if errors.Is(err, ai.ErrBudgetExceeded) {
+			RespondWithError(w, http.StatusPaymentRequired, "monthly AI budget exceeded", err)
+			return
+		}
+		if errors.Is(err, ai.ErrProviderNotAllowed) || errors.Is(err, ai.ErrModelNotAllowed) {
+			RespondWithError(w, http.StatusForbidden, "provider or model not allowed for company", err)
+			return
+		}
 		RespondWithError(w, http.StatusInternalServerError, "failed to generate reply", err)
 		return
 	}
@@ -1223,24 +1731,51 @@ func (h *AI) CreateDocumentJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req createDocumentJobRequest
-	if err := decodeJSON(r, &req); err != nil {
+	var (
+		req     createDocumentJobRequest
+		uploads []*multipart.FileHeader
+		err     error
+	)
+	if isMultipartRequest(r) {
+		req, uploads, err = parseMultipartDocumentJob(w, r)
+	} else {
+		err = decodeJSON(r, &req)
+	}
+	if err != nil {
 		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
 		return
 	}
-	if len(req.Documents) == 0 {
-		RespondWithError(w, http.StatusBadRequest, "documents are required", errors.New("missing documents"))
+	if len(req.Documents) == 0 && len(req.Files) == 0 && len(uploads) == 0 {
+		RespondWithError(w, http.StatusBadRequest, "documents or files are required", errors.New("missing documents"))
+		return
+	}
+
+	jobType := documents.JobTypeSummary
+	if req.Type != "" {
+		jobType = req.Type
+	}
+	if !documents.ValidJobType(jobType) {
+		RespondWithError(w, http.StatusBadRequest, "unsupported job type", fmt.Errorf("job type %q", jobType))
 		return
 	}
 
 	providerID := req.Provider
 	if providerID == "" {
-		providerID = h.DefaultProvider
+		providerID = h.preferredProvider(r.Context(), session.CompanyID, session.UserID)
 	}
 
 	requestPayload := map[string]any{
+		"type":      jobType,
 		"documents": req.Documents,
 	}
+	if req.CustomerID != "" {
+		customerID, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "invalid customer id", err)
+			return
+		}
+		requestPayload["customer_id"] = customerID.String()
+	}
 	if req.Instructions != "" {
 		requestPayload["instructions"] = req.Instructions
 	}
@@ -1248,9 +1783,37 @@ func (h *AI) CreateDocumentJob(w http.ResponseWriter, r *http.Request) {
 		requestPayload["metadata"] = req.Metadata
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	timeout := 10 * time.Second
+	if len(uploads) > 0 {
+		timeout = uploadTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
 	defer cancel()
 
+	if req.PromptTemplateVersionID != "" {
+		if jobType != documents.JobTypeSummary {
+			RespondWithError(w, http.StatusBadRequest, "prompt templates apply to summary jobs only", fmt.Errorf("job type %q", jobType))
+			return
+		}
+		version, err := h.documentPromptVersion(ctx, session.CompanyID, req.PromptTemplateVersionID)
+		if err != nil {
+			status, msg := promptTemplateErrorStatus(err)
+			RespondWithError(w, status, msg, err)
+			return
+		}
+		requestPayload[prompts.MetadataPinnedVersion] = version.ID.String()
+	}
+
+	files, err := h.resolveJobFiles(ctx, session, req.Files, uploads)
+	if err != nil {
+		status, msg := uploadErrorStatus(err)
+		RespondWithError(w, status, msg, err)
+		return
+	}
+	if len(files) > 0 {
+		requestPayload["files"] = documents.FileRefs(files)
+	}
+
 	job, err := h.Documents.Enqueue(ctx, documents.CreateJobParams{
 		CompanyID:  session.CompanyID,
 		UserID:     session.UserID,
@@ -1332,6 +1895,83 @@ func (h *AI) GetDocumentJob(w http.ResponseWriter, r *http.Request) {
 	RespondWithJSON(w, http.StatusOK, jobToResponse(job))
 }
 
+// CancelDocumentJob stops a queued or running job. Running jobs stop at their worker's next
+// lease renewal, so the response may still report them as processing.
+func (h *AI) CancelDocumentJob(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Documents == nil {
+		RespondWithError(w, http.StatusInternalServerError, "document analysis unavailable", errors.New("document service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid job id", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.Documents.Cancel(ctx, session.CompanyID, jobID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		RespondWithError(w, http.StatusNotFound, "job not found", err)
+		return
+	case errors.Is(err, documents.ErrJobNotCancellable):
+		RespondWithError(w, http.StatusConflict, "job has already finished", err)
+		return
+	case err != nil:
+		RespondWithError(w, http.StatusInternalServerError, "failed to cancel job", err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusAccepted, jobToResponse(job))
+}
+
+// DeleteDocumentJob removes a job and its result. A running job is stopped as well.
+func (h *AI) DeleteDocumentJob(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Documents == nil {
+		RespondWithError(w, http.StatusInternalServerError, "document analysis unavailable", errors.New("document service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid job id", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.Documents.Job(ctx, session.CompanyID, jobID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "job not found", err)
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "failed to load job", err)
+		return
+	}
+	if err := h.Documents.Remove(ctx, session.CompanyID, jobID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to delete job", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func sessionToResponse(record conversation.Session) conversationResponse {
 	return conversationResponse{
 		ID:        record.ID.String(),
@@ -1354,17 +1994,28 @@ func messageToResponse(record conversation.Message) messageResponse {
 }
 
 func jobToResponse(job documents.Job) documentJobResponse {
-	return documentJobResponse{
+	resp := documentJobResponse{
 		ID:          job.ID.String(),
 		Provider:    job.ProviderID,
 		Status:      job.Status,
 		Request:     job.Request,
 		Response:    job.Response,
 		Error:       job.ErrorMessage,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
 		CreatedAt:   job.CreatedAt,
 		UpdatedAt:   job.UpdatedAt,
 		CompletedAt: job.CompletedAt,
 	}
+	if job.Status == documents.StatusQueued && job.Attempts > 0 && job.RunAfter.After(time.Now()) {
+		next := job.RunAfter
+		resp.NextAttemptAt = &next
+	}
+	if job.Status == documents.StatusProcessing && job.ProgressStage != "" {
+		resp.Progress = &documentJobProgress{Stage: job.ProgressStage, Detail: job.ProgressDetail}
+	}
+	resp.CancelRequested = job.CancelRequestedAt != nil
+	return resp
 }
 
 func paginationParams(r *http.Request, fallback int32) (int32, int32) {
@@ -1417,7 +2068,7 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 	if providerCandidate == "" {
 		providerCandidate = req.Provider
 	}
-	providerID, _, err := h.normalizeProvider(r.Context(), providerCandidate)
+	providerID, entry, err := h.normalizeProvider(r.Context(), providerCandidate)
 	if err != nil {
 		if respondWithAINotice(w, r, "error", "Unknown provider", err) {
 			return
@@ -1469,6 +2120,18 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 		labelPtr = &labelValue
 	}
 
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		expiresAt, err = parseCredentialExpiry(*req.ExpiresAt)
+		if err != nil {
+			if respondWithAINotice(w, r, "error", "Invalid expiry date", err) {
+				return
+			}
+			RespondWithError(w, http.StatusBadRequest, "invalid expiry date", err)
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
@@ -1551,11 +2214,22 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Ciphertext is bound to its row, so a new credential gets its ID before encryption.
+	credentialID := uuid.New()
+	if hasExisting {
+		credentialID = existing.ID
+	}
+	bound := ai.WithCredentialBinding(ctx, ai.CredentialBinding{
+		CompanyID:    session.CompanyID,
+		ProviderID:   providerID,
+		CredentialID: credentialID,
+	})
+
 	var credentialCipher []byte
 	var credentialHash []byte
 	switch {
 	case req.APIKey != "":
-		ciphertext, err := h.CredentialCipher.Encrypt(ctx, []byte(req.APIKey))
+		ciphertext, err := h.CredentialCipher.Encrypt(bound, []byte(req.APIKey))
 		if err != nil {
 			if respondWithAINotice(w, r, "error", "Failed to encrypt credential", err) {
 				return
@@ -1568,6 +2242,19 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 	case hasExisting:
 		credentialCipher = append([]byte(nil), existing.CredentialCipher...)
 		credentialHash = append([]byte(nil), existing.CredentialHash...)
+	case !h.providerRequiresAPIKey(entry):
+		// Self-hosted providers may run without a key; store an empty secret so the
+		// credential still carries the base URL and model metadata.
+		ciphertext, err := h.CredentialCipher.Encrypt(bound, []byte{})
+		if err != nil {
+			if respondWithAINotice(w, r, "error", "Failed to encrypt credential", err) {
+				return
+			}
+			RespondWithError(w, http.StatusInternalServerError, "failed to encrypt credential", err)
+			return
+		}
+		credentialCipher = ciphertext
+		credentialHash = hashSecret([]byte{})
 	default:
 		missingErr := errors.New("missing api key")
 		if respondWithAINotice(w, r, "error", "API key is required", missingErr) {
@@ -1578,6 +2265,7 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 	}
 
 	record := ai.CredentialRecord{
+		ID:               credentialID,
 		CompanyID:        session.CompanyID,
 		UserID:           scopeUser,
 		ProviderID:       providerID,
@@ -1586,11 +2274,14 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 		Metadata:         metadata,
 		Label:            labelPtr,
 		IsDefault:        req.MakeDefault,
+		ExpiresAt:        expiresAt,
 	}
 
 	if hasExisting {
-		record.ID = existing.ID
 		record.UserID = existing.UserID
+		if req.ExpiresAt == nil {
+			record.ExpiresAt = existing.ExpiresAt
+		}
 	}
 
 	if record.IsDefault {
@@ -1616,6 +2307,10 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 	if status == http.StatusCreated {
 		action = "create"
 	}
+	if hasExisting {
+		// The key or its metadata may have changed; drop providers built from the old ones.
+		h.evictCredential(stored.ID)
+	}
 	eventMeta := map[string]any{
 		"credential_id":     stored.ID.String(),
 		"fingerprint":       stored.Fingerprint,
@@ -1629,6 +2324,9 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 	if stored.UserID.Valid {
 		eventMeta["user_id"] = stored.UserID.UUID.String()
 	}
+	if stored.ExpiresAt != nil {
+		eventMeta["expires_at"] = stored.ExpiresAt.UTC().Format(time.RFC3339)
+	}
 	if keys := mapKeys(metadata); len(keys) > 0 {
 		eventMeta["metadata_keys"] = keys
 	}
@@ -1643,7 +2341,7 @@ func (h *AI) UpsertProviderCredential(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	RespondWithJSON(w, status, credentialRecordToResponse(stored))
+	RespondWithJSON(w, status, credentialRecordToResponse(stored, h.credentialPolicy(ctx, session.CompanyID)))
 }
 
 func hashSecret(secret []byte) []byte {
@@ -1655,7 +2353,7 @@ func hashSecret(secret []byte) []byte {
 
 func (h *AI) BuildChatProps(ctx context.Context, session auth.Session, providerCandidate, conversationCandidate string, ensureSession bool) (pages.ChatPageProps, error) {
 	props := pages.ChatPageProps{}
-	entries := h.catalogEntries(ctx)
+	entries := h.companyCatalog(ctx, session)
 	props.Providers = chatProvidersFromEntries(entries)
 
 	if len(entries) == 0 {
@@ -1670,7 +2368,7 @@ func (h *AI) BuildChatProps(ctx context.Context, session auth.Session, providerC
 
 	activeID := strings.TrimSpace(providerCandidate)
 	if activeID == "" {
-		activeID = h.DefaultProvider
+		activeID = h.preferredProvider(ctx, session.CompanyID, session.UserID)
 	}
 
 	activeEntry, found := providerLookup[activeID]
@@ -1772,9 +2470,15 @@ func (h *AI) BuildChatProps(ctx context.Context, session auth.Session, providerC
 }
 
 func (h *AI) chatCredentialReason(ctx context.Context, session auth.Session, providerID string) string {
+	if _, ok := h.allowedModels(ctx, session.CompanyID, providerID); !ok {
+		return "Your company does not allow this provider. Start a conversation with another one."
+	}
 	if h.APIKey != "" {
 		return ""
 	}
+	if entry, ok := h.catalogEntry(ctx, providerID); ok && !h.providerRequiresAPIKey(entry) {
+		return ""
+	}
 	if h.CredentialStore == nil {
 		return "Credential store unavailable."
 	}
@@ -1816,16 +2520,70 @@ func chatProvidersFromEntries(entries []ai.ProviderCatalogEntry) []pages.ChatPro
 func chatMessagesToView(messages []conversation.Message) []pages.ChatMessageView {
 	views := make([]pages.ChatMessageView, 0, len(messages))
 	for _, msg := range messages {
+		// Tool traffic is replayed to the model but not shown in the transcript.
+		if msg.Role == ai.RoleTool || (msg.Role == ai.RoleAssistant && msg.Content == "" && msg.Metadata[conversation.MetadataToolCalls] != nil) {
+			continue
+		}
+		warning, _ := msg.Metadata["budget_warning"].(string)
+		citations := grounding.CitationsFromMetadata(msg.Metadata)
 		views = append(views, pages.ChatMessageView{
 			ID:        msg.ID.String(),
 			Role:      msg.Role,
 			Content:   msg.Content,
+			Segments:  citationSegments(msg.Content, citations),
+			Citations: citationsToView(citations),
+			Warning:   warning,
 			CreatedAt: msg.CreatedAt,
 		})
 	}
 	return views
 }
 
+func citationsToView(citations []grounding.Citation) []pages.ChatCitationView {
+	if len(citations) == 0 {
+		return nil
+	}
+	views := make([]pages.ChatCitationView, 0, len(citations))
+	for _, citation := range citations {
+		views = append(views, pages.ChatCitationView{Reference: citation.Reference, URL: citation.URL()})
+	}
+	return views
+}
+
+// citationSegments splits content so each cited ASC reference renders as a link to its
+// paragraph. It returns nil when the content mentions none of the citations.
+func citationSegments(content string, citations []grounding.Citation) []pages.ChatSegment {
+	if len(citations) == 0 {
+		return nil
+	}
+	var segments []pages.ChatSegment
+	linked := false
+	rest := content
+	for rest != "" {
+		index, match := -1, grounding.Citation{}
+		for _, citation := range citations {
+			i := strings.Index(rest, citation.Reference)
+			if i >= 0 && (index < 0 || i < index || (i == index && len(citation.Reference) > len(match.Reference))) {
+				index, match = i, citation
+			}
+		}
+		if index < 0 {
+			segments = append(segments, pages.ChatSegment{Text: rest})
+			break
+		}
+		if index > 0 {
+			segments = append(segments, pages.ChatSegment{Text: rest[:index]})
+		}
+		segments = append(segments, pages.ChatSegment{Text: match.Reference, URL: match.URL()})
+		linked = true
+		rest = rest[index+len(match.Reference):]
+	}
+	if !linked {
+		return nil
+	}
+	return segments
+}
+
 func chatSessionsToView(sessions []conversation.Session, providers map[string]ai.ProviderCatalogEntry, active uuid.UUID) []pages.ChatConversationView {
 	views := make([]pages.ChatConversationView, 0, len(sessions))
 	for _, sessionRecord := range sessions {
@@ -1913,35 +2671,88 @@ func (h *AI) appendConversationAndListMessages(ctx context.Context, session auth
 		return conversation.Session{}, nil, conversation.Message{}, err
 	}
 
+	// Conversation and message metadata override the user's preferences. The chosen model
+	// applies to the session's provider only; the client drops it on failover.
 	metadataMerged := mergeMetadataMaps(sessionRecord.Metadata, metadata)
-	completionMetadata := map[string]any{}
+	metadataMerged = h.userPreference(ctx, session.CompanyID, session.UserID).Apply(metadataMerged, sessionRecord.ProviderID)
+	chatMetadata := map[string]any{}
+	if model, ok := metadataMerged["model"].(string); ok && strings.TrimSpace(model) != "" {
+		chatMetadata["model"] = strings.TrimSpace(model)
+	}
+	if temperature, ok := metadataMerged["temperature"]; ok {
+		chatMetadata["temperature"] = temperature
+	}
 	if addendum, ok := metadataMerged["system_addendum"].(string); ok && addendum != "" {
-		completionMetadata = ai.WithSystemAddendum(completionMetadata, addendum)
+		chatMetadata = ai.WithSystemAddendum(chatMetadata, addendum)
 	}
+	promptVersion, promptText := h.chatPrompt(ctx, session, metadataMerged)
+	chatMetadata = ai.WithSystemAddendum(chatMetadata, promptText)
 
 	options := h.userOptions(ctx, session.CompanyID, session.UserID, sessionRecord.ProviderID)
-	if options.APIKey == "" {
+	options.Feature = featureChat
+	if options.APIKey == "" && h.providerIDRequiresAPIKey(ctx, options.Provider) {
 		return sessionRecord, messages, msg, errors.New("credential missing for provider")
 	}
 
-	prompt := buildConversationPrompt(messages)
-	resp, err := h.Client.Completion(ctx, options, ai.CompletionRequest{Prompt: prompt, Metadata: completionMetadata})
+	var grounded grounding.Context
+	if role == ai.RoleUser && h.Grounding != nil {
+		// Guidance is best effort: a failed search should not block the chat.
+		if grounded, err = h.Grounding.Context(ctx, content); err != nil {
+			log.Printf("ai: guidance retrieval failed: %v", err)
+		}
+		chatMetadata = ai.WithSystemAddendum(chatMetadata, grounded.Addendum)
+	}
+
+	transcript, err := h.Conversations.BuildContext(ctx, sessionRecord, messages, h.contextOptions(ctx, options.Provider, metadataMerged), h.conversationSummarizer(options))
 	if err != nil {
 		return conversation.Session{}, nil, conversation.Message{}, err
 	}
 
-	reply, err := h.Conversations.AppendMessage(ctx, conversation.CreateMessageParams{
-		SessionID: sessionID,
-		Role:      "assistant",
-		Content:   strings.TrimSpace(resp.Text),
-		Metadata: map[string]any{
-			"provider": sessionRecord.ProviderID,
-		},
+	resp, err := h.Client.Chat(ctx, options, ai.ChatRequest{
+		Messages: transcript,
+		Metadata: chatMetadata,
 	})
 	if err != nil {
 		return conversation.Session{}, nil, conversation.Message{}, err
 	}
 
+	// Persist tool calls and results ahead of the answer so later turns replay them.
+	for _, turn := range resp.Turns {
+		params := conversation.TurnParams(sessionID, turn, map[string]any{"provider": sessionRecord.ProviderID})
+		if _, err := h.Conversations.AppendMessage(ctx, params); err != nil {
+			return conversation.Session{}, nil, conversation.Message{}, err
+		}
+	}
+
+	replyMetadata := map[string]any{
+		"provider": sessionRecord.ProviderID,
+	}
+	if resp.Usage.PromptTokens > 0 || resp.Usage.CompletionTokens > 0 {
+		replyMetadata["usage"] = map[string]any{
+			"model":             resp.Usage.Model,
+			"prompt_tokens":     resp.Usage.PromptTokens,
+			"completion_tokens": resp.Usage.CompletionTokens,
+		}
+	}
+	if len(resp.Warnings) > 0 {
+		replyMetadata["budget_warning"] = resp.Warnings[0]
+	}
+	if promptVersion.ID != uuid.Nil {
+		replyMetadata[prompts.MetadataTemplate] = promptVersion.Ref()
+	}
+
+	answer := resp.Message
+	answer.Role = ai.RoleAssistant
+	answer.Content = strings.TrimSpace(answer.Content)
+	candidates := append(grounded.Citations, grounding.FromTurns(resp.Turns)...)
+	if citations := grounding.Cited(answer.Content, candidates); len(citations) > 0 {
+		replyMetadata[grounding.MetadataCitations] = citations
+	}
+	reply, err := h.Conversations.AppendMessage(ctx, conversation.TurnParams(sessionID, answer, replyMetadata))
+	if err != nil {
+		return conversation.Session{}, nil, conversation.Message{}, err
+	}
+
 	updated, err := h.Conversations.ListSessionMessages(ctx, sessionID)
 	if err != nil {
 		return conversation.Session{}, nil, conversation.Message{}, err
@@ -1950,6 +2761,43 @@ func (h *AI) appendConversationAndListMessages(ctx context.Context, session auth
 	return sessionRecord, updated, reply, nil
 }
 
+// chatPrompt renders the session's pinned chat template, or the company default, as a
+// system addendum. Template problems are logged and the chat continues without it.
+func (h *AI) chatPrompt(ctx context.Context, session auth.Session, metadata map[string]any) (ai.PromptTemplateVersion, string) {
+	if h.Prompts == nil {
+		return ai.PromptTemplateVersion{}, ""
+	}
+	version, err := h.Prompts.Resolve(ctx, session.CompanyID, prompts.KindChat, prompts.PinnedVersion(metadata))
+	if err != nil {
+		if !errors.Is(err, prompts.ErrNotFound) {
+			log.Printf("ai: resolve chat prompt template: %v", err)
+		}
+		return ai.PromptTemplateVersion{}, ""
+	}
+	text, err := version.Render(prompts.Data{Today: time.Now().Format(time.DateOnly)})
+	if err != nil {
+		log.Printf("ai: render prompt template %s v%d: %v", version.Name, version.Version, err)
+		return ai.PromptTemplateVersion{}, ""
+	}
+	return version, text
+}
+
+// contextOptions sizes chat history to the catalog's context window for the session's model.
+func (h *AI) contextOptions(ctx context.Context, providerID string, metadata map[string]any) conversation.ContextOptions {
+	entry, _ := h.catalogEntry(ctx, providerID)
+	model, _ := metadata["model"].(string)
+	return conversation.ContextOptions{Window: entry.ContextWindow(model)}
+}
+
+// conversationSummarizer folds older turns into the rolling summary using the chat's own
+// provider chain, billed separately from the chat itself.
+func (h *AI) conversationSummarizer(options ai.UserOptions) conversation.Summarizer {
+	options.Feature = featureChatSummary
+	return conversation.CompletionSummarizer(func(ctx context.Context, req ai.CompletionRequest) (ai.CompletionResponse, error) {
+		return h.Client.Completion(ctx, options, req)
+	})
+}
+
 func (h *AI) writeChatShell(w http.ResponseWriter, ctx context.Context, props pages.ChatPageProps) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := pages.ChatShell(props).Render(ctx, w); err != nil {
@@ -1971,23 +2819,37 @@ func (h *AI) writeChatConversationItems(w http.ResponseWriter, ctx context.Conte
 	}
 }
 
+// userOptions resolves credentials and the fallback chain for a call. Without an explicit
+// provider the user's preferred provider is used. Preferred model, temperature and addendum
+// travel in request metadata rather than opts.Metadata, because provider instances are
+// cached per credential and shared by every user of a company key.
 func (h *AI) userOptions(ctx context.Context, companyID, userID uuid.UUID, providerID string) ai.UserOptions {
 	if providerID == "" {
-		providerID = h.DefaultProvider
+		providerID = h.preferredProvider(ctx, companyID, userID)
 	}
+	opts := h.primaryOptions(ctx, companyID, userID, providerID)
+	opts.Fallbacks = h.fallbackOptions(ctx, companyID, userID, providerID)
+	opts.CompanyID = companyID
+	opts.UserID = userID
+	return opts
+}
+
+func (h *AI) primaryOptions(ctx context.Context, companyID, userID uuid.UUID, providerID string) ai.UserOptions {
 	opts := ai.UserOptions{Provider: providerID}
 	if h.APIKey != "" {
 		opts.APIKey = h.APIKey
 	}
 	if opts.APIKey == "" && h.Resolver != nil {
 		reference := ai.CredentialReference{CompanyID: companyID, UserID: userID, ProviderID: providerID}
-		if key, err := h.Resolver.Resolve(ctx, reference.String()); err == nil && key != "" {
-			opts.APIKey = key
+		if credential, err := h.Resolver.Resolve(ctx, reference.String()); err == nil && !credential.IsZero() {
+			opts.APIKey = credential.Secret
+			opts.Metadata = credential.Metadata
+			opts.CredentialID = credential.ID
 		} else if err != nil && h.CredentialMetrics != nil {
 			h.CredentialMetrics.CredentialResolveFailure(companyID, providerID)
 		}
 	}
-	if opts.APIKey == "" && h.CredentialMetrics != nil {
+	if opts.APIKey == "" && h.CredentialMetrics != nil && h.providerIDRequiresAPIKey(ctx, providerID) {
 		scope := "user"
 		if userID == uuid.Nil {
 			scope = "company"
@@ -1997,6 +2859,43 @@ func (h *AI) userOptions(ctx context.Context, companyID, userID uuid.UUID, provi
 	return opts
 }
 
+// fallbackOptions resolves credentials for the company's fallback chain, skipping providers
+// that need a key the user or company has not configured.
+func (h *AI) fallbackOptions(ctx context.Context, companyID, userID uuid.UUID, primary string) []ai.UserOptions {
+	if h.Settings == nil {
+		return nil
+	}
+	chain, err := h.Settings.FallbackProviders(ctx, companyID)
+	if err != nil {
+		log.Printf("ai: load fallback providers failed: %v", err)
+		return nil
+	}
+
+	fallbacks := make([]ai.UserOptions, 0, len(chain))
+	for _, providerID := range chain {
+		if providerID == primary {
+			continue
+		}
+		opts := ai.UserOptions{Provider: providerID}
+		if h.APIKey != "" && providerID == h.DefaultProvider {
+			opts.APIKey = h.APIKey
+		}
+		if opts.APIKey == "" && h.Resolver != nil {
+			reference := ai.CredentialReference{CompanyID: companyID, UserID: userID, ProviderID: providerID}
+			if credential, err := h.Resolver.Resolve(ctx, reference.String()); err == nil {
+				opts.APIKey = credential.Secret
+				opts.Metadata = credential.Metadata
+				opts.CredentialID = credential.ID
+			}
+		}
+		if opts.APIKey == "" && h.providerIDRequiresAPIKey(ctx, providerID) {
+			continue
+		}
+		fallbacks = append(fallbacks, opts)
+	}
+	return fallbacks
+}
+
 func mergeMetadataMaps(values ...map[string]any) map[string]any {
 	out := map[string]any{}
 	for _, item := range values {
@@ -2007,21 +2906,6 @@ func mergeMetadataMaps(values ...map[string]any) map[string]any {
 	return out
 }
 
-func buildConversationPrompt(messages []conversation.Message) string {
-	var builder strings.Builder
-	for _, msg := range messages {
-		role := msg.Role
-		if role == "" {
-			role = "user"
-		}
-		builder.WriteString(strings.ToUpper(role))
-		builder.WriteString(": ")
-		builder.WriteString(msg.Content)
-		builder.WriteString("\n\n")
-	}
-	return builder.String()
-}
-
 func resolveCredentialScope(session auth.Session, scope, userIDParam string) (uuid.NullUUID, string, error) {
 	normalized := strings.ToLower(strings.TrimSpace(scope))
 	if normalized == "" {
@@ -2112,6 +2996,14 @@ func renderCredentialEvents(ctx context.Context, w http.ResponseWriter, views []
 	return err
 }
 
+// evictCredential drops the AI client's cached providers for a deleted or changed
+// credential so the next call resolves it again.
+func (h *AI) evictCredential(credentialID uuid.UUID) {
+	if h.Client != nil {
+		h.Client.EvictCredential(credentialID)
+	}
+}
+
 func triggerCredentialRefresh(w http.ResponseWriter) {
 	const trigger = "ai-credentials-refresh"
 	if existing := w.Header().Get("HX-Trigger"); existing != "" {
@@ -2131,10 +3023,33 @@ func validateAPIKeyFormat(providerID, key string) error {
 		if !strings.HasPrefix(key, "sk-") {
 			return fmt.Errorf("openai api keys must begin with 'sk-'")
 		}
+	case "anthropic":
+		if !strings.HasPrefix(key, "sk-ant-") {
+			return fmt.Errorf("anthropic api keys must begin with 'sk-ant-'")
+		}
 	}
 	return nil
 }
 
+// providerRequiresAPIKey reports whether the catalog marks the apiKey field as required.
+// Entries without an apiKey field (or unknown providers) are assumed to need one.
+func (h *AI) providerRequiresAPIKey(entry ai.ProviderCatalogEntry) bool {
+	for _, field := range entry.Fields {
+		if field.ID == "apiKey" {
+			return field.Required
+		}
+	}
+	return true
+}
+
+func (h *AI) providerIDRequiresAPIKey(ctx context.Context, providerID string) bool {
+	entry, ok := h.catalogEntry(ctx, providerID)
+	if !ok {
+		return true
+	}
+	return h.providerRequiresAPIKey(entry)
+}
+
 func cloneMetadata(metadata map[string]any) map[string]any {
 	if len(metadata) == 0 {
 		return map[string]any{}
@@ -2297,6 +3212,10 @@ func parseUpsertProviderCredentialRequest(r *http.Request) (upsertProviderCreden
 	req.Label = strings.TrimSpace(formValue(form, "label"))
 	req.CredentialID = strings.TrimSpace(formValue(form, "credentialId"))
 	req.MakeDefault = formValue(form, "makeDefault") != ""
+	if _, ok := form["expiresAt"]; ok {
+		expires := formValue(form, "expiresAt")
+		req.ExpiresAt = &expires
+	}
 	req.Metadata = make(map[string]any)
 
 	skip := map[string]struct{}{
@@ -2310,6 +3229,7 @@ func parseUpsertProviderCredentialRequest(r *http.Request) (upsertProviderCreden
 		"label":        {},
 		"makeDefault":  {},
 		"credentialId": {},
+		"expiresAt":    {},
 	}
 
 	for key, values := range form {
@@ -2325,6 +3245,22 @@ func parseUpsertProviderCredentialRequest(r *http.Request) (upsertProviderCreden
 	return req, nil
 }
 
+// parseCredentialExpiry accepts a date, taken as midnight UTC, or an RFC 3339 timestamp.
+// An empty value means no expiry.
+func parseCredentialExpiry(raw string) (*time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			parsed = parsed.UTC()
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid expiry %q: use YYYY-MM-DD", raw)
+}
+
 func formValue(values url.Values, key string) string {
 	return strings.TrimSpace(values.Get(key))
 }
@@ -2412,44 +3348,27 @@ func metadataString(meta map[string]any, key string) string {
 	return ""
 }
 
-func (h *AI) pingProvider(ctx context.Context, providerID string, entry ai.ProviderCatalogEntry, apiKey string, metadata map[string]any) error {
-	switch providerID {
-	case "openai":
-		baseURL := metadataString(metadata, "base_url")
-		if baseURL == "" {
-			baseURL = metadataString(metadata, "baseUrl")
-		}
-		return pingOpenAI(ctx, baseURL, apiKey)
-	default:
-		return fmt.Errorf("%w: %s", errStatusNotImplemented, providerID)
-	}
-}
-
-func pingOpenAI(ctx context.Context, baseURL, apiKey string) error {
-	if apiKey == "" {
-		return errors.New("missing api key")
-	}
-	if baseURL == "" {
-		baseURL = "https://api.openai.com/v1"
-	}
-	baseURL = strings.TrimRight(baseURL, "/")
-	url := baseURL + "/models?limit=1"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// CheckCredential pings providerID with apiKey the same way the settings status endpoint
+// does. The background credential health checker calls it for stored credentials.
+func (h *AI) CheckCredential(ctx context.Context, providerID, apiKey string, metadata map[string]any) error {
+	normalized, _, err := h.normalizeProvider(ctx, providerID)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+	return h.pingProvider(ctx, normalized, apiKey, metadata)
+}
+
+// pingProvider runs the provider's status check through the shared AI client, so the
+// check uses the client's HTTP timeouts and the credential's base URL metadata.
+func (h *AI) pingProvider(ctx context.Context, providerID, apiKey string, metadata map[string]any) error {
+	if h.Client == nil {
+		return fmt.Errorf("%w: %s", errStatusNotImplemented, providerID)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return nil
+	err := h.Client.Ping(ctx, ai.UserOptions{Provider: providerID, APIKey: apiKey, Metadata: metadata})
+	if errors.Is(err, ai.ErrCapabilityNotImplemented) || errors.Is(err, ai.ErrProviderNotConfigured) {
+		return fmt.Errorf("%w: %s", errStatusNotImplemented, providerID)
 	}
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-	return fmt.Errorf("openai status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	return err
 }
 
 func (h *AI) recordCredentialEvent(ctx context.Context, companyID uuid.UUID, scopeUser uuid.NullUUID, actor uuid.UUID, providerID, action string, metadata map[string]any) {
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/provider/catalog"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+// ListCatalogEntries returns every provider catalog entry, including disabled ones, for
+// platform operators.
+func (h *AI) ListCatalogEntries(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.CatalogAdmin == nil {
+		RespondWithError(w, http.StatusInternalServerError, "catalog administration unavailable", errors.New("catalog admin not configured"))
+		return
+	}
+
+	entries, err := h.CatalogAdmin.List(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to list catalog", err)
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, struct {
+		Items []catalog.AdminEntry `json:"items"`
+	}{Items: entries})
+}
+
+// GetCatalogEntry returns a single provider catalog entry.
+func (h *AI) GetCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.CatalogAdmin == nil {
+		RespondWithError(w, http.StatusInternalServerError, "catalog administration unavailable", errors.New("catalog admin not configured"))
+		return
+	}
+
+	entry, err := h.CatalogAdmin.Get(r.Context(), chi.URLParam(r, "providerID"))
+	if err != nil {
+		respondWithCatalogError(w, err, "failed to load catalog entry")
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, entry)
+}
+
+// SaveCatalogEntry creates or replaces the catalog entry named in the URL. The body
+// carries the full entry; fields, models and capabilities are replaced wholesale.
+func (h *AI) SaveCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.CatalogAdmin == nil {
+		RespondWithError(w, http.StatusInternalServerError, "catalog administration unavailable", errors.New("catalog admin not configured"))
+		return
+	}
+
+	var entry catalog.AdminEntry
+	if err := decodeJSON(r, &entry); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+	providerID := strings.TrimSpace(chi.URLParam(r, "providerID"))
+	if entry.ID != "" && entry.ID != providerID {
+		RespondWithError(w, http.StatusBadRequest, "id does not match URL", fmt.Errorf("body id %q, url id %q", entry.ID, providerID))
+		return
+	}
+	entry.ID = providerID
+
+	saved, err := h.CatalogAdmin.Save(r.Context(), entry)
+	if err != nil {
+		respondWithCatalogError(w, err, "failed to save catalog entry")
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, saved)
+}
+
+// DeleteCatalogEntry removes a provider from the catalog.
+func (h *AI) DeleteCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.CatalogAdmin == nil {
+		RespondWithError(w, http.StatusInternalServerError, "catalog administration unavailable", errors.New("catalog admin not configured"))
+		return
+	}
+
+	if err := h.CatalogAdmin.Delete(r.Context(), chi.URLParam(r, "providerID")); err != nil {
+		respondWithCatalogError(w, err, "failed to delete catalog entry")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondWithCatalogError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, catalog.ErrNotFound):
+		RespondWithError(w, http.StatusNotFound, "catalog entry not found", err)
+	case errors.Is(err, catalog.ErrInvalidEntry):
+		RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+	default:
+		RespondWithError(w, http.StatusInternalServerError, message, err)
+	}
+}
+
+type modelAllowlistResponse struct {
+	Restricted bool                `json:"restricted"`
+	Providers  map[string][]string `json:"providers,omitempty"`
+}
+
+// modelAllowlistRequest accepts either the JSON providers map or the settings form's
+// "provider: model, model" text. A null or missing providers map lifts the restriction.
+type modelAllowlistRequest struct {
+	Providers map[string][]string `json:"providers"`
+}
+
+// GetModelAllowlist returns the providers and models the company may use.
+func (h *AI) GetModelAllowlist(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanViewCompanySettings {
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("view not permitted"))
+		return
+	}
+
+	allowlist, err := h.Settings.Allowlist(r.Context(), session.CompanyID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to load model allowlist", err)
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, modelAllowlistResponse{Restricted: allowlist.Restricted(), Providers: allowlist.Providers})
+}
+
+// UpdateModelAllowlist replaces the providers and models company members may select.
+func (h *AI) UpdateModelAllowlist(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Settings == nil {
+		if respondWithAINotice(w, r, "error", "Settings unavailable", errors.New("settings service not configured")) {
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "settings unavailable", errors.New("settings service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		if respondWithAINotice(w, r, "error", "Authentication required", errors.New("session missing")) {
+			return
+		}
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+	if !session.Capabilities.CanManageCompanyCredentials {
+		if respondWithAINotice(w, r, "error", "Only company administrators can change the model allowlist", errors.New("manage not permitted")) {
+			return
+		}
+		RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("manage not permitted"))
+		return
+	}
+
+	allowlist, err := parseModelAllowlistRequest(r)
+	if err == nil {
+		err = h.validateAllowlistProviders(r.Context(), allowlist)
+	}
+	if err != nil {
+		if respondWithAINotice(w, r, "error", "Invalid model allowlist", err) {
+			return
+		}
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+
+	saved, err := h.Settings.SetAllowlist(r.Context(), session.CompanyID, allowlist)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "failed to save model allowlist"
+		if errors.Is(err, ai.ErrInvalidModelAllowlist) {
+			status, message = http.StatusBadRequest, "allow at least one provider, or clear the list to allow all"
+		}
+		if respondWithAINotice(w, r, "error", message, err) {
+			return
+		}
+		RespondWithError(w, status, message, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Model allowlist saved", nil)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, modelAllowlistResponse{Restricted: saved.Restricted(), Providers: saved.Providers})
+}
+
+func parseModelAllowlistRequest(r *http.Request) (ai.ModelAllowlist, error) {
+	contentType := strings.TrimSpace(r.Header.Get("Content-Type"))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+
+	if contentType == "application/json" {
+		var req modelAllowlistRequest
+		if err := decodeJSON(r, &req); err != nil {
+			return ai.ModelAllowlist{}, err
+		}
+		return ai.ModelAllowlist{Providers: req.Providers}, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return ai.ModelAllowlist{}, err
+	}
+	return ai.ParseModelAllowlist(r.PostForm.Get("allowlist"))
+}
+
+// validateAllowlistProviders rejects provider IDs that are not in the catalog, which
+// would otherwise silently block the provider the admin meant to allow.
+func (h *AI) validateAllowlistProviders(ctx context.Context, allowlist ai.ModelAllowlist) error {
+	for _, providerID := range allowlist.ProviderIDs() {
+		if _, ok := h.catalogEntry(ctx, providerID); !ok {
+			return fmt.Errorf("unknown provider %q", providerID)
+		}
+	}
+	return nil
+}
+
+// companyCatalog narrows the catalog to the providers and models the company allowlist
+// permits, so pickers only offer choices the client will accept.
+func (h *AI) companyCatalog(ctx context.Context, session auth.Session) []ai.ProviderCatalogEntry {
+	entries := h.catalogEntries(ctx)
+	if h.Settings == nil {
+		return entries
+	}
+	allowlist, err := h.Settings.Allowlist(ctx, session.CompanyID)
+	if err != nil || !allowlist.Restricted() {
+		return entries
+	}
+
+	filtered := make([]ai.ProviderCatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		models, ok := allowlist.AllowedModels(entry.ID)
+		if !ok {
+			continue
+		}
+		if models != nil {
+			entry.Models = models
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// allowedModels reports whether the company may use providerID and which models it may
+// pick; nil means any. Lookup failures are logged and treated as unrestricted because the
+// client enforces the allowlist again on every call.
+func (h *AI) allowedModels(ctx context.Context, companyID uuid.UUID, providerID string) ([]string, bool) {
+	if h.Settings == nil {
+		return nil, true
+	}
+	models, ok, err := h.Settings.AllowedModels(ctx, companyID, providerID)
+	if err != nil {
+		log.Printf("ai: load model allowlist failed: %v", err)
+		return nil, true
+	}
+	return models, ok
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/credentials/aescipher"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+const testJWTSecret = "handler-test-secret"
+
+// memoryCredentialStore keeps credentials in a map keyed by ID.
+type memoryCredentialStore struct {
+	records map[uuid.UUID]ai.CredentialRecord
+}
+
+func newMemoryCredentialStore() *memoryCredentialStore {
+	return &memoryCredentialStore{records: make(map[uuid.UUID]ai.CredentialRecord)}
+}
+
+func (s *memoryCredentialStore) ResolveCredential(_ context.Context, companyID uuid.UUID, userID uuid.NullUUID, providerID string) (ai.CredentialRecord, error) {
+	for _, rec := range s.records {
+		if rec.CompanyID == companyID && rec.ProviderID == providerID && (!rec.UserID.Valid || rec.UserID == userID) {
+			return rec, nil
+		}
+	}
+	return ai.CredentialRecord{}, sql.ErrNoRows
+}
+
+func (s *memoryCredentialStore) GetCredential(_ context.Context, id uuid.UUID) (ai.CredentialRecord, error) {
+	rec, ok := s.records[id]
+	if !ok {
+		return ai.CredentialRecord{}, sql.ErrNoRows
+	}
+	return rec, nil
+}
+
+func (s *memoryCredentialStore) TouchCredential(context.Context, uuid.UUID) error { return nil }
+
+func (s *memoryCredentialStore) UpsertCredential(_ context.Context, record ai.CredentialRecord) (ai.CredentialRecord, error) {
+	record.UpdatedAt = time.Now()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = record.UpdatedAt
+	}
+	s.records[record.ID] = record
+	return record, nil
+}
+
+func (s *memoryCredentialStore) ListCompanyCredentials(_ context.Context, companyID uuid.UUID, _, _ int32) ([]ai.CredentialRecord, error) {
+	var out []ai.CredentialRecord
+	for _, rec := range s.records {
+		if rec.CompanyID == companyID {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryCredentialStore) ListProviderCredentials(_ context.Context, companyID uuid.UUID, providerID string, userID uuid.NullUUID) ([]ai.CredentialRecord, error) {
+	var out []ai.CredentialRecord
+	for _, rec := range s.records {
+		if rec.CompanyID == companyID && rec.ProviderID == providerID && rec.UserID == userID {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryCredentialStore) DeleteCredential(_ context.Context, id uuid.UUID) error {
+	delete(s.records, id)
+	return nil
+}
+
+func (s *memoryCredentialStore) ClearDefault(context.Context, uuid.UUID, string, uuid.NullUUID) error {
+	return nil
+}
+
+func TestUpsertProviderCredentialRoundTripsOpenAIOrganizationAndProject(t *testing.T) {
+	entry, ok := ai.ProviderCatalogEntryByID("openai")
+	if !ok {
+		t.Fatal("openai missing from the default catalog")
+	}
+	fields := make(map[string]bool, len(entry.Fields))
+	for _, field := range entry.Fields {
+		fields[field.ID] = true
+	}
+	if !fields["organization"] || !fields["project"] {
+		t.Fatalf("openai catalog fields = %v, want organization and project", fields)
+	}
+
+	cipher, err := aescipher.New(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	store := newMemoryCredentialStore()
+	h := &AI{DefaultProvider: "openai", CredentialStore: store, CredentialCipher: cipher}
+
+	companyID, userID := uuid.New(), uuid.New()
+	token, err := auth.MakeJWT(auth.JWTreq{
+		UserID:      userID,
+		CompanyID:   companyID,
+		CurrentRole: auth.RoleAdmin,
+		Roles:       map[uuid.UUID]auth.Role{companyID: auth.RoleAdmin},
+	}, testJWTSecret, time.Minute)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+
+	form := url.Values{
+		"provider":     {"openai"},
+		"scope":        {"company"},
+		"apiKey":       {"sk-test-0123456789"},
+		"organization": {"org-acme"},
+		"project":      {"proj_ledger"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/ai/providers/openai/credentials", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	auth.JWTMiddleware(testJWTSecret)(http.HandlerFunc(h.UpsertProviderCredential)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp providerCredentialResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Metadata["organization"] != "org-acme" || resp.Metadata["project"] != "proj_ledger" {
+		t.Fatalf("response metadata = %v", resp.Metadata)
+	}
+
+	resolver := ai.NewDBCredentialResolver(store, cipher, ai.NewNoopCredentialLogger())
+	credential, err := resolver.Resolve(context.Background(), fmt.Sprintf("%s:%s:openai", companyID, uuid.Nil))
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if credential.Secret != "sk-test-0123456789" {
+		t.Fatalf("secret did not round-trip")
+	}
+	if got := credential.Organization(); got != "org-acme" {
+		t.Fatalf("Organization() = %q", got)
+	}
+	if got := credential.Project(); got != "proj_ledger" {
+		t.Fatalf("Project() = %q", got)
+	}
+}
@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents/extract"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+const (
+	// maxMultipartBytes bounds a whole upload request; individual files are capped by
+	// documents.MaxUploadBytes.
+	maxMultipartBytes  = 5 * documents.MaxUploadBytes
+	multipartMemory    = 8 << 20
+	uploadTimeout      = 60 * time.Second
+	defaultFileLimit   = 50
+	maxFilesPerRequest = 10
+)
+
+// uploadFields are the multipart field names accepted for files; "attachment" is what the
+// review workspace form submits.
+var uploadFields = []string{"files", "file", "attachment"}
+
+type documentFileResponse struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType,omitempty"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	SHA256      string    `json:"sha256"`
+	Chunks      int       `json:"chunks"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// UploadDocumentFiles stores one or more files for later document jobs.
+func (h *AI) UploadDocumentFiles(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Documents == nil {
+		RespondWithError(w, http.StatusInternalServerError, "document analysis unavailable", errors.New("document service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	if !isMultipartRequest(r) {
+		RespondWithError(w, http.StatusUnsupportedMediaType, "multipart/form-data required", errors.New("not a multipart request"))
+		return
+	}
+	headers, err := parseUploadForm(w, r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid upload", err)
+		return
+	}
+	if len(headers) == 0 {
+		RespondWithError(w, http.StatusBadRequest, "at least one file is required", errors.New("missing files"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), uploadTimeout)
+	defer cancel()
+
+	files, err := h.uploadFiles(ctx, session, headers)
+	if err != nil {
+		status, msg := uploadErrorStatus(err)
+		RespondWithError(w, status, msg, err)
+		return
+	}
+
+	resp := listResponse[documentFileResponse]{}
+	for _, file := range files {
+		resp.Items = append(resp.Items, fileToResponse(file))
+	}
+	RespondWithJSON(w, http.StatusCreated, resp)
+}
+
+// ListDocumentFiles lists the company's uploaded files.
+func (h *AI) ListDocumentFiles(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Documents == nil {
+		RespondWithError(w, http.StatusInternalServerError, "document analysis unavailable", errors.New("document service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	limit, offset := paginationParams(r, defaultFileLimit)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	files, err := h.Documents.ListFiles(ctx, session.CompanyID, limit, offset)
+	if err != nil {
+		status, msg := uploadErrorStatus(err)
+		RespondWithError(w, status, msg, err)
+		return
+	}
+
+	resp := listResponse[documentFileResponse]{NextOffset: offset + limit}
+	for _, file := range files {
+		resp.Items = append(resp.Items, fileToResponse(file))
+	}
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// DeleteDocumentFile removes an uploaded file and its stored bytes.
+func (h *AI) DeleteDocumentFile(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Documents == nil {
+		RespondWithError(w, http.StatusInternalServerError, "document analysis unavailable", errors.New("document service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	fileID, err := uuid.Parse(chi.URLParam(r, "fileID"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid file id", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Documents.RemoveFile(ctx, session.CompanyID, fileID); err != nil {
+		status, msg := uploadErrorStatus(err)
+		RespondWithError(w, status, msg, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolveJobFiles stores any uploaded files and loads the referenced ones, returning them
+// in request order.
+func (h *AI) resolveJobFiles(ctx context.Context, session auth.Session, refs []string, uploads []*multipart.FileHeader) ([]documents.File, error) {
+	ids := make([]uuid.UUID, 0, len(refs))
+	for _, ref := range refs {
+		id, err := uuid.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return nil, errors.Join(documents.ErrFileNotFound, err)
+		}
+		ids = append(ids, id)
+	}
+	files, err := h.Documents.Files(ctx, session.CompanyID, ids)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := h.uploadFiles(ctx, session, uploads)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, uploaded...), nil
+}
+
+func (h *AI) uploadFiles(ctx context.Context, session auth.Session, headers []*multipart.FileHeader) ([]documents.File, error) {
+	if len(headers) > maxFilesPerRequest {
+		return nil, errTooManyFiles
+	}
+	files := make([]documents.File, 0, len(headers))
+	for _, header := range headers {
+		if header.Size > documents.MaxUploadBytes {
+			return nil, documents.ErrFileTooLarge
+		}
+		body, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		file, err := h.Documents.Upload(ctx, documents.UploadParams{
+			CompanyID:   session.CompanyID,
+			UserID:      session.UserID,
+			Filename:    header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			Body:        body,
+		})
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+var errTooManyFiles = errors.New("too many files in one request")
+
+func uploadErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, documents.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge, err.Error()
+	case errors.Is(err, errTooManyFiles):
+		return http.StatusBadRequest, "upload at most 10 files at a time"
+	case errors.Is(err, extract.ErrUnsupported):
+		return http.StatusUnsupportedMediaType, "unsupported file type; upload text, CSV, XLSX, or PDF files"
+	case errors.Is(err, extract.ErrNoText):
+		return http.StatusUnprocessableEntity, "no text could be extracted; scanned PDFs are not supported"
+	case errors.Is(err, extract.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, "the document contains too much text to process"
+	case errors.Is(err, documents.ErrFileNotFound):
+		return http.StatusNotFound, "file not found"
+	case errors.Is(err, documents.ErrFilesNotConfigured):
+		return http.StatusServiceUnavailable, "file uploads are not configured"
+	default:
+		return http.StatusInternalServerError, "failed to process files"
+	}
+}
+
+// parseMultipartDocumentJob reads a job submitted as multipart/form-data, returning the
+// text fields as a request plus any attached files.
+func parseMultipartDocumentJob(w http.ResponseWriter, r *http.Request) (createDocumentJobRequest, []*multipart.FileHeader, error) {
+	headers, err := parseUploadForm(w, r)
+	if err != nil {
+		return createDocumentJobRequest{}, nil, err
+	}
+	form := r.MultipartForm.Value
+	req := createDocumentJobRequest{
+		Provider:                formValue(form, "provider"),
+		Type:                    formValue(form, "type"),
+		CustomerID:              formValue(form, "customerId"),
+		Instructions:            formValue(form, "instructions"),
+		PromptTemplateVersionID: formValue(form, "promptTemplateVersionId"),
+	}
+	for _, doc := range form["documents"] {
+		if doc = strings.TrimSpace(doc); doc != "" {
+			req.Documents = append(req.Documents, doc)
+		}
+	}
+	for _, id := range append(form["files"], form["fileIds"]...) {
+		if id = strings.TrimSpace(id); id != "" {
+			req.Files = append(req.Files, id)
+		}
+	}
+	return req, headers, nil
+}
+
+func parseUploadForm(w http.ResponseWriter, r *http.Request) ([]*multipart.FileHeader, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxMultipartBytes)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		return nil, err
+	}
+	var headers []*multipart.FileHeader
+	for _, field := range uploadFields {
+		for _, header := range r.MultipartForm.File[field] {
+			if header.Filename != "" && header.Size > 0 {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers, nil
+}
+
+func isMultipartRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+func fileToResponse(file documents.File) documentFileResponse {
+	return documentFileResponse{
+		ID:          file.ID.String(),
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		SizeBytes:   file.SizeBytes,
+		SHA256:      file.SHA256,
+		Chunks:      file.Chunks,
+		CreatedAt:   file.CreatedAt,
+	}
+}
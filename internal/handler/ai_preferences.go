@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+// GetAIPreferences returns the signed-in user's AI defaults. Users without saved
+// preferences get an empty object and the company defaults apply.
+func (h *AI) GetAIPreferences(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Preferences == nil {
+		RespondWithError(w, http.StatusInternalServerError, "preferences unavailable", errors.New("preference service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	pref, err := h.Preferences.Get(r.Context(), session.CompanyID, session.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to load preferences", err)
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, pref)
+}
+
+// UpdateAIPreferences saves the signed-in user's default provider, model, temperature and
+// system addendum.
+func (h *AI) UpdateAIPreferences(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Preferences == nil {
+		if respondWithAINotice(w, r, "error", "Preferences unavailable", errors.New("preference service not configured")) {
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "preferences unavailable", errors.New("preference service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		if respondWithAINotice(w, r, "error", "Authentication required", errors.New("session missing")) {
+			return
+		}
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	pref, err := parseAIPreferenceRequest(r)
+	if err == nil {
+		err = h.validatePreference(r.Context(), session.CompanyID, pref)
+	}
+	if err != nil {
+		if respondWithAINotice(w, r, "error", "Invalid preferences", err) {
+			return
+		}
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+
+	saved, err := h.Preferences.Save(r.Context(), session.CompanyID, session.UserID, pref)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "failed to save preferences"
+		if errors.Is(err, ai.ErrInvalidUserPreference) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		if respondWithAINotice(w, r, "error", message, err) {
+			return
+		}
+		RespondWithError(w, status, message, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Preferences saved", nil)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, saved)
+}
+
+// DeleteAIPreferences clears the signed-in user's AI defaults.
+func (h *AI) DeleteAIPreferences(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Preferences == nil {
+		if respondWithAINotice(w, r, "error", "Preferences unavailable", errors.New("preference service not configured")) {
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "preferences unavailable", errors.New("preference service not configured"))
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		if respondWithAINotice(w, r, "error", "Authentication required", errors.New("session missing")) {
+			return
+		}
+		RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		return
+	}
+
+	if err := h.Preferences.Delete(r.Context(), session.CompanyID, session.UserID); err != nil {
+		if respondWithAINotice(w, r, "error", "Failed to reset preferences", err) {
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "failed to reset preferences", err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Preferences reset to company defaults", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseAIPreferenceRequest(r *http.Request) (ai.UserPreference, error) {
+	contentType := strings.TrimSpace(r.Header.Get("Content-Type"))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+
+	if contentType == "application/json" {
+		var pref ai.UserPreference
+		err := decodeJSON(r, &pref)
+		return pref, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return ai.UserPreference{}, err
+	}
+	pref := ai.UserPreference{
+		ProviderID:     formValue(r.PostForm, "providerId"),
+		Model:          formValue(r.PostForm, "model"),
+		SystemAddendum: formValue(r.PostForm, "systemAddendum"),
+	}
+	if raw := formValue(r.PostForm, "temperature"); raw != "" {
+		temperature, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return ai.UserPreference{}, fmt.Errorf("invalid temperature: %w", err)
+		}
+		pref.Temperature = &temperature
+	}
+	return pref, nil
+}
+
+// validatePreference checks the preferred provider and model against the catalog and the
+// company allowlist, so a saved preference never selects something the client would refuse.
+func (h *AI) validatePreference(ctx context.Context, companyID uuid.UUID, pref ai.UserPreference) error {
+	providerID := strings.TrimSpace(pref.ProviderID)
+	if providerID == "" {
+		return nil
+	}
+	if _, ok := h.catalogEntry(ctx, providerID); !ok {
+		return fmt.Errorf("unknown provider %q", providerID)
+	}
+	allowed, ok := h.allowedModels(ctx, companyID, providerID)
+	if !ok {
+		return fmt.Errorf("provider %q is not allowed for your company", providerID)
+	}
+	if model := strings.TrimSpace(pref.Model); model != "" && allowed != nil {
+		for _, candidate := range allowed {
+			if candidate == model {
+				return nil
+			}
+		}
+		return fmt.Errorf("model %q is not allowed for your company", model)
+	}
+	return nil
+}
+
+// userPreference loads the user's AI defaults. Failures are logged and treated as no
+// preference so chat keeps working on company defaults.
+func (h *AI) userPreference(ctx context.Context, companyID, userID uuid.UUID) ai.UserPreference {
+	if h.Preferences == nil {
+		return ai.UserPreference{}
+	}
+	pref, err := h.Preferences.Get(ctx, companyID, userID)
+	if err != nil {
+		log.Printf("ai: load user preference failed: %v", err)
+		return ai.UserPreference{}
+	}
+	return pref
+}
+
+// preferredProvider returns the provider to use when the caller did not pick one: the
+// user's preferred provider while it is still in the catalog and allowed for the company,
+// otherwise the deployment default.
+func (h *AI) preferredProvider(ctx context.Context, companyID, userID uuid.UUID) string {
+	pref := h.userPreference(ctx, companyID, userID)
+	if pref.ProviderID != "" {
+		if _, ok := h.catalogEntry(ctx, pref.ProviderID); ok {
+			if _, allowed := h.allowedModels(ctx, companyID, pref.ProviderID); allowed {
+				return pref.ProviderID
+			}
+		}
+	}
+	return h.DefaultProvider
+}
@@ -0,0 +1,408 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+type promptTemplateRequest struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind,omitempty"`
+	Description string `json:"description,omitempty"`
+	Body        string `json:"body"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+type pinPromptTemplateRequest struct {
+	// VersionID pins the session to a chat template version; empty unpins it.
+	VersionID string `json:"versionId"`
+}
+
+type promptTemplateResponse struct {
+	ID          string                        `json:"id"`
+	Name        string                        `json:"name"`
+	Kind        string                        `json:"kind"`
+	Description string                        `json:"description,omitempty"`
+	IsDefault   bool                          `json:"isDefault"`
+	Latest      promptTemplateVersionResponse `json:"latest"`
+	CreatedAt   time.Time                     `json:"createdAt"`
+	UpdatedAt   time.Time                     `json:"updatedAt"`
+}
+
+type promptTemplateVersionResponse struct {
+	ID        string    `json:"id"`
+	Version   int       `json:"version"`
+	Body      string    `json:"body"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ListPromptTemplates lists the company's prompt templates with their latest versions.
+func (h *AI) ListPromptTemplates(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, false)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	templates, err := h.Prompts.List(ctx, session.CompanyID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to list prompt templates", err)
+		return
+	}
+	resp := make([]promptTemplateResponse, 0, len(templates))
+	for _, tmpl := range templates {
+		resp = append(resp, promptTemplateToResponse(tmpl))
+	}
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// CreatePromptTemplate stores a new template as version 1.
+func (h *AI) CreatePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, true)
+	if !ok {
+		return
+	}
+
+	req, err := parsePromptTemplateRequest(r)
+	if err != nil {
+		h.respondPromptTemplateError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tmpl, err := h.Prompts.Create(ctx, session.CompanyID, session.UserID, prompts.CreateParams{
+		Name:        req.Name,
+		Kind:        req.Kind,
+		Description: req.Description,
+		Body:        req.Body,
+		Default:     req.Default,
+	})
+	if err != nil {
+		h.respondPromptTemplateError(w, r, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Prompt template created", nil)
+		return
+	}
+	RespondWithJSON(w, http.StatusCreated, promptTemplateToResponse(tmpl))
+}
+
+// UpdatePromptTemplate edits a template; a changed body is saved as a new version.
+func (h *AI) UpdatePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, true)
+	if !ok {
+		return
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateID"))
+	if err != nil {
+		h.respondPromptTemplateError(w, r, fmt.Errorf("%w: invalid template id", prompts.ErrInvalidTemplate))
+		return
+	}
+
+	req, err := parsePromptTemplateRequest(r)
+	if err != nil {
+		h.respondPromptTemplateError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tmpl, err := h.Prompts.Update(ctx, session.CompanyID, templateID, session.UserID, prompts.UpdateParams{
+		Name:        req.Name,
+		Description: req.Description,
+		Body:        req.Body,
+	})
+	if err == nil && req.Default && !tmpl.IsDefault {
+		if err = h.Prompts.SetDefault(ctx, session.CompanyID, templateID); err == nil {
+			tmpl.IsDefault = true
+		}
+	}
+	if err != nil {
+		h.respondPromptTemplateError(w, r, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", fmt.Sprintf("Saved %s (version %d)", tmpl.Name, tmpl.Latest.Version), nil)
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, promptTemplateToResponse(tmpl))
+}
+
+// SetDefaultPromptTemplate makes a template the default for its kind.
+func (h *AI) SetDefaultPromptTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, true)
+	if !ok {
+		return
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateID"))
+	if err != nil {
+		h.respondPromptTemplateError(w, r, fmt.Errorf("%w: invalid template id", prompts.ErrInvalidTemplate))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Prompts.SetDefault(ctx, session.CompanyID, templateID); err != nil {
+		h.respondPromptTemplateError(w, r, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Default template updated", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeletePromptTemplate archives a template. Versions stay readable for sessions, jobs, and
+// messages that reference them.
+func (h *AI) DeletePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, true)
+	if !ok {
+		return
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateID"))
+	if err != nil {
+		h.respondPromptTemplateError(w, r, fmt.Errorf("%w: invalid template id", prompts.ErrInvalidTemplate))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Prompts.Archive(ctx, session.CompanyID, templateID); err != nil {
+		h.respondPromptTemplateError(w, r, err)
+		return
+	}
+
+	if isHTMX(r) {
+		w.Header().Set("HX-Refresh", "true")
+		respondWithAINotice(w, r, "success", "Prompt template deleted", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListPromptTemplateVersions lists a template's versions, newest first.
+func (h *AI) ListPromptTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, false)
+	if !ok {
+		return
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateID"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid template id", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	versions, err := h.Prompts.Versions(ctx, session.CompanyID, templateID)
+	if err != nil {
+		status, msg := promptTemplateErrorStatus(err)
+		RespondWithError(w, status, msg, err)
+		return
+	}
+	resp := make([]promptTemplateVersionResponse, 0, len(versions))
+	for _, version := range versions {
+		resp = append(resp, promptVersionToResponse(version))
+	}
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// PinConversationPromptTemplate pins a conversation to a chat template version, or unpins
+// it so the company default applies.
+func (h *AI) PinConversationPromptTemplate(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.promptTemplateSession(w, r, false)
+	if !ok {
+		return
+	}
+	if h.Conversations == nil {
+		RespondWithError(w, http.StatusInternalServerError, "conversation service unavailable", errors.New("conversation service not configured"))
+		return
+	}
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid session id", err)
+		return
+	}
+
+	var req pinPromptTemplateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "invalid payload", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.Conversations.Session(ctx, session.CompanyID, sessionID); err != nil {
+		RespondWithError(w, http.StatusNotFound, "conversation not found", err)
+		return
+	}
+
+	pinned := ""
+	var resp map[string]any
+	if raw := strings.TrimSpace(req.VersionID); raw != "" {
+		version, err := h.resolvePromptVersion(ctx, session.CompanyID, prompts.KindChat, raw)
+		if err != nil {
+			status, msg := promptTemplateErrorStatus(err)
+			RespondWithError(w, status, msg, err)
+			return
+		}
+		pinned = version.ID.String()
+		resp = version.Ref()
+	}
+
+	if err := h.Conversations.UpdateSessionMetadata(ctx, session.CompanyID, sessionID, map[string]any{prompts.MetadataPinnedVersion: pinned}); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "failed to pin prompt template", err)
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, map[string]any{"promptTemplate": resp})
+}
+
+// documentPromptVersion validates a document job's pinned template version.
+func (h *AI) documentPromptVersion(ctx context.Context, companyID uuid.UUID, raw string) (ai.PromptTemplateVersion, error) {
+	return h.resolvePromptVersion(ctx, companyID, prompts.KindDocumentSummary, raw)
+}
+
+func (h *AI) resolvePromptVersion(ctx context.Context, companyID uuid.UUID, kind, raw string) (ai.PromptTemplateVersion, error) {
+	if h.Prompts == nil {
+		return ai.PromptTemplateVersion{}, errors.New("prompt templates not configured")
+	}
+	versionID, err := uuid.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ai.PromptTemplateVersion{}, fmt.Errorf("%w: invalid version id", prompts.ErrInvalidTemplate)
+	}
+	return h.Prompts.Resolve(ctx, companyID, kind, versionID)
+}
+
+// promptTemplateSession authenticates the request and, for changes, requires a company
+// administrator.
+func (h *AI) promptTemplateSession(w http.ResponseWriter, r *http.Request, manage bool) (auth.Session, bool) {
+	if h == nil || h.Prompts == nil {
+		if !respondWithAINotice(w, r, "error", "Prompt templates unavailable", errors.New("prompt template service not configured")) {
+			RespondWithError(w, http.StatusInternalServerError, "prompt templates unavailable", errors.New("prompt template service not configured"))
+		}
+		return auth.Session{}, false
+	}
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		if !respondWithAINotice(w, r, "error", "Authentication required", errors.New("session missing")) {
+			RespondWithError(w, http.StatusUnauthorized, "authentication required", errors.New("session missing"))
+		}
+		return auth.Session{}, false
+	}
+	if manage && !session.Capabilities.CanManageCompanyCredentials {
+		if !respondWithAINotice(w, r, "error", "Only company administrators can change prompt templates", errors.New("manage not permitted")) {
+			RespondWithError(w, http.StatusForbidden, "insufficient permissions", errors.New("manage not permitted"))
+		}
+		return auth.Session{}, false
+	}
+	return session, true
+}
+
+func (h *AI) respondPromptTemplateError(w http.ResponseWriter, r *http.Request, err error) {
+	status, msg := promptTemplateErrorStatus(err)
+	if errors.Is(err, prompts.ErrInvalidTemplate) {
+		// Parse errors explain what to fix, so show them instead of the generic message.
+		msg = strings.TrimPrefix(err.Error(), prompts.ErrInvalidTemplate.Error()+": ")
+	}
+	if respondWithAINotice(w, r, "error", msg, err) {
+		return
+	}
+	RespondWithError(w, status, msg, err)
+}
+
+func promptTemplateErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, prompts.ErrInvalidTemplate):
+		return http.StatusBadRequest, "invalid prompt template"
+	case errors.Is(err, prompts.ErrKindMismatch):
+		return http.StatusBadRequest, "prompt template is for a different use"
+	case errors.Is(err, prompts.ErrNotFound):
+		return http.StatusNotFound, "prompt template not found"
+	default:
+		return http.StatusInternalServerError, "prompt template request failed"
+	}
+}
+
+func parsePromptTemplateRequest(r *http.Request) (promptTemplateRequest, error) {
+	var req promptTemplateRequest
+	contentType := strings.TrimSpace(r.Header.Get("Content-Type"))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+
+	if contentType == "application/json" {
+		if err := decodeJSON(r, &req); err != nil {
+			return promptTemplateRequest{}, fmt.Errorf("%w: %v", prompts.ErrInvalidTemplate, err)
+		}
+		return req, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return promptTemplateRequest{}, fmt.Errorf("%w: %v", prompts.ErrInvalidTemplate, err)
+	}
+	req.Name = formValue(r.PostForm, "name")
+	req.Kind = formValue(r.PostForm, "kind")
+	req.Description = formValue(r.PostForm, "description")
+	// The body keeps its whitespace; it is part of the prompt.
+	req.Body = r.PostForm.Get("body")
+	switch strings.ToLower(formValue(r.PostForm, "default")) {
+	case "on", "true", "1":
+		req.Default = true
+	}
+	return req, nil
+}
+
+func promptTemplateToResponse(tmpl ai.PromptTemplate) promptTemplateResponse {
+	return promptTemplateResponse{
+		ID:          tmpl.ID.String(),
+		Name:        tmpl.Name,
+		Kind:        tmpl.Kind,
+		Description: tmpl.Description,
+		IsDefault:   tmpl.IsDefault,
+		Latest:      promptVersionToResponse(tmpl.Latest),
+		CreatedAt:   tmpl.CreatedAt,
+		UpdatedAt:   tmpl.UpdatedAt,
+	}
+}
+
+func promptVersionToResponse(version ai.PromptTemplateVersion) promptTemplateVersionResponse {
+	resp := promptTemplateVersionResponse{
+		ID:        version.ID.String(),
+		Version:   version.Version,
+		Body:      version.Body,
+		CreatedAt: version.CreatedAt,
+	}
+	if version.CreatedBy != uuid.Nil {
+		resp.CreatedBy = version.CreatedBy.String()
+	}
+	return resp
+}
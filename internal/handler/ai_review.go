@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/app/pages"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+)
+
+const (
+	reviewJobLimit         = 20
+	reviewTitleCharLimit   = 60
+	reviewSummaryCharLimit = 280
+)
+
+var reviewStatusLabels = map[string]string{
+	documents.StatusQueued:     "Queued",
+	documents.StatusProcessing: "Running",
+	documents.StatusCompleted:  "Completed",
+	documents.StatusFailed:     "Failed",
+	documents.StatusDeadLetter: "Gave up",
+	documents.StatusCancelled:  "Cancelled",
+}
+
+var reviewStageLabels = map[string]string{
+	documents.StageLoadingFiles:   "Loading files",
+	documents.StageCondensing:     "Condensing",
+	documents.StageAnalyzing:      "Analyzing",
+	documents.StageExtracting:     "Extracting contract terms",
+	documents.StageCreatingDrafts: "Creating draft contract",
+}
+
+// ReviewJobs renders the review workspace feed of recent document jobs.
+func (h *AI) ReviewJobs(w http.ResponseWriter, r *http.Request) {
+	props := pages.ReviewJobListProps{}
+	session, ok := auth.SessionFromContext(r.Context())
+	switch {
+	case !ok:
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	case h == nil || h.Documents == nil:
+		props.ErrorMessage = "Document analysis is not configured."
+	default:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		jobs, err := h.Documents.Jobs(ctx, session.CompanyID, reviewJobLimit, 0)
+		if err != nil {
+			props.ErrorMessage = "Failed to load recent analyses."
+			break
+		}
+		for _, job := range jobs {
+			props.Jobs = append(props.Jobs, reviewJobView(job))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ReviewJobList(props).Render(r.Context(), w); err != nil {
+		http.Error(w, "Failed to render jobs", http.StatusInternalServerError)
+	}
+}
+
+// ReviewJobStatus renders a single job card; cards poll it while their job is active. A
+// deleted job renders nothing so its card disappears.
+func (h *AI) ReviewJobStatus(w http.ResponseWriter, r *http.Request) {
+	session, jobID, ok := h.reviewJobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.Documents.Job(ctx, session.CompanyID, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load job", http.StatusInternalServerError)
+		return
+	}
+	h.writeReviewJobCard(w, r.Context(), job)
+}
+
+// ReviewCancelJob cancels a job and re-renders its card.
+func (h *AI) ReviewCancelJob(w http.ResponseWriter, r *http.Request) {
+	session, jobID, ok := h.reviewJobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.Documents.Cancel(ctx, session.CompanyID, jobID)
+	if errors.Is(err, documents.ErrJobNotCancellable) {
+		// The job finished first; show how it ended.
+		job, err = h.Documents.Job(ctx, session.CompanyID, jobID)
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusOK)
+		return
+	case err != nil:
+		http.Error(w, "Failed to cancel job", http.StatusInternalServerError)
+		return
+	}
+	h.writeReviewJobCard(w, r.Context(), job)
+}
+
+// ReviewDeleteJob deletes a job, replacing its card with nothing.
+func (h *AI) ReviewDeleteJob(w http.ResponseWriter, r *http.Request) {
+	session, jobID, ok := h.reviewJobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Documents.Remove(ctx, session.CompanyID, jobID); err != nil {
+		http.Error(w, "Failed to delete job", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AI) reviewJobRequest(w http.ResponseWriter, r *http.Request) (auth.Session, uuid.UUID, bool) {
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return auth.Session{}, uuid.Nil, false
+	}
+	if h == nil || h.Documents == nil {
+		http.Error(w, "Document analysis is not configured", http.StatusServiceUnavailable)
+		return auth.Session{}, uuid.Nil, false
+	}
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return auth.Session{}, uuid.Nil, false
+	}
+	return session, jobID, true
+}
+
+func (h *AI) writeReviewJobCard(w http.ResponseWriter, ctx context.Context, job documents.Job) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ReviewJobCard(reviewJobView(job)).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render job", http.StatusInternalServerError)
+	}
+}
+
+func reviewJobView(job documents.Job) pages.ReviewJob {
+	view := pages.ReviewJob{
+		ID:              job.ID.String(),
+		Title:           reviewJobTitle(job),
+		Status:          job.Status,
+		StatusLabel:     reviewStatusLabels[job.Status],
+		Stage:           reviewStageLabels[job.ProgressStage],
+		Detail:          job.ProgressDetail,
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		CreatedAt:       job.CreatedAt.Local().Format("Jan 2, 15:04"),
+		Active:          job.Active(),
+		CancelRequested: job.CancelRequestedAt != nil,
+	}
+	if view.StatusLabel == "" {
+		view.StatusLabel = job.Status
+	}
+	if job.Status == documents.StatusProcessing && view.Stage == "" {
+		view.Stage = "Starting"
+	}
+	if job.Status == documents.StatusQueued && job.Attempts > 0 && job.ErrorMessage != nil {
+		view.Error = "Retrying after: " + *job.ErrorMessage
+	} else if !view.Active && job.Status != documents.StatusCompleted && job.ErrorMessage != nil {
+		view.Error = *job.ErrorMessage
+	}
+
+	if summary, ok := job.Response["summary"].(string); ok {
+		view.Summary = truncateText(strings.TrimSpace(summary), reviewSummaryCharLimit)
+	} else if draftErr, ok := job.Response["draft_error"].(string); ok {
+		view.Error = draftErr
+	} else if _, ok := job.Response["drafts"]; ok {
+		view.Summary = "Draft contract created for review."
+	}
+	return view
+}
+
+// reviewJobTitle labels a job with its instructions, or else the files it analyses.
+func reviewJobTitle(job documents.Job) string {
+	if instructions, ok := job.Request["instructions"].(string); ok && strings.TrimSpace(instructions) != "" {
+		return truncateText(strings.TrimSpace(instructions), reviewTitleCharLimit)
+	}
+
+	var names []string
+	if files, ok := job.Request["files"].([]any); ok {
+		for _, file := range files {
+			if ref, ok := file.(map[string]any); ok {
+				if name, ok := ref["filename"].(string); ok && name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	label := "Document analysis"
+	if documents.JobType(job.Request) == documents.JobTypeContractExtraction {
+		label = "Contract extraction"
+	}
+	switch len(names) {
+	case 0:
+		return label
+	case 1:
+		return fmt.Sprintf("%s: %s", label, names[0])
+	default:
+		return fmt.Sprintf("%s: %s and %d more", label, names[0], len(names)-1)
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ContractDrafts creates non-final contracts and performance obligations from document
+// extraction jobs. Every record passes the same validators as the contract and obligation
+// endpoints before anything is written, and each job's drafts are written in a single
+// transaction together with a record keyed on the job, so a replayed job creates nothing
+// new.
+type ContractDrafts struct {
+	DB *database.Queries
+	// Conn begins the transaction drafts are written in; DB must be built on it.
+	Conn *sql.DB
+}
+
+var _ documents.DraftWriter = (*ContractDrafts)(nil)
+
+// CreateDrafts implements documents.DraftWriter.
+func (d *ContractDrafts) CreateDrafts(ctx context.Context, jobID, companyID, customerID uuid.UUID, extraction documents.ContractExtraction) (documents.Drafts, error) {
+	if d.Conn == nil {
+		return documents.Drafts{}, errors.New("contract drafts: database connection not configured")
+	}
+	if drafts, ok, err := d.existingDrafts(ctx, jobID, companyID); err != nil || ok {
+		return drafts, err
+	}
+
+	customerID, err := d.resolveCustomer(ctx, companyID, customerID, extraction.Customer)
+	if err != nil {
+		return documents.Drafts{}, err
+	}
+
+	start, end, err := extraction.Dates()
+	if err != nil {
+		return documents.Drafts{}, err
+	}
+	contract := database.CreateContractParams{
+		CompanyID:  companyID,
+		CustomerID: customerID,
+		StartDate:  start,
+		EndDate:    end,
+		IsFinal:    false,
+	}
+	if err := (&Contract{}).contractInputValidation(&contract); err != nil {
+		return documents.Drafts{}, fmt.Errorf("contract: %w", err)
+	}
+
+	obligations := make([]database.CreatePerformanceObligationParams, 0, len(extraction.Obligations))
+	for _, candidate := range extraction.Obligations {
+		obStart, obEnd, err := extraction.ObligationDates(candidate)
+		if err != nil {
+			return documents.Drafts{}, err
+		}
+		payload := performanceObPayload{
+			PerformanceObligationsName: candidate.Name,
+			StartDate:                  obStart,
+			EndDate:                    obEnd,
+			FunctionalCurrency:         extraction.Currency,
+			Discount:                   fmt.Sprintf("%.5f", candidate.Discount),
+			TransactionPrice:           documents.MinorUnits(candidate.TransactionPrice),
+		}
+		if err := validatePerformanceObStrict(&payload); err != nil {
+			return documents.Drafts{}, fmt.Errorf("performance obligation %q: %w", candidate.Name, err)
+		}
+		obligations = append(obligations, database.CreatePerformanceObligationParams{
+			PerformanceObligationsName: payload.PerformanceObligationsName,
+			StartDate:                  payload.StartDate,
+			EndDate:                    payload.EndDate,
+			FunctionalCurrency:         payload.FunctionalCurrency,
+			Discount:                   payload.Discount,
+			TransactionPrice:           payload.TransactionPrice,
+		})
+	}
+
+	tx, err := d.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return documents.Drafts{}, err
+	}
+	defer tx.Rollback()
+	q := d.DB.WithTx(tx)
+
+	created, err := q.CreateContract(ctx, contract)
+	if err != nil {
+		return documents.Drafts{}, err
+	}
+	drafts := documents.Drafts{CustomerID: customerID, ContractID: created.ID}
+	for _, params := range obligations {
+		params.ContractID = created.ID
+		obligation, err := q.CreatePerformanceObligation(ctx, params)
+		if err != nil {
+			return documents.Drafts{}, err
+		}
+		drafts.Obligations = append(drafts.Obligations, obligation.ID)
+	}
+
+	err = q.InsertAIDocumentJobDraft(ctx, database.InsertAIDocumentJobDraftParams{
+		JobID:      jobID,
+		CompanyID:  companyID,
+		ContractID: created.ID,
+	})
+	if isUniqueViolation(err) {
+		// Another worker holding a stale lease on the same job committed first.
+		tx.Rollback()
+		drafts, _, err := d.existingDrafts(ctx, jobID, companyID)
+		return drafts, err
+	}
+	if err != nil {
+		return documents.Drafts{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return documents.Drafts{}, err
+	}
+	return drafts, nil
+}
+
+// existingDrafts returns the drafts a job already created, if any.
+func (d *ContractDrafts) existingDrafts(ctx context.Context, jobID, companyID uuid.UUID) (documents.Drafts, bool, error) {
+	record, err := d.DB.GetAIDocumentJobDraft(ctx, database.GetAIDocumentJobDraftParams{JobID: jobID, CompanyID: companyID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return documents.Drafts{}, false, nil
+	}
+	if err != nil {
+		return documents.Drafts{}, false, err
+	}
+	contract, err := d.DB.GetContract(ctx, database.GetContractParams{ID: record.ContractID, CompanyID: companyID})
+	if err != nil {
+		return documents.Drafts{}, false, err
+	}
+	obligations, err := d.DB.GetPerformanceObligationsForContract(ctx, database.GetPerformanceObligationsForContractParams{
+		ContractID: record.ContractID,
+		CompanyID:  companyID,
+	})
+	if err != nil {
+		return documents.Drafts{}, false, err
+	}
+	drafts := documents.Drafts{CustomerID: contract.CustomerID, ContractID: contract.ID}
+	for _, obligation := range obligations {
+		drafts.Obligations = append(drafts.Obligations, obligation.ID)
+	}
+	return drafts, true, nil
+}
+
+// resolveCustomer confirms a chosen customer belongs to the company, or matches the
+// extracted name (case-insensitively, as customer names are citext).
+func (d *ContractDrafts) resolveCustomer(ctx context.Context, companyID, customerID uuid.UUID, name string) (uuid.UUID, error) {
+	var (
+		customer database.Customer
+		err      error
+	)
+	if customerID != uuid.Nil {
+		customer, err = d.DB.GetCustomer(ctx, database.GetCustomerParams{ID: customerID, CompanyID: companyID})
+	} else {
+		customer, err = d.DB.GetCustomerByName(ctx, database.GetCustomerByNameParams{CompanyID: companyID, CustomerName: name})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, documents.ErrCustomerUnmatched
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return customer.ID, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+
+	"github.com/JonMunkholm/RevProject1/app/pages"
+	"github.com/JonMunkholm/RevProject1/internal/ai/documents"
+	"github.com/JonMunkholm/RevProject1/internal/ai/prompts"
+	"github.com/JonMunkholm/RevProject1/internal/auth"
+	"github.com/JonMunkholm/RevProject1/internal/database"
+)
+
+const (
+	reviewCustomerLimit = 8
+	// reviewContractLimit caps how many of a customer's contracts (newest first) are
+	// included in an analysis prompt.
+	reviewContractLimit = 25
+	// reviewJobsChanged is the HX-Trigger event that refreshes the review feed.
+	reviewJobsChanged = "review:jobs-changed"
+)
+
+// Review serves the review workspace: the customer picker and analyses that combine a
+// customer's records with uploaded evidence.
+type Review struct {
+	DB *database.Queries
+	AI *AI
+}
+
+// SearchCustomers renders picker results matching a customer name, or a customer or
+// contract ID prefix.
+func (v *Review) SearchCustomers(w http.ResponseWriter, r *http.Request) {
+	if v == nil || v.DB == nil {
+		http.Error(w, "Review workspace unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	query := firstNonEmpty(r.URL.Query(), "customer", "q")
+	escaped := escapeLike(query)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := v.DB.SearchCustomers(ctx, database.SearchCustomersParams{
+		CompanyID:   session.CompanyID,
+		NamePattern: "%" + escaped + "%",
+		IDPrefix:    strings.ToLower(escaped) + "%",
+		RowLimit:    reviewCustomerLimit,
+	})
+	if err != nil {
+		http.Error(w, "Failed to search customers", http.StatusInternalServerError)
+		return
+	}
+
+	props := pages.ReviewCustomerResultsProps{Query: query}
+	for _, row := range rows {
+		props.Customers = append(props.Customers, pages.ReviewCustomerOption{
+			ID:        row.ID.String(),
+			Name:      row.CustomerName,
+			Contracts: row.ContractCount,
+			Active:    row.IsActive,
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ReviewCustomerResults(props).Render(r.Context(), w); err != nil {
+		http.Error(w, "Failed to render customers", http.StatusInternalServerError)
+	}
+}
+
+// Analyze queues a summary job from the review form: the prompt, the selected customer's
+// contracts and obligations, and any attached evidence. It renders the report partial,
+// which polls until the job finishes.
+func (v *Review) Analyze(w http.ResponseWriter, r *http.Request) {
+	if v == nil || v.DB == nil || v.AI == nil || v.AI.Documents == nil {
+		v.writeReportError(w, r.Context(), "Document analysis is not configured.")
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var (
+		form    url.Values
+		uploads []*multipart.FileHeader
+		err     error
+	)
+	if isMultipartRequest(r) {
+		uploads, err = parseUploadForm(w, r)
+		if err == nil {
+			form = r.MultipartForm.Value
+		}
+	} else if err = r.ParseForm(); err == nil {
+		form = r.PostForm
+	}
+	if err != nil {
+		v.writeReportError(w, r.Context(), "The form could not be read; the attachments may be too large.")
+		return
+	}
+
+	prompt := formValue(form, "prompt")
+	if prompt == "" {
+		v.writeReportError(w, r.Context(), "Enter a prompt describing what to analyze.")
+		return
+	}
+	var customerID uuid.UUID
+	if raw := formValue(form, "customerId"); raw != "" {
+		if customerID, err = uuid.Parse(raw); err != nil {
+			v.writeReportError(w, r.Context(), "Select a customer from the list.")
+			return
+		}
+	}
+	fileRefs := form["fileIds"]
+	if customerID == uuid.Nil && len(uploads) == 0 && len(fileRefs) == 0 {
+		v.writeReportError(w, r.Context(), "Select a customer or attach a file to analyze.")
+		return
+	}
+	// Reject unknown providers before anything is stored; the worker would only fail later.
+	providerID, _, err := v.AI.normalizeProvider(r.Context(), formValue(form, "provider"))
+	if err != nil {
+		v.writeReportError(w, r.Context(), "Choose an available AI provider.")
+		return
+	}
+
+	timeout := 10 * time.Second
+	if len(uploads) > 0 {
+		timeout = uploadTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	requestPayload := map[string]any{
+		"type":         documents.JobTypeSummary,
+		"instructions": prompt,
+	}
+	if customerID != uuid.Nil {
+		customer, err := v.DB.GetCustomer(ctx, database.GetCustomerParams{ID: customerID, CompanyID: session.CompanyID})
+		if errors.Is(err, sql.ErrNoRows) {
+			v.writeReportError(w, r.Context(), "The selected customer no longer exists.")
+			return
+		}
+		if err != nil {
+			v.writeReportError(w, r.Context(), "Failed to load the selected customer.")
+			return
+		}
+		records, contracts, err := v.customerRecords(ctx, session.CompanyID, customer)
+		if err != nil {
+			log.Printf("review: load records for customer %s: %v", customer.ID, err)
+			v.writeReportError(w, r.Context(), "Failed to load the customer's contracts.")
+			return
+		}
+		requestPayload["customer_id"] = customer.ID.String()
+		requestPayload["customer_name"] = customer.CustomerName
+		requestPayload["records"] = records
+		requestPayload["contracts"] = contracts
+	}
+	if raw := formValue(form, "promptTemplateVersionId"); raw != "" {
+		version, err := v.AI.documentPromptVersion(ctx, session.CompanyID, raw)
+		if err != nil {
+			v.writeReportError(w, r.Context(), "The selected prompt template is no longer available.")
+			return
+		}
+		requestPayload[prompts.MetadataPinnedVersion] = version.ID.String()
+	}
+
+	files, err := v.AI.resolveJobFiles(ctx, session, fileRefs, uploads)
+	if err != nil {
+		_, msg := uploadErrorStatus(err)
+		v.writeReportError(w, r.Context(), "Attachment rejected: "+msg+".")
+		return
+	}
+	if len(files) > 0 {
+		requestPayload["files"] = documents.FileRefs(files)
+	}
+
+	job, err := v.AI.Documents.Enqueue(ctx, documents.CreateJobParams{
+		CompanyID:  session.CompanyID,
+		UserID:     session.UserID,
+		ProviderID: providerID,
+		Request:    requestPayload,
+	})
+	if err != nil {
+		v.writeReportError(w, r.Context(), "Failed to queue the analysis. Try again shortly.")
+		return
+	}
+
+	w.Header().Set("HX-Trigger", reviewJobsChanged)
+	v.writeReport(w, r.Context(), job)
+}
+
+// Report renders the report for a job; the partial polls it while the job is running.
+func (v *Review) Report(w http.ResponseWriter, r *http.Request) {
+	if v == nil || v.AI == nil || v.AI.Documents == nil {
+		v.writeReportError(w, r.Context(), "Document analysis is not configured.")
+		return
+	}
+
+	session, ok := auth.SessionFromContext(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := v.AI.Documents.Job(ctx, session.CompanyID, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		v.writeReportError(w, r.Context(), "This analysis was deleted.")
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load job", http.StatusInternalServerError)
+		return
+	}
+	v.writeReport(w, r.Context(), job)
+}
+
+// customerRecords formats a customer's newest contracts and their performance obligations
+// for the analysis prompt, and lists the same contracts for prompt template variables.
+func (v *Review) customerRecords(ctx context.Context, companyID uuid.UUID, customer database.Customer) (string, []map[string]any, error) {
+	contracts, err := v.DB.GetContractsByCustomer(ctx, database.GetContractsByCustomerParams{
+		CompanyID:  companyID,
+		CustomerID: customer.ID,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	slices.SortFunc(contracts, func(a, b database.Contract) int {
+		return b.StartDate.Compare(a.StartDate)
+	})
+
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("Customer: %s (%s)\n", customer.CustomerName, activeLabel(customer.IsActive)))
+	if len(contracts) == 0 {
+		builder.WriteString("No contracts are recorded for this customer.\n")
+		return builder.String(), nil, nil
+	}
+	if len(contracts) > reviewContractLimit {
+		builder.WriteString(fmt.Sprintf("Showing the %d most recent of %d contracts.\n", reviewContractLimit, len(contracts)))
+		contracts = contracts[:reviewContractLimit]
+	}
+
+	refs := make([]map[string]any, 0, len(contracts))
+	for _, contract := range contracts {
+		refs = append(refs, map[string]any{
+			"id":         contract.ID.String(),
+			"start_date": contract.StartDate.Format(time.DateOnly),
+			"end_date":   contract.EndDate.Format(time.DateOnly),
+		})
+		status := "draft"
+		if contract.IsFinal {
+			status = "final"
+		}
+		builder.WriteString(fmt.Sprintf("\nContract %s (%s), %s to %s\n",
+			contract.ID, status, contract.StartDate.Format(time.DateOnly), contract.EndDate.Format(time.DateOnly)))
+
+		obligations, err := v.DB.GetPerformanceObligationsForContract(ctx, database.GetPerformanceObligationsForContractParams{
+			ContractID: contract.ID,
+			CompanyID:  companyID,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		if len(obligations) == 0 {
+			builder.WriteString("- No performance obligations recorded.\n")
+		}
+		for _, ob := range obligations {
+			builder.WriteString(fmt.Sprintf("- %s: %s to %s, transaction price %.2f %s, discount %s\n",
+				ob.PerformanceObligationsName,
+				ob.StartDate.Format(time.DateOnly),
+				ob.EndDate.Format(time.DateOnly),
+				float64(ob.TransactionPrice)/100,
+				ob.FunctionalCurrency,
+				ob.Discount,
+			))
+		}
+	}
+	return builder.String(), refs, nil
+}
+
+func (v *Review) writeReport(w http.ResponseWriter, ctx context.Context, job documents.Job) {
+	props := pages.ReviewReportProps{Job: reviewJobView(job)}
+	props.Customer, _ = job.Request["customer_name"].(string)
+	if files, ok := job.Request["files"].([]any); ok {
+		for _, file := range files {
+			if ref, ok := file.(map[string]any); ok {
+				if name, ok := ref["filename"].(string); ok {
+					props.Files = append(props.Files, name)
+				}
+			}
+		}
+	}
+	if summary, ok := job.Response["summary"].(string); ok {
+		props.Summary = strings.TrimSpace(summary)
+	}
+	if warnings, ok := job.Response["warnings"].([]any); ok {
+		for _, warning := range warnings {
+			if text, ok := warning.(string); ok {
+				props.Warnings = append(props.Warnings, text)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ReviewReport(props).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render report", http.StatusInternalServerError)
+	}
+}
+
+// writeReportError renders a problem in the report panel. It responds 200 so htmx swaps it
+// in place of the previous report.
+func (v *Review) writeReportError(w http.ResponseWriter, ctx context.Context, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ReviewReport(pages.ReviewReportProps{ErrorMessage: message}).Render(ctx, w); err != nil {
+		http.Error(w, "Failed to render report", http.StatusInternalServerError)
+	}
+}
+
+func activeLabel(active bool) string {
+	if active {
+		return "active"
+	}
+	return "inactive"
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -20,6 +20,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrParagraphNotFound is returned when a paragraph ID does not exist.
+var ErrParagraphNotFound = errors.New("retrieval: paragraph not found")
+
 // QueryParams captures user input for a Stage 1 query.
 type QueryParams struct {
 	Query string
@@ -150,6 +153,30 @@ func (s *Service) Search(ctx context.Context, params QueryParams) ([]Result, err
 	return results, nil
 }
 
+// Paragraph loads a single paragraph by ID, e.g. to resolve a citation.
+func (s *Service) Paragraph(ctx context.Context, id uuid.UUID) (Result, error) {
+	if s == nil {
+		return Result{}, errors.New("retrieval: service is nil")
+	}
+	var r Result
+	err := s.db.QueryRowContext(ctx, paragraphSQL, id).Scan(
+		&r.ParagraphID,
+		&r.ASCReference,
+		&r.Content,
+		&r.Guidance,
+		&r.SourceType,
+		&r.Authority,
+		&r.SchemaVersion,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Result{}, ErrParagraphNotFound
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return r, nil
+}
+
 func (s *Service) generateEmbedding(ctx context.Context, input string) ([]float32, error) {
 	payload := embeddingRequest{Model: s.model, Input: input}
 	body, err := json.Marshal(payload)
@@ -222,3 +249,15 @@ join asc_paragraphs p on p.id = e.paragraph_id
 join query on true
 order by e.embedding <=> query.embedding
 limit $2`
+
+const paragraphSQL = `
+select
+	p.id,
+	p.asc_reference,
+	p.content,
+	p.guidance_version,
+	p.source_type,
+	p.authority_score,
+	p.schema_version
+from asc_paragraphs p
+where p.id = $1`
@@ -8,7 +8,9 @@ INSERT INTO ai_provider_credentials (
     metadata,
     label,
     is_default,
-    last_tested_at
+    last_tested_at,
+    id,
+    expires_at
 )
 VALUES (
     sqlc.arg('company_id'),
@@ -19,7 +21,9 @@ VALUES (
     COALESCE(sqlc.narg('metadata'), '{}'::jsonb),
     sqlc.narg('label'),
     COALESCE(sqlc.narg('is_default'), false),
-    sqlc.narg('last_tested_at')
+    sqlc.narg('last_tested_at'),
+    COALESCE(sqlc.narg('id')::uuid, gen_random_uuid()),
+    sqlc.narg('expires_at')
 )
 RETURNING *;
 
@@ -32,11 +36,17 @@ SET
     label             = COALESCE(sqlc.narg('label'), label),
     is_default        = COALESCE(sqlc.narg('is_default'), is_default),
     last_tested_at    = COALESCE(sqlc.narg('last_tested_at'), last_tested_at),
+    expires_at        = sqlc.narg('expires_at'),
     updated_at        = now(),
     rotated_at        = CASE
         WHEN sqlc.narg('credential_cipher') IS NOT NULL
              AND sqlc.narg('credential_cipher') IS DISTINCT FROM credential_cipher THEN now()
         ELSE rotated_at
+    END,
+    health_status     = CASE
+        WHEN sqlc.narg('credential_cipher') IS NOT NULL
+             AND sqlc.narg('credential_cipher') IS DISTINCT FROM credential_cipher THEN NULL
+        ELSE health_status
     END
 WHERE id = sqlc.arg('id')
 RETURNING *;
@@ -113,6 +123,21 @@ WHERE company_id = sqlc.arg('company_id')
     OR (sqlc.narg('user_id')::uuid IS NOT NULL AND user_id IS NOT DISTINCT FROM sqlc.narg('user_id')::uuid)
   );
 
+-- name: ListAIProviderCredentialsAfter :many
+SELECT *
+FROM ai_provider_credentials
+WHERE id > sqlc.arg('after')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ReplaceAIProviderCredentialCipher :execrows
+-- Re-encryption keeps the secret, so updated_at (which orders credential resolution) and
+-- rotated_at are left alone. The old ciphertext guards against concurrent edits.
+UPDATE ai_provider_credentials
+SET credential_cipher = sqlc.arg('new_cipher')
+WHERE id = sqlc.arg('id')
+  AND credential_cipher = sqlc.arg('old_cipher');
+
 -- name: UpsertAIUserPreference :one
 INSERT INTO ai_user_preferences (company_id, user_id, provider_id, model, metadata)
 VALUES ($1, $2, $3, $4, COALESCE($5, '{}'::jsonb))
@@ -204,3 +229,27 @@ WHERE company_id = sqlc.arg('company_id')
   )
 ORDER BY created_at DESC
 LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ClaimAIProviderCredentialsForHealthCheck :many
+-- Marks up to limit default credentials not checked since checked_before as checked now
+-- and returns them. SKIP LOCKED lets several app instances share the work.
+UPDATE ai_provider_credentials
+SET health_checked_at = now()
+WHERE id IN (
+    SELECT id
+    FROM ai_provider_credentials
+    WHERE is_default
+      AND (health_checked_at IS NULL OR health_checked_at < sqlc.arg('checked_before'))
+    ORDER BY health_checked_at NULLS FIRST
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RecordAIProviderCredentialHealth :exec
+-- Stores a health check outcome without touching updated_at, which orders resolution.
+UPDATE ai_provider_credentials
+SET health_status  = sqlc.arg('health_status'),
+    health_error   = sqlc.narg('health_error'),
+    last_tested_at = now()
+WHERE id = sqlc.arg('id');
@@ -10,7 +10,86 @@ SELECT
     fields,
     enabled,
     created_at,
-    updated_at
+    updated_at,
+    context_windows
 FROM ai_provider_catalog
 WHERE enabled = TRUE
 ORDER BY id;
+
+-- name: ListAllAIProviderCatalogEntries :many
+-- Includes disabled entries for catalog administration.
+SELECT
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    created_at,
+    updated_at,
+    context_windows
+FROM ai_provider_catalog
+ORDER BY id;
+
+-- name: GetAIProviderCatalogEntry :one
+SELECT
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    created_at,
+    updated_at,
+    context_windows
+FROM ai_provider_catalog
+WHERE id = $1;
+
+-- name: UpsertAIProviderCatalogEntry :one
+INSERT INTO ai_provider_catalog (
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    context_windows
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (id) DO UPDATE SET
+    label = EXCLUDED.label,
+    icon_url = EXCLUDED.icon_url,
+    description = EXCLUDED.description,
+    documentation_url = EXCLUDED.documentation_url,
+    capabilities = EXCLUDED.capabilities,
+    models = EXCLUDED.models,
+    fields = EXCLUDED.fields,
+    enabled = EXCLUDED.enabled,
+    context_windows = EXCLUDED.context_windows
+RETURNING
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled,
+    created_at,
+    updated_at,
+    context_windows;
+
+-- name: DeleteAIProviderCatalogEntry :execrows
+DELETE FROM ai_provider_catalog
+WHERE id = $1;
@@ -27,6 +27,13 @@ SET title = $3,
 WHERE id = $1
   AND company_id = $2;
 
+-- name: MergeAIConversationSessionMetadata :exec
+UPDATE ai_conversation_sessions
+SET metadata = COALESCE(metadata, '{}'::jsonb) || sqlc.arg(patch)::jsonb,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND company_id = sqlc.arg(company_id);
+
 -- name: ListAIConversationSessionsByCompany :many
 SELECT *
 FROM ai_conversation_sessions
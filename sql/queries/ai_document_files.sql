@@ -0,0 +1,48 @@
+-- name: InsertAIDocumentFile :one
+INSERT INTO ai_document_files (
+    company_id,
+    user_id,
+    filename,
+    content_type,
+    size_bytes,
+    sha256,
+    storage_key,
+    extracted_text,
+    chunk_count
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: GetAIDocumentFile :one
+SELECT *
+FROM ai_document_files
+WHERE id = $1
+  AND company_id = $2;
+
+-- name: ListAIDocumentFilesByIDs :many
+SELECT *
+FROM ai_document_files
+WHERE company_id = $1
+  AND id = ANY($2::uuid[])
+ORDER BY created_at ASC;
+
+-- name: ListAIDocumentFilesByCompany :many
+SELECT *
+FROM ai_document_files
+WHERE company_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: DeleteAIDocumentFile :exec
+DELETE FROM ai_document_files
+WHERE id = $1
+  AND company_id = $2;
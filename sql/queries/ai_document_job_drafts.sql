@@ -0,0 +1,16 @@
+-- name: InsertAIDocumentJobDraft :exec
+INSERT INTO ai_document_job_drafts (
+    job_id,
+    company_id,
+    contract_id
+) VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetAIDocumentJobDraft :one
+SELECT *
+FROM ai_document_job_drafts
+WHERE job_id = $1
+  AND company_id = $2;
@@ -19,16 +19,18 @@ UPDATE ai_document_jobs
 SET status        = $3,
     error_message = $4,
     updated_at    = now(),
-    completed_at  = CASE WHEN $3 IN ('completed', 'failed') THEN now() ELSE completed_at END
+    completed_at  = CASE WHEN $3 IN ('completed', 'failed', 'dead_letter', 'cancelled') THEN now() ELSE completed_at END
 WHERE id = $1
   AND company_id = $2;
 
 -- name: UpdateAIDocumentJobResponse :exec
 UPDATE ai_document_jobs
-SET response     = $3,
-    status       = 'completed',
-    updated_at   = now(),
-    completed_at = now()
+SET response         = $3,
+    status           = 'completed',
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = now()
 WHERE id = $1
   AND company_id = $2;
 
@@ -38,12 +40,116 @@ FROM ai_document_jobs
 WHERE id = $1
   AND company_id = $2;
 
--- name: GetNextQueuedAIDocumentJob :one
-SELECT *
+-- name: ClaimAIDocumentJob :one
+UPDATE ai_document_jobs
+SET status           = 'processing',
+    attempts         = attempts + 1,
+    locked_by        = sqlc.arg(locked_by)::text,
+    lease_expires_at = now() + sqlc.arg(lease_seconds)::int * interval '1 second',
+    progress_stage   = '',
+    progress_detail  = '',
+    updated_at       = now()
+WHERE id = (
+    SELECT id
+    FROM ai_document_jobs
+    WHERE status = 'queued'
+      AND run_after <= now()
+    ORDER BY run_after ASC, created_at ASC
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: ExtendAIDocumentJobLease :one
+UPDATE ai_document_jobs
+SET lease_expires_at = now() + sqlc.arg(lease_seconds)::int * interval '1 second',
+    updated_at       = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'processing'
+  AND locked_by = sqlc.arg(locked_by)::text
+RETURNING (cancel_requested_at IS NOT NULL)::boolean AS cancel_requested;
+
+-- name: UpdateAIDocumentJobProgress :execrows
+UPDATE ai_document_jobs
+SET progress_stage  = sqlc.arg(progress_stage),
+    progress_detail = sqlc.arg(progress_detail),
+    updated_at      = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'processing'
+  AND locked_by = sqlc.arg(locked_by)::text;
+
+-- name: CancelAIDocumentJob :one
+UPDATE ai_document_jobs
+SET status              = CASE WHEN status = 'queued' THEN 'cancelled' ELSE status END,
+    cancel_requested_at = now(),
+    completed_at        = CASE WHEN status = 'queued' THEN now() ELSE completed_at END,
+    updated_at          = now()
+WHERE id = sqlc.arg(id)
+  AND company_id = sqlc.arg(company_id)
+  AND status IN ('queued', 'processing')
+RETURNING *;
+
+-- name: CompleteAIDocumentJob :execrows
+UPDATE ai_document_jobs
+SET response         = sqlc.arg(response),
+    status           = 'completed',
+    error_message    = NULL,
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'processing'
+  AND locked_by = sqlc.arg(locked_by)::text;
+
+-- name: RetryAIDocumentJob :execrows
+UPDATE ai_document_jobs
+SET status           = CASE WHEN cancel_requested_at IS NULL THEN 'queued' ELSE 'cancelled' END,
+    error_message    = sqlc.arg(error_message)::text,
+    run_after        = now() + sqlc.arg(delay_seconds)::int * interval '1 second',
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = CASE WHEN cancel_requested_at IS NULL THEN completed_at ELSE now() END
+WHERE id = sqlc.arg(id)
+  AND status = 'processing'
+  AND locked_by = sqlc.arg(locked_by)::text;
+
+-- name: FailAIDocumentJob :execrows
+UPDATE ai_document_jobs
+SET status           = sqlc.arg(status),
+    error_message    = sqlc.arg(error_message)::text,
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'processing'
+  AND locked_by = sqlc.arg(locked_by)::text;
+
+-- name: RecoverExpiredAIDocumentJobs :execrows
+UPDATE ai_document_jobs
+SET status           = CASE
+                           WHEN cancel_requested_at IS NOT NULL THEN 'cancelled'
+                           WHEN attempts >= max_attempts THEN 'dead_letter'
+                           ELSE 'queued'
+                       END,
+    error_message    = 'worker lease expired',
+    run_after        = now(),
+    locked_by        = NULL,
+    lease_expires_at = NULL,
+    updated_at       = now(),
+    completed_at     = CASE
+                           WHEN cancel_requested_at IS NOT NULL OR attempts >= max_attempts THEN now()
+                           ELSE completed_at
+                       END
+WHERE status = 'processing'
+  AND (lease_expires_at IS NULL OR lease_expires_at < now());
+
+-- name: CountAIDocumentJobsByStatus :many
+SELECT status, count(*)::bigint AS jobs
 FROM ai_document_jobs
-WHERE status = 'queued'
-ORDER BY created_at ASC
-LIMIT 1;
+GROUP BY status;
 
 -- name: ListAIDocumentJobsByCompany :many
 SELECT *
@@ -0,0 +1,112 @@
+-- name: InsertAIPromptTemplate :one
+INSERT INTO ai_prompt_templates (
+    company_id,
+    name,
+    kind,
+    description
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetAIPromptTemplate :one
+SELECT *
+FROM ai_prompt_templates
+WHERE id = $1
+  AND company_id = $2
+  AND archived_at IS NULL;
+
+-- name: ListAIPromptTemplatesByCompany :many
+SELECT *
+FROM ai_prompt_templates
+WHERE company_id = $1
+  AND archived_at IS NULL
+ORDER BY kind, lower(name);
+
+-- name: UpdateAIPromptTemplate :one
+UPDATE ai_prompt_templates
+SET name        = $3,
+    description = $4,
+    updated_at  = now()
+WHERE id = $1
+  AND company_id = $2
+  AND archived_at IS NULL
+RETURNING *;
+
+-- name: ArchiveAIPromptTemplate :execrows
+UPDATE ai_prompt_templates
+SET archived_at = now(),
+    is_default  = false,
+    updated_at  = now()
+WHERE id = $1
+  AND company_id = $2
+  AND archived_at IS NULL;
+
+-- name: ClearAIPromptTemplateDefault :exec
+UPDATE ai_prompt_templates
+SET is_default = false,
+    updated_at = now()
+WHERE company_id = $1
+  AND kind = $2
+  AND is_default;
+
+-- name: SetAIPromptTemplateDefault :execrows
+UPDATE ai_prompt_templates
+SET is_default = true,
+    updated_at = now()
+WHERE id = $1
+  AND company_id = $2
+  AND archived_at IS NULL;
+
+-- name: InsertAIPromptTemplateVersion :one
+INSERT INTO ai_prompt_template_versions (
+    template_id,
+    version,
+    body,
+    created_by
+)
+SELECT sqlc.arg(template_id)::uuid,
+       COALESCE(MAX(version), 0) + 1,
+       sqlc.arg(body)::text,
+       sqlc.narg(created_by)::uuid
+FROM ai_prompt_template_versions
+WHERE template_id = sqlc.arg(template_id)::uuid
+RETURNING *;
+
+-- name: ListAIPromptTemplateVersions :many
+SELECT v.*
+FROM ai_prompt_template_versions v
+JOIN ai_prompt_templates t ON t.id = v.template_id
+WHERE v.template_id = $1
+  AND t.company_id = $2
+ORDER BY v.version DESC;
+
+-- name: GetAIPromptTemplateVersion :one
+SELECT v.*, t.name, t.kind
+FROM ai_prompt_template_versions v
+JOIN ai_prompt_templates t ON t.id = v.template_id
+WHERE v.id = $1
+  AND t.company_id = $2;
+
+-- name: GetLatestAIPromptTemplateVersion :one
+SELECT v.*, t.name, t.kind
+FROM ai_prompt_template_versions v
+JOIN ai_prompt_templates t ON t.id = v.template_id
+WHERE v.template_id = $1
+  AND t.company_id = $2
+ORDER BY v.version DESC
+LIMIT 1;
+
+-- name: GetDefaultAIPromptTemplateVersion :one
+SELECT v.*, t.name, t.kind
+FROM ai_prompt_template_versions v
+JOIN ai_prompt_templates t ON t.id = v.template_id
+WHERE t.company_id = $1
+  AND t.kind = $2
+  AND t.is_default
+  AND t.archived_at IS NULL
+ORDER BY v.version DESC
+LIMIT 1;
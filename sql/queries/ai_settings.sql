@@ -0,0 +1,34 @@
+-- name: GetAICompanySettings :one
+SELECT company_id, fallback_providers, created_at, updated_at, monthly_budget_usd_micros, budget_warning_percent, credential_rotation_days, credential_warning_days, model_allowlist
+FROM ai_company_settings
+WHERE company_id = $1;
+
+-- name: UpsertAICompanyFallbackProviders :one
+INSERT INTO ai_company_settings (company_id, fallback_providers)
+VALUES ($1, $2)
+ON CONFLICT (company_id)
+    DO UPDATE SET fallback_providers = EXCLUDED.fallback_providers
+RETURNING company_id, fallback_providers, created_at, updated_at, monthly_budget_usd_micros, budget_warning_percent, credential_rotation_days, credential_warning_days, model_allowlist;
+
+-- name: UpsertAICompanyBudget :one
+INSERT INTO ai_company_settings (company_id, monthly_budget_usd_micros, budget_warning_percent)
+VALUES ($1, $2, $3)
+ON CONFLICT (company_id)
+    DO UPDATE SET monthly_budget_usd_micros = EXCLUDED.monthly_budget_usd_micros,
+                  budget_warning_percent = EXCLUDED.budget_warning_percent
+RETURNING company_id, fallback_providers, created_at, updated_at, monthly_budget_usd_micros, budget_warning_percent, credential_rotation_days, credential_warning_days, model_allowlist;
+
+-- name: UpsertAICompanyCredentialPolicy :one
+INSERT INTO ai_company_settings (company_id, credential_rotation_days, credential_warning_days)
+VALUES ($1, $2, $3)
+ON CONFLICT (company_id)
+    DO UPDATE SET credential_rotation_days = EXCLUDED.credential_rotation_days,
+                  credential_warning_days = EXCLUDED.credential_warning_days
+RETURNING company_id, fallback_providers, created_at, updated_at, monthly_budget_usd_micros, budget_warning_percent, credential_rotation_days, credential_warning_days, model_allowlist;
+
+-- name: UpsertAICompanyModelAllowlist :one
+INSERT INTO ai_company_settings (company_id, model_allowlist)
+VALUES ($1, $2)
+ON CONFLICT (company_id)
+    DO UPDATE SET model_allowlist = EXCLUDED.model_allowlist
+RETURNING company_id, fallback_providers, created_at, updated_at, monthly_budget_usd_micros, budget_warning_percent, credential_rotation_days, credential_warning_days, model_allowlist;
@@ -0,0 +1,33 @@
+-- name: InsertAIUsageEntry :one
+INSERT INTO ai_usage_ledger (
+    company_id,
+    user_id,
+    provider_id,
+    model,
+    feature,
+    prompt_tokens,
+    completion_tokens,
+    cost_usd_micros
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, company_id, user_id, provider_id, model, feature, prompt_tokens, completion_tokens, cost_usd_micros, created_at;
+
+-- name: SumAIUsageCostSince :one
+SELECT COALESCE(SUM(cost_usd_micros), 0)::bigint AS total_cost_usd_micros
+FROM ai_usage_ledger
+WHERE company_id = $1
+  AND created_at >= $2;
+
+-- name: ListAIUsageByModelSince :many
+SELECT
+    provider_id,
+    model,
+    COUNT(*)::bigint AS requests,
+    COALESCE(SUM(prompt_tokens), 0)::bigint AS prompt_tokens,
+    COALESCE(SUM(completion_tokens), 0)::bigint AS completion_tokens,
+    COALESCE(SUM(cost_usd_micros), 0)::bigint AS cost_usd_micros
+FROM ai_usage_ledger
+WHERE company_id = $1
+  AND created_at >= $2
+GROUP BY provider_id, model
+ORDER BY cost_usd_micros DESC, provider_id, model;
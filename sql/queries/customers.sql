@@ -47,6 +47,28 @@ AND Is_Active = TRUE;
 
 
 
+-- name: SearchCustomers :many
+SELECT cu.*,
+    (
+        SELECT COUNT(*) FROM contracts co
+        WHERE co.Customer_ID = cu.ID
+        AND co.Company_ID = cu.Company_ID
+    ) AS contract_count
+FROM customers cu
+WHERE cu.Company_ID = sqlc.arg(company_id)
+AND (
+    cu.Customer_Name ILIKE sqlc.arg(name_pattern)::text
+    OR cu.ID::text LIKE sqlc.arg(id_prefix)::text
+    OR EXISTS (
+        SELECT 1 FROM contracts co
+        WHERE co.Customer_ID = cu.ID
+        AND co.Company_ID = cu.Company_ID
+        AND co.ID::text LIKE sqlc.arg(id_prefix)::text
+    )
+)
+ORDER BY cu.Is_Active DESC, cu.Customer_Name
+LIMIT sqlc.arg(row_limit);
+
 -- name: GetAllCustomers :many
 SELECT * FROM customers;
 
@@ -0,0 +1,40 @@
+-- +goose Up
+INSERT INTO ai_provider_catalog (
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled
+)
+VALUES (
+    'anthropic',
+    'Anthropic',
+    'https://www.anthropic.com/images/icons/apple-touch-icon.png',
+    'Claude models for chat, long-context analysis, and tool use',
+    'https://docs.anthropic.com/en/api/messages',
+    ARRAY['chat', 'completion', 'tools'],
+    ARRAY['claude-3-5-sonnet-latest', 'claude-3-5-haiku-latest', 'claude-3-opus-latest'],
+    '[
+        {"id":"apiKey","label":"API Key","type":"password","required":true,"sensitive":true,"placeholder":"sk-ant-..."},
+        {"id":"baseUrl","label":"Base URL","type":"url","placeholder":"https://api.anthropic.com/v1"},
+        {"id":"model","label":"Default Model","type":"text","placeholder":"claude-3-5-sonnet-latest"}
+    ]',
+    TRUE
+)
+ON CONFLICT (id) DO UPDATE SET
+    label = EXCLUDED.label,
+    icon_url = EXCLUDED.icon_url,
+    description = EXCLUDED.description,
+    documentation_url = EXCLUDED.documentation_url,
+    capabilities = EXCLUDED.capabilities,
+    models = EXCLUDED.models,
+    fields = EXCLUDED.fields,
+    enabled = EXCLUDED.enabled,
+    updated_at = now();
+
+-- +goose Down
+DELETE FROM ai_provider_catalog WHERE id = 'anthropic';
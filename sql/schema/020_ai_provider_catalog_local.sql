@@ -0,0 +1,40 @@
+-- +goose Up
+INSERT INTO ai_provider_catalog (
+    id,
+    label,
+    icon_url,
+    description,
+    documentation_url,
+    capabilities,
+    models,
+    fields,
+    enabled
+)
+VALUES (
+    'local',
+    'Self-hosted (OpenAI-compatible)',
+    NULL,
+    'Ollama, vLLM, or any server exposing /v1/chat/completions; contract data stays on-prem',
+    'https://github.com/ollama/ollama/blob/main/docs/openai.md',
+    ARRAY['chat', 'completion', 'tools', 'self-hosted'],
+    ARRAY[]::text[],
+    '[
+        {"id":"baseUrl","label":"Base URL","type":"url","required":true,"placeholder":"http://localhost:11434/v1","description":"OpenAI-compatible endpoint root; models are discovered from /v1/models"},
+        {"id":"apiKey","label":"API Key","type":"password","required":false,"sensitive":true,"placeholder":"Optional bearer token"},
+        {"id":"model","label":"Default Model","type":"text","placeholder":"llama3.1"}
+    ]',
+    TRUE
+)
+ON CONFLICT (id) DO UPDATE SET
+    label = EXCLUDED.label,
+    icon_url = EXCLUDED.icon_url,
+    description = EXCLUDED.description,
+    documentation_url = EXCLUDED.documentation_url,
+    capabilities = EXCLUDED.capabilities,
+    models = EXCLUDED.models,
+    fields = EXCLUDED.fields,
+    enabled = EXCLUDED.enabled,
+    updated_at = now();
+
+-- +goose Down
+DELETE FROM ai_provider_catalog WHERE id = 'local';
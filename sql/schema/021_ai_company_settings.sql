@@ -0,0 +1,16 @@
+-- +goose Up
+-- Company-wide AI policy such as the ordered provider fallback chain.
+CREATE TABLE IF NOT EXISTS ai_company_settings (
+    company_id          uuid PRIMARY KEY REFERENCES companies (id) ON DELETE CASCADE,
+    fallback_providers  text[] NOT NULL DEFAULT '{}',
+    created_at          timestamptz NOT NULL DEFAULT now(),
+    updated_at          timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER update_ai_company_settings_updated_at
+    BEFORE UPDATE ON ai_company_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_ai_company_settings_updated_at ON ai_company_settings;
+DROP TABLE IF EXISTS ai_company_settings;
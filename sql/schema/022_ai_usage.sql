@@ -0,0 +1,30 @@
+-- +goose Up
+-- Per-call token usage for billing visibility and budget enforcement.
+CREATE TABLE IF NOT EXISTS ai_usage_ledger (
+    id                 uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id         uuid NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+    user_id            uuid REFERENCES users (id) ON DELETE SET NULL,
+    provider_id        text NOT NULL,
+    model              text NOT NULL DEFAULT '',
+    feature            text NOT NULL DEFAULT '',
+    prompt_tokens      integer NOT NULL DEFAULT 0 CHECK (prompt_tokens >= 0),
+    completion_tokens  integer NOT NULL DEFAULT 0 CHECK (completion_tokens >= 0),
+    cost_usd_micros    bigint NOT NULL DEFAULT 0 CHECK (cost_usd_micros >= 0),
+    created_at         timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_usage_ledger_company_created
+    ON ai_usage_ledger (company_id, created_at DESC);
+
+ALTER TABLE ai_company_settings
+    ADD COLUMN IF NOT EXISTS monthly_budget_usd_micros bigint CHECK (monthly_budget_usd_micros >= 0),
+    ADD COLUMN IF NOT EXISTS budget_warning_percent integer NOT NULL DEFAULT 80
+        CHECK (budget_warning_percent BETWEEN 1 AND 100);
+
+-- +goose Down
+ALTER TABLE ai_company_settings
+    DROP COLUMN IF EXISTS budget_warning_percent,
+    DROP COLUMN IF EXISTS monthly_budget_usd_micros;
+
+DROP INDEX IF EXISTS idx_ai_usage_ledger_company_created;
+DROP TABLE IF EXISTS ai_usage_ledger;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Per-model context windows (in tokens) so chat history can be trimmed to fit.
+ALTER TABLE ai_provider_catalog
+    ADD COLUMN IF NOT EXISTS context_windows jsonb NOT NULL DEFAULT '{}'::jsonb;
+
+UPDATE ai_provider_catalog
+SET context_windows = '{"gpt-4o": 128000, "gpt-4o-mini": 128000, "gpt-3.5-turbo": 16385}',
+    updated_at = now()
+WHERE id = 'openai';
+
+UPDATE ai_provider_catalog
+SET context_windows = '{"gemini-pro": 32760, "gemini-pro-vision": 16384, "gemini-1.5": 1048576}',
+    updated_at = now()
+WHERE id = 'gemini';
+
+UPDATE ai_provider_catalog
+SET context_windows = '{"claude-3": 200000}',
+    updated_at = now()
+WHERE id = 'anthropic';
+
+-- +goose Down
+ALTER TABLE ai_provider_catalog
+    DROP COLUMN IF EXISTS context_windows;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Files uploaded for document jobs. Bytes live in the blob store under storage_key; the
+-- extracted text is kept here so workers do not re-parse the original on every run.
+CREATE TABLE IF NOT EXISTS ai_document_files (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id     uuid NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+    user_id        uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    filename       text NOT NULL,
+    content_type   text NOT NULL DEFAULT '',
+    size_bytes     bigint NOT NULL CHECK (size_bytes >= 0),
+    sha256         text NOT NULL,
+    storage_key    text NOT NULL UNIQUE,
+    extracted_text text NOT NULL DEFAULT '',
+    chunk_count    integer NOT NULL DEFAULT 0,
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_document_files_company
+    ON ai_document_files (company_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ai_document_files_company;
+DROP TABLE IF EXISTS ai_document_files;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Workers claim jobs with a lease so several app instances can share the queue. Failed
+-- attempts are retried after run_after until max_attempts, then parked as 'dead_letter'.
+ALTER TABLE ai_document_jobs
+    ADD COLUMN IF NOT EXISTS attempts         integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_attempts     integer NOT NULL DEFAULT 5 CHECK (max_attempts > 0),
+    ADD COLUMN IF NOT EXISTS run_after        timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS locked_by        text,
+    ADD COLUMN IF NOT EXISTS lease_expires_at timestamptz;
+
+-- Jobs left in 'processing' by the previous single-worker loop have no lease; expire them
+-- immediately so recovery puts them back on the queue.
+UPDATE ai_document_jobs
+SET lease_expires_at = now()
+WHERE status = 'processing';
+
+CREATE INDEX IF NOT EXISTS idx_ai_document_jobs_queued
+    ON ai_document_jobs (run_after, created_at)
+    WHERE status = 'queued';
+
+CREATE INDEX IF NOT EXISTS idx_ai_document_jobs_leases
+    ON ai_document_jobs (lease_expires_at)
+    WHERE status = 'processing';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ai_document_jobs_leases;
+DROP INDEX IF EXISTS idx_ai_document_jobs_queued;
+
+ALTER TABLE ai_document_jobs
+    DROP COLUMN IF EXISTS lease_expires_at,
+    DROP COLUMN IF EXISTS locked_by,
+    DROP COLUMN IF EXISTS run_after,
+    DROP COLUMN IF EXISTS max_attempts,
+    DROP COLUMN IF EXISTS attempts;
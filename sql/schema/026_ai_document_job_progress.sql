@@ -0,0 +1,13 @@
+-- +goose Up
+-- Progress reporting and cancellation for document jobs. Cancelling a queued job finishes
+-- it immediately; a running job is flagged and its worker stops at the next lease renewal.
+ALTER TABLE ai_document_jobs
+    ADD COLUMN IF NOT EXISTS progress_stage      text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS progress_detail     text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS cancel_requested_at timestamptz;
+
+-- +goose Down
+ALTER TABLE ai_document_jobs
+    DROP COLUMN IF EXISTS cancel_requested_at,
+    DROP COLUMN IF EXISTS progress_detail,
+    DROP COLUMN IF EXISTS progress_stage;
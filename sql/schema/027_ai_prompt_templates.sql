@@ -0,0 +1,40 @@
+-- +goose Up
+-- Named, versioned prompt templates per company. Editing a template's body adds a
+-- version rather than changing one in place, so sessions and jobs pinned to a version
+-- (and the messages it produced) keep referring to the exact text that was used.
+CREATE TABLE IF NOT EXISTS ai_prompt_templates (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id  uuid NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+    name        text NOT NULL CHECK (btrim(name) <> ''),
+    kind        text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    is_default  boolean NOT NULL DEFAULT false,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    updated_at  timestamptz NOT NULL DEFAULT now(),
+    archived_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_ai_prompt_templates_company_name
+    ON ai_prompt_templates (company_id, lower(name))
+    WHERE archived_at IS NULL;
+
+-- At most one default template per kind.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_ai_prompt_templates_default
+    ON ai_prompt_templates (company_id, kind)
+    WHERE is_default AND archived_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS ai_prompt_template_versions (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id uuid NOT NULL REFERENCES ai_prompt_templates (id) ON DELETE CASCADE,
+    version     integer NOT NULL CHECK (version > 0),
+    body        text NOT NULL,
+    created_by  uuid REFERENCES users (id) ON DELETE SET NULL,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (template_id, version)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS ai_prompt_template_versions;
+DROP INDEX IF EXISTS uq_ai_prompt_templates_default;
+DROP INDEX IF EXISTS uq_ai_prompt_templates_company_name;
+DROP TABLE IF EXISTS ai_prompt_templates;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Optional expiry dates on stored provider credentials plus the outcome of the background
+-- health check, and the company policy that decides when a credential is due for rotation.
+ALTER TABLE ai_provider_credentials
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS health_checked_at timestamptz,
+    ADD COLUMN IF NOT EXISTS health_status text CHECK (health_status IN ('ok', 'failure')),
+    ADD COLUMN IF NOT EXISTS health_error text;
+
+-- Default credentials are claimed for checks oldest first.
+CREATE INDEX IF NOT EXISTS idx_ai_provider_credentials_health
+    ON ai_provider_credentials (health_checked_at NULLS FIRST)
+    WHERE is_default;
+
+ALTER TABLE ai_company_settings
+    ADD COLUMN IF NOT EXISTS credential_rotation_days integer CHECK (credential_rotation_days > 0),
+    ADD COLUMN IF NOT EXISTS credential_warning_days integer NOT NULL DEFAULT 14
+        CHECK (credential_warning_days BETWEEN 1 AND 365);
+
+-- +goose Down
+ALTER TABLE ai_company_settings
+    DROP COLUMN IF EXISTS credential_warning_days,
+    DROP COLUMN IF EXISTS credential_rotation_days;
+
+DROP INDEX IF EXISTS idx_ai_provider_credentials_health;
+
+ALTER TABLE ai_provider_credentials
+    DROP COLUMN IF EXISTS health_error,
+    DROP COLUMN IF EXISTS health_status,
+    DROP COLUMN IF EXISTS health_checked_at,
+    DROP COLUMN IF EXISTS expires_at;
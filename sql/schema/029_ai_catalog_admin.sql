@@ -0,0 +1,11 @@
+-- +goose Up
+-- Per-company restrictions on which catalog providers and models members may use. NULL
+-- means unrestricted; otherwise an object mapping each allowed provider ID to its allowed
+-- models, where an empty array allows every model of that provider.
+ALTER TABLE ai_company_settings
+    ADD COLUMN IF NOT EXISTS model_allowlist jsonb
+        CHECK (model_allowlist IS NULL OR jsonb_typeof(model_allowlist) = 'object');
+
+-- +goose Down
+ALTER TABLE ai_company_settings
+    DROP COLUMN IF EXISTS model_allowlist;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Draft contracts created by extraction jobs, keyed on the job so a retried or re-claimed
+-- job returns the drafts it already created instead of writing a second set.
+CREATE TABLE IF NOT EXISTS ai_document_job_drafts (
+    job_id      uuid PRIMARY KEY REFERENCES ai_document_jobs (id) ON DELETE CASCADE,
+    company_id  uuid NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+    contract_id uuid NOT NULL REFERENCES contracts (id) ON DELETE CASCADE,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS ai_document_job_drafts;
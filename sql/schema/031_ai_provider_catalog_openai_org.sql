@@ -0,0 +1,20 @@
+-- +goose Up
+-- Optional OpenAI organization/project overrides, stored as credential metadata.
+UPDATE ai_provider_catalog
+SET fields = fields || '[
+        {"id":"organization","label":"Organization ID","type":"text","placeholder":"org-...","description":"Optional; sent as the OpenAI-Organization header."},
+        {"id":"project","label":"Project ID","type":"text","placeholder":"proj_...","description":"Optional; sent as the OpenAI-Project header."}
+    ]'::jsonb,
+    updated_at = now()
+WHERE id = 'openai'
+  AND NOT fields @> '[{"id":"organization"}]'::jsonb;
+
+-- +goose Down
+UPDATE ai_provider_catalog
+SET fields = (
+        SELECT COALESCE(jsonb_agg(field), '[]'::jsonb)
+        FROM jsonb_array_elements(fields) AS field
+        WHERE field->>'id' NOT IN ('organization', 'project')
+    ),
+    updated_at = now()
+WHERE id = 'openai';